@@ -219,11 +219,23 @@ func (a *Agent) Run(ctx context.Context) error {
 
 // InitPlugins runs the Init function on plugins.
 func (a *Agent) InitPlugins() error {
+	// Setup the backpressure signal shared between the outputs and inputs
+	backpressure, err := models.NewBackpressure(
+		a.Config.Agent.BackpressureHighWatermark,
+		a.Config.Agent.BackpressureLowWatermark,
+	)
+	if err != nil {
+		return err
+	}
+
 	for _, input := range a.Config.Inputs {
 		// Share the snmp translator setting with plugins that need it.
 		if tp, ok := input.Input.(snmp.TranslatorPlugin); ok {
 			tp.SetTranslator(a.Config.Agent.SnmpTranslator)
 		}
+		if bp, ok := input.Input.(telegraf.BackpressurePlugin); ok {
+			bp.SetBackpressure(backpressure)
+		}
 		err := input.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize input %s: %w", input.LogName(), err)
@@ -250,6 +262,7 @@ func (a *Agent) InitPlugins() error {
 		}
 	}
 	for _, output := range a.Config.Outputs {
+		output.SetBackpressure(backpressure)
 		err := output.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize output %s: %w", output.LogName(), err)
@@ -798,6 +811,12 @@ func (a *Agent) startOutputs(
 			}
 			return nil, nil, fmt.Errorf("connecting output %s: %w", output.LogName(), err)
 		}
+		if err := output.Probe(); err != nil {
+			// Probe failures are non-fatal to the agent but should only remove the plugin
+			log.Printf("I! [agent] Failed to probe %s, shutting down plugin: %s", output.LogName(), err)
+			output.Close()
+			continue
+		}
 
 		unit.outputs = append(unit.outputs, output)
 	}
@@ -901,14 +920,14 @@ func (a *Agent) flushLoop(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.Drain))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.Drain))
 			return
 		case <-ticker.Elapsed():
 			logError(a.flushOnce(output, ticker, output.Write))
@@ -299,6 +299,16 @@ type AgentConfig struct {
 	// BufferDirectory is the directory to store buffer files for serialized
 	// to disk metrics when using the "disk" buffer strategy.
 	BufferDirectory string `toml:"buffer_directory"`
+
+	// BackpressureHighWatermark is the fill level of the output buffers,
+	// relative to the metric buffer limit, above which inputs supporting
+	// backpressure stop accepting new data. Zero disables backpressure.
+	BackpressureHighWatermark float64 `toml:"backpressure_high_watermark"`
+
+	// BackpressureLowWatermark is the fill level of the output buffers,
+	// relative to the metric buffer limit, all buffers must drain below to
+	// release the backpressure. Defaults to half the high-watermark.
+	BackpressureLowWatermark float64 `toml:"backpressure_low_watermark"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -1075,6 +1085,9 @@ func (c *Config) addParser(parentcategory, parentname string, table *ast.Table)
 		}
 	}
 	conf.LogLevel = c.getFieldString(table, "log_level")
+	conf.Category = parentcategory
+	conf.ParseErrorSink = c.getFieldString(table, "parse_error_sink")
+	conf.ParseErrorFile = c.getFieldString(table, "parse_error_file")
 
 	creator, ok := parsers.Parsers[conf.DataFormat]
 	if !ok {
@@ -1672,6 +1685,32 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	oc.NamePrefix = c.getFieldString(tbl, "name_prefix")
 	oc.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	oc.LogLevel = c.getFieldString(tbl, "log_level")
+	oc.MetricTTL, _ = c.getFieldDuration(tbl, "metric_ttl")
+	oc.RetryInitialInterval, _ = c.getFieldDuration(tbl, "retry_initial_interval")
+	oc.RetryMaxInterval, _ = c.getFieldDuration(tbl, "retry_max_interval")
+	oc.RetryJitter, _ = c.getFieldDuration(tbl, "retry_jitter")
+	oc.RetryMaxDuration, _ = c.getFieldDuration(tbl, "retry_max_duration")
+	oc.CircuitBreakerThreshold = c.getFieldInt(tbl, "circuit_breaker_threshold")
+	oc.CircuitBreakerCooldown, _ = c.getFieldDuration(tbl, "circuit_breaker_cooldown")
+
+	if node, ok := tbl.Fields["downsample"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			ds := &models.DownsampleConfig{
+				Default: c.getFieldString(subtbl, "default"),
+				GroupBy: c.getFieldStringSlice(subtbl, "group_by"),
+				Fields:  make(map[string]string),
+			}
+			ds.Interval, _ = c.getFieldDuration(subtbl, "interval")
+			if node, ok := subtbl.Fields["fields"]; ok {
+				if fieldtbl, ok := node.(*ast.Table); ok {
+					if err := c.toml.UnmarshalTable(fieldtbl, ds.Fields); err != nil {
+						return nil, fmt.Errorf("could not parse downsample fields for output %s", name)
+					}
+				}
+			}
+			oc.Downsample = ds
+		}
+	}
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
@@ -1691,23 +1730,25 @@ func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	// General options to ignore
 	case "alias", "always_include_local_tags",
 		"buffer_strategy", "buffer_directory",
+		"circuit_breaker_cooldown", "circuit_breaker_threshold",
 		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
+		"data_format", "delay", "downsample", "drop", "drop_original",
 		"fielddrop", "fieldexclude", "fieldinclude", "fieldpass", "flush_interval", "flush_jitter",
 		"grace",
 		"interval",
 		"log_level", "lvm", // What is this used for?
-		"metric_batch_size", "metric_buffer_limit", "metricpass",
+		"metric_batch_size", "metric_buffer_limit", "metric_ttl", "metricpass",
 		"name_override", "name_prefix", "name_suffix", "namedrop", "namedrop_separator", "namepass", "namepass_separator",
 		"order",
 		"pass", "period", "precision",
+		"retry_initial_interval", "retry_jitter", "retry_max_duration", "retry_max_interval",
 		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior":
 
 	// Secret-store options to ignore
 	case "id":
 
 	// Parser and serializer options to ignore
-	case "data_type", "influx_parser_type":
+	case "data_type", "influx_parser_type", "parse_error_sink", "parse_error_file":
 
 	default:
 		c.unusedFieldsMutex.Lock()
@@ -501,6 +501,27 @@ func TestConfig_SliceComment(t *testing.T) {
 	require.Equal(t, []string{"test"}, output.Scopes)
 }
 
+func TestConfig_OutputDownsample(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/output_downsample.toml"))
+	require.Len(t, c.Outputs, 1)
+
+	cfg := c.Outputs[0].Config
+	require.Equal(t, 10*time.Second, cfg.RetryInitialInterval)
+	require.Equal(t, 5, cfg.CircuitBreakerThreshold)
+
+	expected := &models.DownsampleConfig{
+		Interval: 5 * time.Minute,
+		Default:  "last",
+		GroupBy:  []string{"host"},
+		Fields: map[string]string{
+			"usage_*":     "max",
+			"temperature": "mean",
+		},
+	}
+	require.Equal(t, expected, cfg.Downsample)
+}
+
 func TestConfig_BadOrdering(t *testing.T) {
 	// #3444: when not using inline tables, care has to be taken so subsequent configuration
 	// doesn't become part of the table. This is not a bug, but TOML syntax.
@@ -21,3 +21,25 @@ type ServiceInput interface {
 	// to the accumulator before returning.
 	Stop()
 }
+
+// Backpressure signals whether the outputs keep up with the metrics produced
+// by the inputs.
+type Backpressure interface {
+	// Throttled returns true while the buffer of at least one output is
+	// filled above the configured high-watermark. Inputs should stop
+	// accepting new data until the buffers drained below the low-watermark.
+	Throttled() bool
+
+	// Released returns a channel that is closed as soon as the backpressure
+	// is released. The returned channel is already closed if not throttled.
+	Released() <-chan struct{}
+}
+
+// BackpressurePlugin is an interface for service inputs pushing back on their
+// clients if the outputs cannot keep up, e.g. by rejecting requests or by
+// pausing to read from connections.
+type BackpressurePlugin interface {
+	// SetBackpressure is called once before Init() with the agent's
+	// backpressure signal.
+	SetBackpressure(Backpressure)
+}
@@ -164,6 +164,10 @@ func NewContentDecoder(encoding string, options ...DecodingOption) (ContentDecod
 // ContentEncoder applies a wrapper encoding to byte buffers.
 type ContentEncoder interface {
 	Encode([]byte) ([]byte, error)
+
+	// NewWriter returns a writer encoding all data written to it into the
+	// given writer. The returned writer must be closed to flush all data.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
 }
 
 // GzipEncoder compresses the buffer using gzip at the default level.
@@ -171,6 +175,7 @@ type GzipEncoder struct {
 	pwriter *pgzip.Writer
 	writer  *gzip.Writer
 	buf     *bytes.Buffer
+	level   int
 }
 
 func NewGzipEncoder(options ...EncodingOption) (*GzipEncoder, error) {
@@ -198,6 +203,7 @@ func NewGzipEncoder(options ...EncodingOption) (*GzipEncoder, error) {
 		pwriter: pw,
 		writer:  w,
 		buf:     &buf,
+		level:   cfg.level,
 	}, err
 }
 
@@ -242,9 +248,14 @@ func (e *GzipEncoder) encodeBig(data []byte) ([]byte, error) {
 	return e.buf.Bytes(), nil
 }
 
+func (e *GzipEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, e.level)
+}
+
 type ZlibEncoder struct {
 	writer *zlib.Writer
 	buf    *bytes.Buffer
+	level  int
 }
 
 func NewZlibEncoder(options ...EncodingOption) (*ZlibEncoder, error) {
@@ -265,6 +276,7 @@ func NewZlibEncoder(options ...EncodingOption) (*ZlibEncoder, error) {
 	return &ZlibEncoder{
 		writer: w,
 		buf:    &buf,
+		level:  cfg.level,
 	}, err
 }
 
@@ -283,8 +295,13 @@ func (e *ZlibEncoder) Encode(data []byte) ([]byte, error) {
 	return e.buf.Bytes(), nil
 }
 
+func (e *ZlibEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriterLevel(w, e.level)
+}
+
 type ZstdEncoder struct {
 	encoder *zstd.Encoder
+	level   zstd.EncoderLevel
 }
 
 func NewZstdEncoder(options ...EncodingOption) (*ZstdEncoder, error) {
@@ -311,6 +328,7 @@ func NewZstdEncoder(options ...EncodingOption) (*ZstdEncoder, error) {
 	e, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
 	return &ZstdEncoder{
 		encoder: e,
+		level:   level,
 	}, err
 }
 
@@ -318,6 +336,10 @@ func (e *ZstdEncoder) Encode(data []byte) ([]byte, error) {
 	return e.encoder.EncodeAll(data, make([]byte, 0, len(data))), nil
 }
 
+func (e *ZstdEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(e.level), zstd.WithEncoderConcurrency(1))
+}
+
 // IdentityEncoder is a null encoder that applies no transformation.
 type IdentityEncoder struct{}
 
@@ -333,6 +355,18 @@ func (*IdentityEncoder) Encode(data []byte) ([]byte, error) {
 	return data, nil
 }
 
+func (*IdentityEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
 // ContentDecoder removes a wrapper encoding from byte buffers.
 type ContentDecoder interface {
 	SetEncoding(string)
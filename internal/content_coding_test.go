@@ -553,3 +553,27 @@ func BenchmarkIdentityEncodeDecode(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestStreamEncodeDecode(t *testing.T) {
+	for _, encoding := range []string{"gzip", "identity", "zlib", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			enc, err := NewContentEncoder(encoding)
+			require.NoError(t, err)
+			dec, err := NewContentDecoder(encoding)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			w, err := enc.NewWriter(&buf)
+			require.NoError(t, err)
+			_, err = io.WriteString(w, "howdy ")
+			require.NoError(t, err)
+			_, err = io.WriteString(w, "doody")
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			actual, err := dec.Decode(buf.Bytes())
+			require.NoError(t, err)
+			require.Equal(t, "howdy doody", string(actual))
+		})
+	}
+}
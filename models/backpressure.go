@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+var AgentBackpressure = selfstat.Register("agent", "backpressure", make(map[string]string))
+
+// Backpressure tracks the fill level of the output buffers and signals the
+// inputs to throttle as long as at least one buffer is filled above the
+// high-watermark. The signal is released once all buffers drained below the
+// low-watermark. Buffers are only considered if their size is limited i.e.
+// for the memory buffer strategy.
+type Backpressure struct {
+	high float64
+	low  float64
+
+	throttled map[*RunningOutput]bool
+	released  chan struct{}
+	sync.Mutex
+}
+
+// NewBackpressure creates a backpressure signal for the given watermarks
+// relative to the metric buffer limit of the outputs. A high-watermark of
+// zero disables the signal. The low-watermark defaults to half the
+// high-watermark.
+func NewBackpressure(high, low float64) (*Backpressure, error) {
+	if high < 0 || high > 1 {
+		return nil, fmt.Errorf("invalid 'backpressure_high_watermark' setting %v", high)
+	}
+	if low == 0 {
+		low = high / 2
+	}
+	if low < 0 || (high > 0 && low >= high) {
+		return nil, fmt.Errorf("invalid 'backpressure_low_watermark' setting %v", low)
+	}
+
+	released := make(chan struct{})
+	close(released)
+
+	return &Backpressure{
+		high:      high,
+		low:       low,
+		throttled: make(map[*RunningOutput]bool),
+		released:  released,
+	}, nil
+}
+
+// Throttled returns true if at least one output buffer exceeded the
+// high-watermark and did not yet drain below the low-watermark.
+func (b *Backpressure) Throttled() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	return len(b.throttled) > 0
+}
+
+// Released returns a channel that is closed as soon as the backpressure is
+// released.
+func (b *Backpressure) Released() <-chan struct{} {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.released
+}
+
+// enabled returns true if the signal is enabled by a high-watermark
+func (b *Backpressure) enabled() bool {
+	return b.high > 0
+}
+
+// crossing checks if the fill level crosses the watermark relevant for the
+// given throttling state, i.e. the high-watermark if not throttled and the
+// low-watermark if throttled.
+func (b *Backpressure) crossing(throttled bool, length, limit int) bool {
+	fill := float64(length) / float64(limit)
+	if throttled {
+		return fill <= b.low
+	}
+	return fill >= b.high
+}
+
+// update records the current fill level of the output's buffer
+func (b *Backpressure) update(output *RunningOutput, length, limit int) {
+	if !b.enabled() || limit <= 0 {
+		return
+	}
+	fill := float64(length) / float64(limit)
+
+	b.Lock()
+	defer b.Unlock()
+
+	throttled := b.throttled[output]
+	switch {
+	case !throttled && fill >= b.high:
+		output.log.Warnf("Buffer fullness above high-watermark (%d / %d metrics), throttling inputs", length, limit)
+		if len(b.throttled) == 0 {
+			b.released = make(chan struct{})
+			AgentBackpressure.Set(1)
+		}
+		b.throttled[output] = true
+		output.throttled.Store(true)
+	case throttled && fill <= b.low:
+		output.log.Infof("Buffer fullness below low-watermark (%d / %d metrics)", length, limit)
+		delete(b.throttled, output)
+		output.throttled.Store(false)
+		if len(b.throttled) == 0 {
+			close(b.released)
+			AgentBackpressure.Set(0)
+		}
+	}
+}
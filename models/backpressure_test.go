@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestBackpressureInvalidSettings(t *testing.T) {
+	_, err := NewBackpressure(1.5, 0)
+	require.ErrorContains(t, err, "invalid 'backpressure_high_watermark' setting")
+
+	_, err = NewBackpressure(0.5, 0.8)
+	require.ErrorContains(t, err, "invalid 'backpressure_low_watermark' setting")
+
+	_, err = NewBackpressure(0.5, -0.1)
+	require.ErrorContains(t, err, "invalid 'backpressure_low_watermark' setting")
+}
+
+func TestBackpressureDisabled(t *testing.T) {
+	bp, err := NewBackpressure(0, 0)
+	require.NoError(t, err)
+
+	m := &mockOutput{batchAcceptSize: -1}
+	ro := NewRunningOutput(m, &OutputConfig{}, 5, 10)
+	ro.SetBackpressure(bp)
+	require.Nil(t, ro.backpressure)
+
+	for i := 0; i < 20; i++ {
+		ro.AddMetric(testutil.TestMetric(i))
+	}
+	require.False(t, bp.Throttled())
+	require.Equal(t, 10, ro.BufferLength())
+}
+
+func TestBackpressure(t *testing.T) {
+	bp, err := NewBackpressure(0.8, 0.2)
+	require.NoError(t, err)
+	require.False(t, bp.Throttled())
+	require.Equal(t, released, state(bp.Released()))
+
+	m1 := &mockOutput{batchAcceptSize: -1}
+	ro1 := NewRunningOutput(m1, &OutputConfig{Name: "first"}, 5, 10)
+	ro1.SetBackpressure(bp)
+
+	m2 := &mockOutput{batchAcceptSize: -1}
+	ro2 := NewRunningOutput(m2, &OutputConfig{Name: "second"}, 5, 10)
+	ro2.SetBackpressure(bp)
+
+	// Fill the buffers up to the high-watermark
+	for i := 0; i < 7; i++ {
+		ro1.AddMetric(testutil.TestMetric(i))
+		ro2.AddMetric(testutil.TestMetric(i))
+	}
+	require.False(t, bp.Throttled())
+
+	ro1.AddMetric(testutil.TestMetric(7))
+	require.True(t, bp.Throttled())
+	ch := bp.Released()
+	require.Equal(t, pending, state(ch))
+
+	ro2.AddMetric(testutil.TestMetric(7))
+	require.True(t, bp.Throttled())
+
+	// Failing writes must keep the signal
+	require.Error(t, ro1.Write())
+	require.True(t, bp.Throttled())
+
+	// Writing part of the buffer of the first output is not sufficient
+	m1.batchAcceptSize = 0
+	require.NoError(t, ro1.WriteBatch())
+	require.True(t, bp.Throttled())
+	require.Equal(t, pending, state(ch))
+
+	// Draining the first buffer below the low-watermark must keep the signal
+	// as the second buffer is still above
+	require.NoError(t, ro1.Write())
+	require.True(t, bp.Throttled())
+	require.Equal(t, pending, state(ch))
+
+	// Draining all buffers releases the signal
+	m2.batchAcceptSize = 0
+	require.NoError(t, ro2.Write())
+	require.False(t, bp.Throttled())
+	require.Equal(t, released, state(ch))
+	require.Equal(t, released, state(bp.Released()))
+}
+
+const (
+	pending  = "pending"
+	released = "released"
+)
+
+func state(ch <-chan struct{}) string {
+	select {
+	case <-ch:
+		return released
+	default:
+		return pending
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
@@ -90,21 +91,24 @@ type BufferStats struct {
 	MetricsWritten  selfstat.Stat
 	MetricsRejected selfstat.Stat
 	MetricsDropped  selfstat.Stat
+	MetricsExpired  selfstat.Stat
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
 }
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name, id, alias string, capacity int, strategy, path string) (Buffer, error) {
+// NewBuffer returns a new empty Buffer with the given capacity. Metrics older
+// than the given time-to-live are dropped from the buffer, a zero ttl keeps
+// metrics until they are written.
+func NewBuffer(name, id, alias string, capacity int, ttl time.Duration, strategy, path string) (Buffer, error) {
 	registerGob()
 
 	bs := NewBufferStats(name, alias, capacity)
 
 	switch strategy {
 	case "", "memory":
-		return NewMemoryBuffer(capacity, bs)
+		return NewMemoryBuffer(capacity, ttl, bs)
 	case "disk":
-		return NewDiskBuffer(name, id, path, bs)
+		return NewDiskBuffer(name, id, path, ttl, bs)
 	}
 	return nil, fmt.Errorf("invalid buffer strategy %q", strategy)
 }
@@ -136,6 +140,11 @@ func NewBufferStats(name, alias string, capacity int) BufferStats {
 			"metrics_dropped",
 			tags,
 		),
+		MetricsExpired: selfstat.Register(
+			"write",
+			"metrics_expired",
+			tags,
+		),
 		BufferSize: selfstat.Register(
 			"write",
 			"buffer_size",
@@ -173,3 +182,14 @@ func (b *BufferStats) metricDropped(m telegraf.Metric) {
 	b.MetricsDropped.Incr(1)
 	m.Reject()
 }
+
+func (b *BufferStats) metricExpired(m telegraf.Metric) {
+	AgentMetricsDropped.Incr(1)
+	b.MetricsExpired.Incr(1)
+	m.Reject()
+}
+
+// expired checks if the metric is older than the given time-to-live
+func expired(m telegraf.Metric, ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(m.Time()) > ttl
+}
@@ -8,6 +8,7 @@ import (
 	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/tidwall/wal"
 
@@ -21,6 +22,7 @@ type DiskBuffer struct {
 
 	file *wal.Log
 	path string
+	ttl  time.Duration
 
 	batchFirst uint64 // Index of the first metric in the batch
 	batchSize  uint64 // Number of metrics currently in the batch
@@ -40,7 +42,7 @@ type DiskBuffer struct {
 	mask []int
 }
 
-func NewDiskBuffer(name, id, path string, stats BufferStats) (*DiskBuffer, error) {
+func NewDiskBuffer(name, id, path string, ttl time.Duration, stats BufferStats) (*DiskBuffer, error) {
 	filePath := filepath.Join(path, id)
 	walFile, err := wal.Open(filePath, nil)
 	if err != nil {
@@ -57,6 +59,7 @@ func NewDiskBuffer(name, id, path string, stats BufferStats) (*DiskBuffer, error
 		BufferStats: stats,
 		file:        walFile,
 		path:        filePath,
+		ttl:         ttl,
 	}
 	if buf.length() > 0 {
 		buf.originalEnd = buf.writeIndex()
@@ -107,8 +110,13 @@ func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
 	b.Lock()
 	defer b.Unlock()
 
+	now := time.Now()
 	dropped := 0
 	for _, m := range metrics {
+		if expired(m, b.ttl, now) {
+			b.metricExpired(m)
+			continue
+		}
 		if !b.addSingleMetric(m) {
 			dropped++
 		}
@@ -147,6 +155,8 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 	readIndex := b.batchFirst
 	endIndex := b.writeIndex()
 	offset := 0
+	now := time.Now()
+	var expiredOffsets []int
 	for batchSize > 0 && readIndex < endIndex {
 		data, err := b.file.Read(readIndex)
 		if err != nil {
@@ -183,11 +193,23 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 			continue
 		}
 
+		// Mask metrics exceeding the time-to-live for removal
+		if expired(m, b.ttl, now) {
+			b.metricExpired(m)
+			expiredOffsets = append(expiredOffsets, offset)
+			continue
+		}
+
 		metrics = append(metrics, m)
 		offsets = append(offsets, offset)
 		b.batchSize++
 		batchSize--
 	}
+	if len(expiredOffsets) > 0 {
+		b.mask = append(b.mask, expiredOffsets...)
+		sort.Ints(b.mask)
+		b.BufferSize.Set(int64(b.length()))
+	}
 	return &Transaction{Batch: metrics, valid: true, state: offsets}
 }
 
@@ -2,6 +2,7 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 )
@@ -12,20 +13,22 @@ type MemoryBuffer struct {
 	BufferStats
 
 	buf   []telegraf.Metric
-	first int // index of the first/oldest metric
-	last  int // one after the index of the last/newest metric
-	size  int // number of metrics currently in the buffer
-	cap   int // the capacity of the buffer
+	first int           // index of the first/oldest metric
+	last  int           // one after the index of the last/newest metric
+	size  int           // number of metrics currently in the buffer
+	cap   int           // the capacity of the buffer
+	ttl   time.Duration // the time-to-live of the metrics
 
 	batchFirst int // index of the first metric in the batch
 	batchSize  int // number of metrics currently in the batch
 }
 
-func NewMemoryBuffer(capacity int, stats BufferStats) (*MemoryBuffer, error) {
+func NewMemoryBuffer(capacity int, ttl time.Duration, stats BufferStats) (*MemoryBuffer, error) {
 	return &MemoryBuffer{
 		BufferStats: stats,
 		buf:         make([]telegraf.Metric, capacity),
 		cap:         capacity,
+		ttl:         ttl,
 	}, nil
 }
 
@@ -40,8 +43,13 @@ func (b *MemoryBuffer) Add(metrics ...telegraf.Metric) int {
 	b.Lock()
 	defer b.Unlock()
 
+	now := time.Now()
 	dropped := 0
 	for i := range metrics {
+		if expired(metrics[i], b.ttl, now) {
+			b.metricExpired(metrics[i])
+			continue
+		}
 		if n := b.addMetric(metrics[i]); n != 0 {
 			dropped += n
 		}
@@ -55,6 +63,8 @@ func (b *MemoryBuffer) BeginTransaction(batchSize int) *Transaction {
 	b.Lock()
 	defer b.Unlock()
 
+	b.removeExpired()
+
 	outLen := min(b.size, batchSize)
 	if outLen == 0 {
 		return &Transaction{}
@@ -131,6 +141,33 @@ func (b *MemoryBuffer) length() int {
 	return min(b.size+b.batchSize, b.cap)
 }
 
+// removeExpired drops all metrics exceeding the time-to-live while keeping the
+// order of the remaining metrics.
+func (b *MemoryBuffer) removeExpired() {
+	if b.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	src, dst := b.first, b.first
+	size := 0
+	for i := 0; i < b.size; i++ {
+		m := b.buf[src]
+		b.buf[src] = nil
+		if expired(m, b.ttl, now) {
+			b.metricExpired(m)
+		} else {
+			b.buf[dst] = m
+			dst = b.next(dst)
+			size++
+		}
+		src = b.next(src)
+	}
+	b.size = size
+	b.last = dst
+	b.BufferSize.Set(int64(b.length()))
+}
+
 func (b *MemoryBuffer) addMetric(m telegraf.Metric) int {
 	dropped := 0
 	// Check if Buffer is full
@@ -49,12 +49,18 @@ func TestDiskBufferSuite(t *testing.T) {
 
 func (s *BufferSuiteTest) newTestBuffer(capacity int) Buffer {
 	s.T().Helper()
-	buf, err := NewBuffer("test", "123", "", capacity, s.bufferType, s.bufferPath)
+	return s.newTestBufferWithTTL(capacity, 0)
+}
+
+func (s *BufferSuiteTest) newTestBufferWithTTL(capacity int, ttl time.Duration) Buffer {
+	s.T().Helper()
+	buf, err := NewBuffer("test", "123", "", capacity, ttl, s.bufferType, s.bufferPath)
 	s.Require().NoError(err)
 	buf.Stats().MetricsAdded.Set(0)
 	buf.Stats().MetricsWritten.Set(0)
 	buf.Stats().MetricsRejected.Set(0)
 	buf.Stats().MetricsDropped.Set(0)
+	buf.Stats().MetricsExpired.Set(0)
 	return buf
 }
 
@@ -934,6 +940,51 @@ func (s *BufferSuiteTest) TestPartialWriteBackToFront() {
 	s.Equal(int64(0), buf.Stats().MetricsDropped.Get(), "metrics dropped")
 }
 
+func (s *BufferSuiteTest) TestBufferTTLDropsExpiredOnAdd() {
+	buf := s.newTestBufferWithTTL(5, time.Hour)
+	defer buf.Close()
+
+	var rejected int
+	old := &mockMetric{
+		Metric: metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Now().Add(-2*time.Hour)),
+		RejectF: func() {
+			rejected++
+		},
+	}
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Now())
+	buf.Add(old, m, old)
+	s.Equal(1, buf.Len())
+	s.Equal(2, rejected)
+
+	s.Equal(int64(1), buf.Stats().MetricsAdded.Get(), "metrics added")
+	s.Equal(int64(2), buf.Stats().MetricsExpired.Get(), "metrics expired")
+	s.Equal(int64(0), buf.Stats().MetricsDropped.Get(), "metrics dropped")
+}
+
+func (s *BufferSuiteTest) TestBufferTTLDropsExpiredOnBatch() {
+	buf := s.newTestBufferWithTTL(5, 100*time.Millisecond)
+	defer buf.Close()
+
+	now := time.Now()
+	expiring := metric.New("cpu", map[string]string{"state": "expiring"}, map[string]interface{}{"value": 42.0}, now)
+	recent := metric.New("cpu", map[string]string{"state": "recent"}, map[string]interface{}{"value": 42.0}, now.Add(time.Hour))
+	buf.Add(expiring, recent, expiring, recent)
+	s.Equal(4, buf.Len())
+
+	time.Sleep(200 * time.Millisecond)
+
+	// Only the recent metrics must remain in order
+	tx := buf.BeginTransaction(5)
+	testutil.RequireMetricsEqual(s.T(), []telegraf.Metric{recent, recent}, tx.Batch)
+	s.Equal(2, buf.Len())
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	s.Equal(0, buf.Len())
+
+	s.Equal(int64(2), buf.Stats().MetricsExpired.Get(), "metrics expired")
+	s.Equal(int64(2), buf.Stats().MetricsWritten.Get(), "metrics written")
+}
+
 type mockMetric struct {
 	telegraf.Metric
 	AcceptF func()
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// DownsampleConfig configures the aggregation of metrics into time windows
+// before adding them to the output buffer
+type DownsampleConfig struct {
+	Interval time.Duration
+	Default  string
+	Fields   map[string]string
+	GroupBy  []string
+}
+
+// downsampler aggregates the metrics of a series into windows of the
+// configured interval aligned to the metric timestamp. Each field is
+// aggregated by the function configured for the field name or the default
+// function. Series are identified by the metric name and either all tags or
+// the group-by tags. Tracking metrics are kept until the aggregated metric of
+// their window is delivered.
+type downsampler struct {
+	interval  time.Duration
+	defaultFn string
+	patterns  []downsamplePattern
+	groupBy   map[string]bool
+
+	windows map[downsampleKey]*downsampleWindow
+	pushed  time.Time
+	late    selfstat.Stat
+	sync.Mutex
+}
+
+type downsamplePattern struct {
+	filter filter.Filter
+	fn     string
+}
+
+type downsampleKey struct {
+	series uint64
+	start  int64
+}
+
+type downsampleWindow struct {
+	name    string
+	tags    map[string]string
+	start   time.Time
+	fields  map[string]*downsampleField
+	tracked []telegraf.Metric
+}
+
+type downsampleField struct {
+	fn      string
+	count   int64
+	first   interface{}
+	last    interface{}
+	sum     float64
+	min     float64
+	max     float64
+	numeric bool
+}
+
+func newDownsampler(cfg *DownsampleConfig, tags map[string]string) (*downsampler, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("invalid downsample 'interval' setting %s", cfg.Interval)
+	}
+
+	defaultFn := cfg.Default
+	if defaultFn == "" {
+		defaultFn = "mean"
+	}
+	if !isDownsampleFunction(defaultFn) {
+		return nil, fmt.Errorf("invalid downsample 'default' function %q", defaultFn)
+	}
+
+	// Sort the field patterns to get a deterministic order when matching
+	names := make([]string, 0, len(cfg.Fields))
+	for name := range cfg.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	patterns := make([]downsamplePattern, 0, len(names))
+	for _, name := range names {
+		fn := cfg.Fields[name]
+		if !isDownsampleFunction(fn) {
+			return nil, fmt.Errorf("invalid downsample function %q for field %q", fn, name)
+		}
+		f, err := filter.Compile([]string{name})
+		if err != nil {
+			return nil, fmt.Errorf("compiling downsample field %q failed: %w", name, err)
+		}
+		patterns = append(patterns, downsamplePattern{filter: f, fn: fn})
+	}
+
+	var groupBy map[string]bool
+	if len(cfg.GroupBy) > 0 {
+		groupBy = make(map[string]bool, len(cfg.GroupBy))
+		for _, key := range cfg.GroupBy {
+			groupBy[key] = true
+		}
+	}
+
+	return &downsampler{
+		interval:  cfg.Interval,
+		defaultFn: defaultFn,
+		patterns:  patterns,
+		groupBy:   groupBy,
+		windows:   make(map[downsampleKey]*downsampleWindow),
+		late:      selfstat.Register("write", "metrics_late", tags),
+	}, nil
+}
+
+func isDownsampleFunction(fn string) bool {
+	switch fn {
+	case "mean", "min", "max", "sum", "count", "first", "last":
+		return true
+	}
+	return false
+}
+
+// add aggregates the metric into the window of its series. The metric is
+// modified and must be owned by the caller. Non-tracking metrics are dropped
+// after aggregation, tracking metrics are finished on delivery of the window.
+// Metrics of windows already pushed are rejected and false is returned.
+func (d *downsampler) add(m telegraf.Metric) bool {
+	if d.groupBy != nil {
+		for key := range m.Tags() {
+			if !d.groupBy[key] {
+				m.RemoveTag(key)
+			}
+		}
+	}
+
+	start := m.Time().Truncate(d.interval)
+	key := downsampleKey{series: m.HashID(), start: start.UnixNano()}
+
+	d.Lock()
+	defer d.Unlock()
+
+	// Late metrics would create a duplicate of an already emitted window
+	if !start.Add(d.interval).After(d.pushed) {
+		d.late.Incr(1)
+		m.Reject()
+		return false
+	}
+
+	window, found := d.windows[key]
+	if !found {
+		window = &downsampleWindow{
+			name:   m.Name(),
+			tags:   m.Tags(),
+			start:  start,
+			fields: make(map[string]*downsampleField),
+		}
+		d.windows[key] = window
+	}
+
+	for _, field := range m.FieldList() {
+		f, found := window.fields[field.Key]
+		if !found {
+			f = &downsampleField{fn: d.function(field.Key), numeric: true}
+			window.fields[field.Key] = f
+		}
+		f.add(field.Value)
+	}
+
+	if _, ok := m.(telegraf.TrackingMetric); ok {
+		window.tracked = append(window.tracked, m)
+	} else {
+		m.Drop()
+	}
+	return true
+}
+
+// push returns the metrics of all windows completed at the given time and
+// removes those windows
+func (d *downsampler) push(now time.Time) []telegraf.Metric {
+	d.Lock()
+	defer d.Unlock()
+
+	if now.After(d.pushed) {
+		d.pushed = now
+	}
+	return d.emit(func(w *downsampleWindow) bool {
+		return !w.start.Add(d.interval).After(now)
+	})
+}
+
+// pushAll returns the metrics of all windows including incomplete ones and
+// removes those windows
+func (d *downsampler) pushAll() []telegraf.Metric {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.emit(func(*downsampleWindow) bool { return true })
+}
+
+// emit removes the selected windows and returns their aggregated metrics
+func (d *downsampler) emit(selected func(*downsampleWindow) bool) []telegraf.Metric {
+	completed := make([]*downsampleWindow, 0, len(d.windows))
+	for key, window := range d.windows {
+		if !selected(window) {
+			continue
+		}
+		completed = append(completed, window)
+		delete(d.windows, key)
+	}
+
+	// Emit the windows in chronological order
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completed[i].start.Before(completed[j].start)
+	})
+
+	metrics := make([]telegraf.Metric, 0, len(completed))
+	for _, window := range completed {
+		fields := make(map[string]interface{}, len(window.fields))
+		for name, f := range window.fields {
+			fields[name] = f.result()
+		}
+		m := metric.New(window.name, window.tags, fields, window.start)
+
+		// Finish the tracking metrics of the window once the aggregated
+		// metric is delivered
+		if len(window.tracked) > 0 {
+			tracked := window.tracked
+			m, _ = metric.WithTracking(m, func(info telegraf.DeliveryInfo) {
+				for _, t := range tracked {
+					if info.Delivered() {
+						t.Accept()
+					} else {
+						t.Reject()
+					}
+				}
+			})
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// function returns the aggregation function for the given field
+func (d *downsampler) function(field string) string {
+	for _, p := range d.patterns {
+		if p.filter.Match(field) {
+			return p.fn
+		}
+	}
+	return d.defaultFn
+}
+
+func (f *downsampleField) add(value interface{}) {
+	f.count++
+	if f.count == 1 {
+		f.first = value
+	}
+	f.last = value
+
+	var v float64
+	switch x := value.(type) {
+	case float64:
+		v = x
+	case int64:
+		v = float64(x)
+	case uint64:
+		v = float64(x)
+	default:
+		f.numeric = false
+		return
+	}
+
+	if f.count == 1 {
+		f.min, f.max = v, v
+	}
+	f.sum += v
+	f.min = min(f.min, v)
+	f.max = max(f.max, v)
+}
+
+// result returns the aggregated value. Non-numeric fields fall back to the
+// last value for functions requiring numbers.
+func (f *downsampleField) result() interface{} {
+	switch f.fn {
+	case "count":
+		return f.count
+	case "first":
+		return f.first
+	case "last":
+		return f.last
+	}
+
+	if !f.numeric {
+		return f.last
+	}
+	switch f.fn {
+	case "min":
+		return f.min
+	case "max":
+		return f.max
+	case "sum":
+		return f.sum
+	}
+	return f.sum / float64(f.count)
+}
@@ -0,0 +1,246 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDownsampleInvalidSettings(t *testing.T) {
+	_, err := newDownsampler(&DownsampleConfig{}, nil)
+	require.ErrorContains(t, err, "invalid downsample 'interval' setting")
+
+	_, err = newDownsampler(&DownsampleConfig{Interval: time.Minute, Default: "median"}, nil)
+	require.ErrorContains(t, err, "invalid downsample 'default' function")
+
+	_, err = newDownsampler(&DownsampleConfig{Interval: time.Minute, Fields: map[string]string{"value": "avg"}}, nil)
+	require.ErrorContains(t, err, `invalid downsample function "avg" for field "value"`)
+}
+
+func TestDownsampleFunctions(t *testing.T) {
+	d, err := newDownsampler(&DownsampleConfig{
+		Interval: time.Minute,
+		Fields: map[string]string{
+			"min":    "min",
+			"max":    "max",
+			"sum":    "sum",
+			"count":  "count",
+			"first":  "first",
+			"last":   "last",
+			"status": "mean",
+		},
+	}, map[string]string{"output": "downsample_functions"})
+	require.NoError(t, err)
+
+	start := time.Unix(600, 0)
+	for i, v := range []int64{3, 1, 2} {
+		m := metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"min":    v,
+				"max":    v,
+				"sum":    v,
+				"count":  v,
+				"first":  v,
+				"last":   v,
+				"mean":   v,
+				"status": []string{"ok", "warn", "fail"}[i],
+			},
+			start.Add(time.Duration(i)*time.Second),
+		)
+		d.add(m)
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"min":    float64(1),
+				"max":    float64(3),
+				"sum":    float64(6),
+				"count":  int64(3),
+				"first":  int64(3),
+				"last":   int64(2),
+				"mean":   float64(2),
+				"status": "fail",
+			},
+			start,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.push(start.Add(time.Minute)))
+}
+
+func TestDownsampleWindows(t *testing.T) {
+	d, err := newDownsampler(&DownsampleConfig{
+		Interval: time.Minute,
+		GroupBy:  []string{"host"},
+	}, map[string]string{"output": "downsample_windows"})
+	require.NoError(t, err)
+
+	start := time.Unix(600, 0)
+	for i, v := range []float64{1, 3, 10, 20} {
+		d.add(metric.New(
+			"cpu",
+			map[string]string{"host": "a", "cpu": "cpu" + string(rune('0'+i))},
+			map[string]interface{}{"value": v},
+			start.Add(time.Duration(i/2)*time.Minute),
+		))
+	}
+	d.add(metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 5.0}, start))
+
+	// Incomplete windows must be kept
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 2.0}, start),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 5.0}, start),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.push(start.Add(90*time.Second)), testutil.SortMetrics())
+
+	expected = []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 15.0}, start.Add(time.Minute)),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.push(start.Add(2*time.Minute)))
+	require.Empty(t, d.push(start.Add(time.Hour)))
+}
+
+func TestRunningOutputDownsample(t *testing.T) {
+	m := &mockOutput{}
+	ro := NewRunningOutput(
+		m,
+		&OutputConfig{
+			Name: "downsample",
+			Downsample: &DownsampleConfig{
+				Interval: time.Minute,
+				Default:  "max",
+			},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, ro.Init())
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := range 10 {
+		ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": i}, start.Add(time.Duration(i)*time.Second)))
+	}
+	require.Equal(t, 0, ro.BufferLength())
+
+	require.NoError(t, ro.Write())
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 9.0}, start),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestRunningOutputDownsampleAggregatingOutput(t *testing.T) {
+	ro := NewRunningOutput(
+		&mockAggregatingOutput{},
+		&OutputConfig{
+			Name:       "downsample_aggregating",
+			Downsample: &DownsampleConfig{Interval: time.Minute},
+		},
+		5,
+		10,
+	)
+	require.ErrorContains(t, ro.Init(), "'downsample' cannot be used with aggregating outputs")
+}
+
+type mockAggregatingOutput struct {
+	mockOutput
+}
+
+func (*mockAggregatingOutput) Add(telegraf.Metric) {}
+
+func (*mockAggregatingOutput) Push() []telegraf.Metric {
+	return nil
+}
+
+func (*mockAggregatingOutput) Reset() {}
+
+func TestDownsampleLateMetrics(t *testing.T) {
+	d, err := newDownsampler(&DownsampleConfig{Interval: time.Minute}, map[string]string{"output": "downsample_late"})
+	require.NoError(t, err)
+
+	start := time.Unix(600, 0)
+	require.True(t, d.add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start)))
+	require.Len(t, d.push(start.Add(time.Minute)), 1)
+
+	// Metrics of the emitted window must not create a duplicate
+	var rejected bool
+	late := &mockMetric{
+		Metric:  metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, start.Add(time.Second)),
+		RejectF: func() { rejected = true },
+	}
+	require.False(t, d.add(late))
+	require.True(t, rejected)
+	require.Equal(t, int64(1), d.late.Get())
+	require.Empty(t, d.pushAll())
+}
+
+func TestRunningOutputDownsampleDrain(t *testing.T) {
+	m := &mockOutput{}
+	ro := NewRunningOutput(
+		m,
+		&OutputConfig{
+			Name:       "downsample_drain",
+			Downsample: &DownsampleConfig{Interval: time.Hour},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, ro.Init())
+
+	now := time.Now()
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, now))
+
+	// Incomplete windows must only be written on shutdown
+	require.NoError(t, ro.Write())
+	require.Empty(t, m.Metrics())
+	require.NoError(t, ro.Drain())
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, now.Truncate(time.Hour)),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestRunningOutputDownsampleTracking(t *testing.T) {
+	m := &mockOutput{batchAcceptSize: -1}
+	ro := NewRunningOutput(
+		m,
+		&OutputConfig{
+			Name:       "downsample_tracking",
+			Downsample: &DownsampleConfig{Interval: time.Minute},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, ro.Init())
+
+	var delivered []bool
+	notify := func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info.Delivered())
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := range 2 {
+		tm, _ := metric.WithTracking(
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start.Add(time.Duration(i)*time.Second)),
+			notify,
+		)
+		ro.AddMetricNoCopy(tm)
+	}
+
+	// The tracking metrics must not be finished before the aggregated metric
+	// is written
+	require.Error(t, ro.Write())
+	require.Empty(t, delivered)
+
+	m.batchAcceptSize = 0
+	require.NoError(t, ro.Write())
+	require.Equal(t, []bool{true, true}, delivered)
+}
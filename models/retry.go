@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Default upper limit of the delay between write attempts
+	DefaultRetryMaxInterval = 5 * time.Minute
+
+	// Default time writes are paused after opening the circuit-breaker
+	DefaultCircuitBreakerCooldown = time.Minute
+)
+
+// retryPolicy delays the write attempts of an output after failed writes
+// using an exponential backoff with jitter. After a number of consecutive
+// failures the circuit-breaker opens and pauses writing for a cool-down
+// period. Afterwards a single write probes the output and either closes the
+// circuit-breaker on success or opens it again.
+type retryPolicy struct {
+	initial     time.Duration
+	max         time.Duration
+	jitter      time.Duration
+	maxDuration time.Duration
+	threshold   int
+	cooldown    time.Duration
+
+	failures     int
+	firstFailure time.Time
+	next         time.Time
+	open         bool
+
+	circuitOpen selfstat.Stat
+	log         telegraf.Logger
+}
+
+func newRetryPolicy(config *OutputConfig, tags map[string]string, log telegraf.Logger) *retryPolicy {
+	maxInterval := config.RetryMaxInterval
+	if maxInterval == 0 {
+		maxInterval = DefaultRetryMaxInterval
+	}
+	cooldown := config.CircuitBreakerCooldown
+	if cooldown == 0 {
+		cooldown = DefaultCircuitBreakerCooldown
+	}
+
+	return &retryPolicy{
+		initial:     config.RetryInitialInterval,
+		max:         maxInterval,
+		jitter:      config.RetryJitter,
+		maxDuration: config.RetryMaxDuration,
+		threshold:   config.CircuitBreakerThreshold,
+		cooldown:    cooldown,
+		circuitOpen: selfstat.Register("write", "circuit_breaker_open", tags),
+		log:         log,
+	}
+}
+
+// allow checks if a write should be attempted at the given time
+func (p *retryPolicy) allow(now time.Time) bool {
+	return !now.Before(p.next)
+}
+
+// success resets the failure state and closes the circuit-breaker
+func (p *retryPolicy) success() {
+	if p.open {
+		p.log.Infof("Closing circuit-breaker after successful write")
+		p.open = false
+		p.circuitOpen.Set(0)
+	}
+	p.failures = 0
+	p.firstFailure = time.Time{}
+	p.next = time.Time{}
+}
+
+// failure records a failed write at the given time and schedules the next
+// write attempt
+func (p *retryPolicy) failure(now time.Time) {
+	if p.failures == 0 {
+		p.firstFailure = now
+	}
+	p.failures++
+
+	// Open the circuit-breaker if too many writes failed or the probing write
+	// of an open circuit-breaker failed
+	if p.threshold > 0 && (p.open || p.failures >= p.threshold) {
+		if !p.open {
+			p.log.Warnf("Opening circuit-breaker after %d failed writes, pausing writes for %s", p.failures, p.cooldown)
+			p.open = true
+			p.circuitOpen.Set(1)
+		}
+		p.next = now.Add(p.cooldown)
+		return
+	}
+
+	if p.initial <= 0 {
+		return
+	}
+
+	// Double the delay for each consecutive failure up to the maximum
+	delay := p.initial
+	for i := 1; i < p.failures && delay < p.max; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.max) + internal.RandomDuration(p.jitter)
+	p.next = now.Add(delay)
+	p.log.Debugf("Retrying write in %s after %d failed writes", delay, p.failures)
+}
+
+// exceeded checks if writes failed for longer than the maximum retry duration
+func (p *retryPolicy) exceeded(now time.Time) bool {
+	return p.maxDuration > 0 && p.failures > 0 && now.Sub(p.firstFailure) >= p.maxDuration
+}
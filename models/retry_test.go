@@ -0,0 +1,163 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := newRetryPolicy(
+		&OutputConfig{
+			RetryInitialInterval: time.Second,
+			RetryMaxInterval:     4 * time.Second,
+		},
+		map[string]string{"output": "retry_backoff"},
+		testutil.Logger{},
+	)
+
+	now := time.Now()
+	require.True(t, p.allow(now))
+
+	// The delay must double with each failure up to the maximum
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		p.failure(now)
+		require.False(t, p.allow(now.Add(delay-time.Millisecond)))
+		require.True(t, p.allow(now.Add(delay)))
+	}
+
+	// A successful write must reset the delay
+	p.success()
+	require.True(t, p.allow(now))
+	p.failure(now)
+	require.True(t, p.allow(now.Add(time.Second)))
+}
+
+func TestRetryPolicyDisabled(t *testing.T) {
+	p := newRetryPolicy(&OutputConfig{}, map[string]string{"output": "retry_disabled"}, testutil.Logger{})
+
+	now := time.Now()
+	for range 10 {
+		p.failure(now)
+		require.True(t, p.allow(now))
+	}
+	require.False(t, p.exceeded(now.Add(time.Hour)))
+}
+
+func TestRetryPolicyCircuitBreaker(t *testing.T) {
+	p := newRetryPolicy(
+		&OutputConfig{
+			CircuitBreakerThreshold: 2,
+			CircuitBreakerCooldown:  time.Minute,
+		},
+		map[string]string{"output": "retry_circuit_breaker"},
+		testutil.Logger{},
+	)
+
+	now := time.Now()
+	p.failure(now)
+	require.True(t, p.allow(now))
+	require.Equal(t, int64(0), p.circuitOpen.Get())
+
+	// Open the circuit-breaker after reaching the threshold
+	p.failure(now)
+	require.False(t, p.allow(now.Add(30*time.Second)))
+	require.True(t, p.allow(now.Add(time.Minute)))
+	require.Equal(t, int64(1), p.circuitOpen.Get())
+
+	// A failed probe must open the circuit-breaker again
+	now = now.Add(time.Minute)
+	p.failure(now)
+	require.False(t, p.allow(now.Add(30*time.Second)))
+	require.Equal(t, int64(1), p.circuitOpen.Get())
+
+	// A successful probe must close the circuit-breaker
+	p.success()
+	require.True(t, p.allow(now))
+	require.Equal(t, int64(0), p.circuitOpen.Get())
+}
+
+func TestRetryPolicyMaxDuration(t *testing.T) {
+	p := newRetryPolicy(
+		&OutputConfig{RetryMaxDuration: time.Minute},
+		map[string]string{"output": "retry_max_duration"},
+		testutil.Logger{},
+	)
+
+	now := time.Now()
+	p.failure(now)
+	require.False(t, p.exceeded(now.Add(30*time.Second)))
+	p.failure(now.Add(30 * time.Second))
+	require.True(t, p.exceeded(now.Add(time.Minute)))
+
+	p.success()
+	require.False(t, p.exceeded(now.Add(time.Hour)))
+}
+
+func TestRunningOutputRetryMaxDuration(t *testing.T) {
+	m := &mockOutput{batchAcceptSize: -1}
+	ro := NewRunningOutput(m, &OutputConfig{Name: "retry_output_max_duration", RetryMaxDuration: time.Nanosecond}, 5, 10)
+	ro.buffer.Stats().MetricsRejected.Set(0)
+
+	for _, x := range first5 {
+		ro.AddMetric(x)
+	}
+
+	// The metrics must be kept on the first failure and dropped once the
+	// maximum retry duration is exceeded
+	require.Error(t, ro.Write())
+	require.Equal(t, 5, ro.BufferLength())
+	require.Error(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+	require.Equal(t, int64(5), ro.buffer.Stats().MetricsRejected.Get())
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	m := &mockOutput{batchAcceptSize: -1}
+	ro := NewRunningOutput(
+		m,
+		&OutputConfig{
+			Name:                    "retry_output_circuit_breaker",
+			CircuitBreakerThreshold: 1,
+			CircuitBreakerCooldown:  time.Hour,
+		},
+		5,
+		10,
+	)
+
+	for _, x := range first5 {
+		ro.AddMetric(x)
+	}
+
+	// Writes must be skipped while the circuit-breaker is open
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.WriteBatch())
+	require.Equal(t, 1, m.writes)
+	require.Equal(t, 5, ro.BufferLength())
+
+	// The final write on shutdown must not be skipped
+	m.batchAcceptSize = 0
+	require.NoError(t, ro.Drain())
+	require.Equal(t, 2, m.writes)
+	require.Equal(t, 0, ro.BufferLength())
+}
+
+func TestRunningOutputMetricTTL(t *testing.T) {
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, &OutputConfig{Name: "retry_output_metric_ttl", MetricTTL: time.Hour}, 5, 10)
+	ro.buffer.Stats().MetricsExpired.Set(0)
+
+	ro.AddMetric(metric.New("expired", map[string]string{}, map[string]interface{}{"value": 1}, time.Now().Add(-2*time.Hour)))
+	ro.AddMetric(metric.New("current", map[string]string{}, map[string]interface{}{"value": 1}, time.Now()))
+	require.Equal(t, 1, ro.BufferLength())
+	require.Equal(t, int64(1), ro.buffer.Stats().MetricsExpired.Get())
+
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 1)
+	require.Equal(t, "current", m.Metrics()[0].Name())
+}
@@ -118,6 +118,10 @@ func (r *RunningInput) Init() error {
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
 
+	if p, ok := r.Input.(telegraf.PluginIDSetter); ok {
+		p.SetPluginID(r.Config.ID)
+	}
+
 	switch r.Config.TimeSource {
 	case "":
 		r.Config.TimeSource = "metric"
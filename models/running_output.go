@@ -41,6 +41,16 @@ type OutputConfig struct {
 
 	BufferStrategy  string
 	BufferDirectory string
+	MetricTTL       time.Duration
+
+	RetryInitialInterval    time.Duration
+	RetryMaxInterval        time.Duration
+	RetryJitter             time.Duration
+	RetryMaxDuration        time.Duration
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	Downsample *DownsampleConfig
 
 	LogLevel string
 }
@@ -62,8 +72,12 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer Buffer
-	log    telegraf.Logger
+	buffer       Buffer
+	backpressure *Backpressure
+	throttled    atomic.Bool
+	retry        *retryPolicy
+	downsampler  *downsampler
+	log          telegraf.Logger
 
 	started bool
 	retries uint64
@@ -100,7 +114,7 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		batchSize = DefaultMetricBatchSize
 	}
 
-	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory)
+	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.MetricTTL, config.BufferStrategy, config.BufferDirectory)
 	if err != nil {
 		panic(err)
 	}
@@ -127,7 +141,8 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 			"startup_errors",
 			tags,
 		),
-		log: logger,
+		retry: newRetryPolicy(config, tags, logger),
+		log:   logger,
 	}
 
 	return ro
@@ -151,10 +166,32 @@ func (r *RunningOutput) ID() string {
 
 func (r *RunningOutput) Init() error {
 	switch r.Config.StartupErrorBehavior {
-	case "", "error", "retry", "ignore":
+	case "", "error", "retry", "ignore", "probe":
 	default:
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
+	if r.Config.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("invalid 'circuit_breaker_threshold' setting %d", r.Config.CircuitBreakerThreshold)
+	}
+
+	if r.Config.Downsample != nil {
+		if _, ok := r.Output.(telegraf.AggregatingOutput); ok {
+			return errors.New("'downsample' cannot be used with aggregating outputs")
+		}
+		tags := map[string]string{"output": r.Config.Name}
+		if r.Config.Alias != "" {
+			tags["alias"] = r.Config.Alias
+		}
+		d, err := newDownsampler(r.Config.Downsample, tags)
+		if err != nil {
+			return err
+		}
+		r.downsampler = d
+	}
+
+	if p, ok := r.Output.(telegraf.PluginIDSetter); ok {
+		p.SetPluginID(r.Config.ID)
+	}
 
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -186,7 +223,7 @@ func (r *RunningOutput) Connect() error {
 	case "retry":
 		r.log.Infof("Connect failed: %v; retrying...", err)
 		return nil
-	case "ignore":
+	case "ignore", "probe":
 		return &internal.FatalError{Err: serr}
 	default:
 		r.log.Errorf("Invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
@@ -195,6 +232,16 @@ func (r *RunningOutput) Connect() error {
 	return err
 }
 
+// Probe checks the output's ability to write metrics after connecting if the
+// plugin supports probing and the startup-error behavior is set to "probe".
+func (r *RunningOutput) Probe() error {
+	p, ok := r.Output.(telegraf.ProbePlugin)
+	if !ok || r.Config.StartupErrorBehavior != "probe" {
+		return nil
+	}
+	return p.Probe()
+}
+
 // Close closes the output
 func (r *RunningOutput) Close() {
 	if err := r.Output.Close(); err != nil {
@@ -260,8 +307,18 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 		metric.AddSuffix(r.Config.NameSuffix)
 	}
 
+	// Downsampled metrics are added to the buffer on write once their window
+	// is complete
+	if r.downsampler != nil {
+		if !r.downsampler.add(metric) {
+			r.log.Debugf("Dropped late metric %q for already written downsampling window", metric.Name())
+		}
+		return
+	}
+
 	dropped := r.buffer.Add(metric)
 	atomic.AddInt64(&r.droppedMetrics, int64(dropped))
+	r.updateBackpressure()
 
 	count := atomic.AddInt64(&r.newMetricsCount, 1)
 	if count == int64(r.MetricBatchSize) {
@@ -276,6 +333,37 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (r *RunningOutput) Write() error {
+	// Buffer the metrics of completed downsampling windows
+	if r.downsampler != nil {
+		r.addDownsampled(r.downsampler.push(time.Now()))
+	}
+
+	// Skip the write while backing off from previous failures
+	if !r.retry.allow(time.Now()) {
+		return nil
+	}
+
+	return r.write()
+}
+
+// Drain writes all metrics to the output on shutdown. In contrast to Write,
+// incomplete downsampling windows are flushed and the write is attempted
+// even while backing off from previous failures.
+func (r *RunningOutput) Drain() error {
+	if r.downsampler != nil {
+		r.addDownsampled(r.downsampler.pushAll())
+	}
+
+	return r.write()
+}
+
+func (r *RunningOutput) addDownsampled(metrics []telegraf.Metric) {
+	dropped := r.buffer.Add(metrics...)
+	atomic.AddInt64(&r.droppedMetrics, int64(dropped))
+	r.updateBackpressure()
+}
+
+func (r *RunningOutput) write() error {
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
@@ -283,6 +371,7 @@ func (r *RunningOutput) Write() error {
 			var serr *internal.StartupError
 			if !errors.As(err, &serr) || !serr.Retry || !serr.Partial {
 				r.StartupErrors.Incr(1)
+				r.retry.failure(time.Now())
 				return internal.ErrNotConnected
 			}
 			r.log.Debugf("Partially connected after %d attempts", r.retries)
@@ -313,7 +402,9 @@ func (r *RunningOutput) Write() error {
 		}
 		err := r.writeMetrics(tx.Batch)
 		r.updateTransaction(tx, err)
+		r.updateRetry(tx, err)
 		r.buffer.EndTransaction(tx)
+		r.updateBackpressure()
 		if err != nil {
 			return err
 		}
@@ -323,11 +414,17 @@ func (r *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (r *RunningOutput) WriteBatch() error {
+	// Skip the write while backing off from previous failures
+	if !r.retry.allow(time.Now()) {
+		return nil
+	}
+
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
 		if err := r.Output.Connect(); err != nil {
 			r.StartupErrors.Incr(1)
+			r.retry.failure(time.Now())
 			return internal.ErrNotConnected
 		}
 		r.started = true
@@ -340,7 +437,9 @@ func (r *RunningOutput) WriteBatch() error {
 	}
 	err := r.writeMetrics(tx.Batch)
 	r.updateTransaction(tx, err)
+	r.updateRetry(tx, err)
 	r.buffer.EndTransaction(tx)
+	r.updateBackpressure()
 
 	return err
 }
@@ -383,6 +482,50 @@ func (*RunningOutput) updateTransaction(tx *Transaction, err error) {
 	tx.Reject = writeErr.MetricsReject
 }
 
+// updateRetry records the outcome of the write for the retry policy. Metrics
+// kept for the next write are dropped if writes failed for longer than the
+// maximum retry duration.
+func (r *RunningOutput) updateRetry(tx *Transaction, err error) {
+	// Writes handling at least a part of the metrics reached the service
+	if len(tx.Accept) > 0 || len(tx.Reject) > 0 {
+		r.retry.success()
+		return
+	}
+	if err == nil {
+		return
+	}
+
+	now := time.Now()
+	r.retry.failure(now)
+	if r.retry.exceeded(now) {
+		tx.Reject = tx.InferKeep()
+		r.log.Warnf("Dropping %d metrics after failing to write for more than %s", len(tx.Reject), r.Config.RetryMaxDuration)
+	}
+}
+
+// SetBackpressure registers the output's buffer with the given backpressure
+// signal to throttle the inputs if the output cannot keep up. Disk buffers
+// are not limited in size so they never overflow and are not registered.
+func (r *RunningOutput) SetBackpressure(b *Backpressure) {
+	if b == nil || !b.enabled() || r.MetricBufferLimit <= 0 || r.Config.BufferStrategy == "disk" {
+		return
+	}
+	r.backpressure = b
+}
+
+func (r *RunningOutput) updateBackpressure() {
+	if r.backpressure == nil {
+		return
+	}
+
+	// Only take the shared lock when crossing a watermark
+	length := r.buffer.Len()
+	if !r.backpressure.crossing(r.throttled.Load(), length, r.MetricBufferLimit) {
+		return
+	}
+	r.backpressure.update(r, length, r.MetricBufferLimit)
+}
+
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
 	if r.Config.BufferStrategy == "disk" {
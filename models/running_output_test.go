@@ -437,10 +437,13 @@ func TestRunningOutputInternalMetrics(t *testing.T) {
 				"metrics_added":    0,
 				"metrics_rejected": 0,
 				"metrics_dropped":  0,
+				"metrics_expired":  0,
 				"metrics_filtered": 0,
 				"metrics_written":  0,
 				"write_time_ns":    0,
 				"startup_errors":   0,
+
+				"circuit_breaker_open": 0,
 			},
 			time.Unix(0, 0),
 		),
@@ -471,6 +474,20 @@ func TestRunningOutputStartupBehaviorInvalid(t *testing.T) {
 	require.ErrorContains(t, ro.Init(), "invalid 'startup_error_behavior'")
 }
 
+func TestRunningOutputSetPluginID(t *testing.T) {
+	plugin := &mockIDOutput{}
+	ro := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Name: "test_name",
+			ID:   "test_id",
+		},
+		5, 10,
+	)
+	require.NoError(t, ro.Init())
+	require.Equal(t, "test_id", plugin.id)
+}
+
 func TestRunningOutputRetryableStartupBehaviorDefault(t *testing.T) {
 	serr := &internal.StartupError{
 		Err:   errors.New("retryable err"),
@@ -590,6 +607,86 @@ func TestRunningOutputRetryableStartupBehaviorIgnore(t *testing.T) {
 	require.False(t, ro.started)
 }
 
+func TestRunningOutputRetryableStartupBehaviorProbe(t *testing.T) {
+	serr := &internal.StartupError{
+		Err:   errors.New("retryable err"),
+		Retry: true,
+	}
+	mo := &mockOutput{
+		startupErrorCount: 2,
+		startupError:      serr,
+	}
+	ro := NewRunningOutput(
+		mo,
+		&OutputConfig{
+			Filter:               Filter{},
+			Name:                 "test_name",
+			Alias:                "test_alias",
+			StartupErrorBehavior: "probe",
+		},
+		5, 10,
+	)
+	require.NoError(t, ro.Init())
+
+	// For probe, Connect() should behave like ignore and return a fatal error
+	var fatalErr *internal.FatalError
+	require.ErrorAs(t, ro.Connect(), &fatalErr)
+	require.ErrorIs(t, fatalErr, serr)
+	require.False(t, ro.started)
+}
+
+func TestRunningOutputProbingFailure(t *testing.T) {
+	ro := NewRunningOutput(
+		&mockProbingOutput{probeReturn: errors.New("probing error")},
+		&OutputConfig{
+			Name:                 "test_name",
+			StartupErrorBehavior: "probe",
+		},
+		5, 10,
+	)
+	require.NoError(t, ro.Init())
+	require.NoError(t, ro.Connect())
+	require.ErrorContains(t, ro.Probe(), "probing error")
+}
+
+func TestRunningOutputProbingSuccess(t *testing.T) {
+	probeErr := errors.New("probing error")
+	for _, tt := range []struct {
+		name                 string
+		output               telegraf.Output
+		startupErrorBehavior string
+	}{
+		{
+			name:                 "non-probing plugin with probe value set",
+			output:               &mockOutput{},
+			startupErrorBehavior: "probe",
+		},
+		{
+			name:                 "probing plugin with probe value set",
+			output:               &mockProbingOutput{},
+			startupErrorBehavior: "probe",
+		},
+		{
+			name:                 "probing plugin with probe value not set",
+			output:               &mockProbingOutput{probeReturn: probeErr},
+			startupErrorBehavior: "ignore",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ro := NewRunningOutput(
+				tt.output,
+				&OutputConfig{
+					Name:                 "test_name",
+					StartupErrorBehavior: tt.startupErrorBehavior,
+				},
+				5, 10,
+			)
+			require.NoError(t, ro.Init())
+			require.NoError(t, ro.Probe())
+		})
+	}
+}
+
 func TestRunningOutputNonRetryableStartupBehaviorDefault(t *testing.T) {
 	serr := &internal.StartupError{
 		Err:   errors.New("non-retryable err"),
@@ -975,6 +1072,24 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+type mockProbingOutput struct {
+	mockOutput
+	probeReturn error
+}
+
+func (m *mockProbingOutput) Probe() error {
+	return m.probeReturn
+}
+
+type mockIDOutput struct {
+	mockOutput
+	id string
+}
+
+func (m *mockIDOutput) SetPluginID(id string) {
+	m.id = id
+}
+
 type perfOutput struct {
 	// if true, mock write failure
 	failWrite bool
@@ -1,7 +1,15 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/influxdata/telegraf"
 	logging "github.com/influxdata/telegraf/logger"
@@ -15,6 +23,7 @@ type RunningParser struct {
 
 	MetricsParsed selfstat.Stat
 	ParseTime     selfstat.Stat
+	ParseErrors   selfstat.Stat
 }
 
 func NewRunningParser(parser telegraf.Parser, config *ParserConfig) *RunningParser {
@@ -33,6 +42,14 @@ func NewRunningParser(parser telegraf.Parser, config *ParserConfig) *RunningPars
 	}
 	SetLoggerOnPlugin(parser, logger)
 
+	// Count parse errors per parser instance, i.e. distinguish the plugin
+	// the parser belongs to.
+	errorTags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		errorTags[k] = v
+	}
+	errorTags["parent"] = config.Parent
+
 	return &RunningParser{
 		Parser: parser,
 		Config: config,
@@ -46,6 +63,11 @@ func NewRunningParser(parser telegraf.Parser, config *ParserConfig) *RunningPars
 			"parse_time_ns",
 			tags,
 		),
+		ParseErrors: selfstat.Register(
+			"parser",
+			"parse_errors",
+			errorTags,
+		),
 		log: logger,
 	}
 }
@@ -57,6 +79,14 @@ type ParserConfig struct {
 	DataFormat  string
 	DefaultTags map[string]string
 	LogLevel    string
+
+	// Category of the plugin the parser belongs to e.g. "inputs"
+	Category string
+
+	// Sink for payloads failing to parse, either empty (disabled), "log" or
+	// "file". For the latter, records are appended to ParseErrorFile.
+	ParseErrorSink string
+	ParseErrorFile string
 }
 
 func (r *RunningParser) LogName() string {
@@ -64,6 +94,16 @@ func (r *RunningParser) LogName() string {
 }
 
 func (r *RunningParser) Init() error {
+	switch r.Config.ParseErrorSink {
+	case "", "log":
+	case "file":
+		if r.Config.ParseErrorFile == "" {
+			return errors.New("'parse_error_file' required for parse-error sink \"file\"")
+		}
+	default:
+		return fmt.Errorf("invalid 'parse_error_sink' setting %q", r.Config.ParseErrorSink)
+	}
+
 	if p, ok := r.Parser.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -79,6 +119,9 @@ func (r *RunningParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	elapsed := time.Since(start)
 	r.ParseTime.Incr(elapsed.Nanoseconds())
 	r.MetricsParsed.Incr(int64(len(m)))
+	if err != nil {
+		r.handleParseError(buf, err)
+	}
 
 	return m, err
 }
@@ -89,10 +132,185 @@ func (r *RunningParser) ParseLine(line string) (telegraf.Metric, error) {
 	elapsed := time.Since(start)
 	r.ParseTime.Incr(elapsed.Nanoseconds())
 	r.MetricsParsed.Incr(1)
+	if err != nil {
+		r.handleParseError([]byte(line), err)
+	}
 
 	return m, err
 }
 
+// ParseStream parses the data read from the given reader and calls the given
+// function for each metric. If the underlying parser does not support
+// streaming, the whole data is read into memory and parsed at once.
+func (r *RunningParser) ParseStream(reader io.Reader, fn func(telegraf.Metric) error) error {
+	sp, ok := r.Parser.(telegraf.StreamingParser)
+	if !ok {
+		return parseAll(r, reader, fn)
+	}
+
+	// Keep the most recently read data for routing it to the sink in case of
+	// errors. The data is limited in size to not defeat the purpose of
+	// streaming with respect to memory consumption.
+	var payload *tailBuffer
+	if r.Config.ParseErrorSink != "" {
+		payload = &tailBuffer{limit: maxStreamErrorPayload}
+		reader = io.TeeReader(reader, payload)
+	}
+
+	var count int64
+	var callbackTime time.Duration
+	var callbackErr error
+	start := time.Now()
+	err := sp.ParseStream(reader, func(m telegraf.Metric) error {
+		count++
+		cbStart := time.Now()
+		defer func() { callbackTime += time.Since(cbStart) }()
+		callbackErr = fn(m)
+		return callbackErr
+	})
+	elapsed := time.Since(start) - callbackTime
+	r.ParseTime.Incr(elapsed.Nanoseconds())
+	r.MetricsParsed.Incr(count)
+
+	// Errors returned by the callback are no parsing errors
+	if err != nil && (callbackErr == nil || !errors.Is(err, callbackErr)) {
+		var buf []byte
+		if payload != nil {
+			buf = payload.buf
+		}
+		r.handleParseError(buf, err)
+	}
+
+	return err
+}
+
+// ParseStream parses the data read from the given reader using the given
+// parser and calls the given function for each metric. The data is streamed
+// to the parser if supported, otherwise it is read into memory and parsed at
+// once. Running parsers always stream to the wrapped parser if possible.
+func ParseStream(parser telegraf.Parser, reader io.Reader, fn func(telegraf.Metric) error) error {
+	if sp, ok := parser.(telegraf.StreamingParser); ok {
+		return sp.ParseStream(reader, fn)
+	}
+	return parseAll(parser, reader, fn)
+}
+
+func parseAll(parser telegraf.Parser, reader io.Reader, fn func(telegraf.Metric) error) error {
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("reading data failed: %w", err)
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Maximum amount of streamed data passed to the parse-error sink
+const maxStreamErrorPayload = 64 * 1024
+
+// tailBuffer keeps the last data written to it up to the given limit
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+// ParseErrorRecord describes a payload that failed to parse and is sent to
+// the configured parse-error sink.
+type ParseErrorRecord struct {
+	Time     time.Time `json:"time"`
+	Plugin   string    `json:"plugin"`
+	Parser   string    `json:"parser"`
+	Alias    string    `json:"alias,omitempty"`
+	Error    string    `json:"error"`
+	Offset   int       `json:"offset"`
+	Payload  string    `json:"payload"`
+	Encoding string    `json:"encoding,omitempty"`
+}
+
+// offsetError is implemented by parser errors that know the byte offset of
+// the error within the payload.
+type offsetError interface {
+	ErrorOffset() int
+}
+
+// Serialize writes to the parse-error files of all parsers
+var parseErrorFileMu sync.Mutex
+
+func (r *RunningParser) handleParseError(buf []byte, err error) {
+	r.ParseErrors.Incr(1)
+
+	if r.Config.ParseErrorSink == "" {
+		return
+	}
+
+	record := &ParseErrorRecord{
+		Time:   time.Now(),
+		Plugin: r.Config.Parent,
+		Parser: r.Config.DataFormat,
+		Alias:  r.Config.Alias,
+		Error:  err.Error(),
+		Offset: -1,
+	}
+	if r.Config.Category != "" {
+		record.Plugin = r.Config.Category + "." + r.Config.Parent
+	}
+	var oerr offsetError
+	if errors.As(err, &oerr) {
+		record.Offset = oerr.ErrorOffset()
+	}
+	if utf8.Valid(buf) {
+		record.Payload = string(buf)
+	} else {
+		record.Payload = base64.StdEncoding.EncodeToString(buf)
+		record.Encoding = "base64"
+	}
+
+	switch r.Config.ParseErrorSink {
+	case "log":
+		r.log.Warnf("Unparseable payload of plugin %q at offset %d (%s): %q", record.Plugin, record.Offset, record.Error, record.Payload)
+	case "file":
+		if err := writeParseErrorRecord(r.Config.ParseErrorFile, record); err != nil {
+			r.log.Errorf("Writing parse-error record failed: %v", err)
+		}
+	}
+}
+
+func writeParseErrorRecord(filename string, record *ParseErrorRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	parseErrorFileMu.Lock()
+	defer parseErrorFileMu.Unlock()
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func (r *RunningParser) SetDefaultTags(tags map[string]string) {
 	r.Parser.SetDefaultTags(tags)
 }
@@ -0,0 +1,209 @@
+package models_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestRunningParserInvalidSink(t *testing.T) {
+	rp := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{
+		Parent:         "test",
+		DataFormat:     "influx",
+		ParseErrorSink: "foo",
+	})
+	require.ErrorContains(t, rp.Init(), "invalid 'parse_error_sink' setting")
+
+	rp = models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{
+		Parent:         "test",
+		DataFormat:     "influx",
+		ParseErrorSink: "file",
+	})
+	require.ErrorContains(t, rp.Init(), "'parse_error_file' required")
+}
+
+func TestRunningParserParseErrorFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "errors.jsonl")
+
+	rp := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{
+		Parent:         "file_error_test",
+		Category:       "inputs",
+		DataFormat:     "influx",
+		ParseErrorSink: "file",
+		ParseErrorFile: filename,
+	})
+	require.NoError(t, rp.Init())
+	errorsBefore := rp.ParseErrors.Get()
+
+	// Valid data must not produce any record
+	_, err := rp.Parse([]byte("cpu value=42 0\n"))
+	require.NoError(t, err)
+	require.NoFileExists(t, filename)
+
+	// Invalid data
+	payload := "cpu value=42 0\ncpu value=\n"
+	_, err = rp.Parse([]byte(payload))
+	require.Error(t, err)
+	_, err = rp.ParseLine("cpu")
+	require.Error(t, err)
+	_, err = rp.Parse([]byte{'c', 0xff, 0xfe, ' '})
+	require.Error(t, err)
+
+	records := readParseErrorRecords(t, filename)
+	require.Len(t, records, 3)
+
+	require.Equal(t, "inputs.file_error_test", records[0].Plugin)
+	require.Equal(t, "influx", records[0].Parser)
+	require.Equal(t, payload, records[0].Payload)
+	require.Empty(t, records[0].Encoding)
+	require.Equal(t, 25, records[0].Offset)
+	require.Contains(t, records[0].Error, "metric parse error")
+
+	require.Equal(t, "cpu", records[1].Payload)
+
+	require.Equal(t, "base64", records[2].Encoding)
+	require.Equal(t, "Y//+IA==", records[2].Payload)
+
+	require.EqualValues(t, 3, rp.ParseErrors.Get()-errorsBefore)
+}
+
+func TestParseStreamNonStreamingParser(t *testing.T) {
+	parser := &json_v2.Parser{
+		Configs: []json_v2.Config{
+			{
+				MeasurementName: "test",
+				Fields:          []json_v2.DataSet{{Path: "value", Type: "int"}},
+			},
+		},
+	}
+	require.NoError(t, parser.Init())
+
+	// Non-streaming parsers get the whole data at once
+	p := &nonStreamingParser{Parser: parser}
+	var acc testutil.Accumulator
+	err := models.ParseStream(p, strings.NewReader(`{"value": 42}`), func(m telegraf.Metric) error {
+		acc.AddMetric(m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+	require.Equal(t, 1, p.calls)
+}
+
+type nonStreamingParser struct {
+	telegraf.Parser
+	calls int
+}
+
+func (p *nonStreamingParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	p.calls++
+	return p.Parser.Parse(buf)
+}
+
+func TestRunningParserParseStreamErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "errors.jsonl")
+
+	rp := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{
+		Parent:         "stream_error_test",
+		DataFormat:     "influx",
+		ParseErrorSink: "file",
+		ParseErrorFile: filename,
+	})
+	require.NoError(t, rp.Init())
+	errorsBefore := rp.ParseErrors.Get()
+
+	// Errors of the callback must not be treated as parsing errors
+	err := rp.ParseStream(strings.NewReader("cpu value=42 0\n"), func(telegraf.Metric) error {
+		return os.ErrClosed
+	})
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.NoFileExists(t, filename)
+	require.Equal(t, errorsBefore, rp.ParseErrors.Get())
+
+	// The full payload should be recorded even if parsing stops early
+	payload := "cpu value=42 0\ncpu value=\ncpu value=23 0\n"
+	var acc testutil.Accumulator
+	err = rp.ParseStream(strings.NewReader(payload), func(m telegraf.Metric) error {
+		acc.AddMetric(m)
+		return nil
+	})
+	require.Error(t, err)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	records := readParseErrorRecords(t, filename)
+	require.Len(t, records, 1)
+	require.Equal(t, "stream_error_test", records[0].Plugin)
+	require.Equal(t, payload, records[0].Payload)
+	require.EqualValues(t, 1, rp.ParseErrors.Get()-errorsBefore)
+}
+
+func TestRunningParserParseStreamErrorPayloadLimit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "errors.jsonl")
+
+	rp := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{
+		Parent:         "stream_limit_test",
+		DataFormat:     "influx",
+		ParseErrorSink: "file",
+		ParseErrorFile: filename,
+	})
+	require.NoError(t, rp.Init())
+
+	// Only the data read last should be recorded for large payloads
+	payload := strings.Repeat("cpu value=42 0\n", 10000) + "cpu value=\n"
+	err := rp.ParseStream(strings.NewReader(payload), func(telegraf.Metric) error { return nil })
+	require.Error(t, err)
+
+	records := readParseErrorRecords(t, filename)
+	require.Len(t, records, 1)
+	require.Len(t, records[0].Payload, 64*1024)
+	require.True(t, strings.HasSuffix(records[0].Payload, "cpu value=\n"))
+}
+
+func TestRunningParserParseErrorsPerInstance(t *testing.T) {
+	a := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{Parent: "instance_a", DataFormat: "influx"})
+	require.NoError(t, a.Init())
+	b := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{Parent: "instance_b", DataFormat: "influx"})
+	require.NoError(t, b.Init())
+	errorsBeforeA := a.ParseErrors.Get()
+	errorsBeforeB := b.ParseErrors.Get()
+
+	_, err := a.Parse([]byte("cpu value=\n"))
+	require.Error(t, err)
+	_, err = a.Parse([]byte("cpu value=\n"))
+	require.Error(t, err)
+	_, err = b.Parse([]byte("cpu value=\n"))
+	require.Error(t, err)
+
+	require.EqualValues(t, 2, a.ParseErrors.Get()-errorsBeforeA)
+	require.EqualValues(t, 1, b.ParseErrors.Get()-errorsBeforeB)
+}
+
+func readParseErrorRecords(t *testing.T, filename string) []models.ParseErrorRecord {
+	t.Helper()
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []models.ParseErrorRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var r models.ParseErrorRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
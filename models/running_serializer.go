@@ -1,6 +1,7 @@
 package models
 
 import (
+	"io"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -101,6 +102,43 @@ func (r *RunningSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, e
 	return buf, err
 }
 
+// SerializeBatchTo serializes the given metrics and writes the result to the
+// given writer. If the underlying serializer does not support streaming, the
+// batch is serialized into memory first.
+func (r *RunningSerializer) SerializeBatchTo(w io.Writer, metrics []telegraf.Metric) error {
+	ss, ok := r.Serializer.(telegraf.StreamingSerializer)
+	if !ok {
+		buf, err := r.SerializeBatch(metrics)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+
+	cw := &countingWriter{w: w}
+	start := time.Now()
+	err := ss.SerializeBatchTo(cw, metrics)
+	elapsed := time.Since(start)
+	r.SerializationTime.Incr(elapsed.Nanoseconds())
+	r.MetricsSerialized.Incr(int64(len(metrics)))
+	r.BytesSerialized.Incr(cw.n)
+
+	return err
+}
+
 func (r *RunningSerializer) Log() telegraf.Logger {
 	return r.log
 }
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "io"
+
 // Parser is an interface defining functions that a parser plugin must satisfy.
 type Parser interface {
 	// Parse takes a byte buffer separated by newlines
@@ -22,6 +24,19 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamingParser is an optional interface for parsers able to process data
+// incrementally from a reader instead of requiring the whole payload in
+// memory.
+type StreamingParser interface {
+	Parser
+
+	// ParseStream reads data from the given reader and calls the given
+	// function for each metric as soon as it is parsed. Parsing stops at the
+	// end of the data, on the first parsing error or if the callback returns
+	// an error. In both error cases the error is returned.
+	ParseStream(r io.Reader, fn func(Metric) error) error
+}
+
 // ParserFunc is a function to create a new instance of a parser
 type ParserFunc func() (Parser, error)
 
@@ -37,6 +37,15 @@ type PluginWithID interface {
 	ID() string
 }
 
+// PluginIDSetter is implemented by input and output plugins requiring the
+// identifier of the plugin instance, e.g. to derive names that have to be
+// stable across Telegraf runs.
+type PluginIDSetter interface {
+	// SetPluginID is called once before Init() with the ID generated from
+	// the plugin's configuration.
+	SetPluginID(id string)
+}
+
 // StatefulPlugin contains the functions that plugins must implement to
 // persist an internal state across Telegraf runs.
 // Note that plugins may define a persister that is not part of the
@@ -0,0 +1,276 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/delivery"
+)
+
+// Entry is the committed read position of a file. Device and inode identify
+// the file independent of its path to detect rotation and renaming. They are
+// zero on platforms not providing this information.
+type Entry struct {
+	Device uint64 `json:"device,omitempty"`
+	Inode  uint64 `json:"inode,omitempty"`
+	Offset int64  `json:"offset"`
+}
+
+// State is the persistable form of the checkpoints keyed by the file path
+type State struct {
+	Files map[string]Entry `json:"files"`
+}
+
+// UnmarshalJSON decodes the state while also accepting the legacy format of
+// a plain path to offset mapping used by earlier versions of the tail plugin.
+func (s *State) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	s.Files = make(map[string]Entry, len(raw))
+	if files, found := raw["files"]; found && len(files) > 0 && files[0] == '{' {
+		return json.Unmarshal(files, &s.Files)
+	}
+
+	for path, v := range raw {
+		var offset int64
+		if err := json.Unmarshal(v, &offset); err != nil {
+			return fmt.Errorf("invalid legacy offset for %q: %w", path, err)
+		}
+		s.Files[path] = Entry{Offset: offset}
+	}
+	return nil
+}
+
+type file struct {
+	device uint64
+	inode  uint64
+	delivery.Sequence[int64]
+}
+
+func (f *file) entry() Entry {
+	return Entry{Device: f.device, Inode: f.inode, Offset: f.Committed}
+}
+
+// Checkpoints keeps track of the read positions of files. Positions are only
+// committed after all data read up to that position was processed by the
+// outputs, providing at-least-once semantics across restarts. Similar to the
+// message queue consumers, data rejected by an output does not block the
+// position as reading it again would fail in the same way.
+type Checkpoints struct {
+	files   map[string]*file
+	tracker *delivery.Tracker[int64]
+
+	sync.Mutex
+}
+
+// New creates an empty set of checkpoints
+func New() *Checkpoints {
+	return &Checkpoints{
+		files:   make(map[string]*file),
+		tracker: delivery.NewTracker[int64](),
+	}
+}
+
+// Open determines the offset to continue reading the file at the given path
+// and starts tracking the file. This is the position after the data tracked
+// last, or the committed position if no data is waiting for delivery. If a
+// checkpoint for the path exists but refers to a different file, i.e. the file
+// was rotated, the new file is read from the beginning. If the file shrunk
+// below the position, i.e. it was truncated, reading also restarts at the
+// beginning. A checkpoint of a renamed file is taken over by the new path. For
+// unknown files reading starts at the end if fromEnd is set and at the
+// beginning otherwise.
+func (c *Checkpoints) Open(path string, fromEnd bool) (int64, error) {
+	return c.open(path, fromEnd, true)
+}
+
+// OpenStream determines the offset to start reading the file at the given
+// path similar to Open. However, the offset refers to a stream derived from
+// the file, e.g. the decompressed content, so truncation cannot be detected
+// and unknown files are always read from the beginning.
+func (c *Checkpoints) OpenStream(path string) (int64, error) {
+	return c.open(path, false, false)
+}
+
+func (c *Checkpoints) open(path string, fromEnd, checkSize bool) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	device, inode := identify(info)
+
+	c.Lock()
+	defer c.Unlock()
+
+	f, found := c.files[path]
+	if !found {
+		f = c.takeover(path, device, inode)
+	}
+	if f == nil {
+		f = &file{device: device, inode: inode}
+		if fromEnd {
+			f.Committed = info.Size()
+		}
+		c.files[path] = f
+		return f.Committed, nil
+	}
+
+	position := f.Latest()
+
+	// Pending records of a previous read of a rotated or truncated file
+	// cannot be committed anymore as their offsets refer to different data
+	if !sameFile(f.entry(), device, inode) || (checkSize && info.Size() < position) {
+		f.Reset(0)
+		position = 0
+	}
+	f.device, f.inode = device, inode
+
+	return position, nil
+}
+
+// takeover moves the checkpoint of a file identified by device and inode but
+// stored under a different path to the given path. This assumes the lock is
+// held.
+func (c *Checkpoints) takeover(path string, device, inode uint64) *file {
+	if device == 0 && inode == 0 {
+		return nil
+	}
+	for p, f := range c.files {
+		if f.device != device || f.inode != inode {
+			continue
+		}
+		// Only take over files that are not actively tracked anymore
+		if f.Pending() > 0 {
+			return nil
+		}
+		if _, err := os.Stat(p); err == nil {
+			return nil
+		}
+		delete(c.files, p)
+		c.files[path] = f
+		return f
+	}
+	return nil
+}
+
+// Track registers data read from the file up to the given offset and sent
+// to the outputs with the given tracking ID. The offset is committed once
+// the tracking ID and all previous ones of the file are delivered.
+func (c *Checkpoints) Track(id telegraf.TrackingID, path string, offset int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	var seq *delivery.Sequence[int64]
+	if f, found := c.files[path]; found {
+		seq = &f.Sequence
+	}
+	c.tracker.Track(id, seq, offset)
+}
+
+// Advance moves the position of the file to the given offset without any data
+// requiring delivery, e.g. for empty lines or data not producing metrics.
+func (c *Checkpoints) Advance(path string, offset int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	if f, found := c.files[path]; found {
+		f.Advance(offset)
+	}
+}
+
+// Delivered handles the delivery notification of tracked data and commits
+// the position of the corresponding file if possible. Records of removed or
+// reopened files are not part of the current file anymore and are ignored.
+func (c *Checkpoints) Delivered(info telegraf.DeliveryInfo) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.tracker.Delivered(info.ID())
+}
+
+// Pending checks if data read from the file at the given path still waits
+// for delivery
+func (c *Checkpoints) Pending(path string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	f, found := c.files[path]
+	return found && f.Pending() > 0
+}
+
+// Committed returns the committed offset for the given path
+func (c *Checkpoints) Committed(path string) (int64, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	f, found := c.files[path]
+	if !found {
+		return 0, false
+	}
+	return f.Committed, true
+}
+
+// Reset restarts the file at the given path from the beginning, e.g. after
+// the file was reopened due to rotation or truncation
+func (c *Checkpoints) Reset(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	device, inode := identify(info)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.files[path] = &file{device: device, inode: inode}
+	return nil
+}
+
+// Remove stops tracking the file at the given path and drops its checkpoint.
+// Deliveries of data still in flight for the file are ignored.
+func (c *Checkpoints) Remove(path string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.files, path)
+}
+
+// GetState returns a copy of the committed positions
+func (c *Checkpoints) GetState() State {
+	c.Lock()
+	defer c.Unlock()
+
+	state := State{Files: make(map[string]Entry, len(c.files))}
+	for path, f := range c.files {
+		state.Files[path] = f.entry()
+	}
+	return state
+}
+
+// SetState replaces the committed positions with the given state
+func (c *Checkpoints) SetState(state State) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.files = make(map[string]*file, len(state.Files))
+	c.tracker.Reset()
+	for path, entry := range state.Files {
+		f := &file{device: entry.Device, inode: entry.Inode}
+		f.Committed = entry.Offset
+		c.files[path] = f
+	}
+}
+
+func sameFile(entry Entry, device, inode uint64) bool {
+	// Legacy entries or platforms without file identity
+	if entry.Device == 0 && entry.Inode == 0 {
+		return true
+	}
+	return entry.Device == device && entry.Inode == inode
+}
@@ -0,0 +1,279 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+type deliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (d *deliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (d *deliveryInfo) Delivered() bool {
+	return d.delivered
+}
+
+func TestOpenUnknown(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	offset, err := c.Open(fn, false)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+
+	c = New()
+	offset, err = c.Open(fn, true)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, offset)
+}
+
+func TestCommitAfterDelivery(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\nbaz\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+
+	c.Track(1, fn, 4)
+	c.Track(2, fn, 8)
+	c.Track(3, fn, 12)
+
+	// Out of order delivery must not commit the gap
+	c.Delivered(&deliveryInfo{id: 2, delivered: true})
+	offset, found := c.Committed(fn)
+	require.True(t, found)
+	require.Zero(t, offset)
+
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	offset, _ = c.Committed(fn)
+	require.EqualValues(t, 8, offset)
+
+	// Rejected data must not block the position
+	c.Delivered(&deliveryInfo{id: 3, delivered: false})
+	offset, _ = c.Committed(fn)
+	require.EqualValues(t, 12, offset)
+}
+
+func TestPending(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	require.False(t, c.Pending(fn))
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	require.False(t, c.Pending(fn))
+
+	c.Track(1, fn, 4)
+	c.Advance(fn, 8)
+	require.True(t, c.Pending(fn))
+
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	require.False(t, c.Pending(fn))
+}
+
+func TestOpenPending(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Track(1, fn, 4)
+
+	// Continue reading after the data waiting for delivery
+	offset, err := c.Open(fn, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, offset)
+
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	offset, _ = c.Committed(fn)
+	require.EqualValues(t, 4, offset)
+}
+
+func TestEarlyDelivery(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	c.Track(1, fn, 4)
+	offset, _ := c.Committed(fn)
+	require.EqualValues(t, 4, offset)
+}
+
+func TestSetStateClearsEarlyDelivery(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\n"), 0600))
+
+	c := New()
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	c.SetState(State{Files: map[string]Entry{}})
+
+	// Early deliveries from before restoring the state must be ignored
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Track(1, fn, 4)
+	offset, _ := c.Committed(fn)
+	require.Zero(t, offset)
+	require.True(t, c.Pending(fn))
+}
+
+func TestResume(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Advance(fn, 4)
+
+	// Restore from the persisted state
+	buf, err := json.Marshal(c.GetState())
+	require.NoError(t, err)
+	var state State
+	require.NoError(t, json.Unmarshal(buf, &state))
+
+	restored := New()
+	restored.SetState(state)
+	offset, err := restored.Open(fn, true)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, offset)
+}
+
+func TestTruncation(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Advance(fn, 8)
+
+	require.NoError(t, os.Truncate(fn, 0))
+	require.NoError(t, os.WriteFile(fn, []byte("x\n"), 0600))
+	offset, err := c.Open(fn, true)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+}
+
+func TestOpenStream(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log.gz")
+	require.NoError(t, os.WriteFile(fn, []byte("compressed"), 0600))
+
+	c := New()
+	offset, err := c.OpenStream(fn)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+
+	// Offsets beyond the file size must be kept for streams
+	c.Advance(fn, 100)
+	offset, err = c.OpenStream(fn)
+	require.NoError(t, err)
+	require.EqualValues(t, 100, offset)
+}
+
+func TestRotation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file identity not supported on windows")
+	}
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Advance(fn, 4)
+
+	// Rotate the file and create a new one at the original path
+	rotated := filepath.Join(dir, "test.log.1")
+	require.NoError(t, os.Rename(fn, rotated))
+	require.NoError(t, os.WriteFile(fn, []byte("a much longer line\n"), 0600))
+
+	// The new file must be read from the beginning...
+	offset, err := c.Open(fn, true)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+
+	// ...while the rotated file is unknown under its new name
+	offset, err = c.Open(rotated, false)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+}
+
+func TestRename(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file identity not supported on windows")
+	}
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Advance(fn, 4)
+
+	// The checkpoint follows the file if the original path vanished
+	renamed := filepath.Join(dir, "renamed.log")
+	require.NoError(t, os.Rename(fn, renamed))
+	offset, err := c.Open(renamed, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, offset)
+
+	_, found := c.Committed(fn)
+	require.False(t, found)
+}
+
+func TestLegacyState(t *testing.T) {
+	var state State
+	require.NoError(t, json.Unmarshal([]byte(`{"/var/log/test.log": 42}`), &state))
+	require.Equal(t, map[string]Entry{"/var/log/test.log": {Offset: 42}}, state.Files)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"files": {"/var/log/test.log": {"inode": 3, "offset": 12}}}`), &state))
+	require.Equal(t, map[string]Entry{"/var/log/test.log": {Inode: 3, Offset: 12}}, state.Files)
+
+	require.Error(t, json.Unmarshal([]byte(`{"/var/log/test.log": "foo"}`), &state))
+}
+
+func TestReset(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\nbar\n"), 0600))
+
+	c := New()
+	_, err := c.Open(fn, false)
+	require.NoError(t, err)
+	c.Advance(fn, 4)
+	c.Track(1, fn, 8)
+
+	// Deliveries for data read before the reset must not be committed
+	require.NoError(t, c.Reset(fn))
+	c.Delivered(&deliveryInfo{id: 1, delivered: true})
+	offset, found := c.Committed(fn)
+	require.True(t, found)
+	require.Zero(t, offset)
+
+	c.Advance(fn, 4)
+	offset, _ = c.Committed(fn)
+	require.EqualValues(t, 4, offset)
+}
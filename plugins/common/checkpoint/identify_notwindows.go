@@ -0,0 +1,16 @@
+//go:build !windows
+
+package checkpoint
+
+import (
+	"os"
+	"syscall"
+)
+
+func identify(info os.FileInfo) (device, inode uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return uint64(stat.Dev), stat.Ino //nolint:unconvert // the device type differs between platforms
+}
@@ -0,0 +1,125 @@
+package delivery
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+type record[T any] struct {
+	seq   *Sequence[T]
+	value T
+	done  bool
+}
+
+// Sequence is the position of a source of data sent to the outputs, e.g. the
+// offset in a file or the watermark of a query. The position is only
+// committed after all data up to that position was processed by the outputs.
+type Sequence[T any] struct {
+	// Committed is the position up to which all data was processed
+	Committed T
+
+	pending []*record[T]
+}
+
+// Latest returns the position of the data sent last, or the committed one if
+// no data is waiting for delivery
+func (s *Sequence[T]) Latest() T {
+	if len(s.pending) > 0 {
+		return s.pending[len(s.pending)-1].value
+	}
+	return s.Committed
+}
+
+// Pending returns the number of positions waiting for delivery
+func (s *Sequence[T]) Pending() int {
+	return len(s.pending)
+}
+
+// Reset sets the committed position and drops all positions waiting for
+// delivery. Deliveries of data still in flight are ignored.
+func (s *Sequence[T]) Reset(value T) {
+	s.Committed = value
+	s.pending = nil
+}
+
+// Advance moves the position to the given value without any data requiring
+// delivery, e.g. for data not producing metrics
+func (s *Sequence[T]) Advance(value T) {
+	s.pending = append(s.pending, &record[T]{seq: s, value: value, done: true})
+	s.commit()
+}
+
+// commit advances the committed position over all leading records that were
+// processed by the outputs
+func (s *Sequence[T]) commit() {
+	var n int
+	for _, r := range s.pending {
+		if !r.done {
+			break
+		}
+		s.Committed = r.value
+		n++
+	}
+	s.pending = s.pending[n:]
+}
+
+// Tracker associates the tracking IDs of data sent to the outputs with the
+// positions of their sequences. Similar to the message queue consumers, data
+// rejected by an output does not block the position as processing it again
+// would fail in the same way. The tracker is not safe for concurrent use.
+type Tracker[T any] struct {
+	tracked map[telegraf.TrackingID]*record[T]
+	early   map[telegraf.TrackingID]struct{}
+}
+
+// NewTracker creates a tracker without any data waiting for delivery
+func NewTracker[T any]() *Tracker[T] {
+	return &Tracker[T]{
+		tracked: make(map[telegraf.TrackingID]*record[T]),
+		early:   make(map[telegraf.TrackingID]struct{}),
+	}
+}
+
+// Track registers data up to the given position of the sequence sent to the
+// outputs with the given tracking ID. The position is committed once the
+// tracking ID and all previous ones of the sequence are delivered. A nil
+// sequence, e.g. of an unknown source, is not tracked.
+func (t *Tracker[T]) Track(id telegraf.TrackingID, seq *Sequence[T], value T) {
+	// The delivery notification might arrive before tracking started
+	_, early := t.early[id]
+	delete(t.early, id)
+
+	if seq == nil {
+		return
+	}
+
+	r := &record[T]{seq: seq, value: value}
+	seq.pending = append(seq.pending, r)
+	if early {
+		r.done = true
+		seq.commit()
+		return
+	}
+	t.tracked[id] = r
+}
+
+// Delivered handles the delivery notification of tracked data and commits the
+// position of the corresponding sequence if possible
+func (t *Tracker[T]) Delivered(id telegraf.TrackingID) {
+	r, found := t.tracked[id]
+	if !found {
+		t.early[id] = struct{}{}
+		return
+	}
+	delete(t.tracked, id)
+
+	// Records of reset sequences are not part of the pending list anymore so
+	// committing is a no-op for those
+	r.done = true
+	r.seq.commit()
+}
+
+// Reset forgets about all tracked data and early deliveries
+func (t *Tracker[T]) Reset() {
+	t.tracked = make(map[telegraf.TrackingID]*record[T])
+	t.early = make(map[telegraf.TrackingID]struct{})
+}
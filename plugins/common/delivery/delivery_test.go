@@ -0,0 +1,66 @@
+package delivery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitInOrder(t *testing.T) {
+	tracker := NewTracker[int64]()
+	seq := &Sequence[int64]{}
+
+	tracker.Track(1, seq, 4)
+	tracker.Track(2, seq, 8)
+	seq.Advance(10)
+	tracker.Track(3, seq, 12)
+	require.Equal(t, 4, seq.Pending())
+	require.EqualValues(t, 12, seq.Latest())
+
+	// Out of order delivery must not commit the gap
+	tracker.Delivered(2)
+	require.Zero(t, seq.Committed)
+
+	tracker.Delivered(1)
+	require.EqualValues(t, 10, seq.Committed)
+	require.Equal(t, 1, seq.Pending())
+
+	tracker.Delivered(3)
+	require.EqualValues(t, 12, seq.Committed)
+	require.Zero(t, seq.Pending())
+}
+
+func TestEarlyDelivery(t *testing.T) {
+	tracker := NewTracker[int64]()
+	seq := &Sequence[int64]{}
+
+	// Deliveries before tracking started must be taken into account
+	tracker.Delivered(1)
+	tracker.Track(1, seq, 4)
+	require.EqualValues(t, 4, seq.Committed)
+
+	// Early deliveries of untracked sources must be consumed
+	tracker.Delivered(2)
+	tracker.Track(2, nil, 8)
+	tracker.Track(2, seq, 8)
+	require.EqualValues(t, 4, seq.Committed)
+}
+
+func TestReset(t *testing.T) {
+	tracker := NewTracker[int64]()
+	seq := &Sequence[int64]{}
+
+	tracker.Track(1, seq, 4)
+	tracker.Delivered(2)
+
+	// Neither in-flight data nor early deliveries must be committed after
+	// resetting
+	seq.Reset(2)
+	tracker.Reset()
+	tracker.Delivered(1)
+	require.EqualValues(t, 2, seq.Committed)
+
+	tracker.Track(2, seq, 8)
+	require.EqualValues(t, 2, seq.Committed)
+	require.Equal(t, 1, seq.Pending())
+}
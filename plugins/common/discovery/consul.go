@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/influxdata/telegraf"
+)
+
+// ConsulProvider discovers the instances of a service registered in Consul
+type ConsulProvider struct {
+	Agent      string `toml:"agent"`
+	Service    string `toml:"service"`
+	Tag        string `toml:"tag"`
+	Datacenter string `toml:"datacenter"`
+
+	catalog *api.Catalog
+}
+
+func (*ConsulProvider) name() string {
+	return "consul"
+}
+
+func (p *ConsulProvider) init(telegraf.Logger) error {
+	if p.Service == "" {
+		return errors.New("no service specified")
+	}
+
+	cfg := api.DefaultConfig()
+	if p.Agent != "" {
+		cfg.Address = p.Agent
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the Consul agent: %w", err)
+	}
+	p.catalog = client.Catalog()
+	return nil
+}
+
+func (p *ConsulProvider) discover(ctx context.Context) ([]Target, error) {
+	opts := &api.QueryOptions{Datacenter: p.Datacenter}
+	services, _, err := p.catalog.Service(p.Service, p.Tag, opts.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(services))
+	for _, s := range services {
+		host := s.ServiceAddress
+		if host == "" {
+			host = s.Address
+		}
+
+		labels := make(map[string]string, len(s.ServiceMeta)+3)
+		for k, v := range s.ServiceMeta {
+			labels[k] = v
+		}
+		labels["consul_service"] = s.ServiceName
+		labels["consul_node"] = s.Node
+		if s.Datacenter != "" {
+			labels["consul_datacenter"] = s.Datacenter
+		}
+		if len(s.ServiceTags) > 0 {
+			labels["consul_tags"] = strings.Join(s.ServiceTags, ",")
+		}
+
+		targets = append(targets, Target{
+			Address: net.JoinHostPort(host, strconv.Itoa(s.ServicePort)),
+			Labels:  labels,
+			Source:  s,
+		})
+	}
+	return targets, nil
+}
@@ -0,0 +1,221 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+)
+
+// Target is a discovered gather target
+type Target struct {
+	// Address as reported by the provider, usually "host:port"
+	Address string
+	// Labels attached to the target by the provider, used as tags
+	Labels map[string]string
+	// Endpoint is the address rendered using the configured template, i.e.
+	// the string to use as URL, address or DSN in the plugin.
+	Endpoint string
+	// Source is the object the target was derived from by the provider, i.e.
+	// a *KubernetesPod for Kubernetes and an *api.CatalogService for Consul.
+	// It is nil for all other providers.
+	Source interface{}
+}
+
+// Host returns the host part of the target's address
+func (t Target) Host() string {
+	if host, _, err := net.SplitHostPort(t.hostPort()); err == nil {
+		return host
+	}
+	return t.Address
+}
+
+// Port returns the port part of the target's address or an empty string if
+// the address does not contain a port
+func (t Target) Port() string {
+	if _, port, err := net.SplitHostPort(t.hostPort()); err == nil {
+		return port
+	}
+	return ""
+}
+
+func (t Target) hostPort() string {
+	if u, err := url.Parse(t.Address); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return t.Address
+}
+
+// Tags returns a copy of the target's labels to be used as tags
+func (t Target) Tags() map[string]string {
+	tags := make(map[string]string, len(t.Labels))
+	for k, v := range t.Labels {
+		tags[k] = v
+	}
+	return tags
+}
+
+// provider is implemented by all discovery mechanisms
+type provider interface {
+	name() string
+	init(log telegraf.Logger) error
+	discover(ctx context.Context) ([]Target, error)
+}
+
+// watcher is implemented by providers able to detect changes of their source
+// without running a full discovery
+type watcher interface {
+	changed() bool
+}
+
+// stopper is implemented by providers running in the background
+type stopper interface {
+	stop()
+}
+
+// Discovery collects gather targets from the configured providers. Targets
+// are refreshed on demand when calling Targets, but at most once per refresh
+// interval unless a provider detected changes.
+type Discovery struct {
+	RefreshInterval config.Duration       `toml:"refresh_interval"`
+	Template        string                `toml:"target_template"`
+	Files           []*FileProvider       `toml:"file"`
+	DNS             []*DNSProvider        `toml:"dns"`
+	HTTP            []*HTTPProvider       `toml:"http"`
+	Kubernetes      []*KubernetesProvider `toml:"kubernetes"`
+	Consul          []*ConsulProvider     `toml:"consul"`
+
+	log       telegraf.Logger
+	template  *template.Template
+	providers []provider
+
+	sync.Mutex
+	discovered  [][]Target
+	targets     []Target
+	lastRefresh time.Time
+}
+
+// Init validates the settings and initializes the providers
+func (d *Discovery) Init(log telegraf.Logger) error {
+	d.log = log
+
+	if d.RefreshInterval == 0 {
+		d.RefreshInterval = config.Duration(time.Minute)
+	}
+	if d.Template == "" {
+		d.Template = "{{.Address}}"
+	}
+	tmpl, err := template.New("target").Parse(d.Template)
+	if err != nil {
+		return fmt.Errorf("invalid 'target_template' setting %q: %w", d.Template, err)
+	}
+	d.template = tmpl
+
+	d.providers = make([]provider, 0, len(d.Files)+len(d.DNS)+len(d.HTTP)+len(d.Kubernetes)+len(d.Consul))
+	for _, p := range d.Files {
+		d.providers = append(d.providers, p)
+	}
+	for _, p := range d.DNS {
+		d.providers = append(d.providers, p)
+	}
+	for _, p := range d.HTTP {
+		d.providers = append(d.providers, p)
+	}
+	for _, p := range d.Kubernetes {
+		d.providers = append(d.providers, p)
+	}
+	for _, p := range d.Consul {
+		d.providers = append(d.providers, p)
+	}
+	if len(d.providers) == 0 {
+		return errors.New("no discovery provider configured")
+	}
+
+	for _, p := range d.providers {
+		if err := p.init(log); err != nil {
+			return fmt.Errorf("initializing %s discovery failed: %w", p.name(), err)
+		}
+	}
+	d.discovered = make([][]Target, len(d.providers))
+
+	return nil
+}
+
+// Targets returns the currently known targets sorted by their endpoint,
+// refreshing them if necessary. If some providers fail, the targets of the
+// last successful discovery of those providers are kept and an error is
+// returned in addition to the targets.
+func (d *Discovery) Targets(ctx context.Context) ([]Target, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.needsRefresh() {
+		return d.targets, nil
+	}
+
+	var errs []error
+	for i, p := range d.providers {
+		targets, err := p.discover(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s discovery failed: %w", p.name(), err))
+			continue
+		}
+		d.discovered[i] = targets
+	}
+	d.lastRefresh = time.Now()
+
+	seen := make(map[string]bool)
+	d.targets = make([]Target, 0, len(d.targets))
+	for _, targets := range d.discovered {
+		for _, t := range targets {
+			var buf bytes.Buffer
+			if err := d.template.Execute(&buf, t); err != nil {
+				errs = append(errs, fmt.Errorf("rendering target %q failed: %w", t.Address, err))
+				continue
+			}
+			t.Endpoint = buf.String()
+			if seen[t.Endpoint] {
+				continue
+			}
+			seen[t.Endpoint] = true
+			d.targets = append(d.targets, t)
+		}
+	}
+	sort.Slice(d.targets, func(i, j int) bool { return d.targets[i].Endpoint < d.targets[j].Endpoint })
+	d.log.Debugf("Discovered %d targets", len(d.targets))
+
+	return d.targets, errors.Join(errs...)
+}
+
+// Stop ends all background activity of the providers
+func (d *Discovery) Stop() {
+	for _, p := range d.providers {
+		if s, ok := p.(stopper); ok {
+			s.stop()
+		}
+	}
+}
+
+func (d *Discovery) needsRefresh() bool {
+	if d.lastRefresh.IsZero() || time.Since(d.lastRefresh) >= time.Duration(d.RefreshInterval) {
+		return true
+	}
+
+	// Check all watchers to reset their state
+	var changed bool
+	for _, p := range d.providers {
+		if w, ok := p.(watcher); ok && w.changed() {
+			changed = true
+		}
+	}
+	return changed
+}
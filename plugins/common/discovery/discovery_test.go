@@ -0,0 +1,541 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitInvalid(t *testing.T) {
+	d := &Discovery{}
+	require.ErrorContains(t, d.Init(testutil.Logger{}), "no discovery provider configured")
+
+	d = &Discovery{
+		Template: "{{.Address",
+		Files:    []*FileProvider{{Files: []string{"foo.json"}}},
+	}
+	require.ErrorContains(t, d.Init(testutil.Logger{}), "invalid 'target_template' setting")
+
+	d = &Discovery{DNS: []*DNSProvider{{Names: []string{"example.com"}, Type: "A"}}}
+	require.ErrorContains(t, d.Init(testutil.Logger{}), "invalid 'port' setting")
+
+	d = &Discovery{DNS: []*DNSProvider{{Names: []string{"example.com"}, Type: "MX"}}}
+	require.ErrorContains(t, d.Init(testutil.Logger{}), "invalid 'type' setting")
+}
+
+func TestFileDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`[
+		{"targets": ["10.0.0.1:9100", "10.0.0.2:9100"], "labels": {"env": "prod"}}
+	]`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(`
+- targets: ["10.0.0.3:9100"]
+  labels:
+    env: dev
+`), 0600))
+
+	d := &Discovery{
+		RefreshInterval: config.Duration(time.Hour),
+		Template:        "http://{{.Address}}/metrics",
+		Files:           []*FileProvider{{Files: []string{filepath.Join(dir, "*")}}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	expected := []Target{
+		{Address: "10.0.0.1:9100", Labels: map[string]string{"env": "prod"}, Endpoint: "http://10.0.0.1:9100/metrics"},
+		{Address: "10.0.0.2:9100", Labels: map[string]string{"env": "prod"}, Endpoint: "http://10.0.0.2:9100/metrics"},
+		{Address: "10.0.0.3:9100", Labels: map[string]string{"env": "dev"}, Endpoint: "http://10.0.0.3:9100/metrics"},
+	}
+	require.Equal(t, expected, targets)
+
+	// Changes to the files must be picked up despite the refresh interval
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.yaml")))
+	targets, err = d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected[:2], targets)
+
+	// Errors must keep the previous targets
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{`), 0600))
+	targets, err = d.Targets(context.Background())
+	require.ErrorContains(t, err, "file discovery failed")
+	require.Equal(t, expected[:2], targets)
+}
+
+type mockResolver struct {
+	srv map[string][]*net.SRV
+	ips map[string][]net.IPAddr
+}
+
+func (r *mockResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	records, found := r.srv[name]
+	if !found {
+		return "", nil, errors.New("not found")
+	}
+	return name, records, nil
+}
+
+func (r *mockResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addrs, found := r.ips[host]
+	if !found {
+		return nil, errors.New("not found")
+	}
+	return addrs, nil
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	resolver := &mockResolver{
+		srv: map[string][]*net.SRV{
+			"_redis._tcp.example.com": {
+				{Target: "redis1.example.com.", Port: 6379},
+				{Target: "redis2.example.com.", Port: 6380},
+			},
+		},
+		ips: map[string][]net.IPAddr{
+			"web.example.com": {
+				{IP: net.ParseIP("192.168.0.1")},
+				{IP: net.ParseIP("2001:db8::1")},
+			},
+		},
+	}
+
+	d := &Discovery{
+		DNS: []*DNSProvider{
+			{Names: []string{"_redis._tcp.example.com"}, resolver: resolver},
+			{Names: []string{"web.example.com"}, Type: "a", Port: 80, resolver: resolver},
+			{Names: []string{"web.example.com"}, Type: "AAAA", Port: 443, resolver: resolver},
+		},
+		Template: "tcp://{{.Address}}",
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	endpoints := make([]string, 0, len(targets))
+	for _, target := range targets {
+		endpoints = append(endpoints, target.Endpoint)
+	}
+	require.Equal(t, []string{
+		"tcp://192.168.0.1:80",
+		"tcp://[2001:db8::1]:443",
+		"tcp://redis1.example.com:6379",
+		"tcp://redis2.example.com:6380",
+	}, endpoints)
+	require.Equal(t, map[string]string{"dns_name": "_redis._tcp.example.com"}, targets[2].Labels)
+}
+
+func TestHTTPDiscovery(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"targets": ["db1:3306"], "labels": {"role": "primary"}}]`))
+	}))
+	defer server.Close()
+
+	d := &Discovery{HTTP: []*HTTPProvider{{URL: server.URL}}}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	expected := []Target{{Address: "db1:3306", Labels: map[string]string{"role": "primary"}, Endpoint: "db1:3306"}}
+	require.Equal(t, expected, targets)
+
+	// Cached targets within the refresh interval
+	targets, err = d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected, targets)
+	require.Equal(t, 1, calls)
+
+	// Keep the previous targets on errors
+	d.lastRefresh = time.Time{}
+	targets, err = d.Targets(context.Background())
+	require.ErrorContains(t, err, "500 Internal Server Error")
+	require.Equal(t, expected, targets)
+}
+
+func TestConsulDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/catalog/service/web" || r.URL.Query().Get("tag") != "prod" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"Node": "n1", "Address": "10.0.0.1", "Datacenter": "dc1", "ServiceName": "web",
+			 "ServiceAddress": "", "ServicePort": 8080, "ServiceTags": ["prod"], "ServiceMeta": {"version": "1"}},
+			{"Node": "n2", "Address": "10.0.0.2", "Datacenter": "dc1", "ServiceName": "web",
+			 "ServiceAddress": "172.16.0.2", "ServicePort": 8081, "ServiceTags": ["prod", "canary"]}
+		]`))
+	}))
+	defer server.Close()
+
+	d := &Discovery{Consul: []*ConsulProvider{{Agent: server.URL, Service: "web", Tag: "prod"}}}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+
+	// The source must be the catalog entry of the service instance
+	for i, target := range targets {
+		service, ok := target.Source.(*api.CatalogService)
+		require.True(t, ok)
+		require.Equal(t, target.Labels["consul_node"], service.Node)
+		targets[i].Source = nil
+	}
+	require.Equal(t, []Target{
+		{
+			Address:  "10.0.0.1:8080",
+			Endpoint: "10.0.0.1:8080",
+			Labels: map[string]string{
+				"consul_service":    "web",
+				"consul_node":       "n1",
+				"consul_datacenter": "dc1",
+				"consul_tags":       "prod",
+				"version":           "1",
+			},
+		},
+		{
+			Address:  "172.16.0.2:8081",
+			Endpoint: "172.16.0.2:8081",
+			Labels: map[string]string{
+				"consul_service":    "web",
+				"consul_node":       "n2",
+				"consul_datacenter": "dc1",
+				"consul_tags":       "prod,canary",
+			},
+		},
+	}, targets)
+}
+
+func TestKubernetesDiscovery(t *testing.T) {
+	ready := corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		PodIP:      "10.1.0.1",
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec: corev1.PodSpec{
+				NodeName: "node-a",
+				Containers: []corev1.Container{{
+					Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}, {Name: "metrics", ContainerPort: 9090}},
+				}},
+			},
+			Status: ready,
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-2", Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9090}}}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	)
+
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{Namespace: "default", PortName: "metrics", client: client}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	source, ok := targets[0].Source.(*KubernetesPod)
+	require.True(t, ok)
+	require.Equal(t, "app-1", source.Pod.Name)
+	require.Nil(t, source.Namespace)
+	targets[0].Source = nil
+	require.Equal(t, []Target{{
+		Address:  "10.1.0.1:9090",
+		Endpoint: "10.1.0.1:9090",
+		Labels: map[string]string{
+			"app":       "web",
+			"pod_name":  "app-1",
+			"namespace": "default",
+			"node_name": "node-a",
+		},
+	}}, targets)
+}
+
+func TestKubernetesDiscoveryNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{"team": "a"}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      "10.1.0.1",
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+	)
+
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{Port: 9090, WithNamespaces: true, client: client}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	source, ok := targets[0].Source.(*KubernetesPod)
+	require.True(t, ok)
+	require.NotNil(t, source.Namespace)
+	require.Equal(t, "a", source.Namespace.Annotations["team"])
+}
+
+func TestKubernetesDiscoveryKubelet(t *testing.T) {
+	ready := corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		PodIP:      "10.1.0.1",
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default", Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{NodeName: "node-a"},
+				Status:     ready,
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-2", Namespace: "default", Labels: map[string]string{"app": "db"}},
+				Spec:       corev1.PodSpec{NodeName: "node-a"},
+				Status:     ready,
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-3", Namespace: "other", Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{NodeName: "node-a"},
+				Status:     ready,
+			},
+		},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(pods); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	provider := &KubernetesProvider{
+		Namespace:     "default",
+		LabelSelector: "app=web",
+		FieldSelector: "spec.nodeName=node-a",
+		Port:          9090,
+		NodeIP:        "127.0.0.1",
+		client:        fake.NewSimpleClientset(),
+		kubelet:       server.Client(),
+		kubeletURL:    server.URL + "/pods",
+	}
+	d := &Discovery{Kubernetes: []*KubernetesProvider{provider}}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	// The namespace and selectors must be applied to the kubelet's pod list
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "10.1.0.1:9090", targets[0].Address)
+	require.Equal(t, "app-1", targets[0].Labels["pod_name"])
+}
+
+func TestKubernetesInvalidSelector(t *testing.T) {
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{LabelSelector: "app in (", client: fake.NewSimpleClientset()}},
+	}
+	require.ErrorContains(t, d.Init(testutil.Logger{}), "invalid 'label_selector' setting")
+}
+
+func TestKubernetesPodReady(t *testing.T) {
+	notReady := kubernetesPod("not-ready", "10.1.0.1")
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+	unknown := kubernetesPod("unknown", "10.1.0.2")
+	unknown.Status.Conditions = nil
+	pending := kubernetesPod("pending", "10.1.0.3")
+	pending.Status.Phase = corev1.PodPending
+
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{Port: 9090, client: fake.NewSimpleClientset(notReady, unknown, pending)}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	// Running pods failing their readiness probe must still be discovered
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "not-ready", targets[0].Labels["pod_name"])
+}
+
+func TestKubernetesMultiplePods(t *testing.T) {
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{
+			Port:   9090,
+			client: fake.NewSimpleClientset(kubernetesPod("app-1", "10.1.0.1"), kubernetesPod("app-2", "10.1.0.2")),
+		}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+}
+
+func TestKubernetesDuplicatePods(t *testing.T) {
+	d := &Discovery{
+		Kubernetes: []*KubernetesProvider{{
+			Port:   9090,
+			client: fake.NewSimpleClientset(kubernetesPod("app-1", "10.1.0.1"), kubernetesPod("app-2", "10.1.0.1")),
+		}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+
+	// Pods with the same address must result in a single target
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+}
+
+func TestKubernetesWatch(t *testing.T) {
+	client := fake.NewSimpleClientset(kubernetesPod("app-1", "10.1.0.1"))
+
+	d := &Discovery{
+		RefreshInterval: config.Duration(time.Hour),
+		Kubernetes:      []*KubernetesProvider{{Port: 9090, Watch: true, client: client}},
+	}
+	require.NoError(t, d.Init(testutil.Logger{}))
+	defer d.Stop()
+
+	targets, err := d.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	// Added pods must be picked up without waiting for the refresh interval
+	_, err = client.CoreV1().Pods("default").Create(context.Background(), kubernetesPod("app-2", "10.1.0.2"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		targets, err := d.Targets(context.Background())
+		return err == nil && len(targets) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Same for deleted pods
+	require.NoError(t, client.CoreV1().Pods("default").Delete(context.Background(), "app-1", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		targets, err := d.Targets(context.Background())
+		return err == nil && len(targets) == 1 && targets[0].Labels["pod_name"] == "app-2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestKubernetesLabelSelector(t *testing.T) {
+	provider := &KubernetesProvider{
+		LabelSelector: "label0==label0,label1=label1,label2!=label,label3 in (label1,label2, label3)," +
+			"label4 notin (label1, label2,label3),label5,!label6",
+		client: fake.NewSimpleClientset(),
+	}
+	require.NoError(t, provider.init(testutil.Logger{}))
+
+	pod := kubernetesPod("app-1", "10.1.0.1")
+	pod.Labels = map[string]string{
+		"label0": "label0",
+		"label1": "label1",
+		"label2": "label2",
+		"label3": "label3",
+		"label4": "label4",
+		"label5": "label5",
+	}
+	require.True(t, provider.matches(pod))
+
+	pod.Labels["label6"] = "label6"
+	require.False(t, provider.matches(pod))
+}
+
+func TestKubernetesFieldSelector(t *testing.T) {
+	provider := &KubernetesProvider{
+		FieldSelector: "status.podIP=10.1.0.1,spec.restartPolicy=Always,spec.nodeName!=nodeName",
+		client:        fake.NewSimpleClientset(),
+	}
+	require.NoError(t, provider.init(testutil.Logger{}))
+
+	pod := kubernetesPod("app-1", "10.1.0.1")
+	pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	pod.Spec.NodeName = "node1000"
+	require.True(t, provider.matches(pod))
+
+	pod.Spec.NodeName = "nodeName"
+	require.False(t, provider.matches(pod))
+
+	provider.FieldSelector += ",spec.nodeName"
+	require.ErrorContains(t, provider.init(testutil.Logger{}), "invalid 'field_selector' setting")
+}
+
+func kubernetesPod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      ip,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestTargetHostPort(t *testing.T) {
+	tests := []struct {
+		address string
+		host    string
+		port    string
+	}{
+		{address: "localhost:8080", host: "localhost", port: "8080"},
+		{address: "[::1]:8080", host: "::1", port: "8080"},
+		{address: "http://example.com:9090/metrics", host: "example.com", port: "9090"},
+		{address: "example.com", host: "example.com", port: ""},
+	}
+	for _, tt := range tests {
+		target := Target{Address: tt.address}
+		require.Equal(t, tt.host, target.Host(), tt.address)
+		require.Equal(t, tt.port, target.Port(), tt.address)
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	var acc testutil.Accumulator
+	target := Target{Address: "localhost:80", Labels: map[string]string{"env": "prod", "host": "discovered"}}
+	tacc := NewAccumulator(&acc, target)
+
+	tacc.AddFields("test", map[string]interface{}{"value": 1}, map[string]string{"host": "own"}, time.Unix(0, 0))
+	tacc.AddGauge("test", map[string]interface{}{"value": 2}, nil, time.Unix(0, 0))
+	tacc.AddMetric(metric.New("test", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(0, 0)))
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"env": "prod", "host": "own"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"env": "prod", "host": "discovered"}, map[string]interface{}{"value": 2}, time.Unix(0, 0), telegraf.Gauge),
+		metric.New("test", map[string]string{"env": "prod", "host": "discovered"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
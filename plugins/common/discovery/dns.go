@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// resolver is the subset of net.Resolver used for DNS discovery
+type resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DNSProvider discovers targets using DNS SRV or A/AAAA records
+type DNSProvider struct {
+	Names []string `toml:"names"`
+	Type  string   `toml:"type"`
+	Port  int      `toml:"port"`
+
+	resolver resolver
+}
+
+func (*DNSProvider) name() string {
+	return "dns"
+}
+
+func (p *DNSProvider) init(telegraf.Logger) error {
+	if len(p.Names) == 0 {
+		return errors.New("no names specified")
+	}
+
+	p.Type = strings.ToUpper(p.Type)
+	switch p.Type {
+	case "":
+		p.Type = "SRV"
+	case "SRV":
+	case "A", "AAAA":
+		if p.Port < 1 || p.Port > 65535 {
+			return fmt.Errorf("invalid 'port' setting %d", p.Port)
+		}
+	default:
+		return fmt.Errorf("invalid 'type' setting %q", p.Type)
+	}
+
+	if p.resolver == nil {
+		p.resolver = net.DefaultResolver
+	}
+	return nil
+}
+
+func (p *DNSProvider) discover(ctx context.Context) ([]Target, error) {
+	var targets []Target
+	for _, name := range p.Names {
+		if p.Type == "SRV" {
+			_, records, err := p.resolver.LookupSRV(ctx, "", "", name)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range records {
+				addr := net.JoinHostPort(strings.TrimSuffix(r.Target, "."), strconv.Itoa(int(r.Port)))
+				targets = append(targets, Target{
+					Address: addr,
+					Labels:  map[string]string{"dns_name": name},
+				})
+			}
+			continue
+		}
+
+		addrs, err := p.resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			isV4 := a.IP.To4() != nil
+			if (p.Type == "A") != isV4 {
+				continue
+			}
+			targets = append(targets, Target{
+				Address: net.JoinHostPort(a.IP.String(), strconv.Itoa(p.Port)),
+				Labels:  map[string]string{"dns_name": name},
+			})
+		}
+	}
+	return targets, nil
+}
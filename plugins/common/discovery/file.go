@@ -0,0 +1,130 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/globpath"
+)
+
+// targetGroup is the format of file-based and HTTP-based discovery as used
+// by Prometheus
+type targetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+func (g *targetGroup) toTargets() []Target {
+	targets := make([]Target, 0, len(g.Targets))
+	for _, addr := range g.Targets {
+		labels := make(map[string]string, len(g.Labels))
+		for k, v := range g.Labels {
+			labels[k] = v
+		}
+		targets = append(targets, Target{Address: addr, Labels: labels})
+	}
+	return targets
+}
+
+// FileProvider discovers targets from JSON or YAML files in the format used
+// by Prometheus' file-based service discovery. Files are checked for changes
+// whenever targets are requested.
+type FileProvider struct {
+	Files []string `toml:"files"`
+
+	globs []*globpath.GlobPath
+	state map[string]fileState
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func (*FileProvider) name() string {
+	return "file"
+}
+
+func (p *FileProvider) init(telegraf.Logger) error {
+	if len(p.Files) == 0 {
+		return errors.New("no files specified")
+	}
+	for _, pattern := range p.Files {
+		g, err := globpath.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+		}
+		p.globs = append(p.globs, g)
+	}
+	return nil
+}
+
+func (p *FileProvider) discover(context.Context) ([]Target, error) {
+	state := p.currentState()
+
+	var targets []Target
+	for fn := range state {
+		buf, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		var groups []targetGroup
+		switch strings.ToLower(filepath.Ext(fn)) {
+		case ".json":
+			err = json.Unmarshal(buf, &groups)
+		case ".yml", ".yaml":
+			err = yaml.Unmarshal(buf, &groups)
+		default:
+			err = errors.New("unknown file extension, expected json, yml or yaml")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %q failed: %w", fn, err)
+		}
+
+		for _, g := range groups {
+			targets = append(targets, g.toTargets()...)
+		}
+	}
+	p.state = state
+
+	return targets, nil
+}
+
+// changed checks if any of the matching files was added, removed or changed
+// since the last discovery.
+func (p *FileProvider) changed() bool {
+	state := p.currentState()
+	if len(state) != len(p.state) {
+		return true
+	}
+	for fn, s := range state {
+		if prev, found := p.state[fn]; !found || prev != s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *FileProvider) currentState() map[string]fileState {
+	state := make(map[string]fileState)
+	for _, g := range p.globs {
+		for _, fn := range g.Match() {
+			info, err := os.Stat(fn)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			state[fn] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return state
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/influxdata/telegraf"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
+)
+
+// HTTPProvider discovers targets by querying an HTTP endpoint returning the
+// targets in the format used by Prometheus' HTTP service discovery.
+type HTTPProvider struct {
+	URL string `toml:"url"`
+	common_http.HTTPClientConfig
+
+	client *http.Client
+}
+
+func (*HTTPProvider) name() string {
+	return "http"
+}
+
+func (p *HTTPProvider) init(log telegraf.Logger) error {
+	if p.URL == "" {
+		return errors.New("no URL specified")
+	}
+
+	client, err := p.HTTPClientConfig.CreateClient(context.Background(), log)
+	if err != nil {
+		return err
+	}
+	p.client = client
+	return nil
+}
+
+func (p *HTTPProvider) discover(ctx context.Context) ([]Target, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status %q", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var groups []targetGroup
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("decoding response failed: %w", err)
+	}
+
+	var targets []Target
+	for _, g := range groups {
+		targets = append(targets, g.toTargets()...)
+	}
+	return targets, nil
+}
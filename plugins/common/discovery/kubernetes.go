@@ -0,0 +1,385 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+)
+
+// KubernetesProvider discovers ready pods using the Kubernetes API or, if a
+// node IP is given, the kubelet of that node. In watch mode, the pods are
+// kept in sync using a watch on the API server instead of listing them on
+// each refresh.
+type KubernetesProvider struct {
+	KubeConfig    string `toml:"kubeconfig"`
+	Namespace     string `toml:"namespace"`
+	LabelSelector string `toml:"label_selector"`
+	FieldSelector string `toml:"field_selector"`
+	Port          int    `toml:"port"`
+	PortName      string `toml:"port_name"`
+	NodeIP        string `toml:"node_ip"`
+
+	// Watch keeps the pods in sync using a watch instead of listing them on
+	// each refresh, changes to the pods cause a refresh on the next call to
+	// Targets. Plugins enabling it must call Stop on the discovery. The
+	// resync interval is the period for fully re-listing the watched pods.
+	Watch          bool            `toml:"-"`
+	ResyncInterval config.Duration `toml:"-"`
+
+	// WithNamespaces adds the namespace object of each pod to the target's
+	// source for plugins filtering on namespace metadata
+	WithNamespaces bool `toml:"-"`
+
+	client        kubernetes.Interface
+	config        *rest.Config
+	kubelet       *http.Client
+	kubeletURL    string
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+
+	// Watch mode
+	podInformer cache.SharedIndexInformer
+	nsInformer  cache.SharedIndexInformer
+	stopCh      chan struct{}
+	dirty       atomic.Bool
+}
+
+// KubernetesPod is the source of a target discovered by Kubernetes
+type KubernetesPod struct {
+	Pod *corev1.Pod
+	// Namespace of the pod, only set if requested by the plugin
+	Namespace *corev1.Namespace
+}
+
+func (*KubernetesProvider) name() string {
+	return "kubernetes"
+}
+
+func (p *KubernetesProvider) init(telegraf.Logger) error {
+	labelSelector, err := labels.Parse(p.LabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid 'label_selector' setting: %w", err)
+	}
+	fieldSelector, err := fields.ParseSelector(p.FieldSelector)
+	if err != nil {
+		return fmt.Errorf("invalid 'field_selector' setting: %w", err)
+	}
+	p.labelSelector = labelSelector
+	p.fieldSelector = fieldSelector
+
+	if p.NodeIP != "" {
+		if p.kubeletURL == "" {
+			p.kubeletURL = "https://" + net.JoinHostPort(p.NodeIP, "10250") + "/pods"
+		}
+		if p.kubelet == nil {
+			// The kubelet usually serves a self-signed certificate
+			p.kubelet = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // required for the kubelet
+				},
+			}
+		}
+	}
+
+	if p.client == nil {
+		cfg, err := loadKubeConfig(p.KubeConfig)
+		if err != nil {
+			// Fall back to the config in the user's home directory
+			u, uerr := user.Current()
+			if uerr != nil {
+				return fmt.Errorf("failed to get kubernetes config: %w", err)
+			}
+			cfg, err = loadKubeConfig(filepath.Join(u.HomeDir, ".kube", "config"))
+			if err != nil {
+				return fmt.Errorf("failed to get kubernetes config: %w", err)
+			}
+		}
+
+		client, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to get kubernetes client: %w", err)
+		}
+		p.client = client
+		p.config = cfg
+	}
+
+	// The kubelet does not support watching pods
+	if p.Watch && p.NodeIP == "" {
+		return p.startWatch()
+	}
+	return nil
+}
+
+// startWatch starts the informers keeping the pods and, if requested, the
+// namespaces in sync and waits for the initial list
+func (p *KubernetesProvider) startWatch() error {
+	resync := time.Duration(p.ResyncInterval)
+	tweak := func(options *metav1.ListOptions) {
+		options.LabelSelector = p.LabelSelector
+		options.FieldSelector = p.FieldSelector
+	}
+	p.podInformer = coreinformers.NewFilteredPodInformer(p.client, p.Namespace, resync, cache.Indexers{}, tweak)
+	_, err := p.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { p.dirty.Store(true) },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Ignore periodic resyncs not changing the pod
+			oldPod, oldOk := oldObj.(*corev1.Pod)
+			newPod, newOk := newObj.(*corev1.Pod)
+			if oldOk && newOk && oldPod.ResourceVersion == newPod.ResourceVersion {
+				return
+			}
+			p.dirty.Store(true)
+		},
+		DeleteFunc: func(interface{}) { p.dirty.Store(true) },
+	})
+	if err != nil {
+		return fmt.Errorf("watching pods failed: %w", err)
+	}
+	synced := []cache.InformerSynced{p.podInformer.HasSynced}
+
+	if p.WithNamespaces {
+		p.nsInformer = coreinformers.NewNamespaceInformer(p.client, resync, cache.Indexers{})
+		synced = append(synced, p.nsInformer.HasSynced)
+	}
+
+	p.stopCh = make(chan struct{})
+	go p.podInformer.Run(p.stopCh)
+	if p.nsInformer != nil {
+		go p.nsInformer.Run(p.stopCh)
+	}
+	if !cache.WaitForCacheSync(p.stopCh, synced...) {
+		return errors.New("syncing the pod cache failed")
+	}
+	return nil
+}
+
+// changed returns true if pods were added, changed or removed since the
+// last call in watch mode
+func (p *KubernetesProvider) changed() bool {
+	return p.dirty.Swap(false)
+}
+
+// stop ends watching the pods
+func (p *KubernetesProvider) stop() {
+	if p.stopCh != nil {
+		close(p.stopCh)
+		p.stopCh = nil
+	}
+}
+
+// loadKubeConfig parses a kubeconfig from a file and returns a Kubernetes
+// rest.Config or the in-cluster config if no path is given.
+func loadKubeConfig(path string) (*rest.Config, error) {
+	if path == "" {
+		return rest.InClusterConfig()
+	}
+	return clientcmd.BuildConfigFromFlags("", path)
+}
+
+func (p *KubernetesProvider) discover(ctx context.Context) ([]Target, error) {
+	var pods []*corev1.Pod
+	switch {
+	case p.podInformer != nil:
+		for _, obj := range p.podInformer.GetStore().List() {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				pods = append(pods, pod)
+			}
+		}
+	case p.NodeIP != "":
+		items, err := p.kubeletPods(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pods = items
+	default:
+		list, err := p.client.CoreV1().Pods(p.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: p.LabelSelector,
+			FieldSelector: p.FieldSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+		pods = make([]*corev1.Pod, 0, len(list.Items))
+		for i := range list.Items {
+			pods = append(pods, &list.Items[i])
+		}
+	}
+
+	namespaces, err := p.namespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(pods))
+	for _, pod := range pods {
+		if !p.matches(pod) || !podReady(pod) || pod.Status.PodIP == "" {
+			continue
+		}
+		port := p.podPort(pod)
+		if port == 0 {
+			continue
+		}
+
+		labels := make(map[string]string, len(pod.Labels)+3)
+		for k, v := range pod.Labels {
+			labels[k] = v
+		}
+		labels["pod_name"] = pod.Name
+		labels["namespace"] = pod.Namespace
+		if pod.Spec.NodeName != "" {
+			labels["node_name"] = pod.Spec.NodeName
+		}
+
+		targets = append(targets, Target{
+			Address: net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port)),
+			Labels:  labels,
+			Source:  &KubernetesPod{Pod: pod, Namespace: namespaces[pod.Namespace]},
+		})
+	}
+	return targets, nil
+}
+
+// namespaces returns the namespace objects by name if requested
+func (p *KubernetesProvider) namespaces(ctx context.Context) (map[string]*corev1.Namespace, error) {
+	if !p.WithNamespaces {
+		return nil, nil
+	}
+
+	namespaces := make(map[string]*corev1.Namespace)
+	if p.nsInformer != nil {
+		for _, obj := range p.nsInformer.GetStore().List() {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				namespaces[ns.Name] = ns
+			}
+		}
+		return namespaces, nil
+	}
+
+	list, err := p.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing namespaces failed: %w", err)
+	}
+	for i := range list.Items {
+		namespaces[list.Items[i].Name] = &list.Items[i]
+	}
+	return namespaces, nil
+}
+
+// kubeletPods lists the pods of the node by querying its kubelet
+func (p *KubernetesProvider) kubeletPods(ctx context.Context) ([]*corev1.Pod, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.kubeletURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.config != nil {
+		token := p.config.BearerToken
+		if p.config.BearerTokenFile != "" {
+			buf, err := os.ReadFile(p.config.BearerTokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading bearer token file failed: %w", err)
+			}
+			token = string(buf)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := p.kubelet.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("querying kubelet returned status %q", resp.Status)
+	}
+
+	var list corev1.PodList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("decoding pod list failed: %w", err)
+	}
+
+	pods := make([]*corev1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		pods = append(pods, &list.Items[i])
+	}
+	return pods, nil
+}
+
+// matches checks the pod against the namespace and selectors. This is
+// required for the kubelet which does not support filtering and guards
+// against API servers ignoring unsupported selectors.
+func (p *KubernetesProvider) matches(pod *corev1.Pod) bool {
+	if p.Namespace != "" && pod.Namespace != p.Namespace {
+		return false
+	}
+	return p.labelSelector.Matches(labels.Set(pod.Labels)) && p.fieldSelector.Matches(podFields(pod))
+}
+
+// podFields returns the selectable fields of a pod, see ToSelectableFields()
+// in https://github.com/kubernetes/kubernetes/blob/master/pkg/registry/core/pod/strategy.go
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
+
+// podPort returns the configured port or the matching container port of the
+// pod. If no port can be determined zero is returned.
+func (p *KubernetesProvider) podPort(pod *corev1.Pod) int {
+	if p.Port > 0 {
+		return p.Port
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if p.PortName == "" || cp.Name == p.PortName {
+				return int(cp.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// podReady returns true for running pods once their readiness is reported.
+// Pods failing their readiness probe are still considered as their metrics
+// are of particular interest in this case.
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return pod.Status.Phase == corev1.PodRunning
+		}
+	}
+	return false
+}
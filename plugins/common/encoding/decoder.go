@@ -32,3 +32,22 @@ func NewDecoder(enc string) (*Decoder, error) {
 	}
 	return nil, errors.New("unknown character encoding")
 }
+
+// EncodedLength returns the number of bytes the given utf-8 text occupies in
+// the specified text encoding. This allows to determine the position in the
+// raw input from decoded text.
+func EncodedLength(enc, text string) int {
+	switch enc {
+	case "utf-16le", "utf-16be":
+		var n int
+		for _, r := range text {
+			if r >= 0x10000 {
+				n += 4
+			} else {
+				n += 2
+			}
+		}
+		return n
+	}
+	return len(text)
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func TestDecoder(t *testing.T) {
@@ -76,3 +78,22 @@ func TestDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodedLength(t *testing.T) {
+	for _, enc := range []string{"", "none", "utf-8", "utf-16le", "utf-16be"} {
+		t.Run(enc, func(t *testing.T) {
+			encoder := encoding.Nop.NewEncoder()
+			switch enc {
+			case "utf-16le":
+				encoder = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder()
+			case "utf-16be":
+				encoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder()
+			}
+			for _, text := range []string{"", "howdy\n", "höwdy 🤠\n"} {
+				raw, err := encoder.String(text)
+				require.NoError(t, err)
+				require.Equal(t, len(raw), EncodedLength(enc, text), text)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package httpconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// ErrNoEndpointAvailable is returned if the circuit-breakers of all endpoints
+// are open.
+var ErrNoEndpointAvailable = errors.New("no endpoint available")
+
+// States of the endpoint circuit-breaker as reported in the statistics
+const (
+	stateClosed = iota
+	stateHalfOpen
+	stateOpen
+)
+
+// Weight of the latest measurement in the latency average
+const latencyWeight = 0.3
+
+// EndpointPoolConfig configures the selection of the endpoint to send a
+// request to, if a plugin is configured with multiple endpoints.
+type EndpointPoolConfig struct {
+	EndpointStrategy            string          `toml:"endpoint_strategy"`
+	EndpointFailureThreshold    int             `toml:"endpoint_failure_threshold"`
+	EndpointOpenTimeout         config.Duration `toml:"endpoint_open_timeout"`
+	EndpointHealthCheckPath     string          `toml:"endpoint_health_check_path"`
+	EndpointHealthCheckInterval config.Duration `toml:"endpoint_health_check_interval"`
+}
+
+// CreateEndpointPool creates a pool for the given endpoint URLs. The first
+// URL is the primary endpoint requests are addressed to. The tags are added
+// to the statistics of each endpoint.
+func (cfg *EndpointPoolConfig) CreateEndpointPool(urls []string, tags map[string]string) (*EndpointPool, error) {
+	switch cfg.EndpointStrategy {
+	case "", "failover", "round-robin", "least-latency":
+	default:
+		return nil, fmt.Errorf("invalid 'endpoint_strategy' setting %q", cfg.EndpointStrategy)
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("no endpoints given")
+	}
+
+	threshold := cfg.EndpointFailureThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	timeout := time.Duration(cfg.EndpointOpenTimeout)
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	p := &EndpointPool{
+		strategy:    cfg.EndpointStrategy,
+		threshold:   threshold,
+		openTimeout: timeout,
+		healthPath:  cfg.EndpointHealthCheckPath,
+		interval:    time.Duration(cfg.EndpointHealthCheckInterval),
+		endpoints:   make([]*endpoint, 0, len(urls)),
+	}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing endpoint %q failed: %w", raw, err)
+		}
+
+		stattags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			stattags[k] = v
+		}
+		stattags["endpoint"] = u.Redacted()
+
+		p.endpoints = append(p.endpoints, &endpoint{
+			url:      u,
+			requests: selfstat.Register("http_endpoint", "requests", stattags),
+			errors:   selfstat.Register("http_endpoint", "errors", stattags),
+			stateVal: selfstat.Register("http_endpoint", "state", stattags),
+			latency:  selfstat.RegisterTiming("http_endpoint", "latency_ns", stattags),
+		})
+	}
+
+	return p, nil
+}
+
+// EndpointPool distributes requests across multiple endpoints according to
+// the configured strategy. Each endpoint is guarded by a circuit-breaker
+// opening after a number of consecutive failures. After the open-timeout a
+// single request is let through to probe the endpoint (half-open state) and
+// the circuit is closed again if the probe succeeds.
+type EndpointPool struct {
+	strategy    string
+	threshold   int
+	openTimeout time.Duration
+	healthPath  string
+	interval    time.Duration
+	endpoints   []*endpoint
+	next        atomic.Uint64
+
+	base   http.RoundTripper
+	cancel chan struct{}
+	wg     sync.WaitGroup
+}
+
+type endpoint struct {
+	url *url.URL
+
+	state    int
+	failures int
+	openedAt time.Time
+	probing  bool
+	avg      float64
+	sync.Mutex
+
+	requests selfstat.Stat
+	errors   selfstat.Stat
+	stateVal selfstat.Stat
+	latency  selfstat.Stat
+}
+
+// Len returns the number of endpoints in the pool
+func (p *EndpointPool) Len() int {
+	return len(p.endpoints)
+}
+
+// URL returns the URL of the endpoint with the given index
+func (p *EndpointPool) URL(idx int) *url.URL {
+	return p.endpoints[idx].url
+}
+
+// Permanent marks the error as not being caused by the endpoint, i.e. the
+// request is not retried on the other endpoints and the error does not count
+// towards opening the circuit-breaker.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Do calls the given function with the index of the endpoints in the order
+// determined by the strategy until the function succeeds. Endpoints with an
+// open circuit-breaker are skipped. The error of the last attempt is returned
+// if all endpoints fail.
+func (p *EndpointPool) Do(fn func(idx int) error) error {
+	var lastErr error
+	for _, idx := range p.order() {
+		ep := p.endpoints[idx]
+		if !ep.allow(time.Now(), p.openTimeout) {
+			continue
+		}
+
+		start := time.Now()
+		err := fn(idx)
+		elapsed := time.Since(start)
+		ep.requests.Incr(1)
+		var perr *permanentError
+		if err == nil || errors.As(err, &perr) {
+			ep.latency.Incr(elapsed.Nanoseconds())
+			ep.success(elapsed)
+			if perr != nil {
+				return perr.err
+			}
+			return nil
+		}
+		ep.errors.Incr(1)
+		ep.failure(time.Now(), p.threshold)
+		lastErr = fmt.Errorf("endpoint %q: %w", ep.url.Redacted(), err)
+	}
+
+	if lastErr == nil {
+		return ErrNoEndpointAvailable
+	}
+	return lastErr
+}
+
+// order returns the endpoint indices in the order to try them
+func (p *EndpointPool) order() []int {
+	indices := make([]int, len(p.endpoints))
+	switch p.strategy {
+	case "round-robin":
+		start := int(p.next.Add(1)-1) % len(p.endpoints)
+		for i := range indices {
+			indices[i] = (start + i) % len(p.endpoints)
+		}
+	case "least-latency":
+		latencies := make([]float64, len(p.endpoints))
+		for i, ep := range p.endpoints {
+			indices[i] = i
+			ep.Lock()
+			latencies[i] = ep.avg
+			ep.Unlock()
+		}
+		// Endpoints without measurement have a zero latency and are tried
+		// first to get a measurement
+		sort.SliceStable(indices, func(i, j int) bool {
+			return latencies[indices[i]] < latencies[indices[j]]
+		})
+	default:
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+	return indices
+}
+
+// Wrap replaces the transport of the client by one sending the requests
+// addressed to the primary endpoint to the endpoints of the pool and starts
+// the health-check if configured. Responses with a 429 or 5xx status code are
+// treated as endpoint failures. Requests to other addresses are passed
+// through unchanged.
+func (p *EndpointPool) Wrap(client *http.Client) {
+	p.base = client.Transport
+	if p.base == nil {
+		p.base = http.DefaultTransport
+	}
+	p.Start(&http.Client{Transport: p.base, Timeout: client.Timeout})
+	client.Transport = p
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (p *EndpointPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	primary := p.endpoints[0].url
+	if req.URL.Scheme != primary.Scheme || req.URL.Host != primary.Host {
+		return p.base.RoundTrip(req)
+	}
+
+	// Buffer the body to be able to resend the request to other endpoints
+	getBody := req.GetBody
+	if req.Body != nil && req.Body != http.NoBody {
+		if getBody == nil {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading request body failed: %w", err)
+			}
+			getBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		} else {
+			req.Body.Close()
+		}
+	} else {
+		getBody = nil
+	}
+
+	var resp, failed *http.Response
+	err := p.Do(func(idx int) error {
+		r := req.Clone(req.Context())
+		if getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return Permanent(err)
+			}
+			r.Body = body
+		}
+		p.rewrite(r.URL, p.endpoints[idx].url)
+		// Keep explicitly overridden host headers
+		if r.Host == req.URL.Host {
+			r.Host = ""
+		}
+
+		res, err := p.base.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+			// Keep the last failed response to return it if all endpoints fail
+			if failed != nil {
+				failed.Body.Close()
+			}
+			failed = res
+			return fmt.Errorf("received status code %d", res.StatusCode)
+		}
+		resp = res
+		return nil
+	})
+	if resp != nil {
+		if failed != nil {
+			failed.Body.Close()
+		}
+		return resp, err
+	}
+	if failed != nil {
+		return failed, nil
+	}
+	return nil, err
+}
+
+// rewrite replaces the primary endpoint prefix of the given URL by the endpoint
+func (p *EndpointPool) rewrite(u, ep *url.URL) {
+	primary := p.endpoints[0].url
+	u.Scheme = ep.Scheme
+	u.Host = ep.Host
+	if ep.User != nil {
+		u.User = ep.User
+	}
+	u.Path = ep.Path + strings.TrimPrefix(u.Path, primary.Path)
+	u.RawPath = ""
+}
+
+// Start starts the periodic health-check of the endpoints using the given
+// client if an interval is configured.
+func (p *EndpointPool) Start(client *http.Client) {
+	if p.interval <= 0 || p.cancel != nil {
+		return
+	}
+	p.cancel = make(chan struct{})
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.cancel:
+				return
+			case <-ticker.C:
+				p.check(client)
+			}
+		}
+	}()
+}
+
+// Stop stops the health-check
+func (p *EndpointPool) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	close(p.cancel)
+	p.wg.Wait()
+	p.cancel = nil
+}
+
+// check probes all endpoints and updates their circuit-breakers
+func (p *EndpointPool) check(client *http.Client) {
+	for _, ep := range p.endpoints {
+		u := *ep.url
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(p.healthPath, "/")
+
+		start := time.Now()
+		resp, err := client.Get(u.String())
+		if err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				err = fmt.Errorf("received status code %d", resp.StatusCode)
+			}
+		}
+		if err != nil {
+			ep.failure(time.Now(), p.threshold)
+			continue
+		}
+		ep.success(time.Since(start))
+	}
+}
+
+// allow checks if a request can be sent to the endpoint. After the open
+// timeout elapsed only a single probing request is allowed.
+func (ep *endpoint) allow(now time.Time, timeout time.Duration) bool {
+	ep.Lock()
+	defer ep.Unlock()
+
+	switch ep.state {
+	case stateOpen:
+		if now.Sub(ep.openedAt) < timeout {
+			return false
+		}
+		ep.setState(stateHalfOpen)
+		ep.probing = true
+		return true
+	case stateHalfOpen:
+		if ep.probing {
+			return false
+		}
+		ep.probing = true
+		return true
+	}
+	return true
+}
+
+func (ep *endpoint) success(latency time.Duration) {
+	ep.Lock()
+	defer ep.Unlock()
+
+	if ep.avg == 0 {
+		ep.avg = float64(latency)
+	} else {
+		ep.avg = latencyWeight*float64(latency) + (1-latencyWeight)*ep.avg
+	}
+	ep.failures = 0
+	ep.probing = false
+	ep.setState(stateClosed)
+}
+
+func (ep *endpoint) failure(now time.Time, threshold int) {
+	ep.Lock()
+	defer ep.Unlock()
+
+	ep.failures++
+	ep.probing = false
+	if ep.state == stateHalfOpen || ep.failures >= threshold {
+		ep.openedAt = now
+		ep.setState(stateOpen)
+	}
+}
+
+func (ep *endpoint) setState(state int) {
+	ep.state = state
+	ep.stateVal.Set(int64(state))
+}
@@ -0,0 +1,202 @@
+package httpconfig
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+)
+
+func TestEndpointPoolInvalidStrategy(t *testing.T) {
+	cfg := &EndpointPoolConfig{EndpointStrategy: "random"}
+	_, err := cfg.CreateEndpointPool([]string{"http://a"}, nil)
+	require.ErrorContains(t, err, "invalid 'endpoint_strategy' setting")
+}
+
+func TestEndpointPoolStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		expected [][]int
+	}{
+		{
+			strategy: "failover",
+			expected: [][]int{{0, 1, 2}, {0, 1, 2}, {0, 1, 2}},
+		},
+		{
+			strategy: "round-robin",
+			expected: [][]int{{0, 1, 2}, {1, 2, 0}, {2, 0, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			cfg := &EndpointPoolConfig{EndpointStrategy: tt.strategy}
+			pool, err := cfg.CreateEndpointPool([]string{"http://a", "http://b", "http://c"}, nil)
+			require.NoError(t, err)
+
+			for _, expected := range tt.expected {
+				require.Equal(t, expected, pool.order())
+			}
+		})
+	}
+}
+
+func TestEndpointPoolLeastLatency(t *testing.T) {
+	cfg := &EndpointPoolConfig{EndpointStrategy: "least-latency"}
+	pool, err := cfg.CreateEndpointPool([]string{"http://a", "http://b", "http://c"}, nil)
+	require.NoError(t, err)
+
+	// Endpoints without measurement must be tried first
+	pool.endpoints[0].success(30 * time.Millisecond)
+	pool.endpoints[1].success(10 * time.Millisecond)
+	require.Equal(t, []int{2, 1, 0}, pool.order())
+
+	pool.endpoints[2].success(20 * time.Millisecond)
+	require.Equal(t, []int{1, 2, 0}, pool.order())
+}
+
+func TestEndpointPoolFailover(t *testing.T) {
+	cfg := &EndpointPoolConfig{EndpointStrategy: "failover"}
+	pool, err := cfg.CreateEndpointPool([]string{"http://a", "http://b"}, nil)
+	require.NoError(t, err)
+
+	var called []int
+	err = pool.Do(func(idx int) error {
+		called = append(called, idx)
+		if idx == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1}, called)
+
+	// Permanent errors must not be tried on other endpoints
+	called = nil
+	err = pool.Do(func(idx int) error {
+		called = append(called, idx)
+		return Permanent(errors.New("bad request"))
+	})
+	require.EqualError(t, err, "bad request")
+	require.Equal(t, []int{0}, called)
+}
+
+func TestEndpointPoolCircuitBreaker(t *testing.T) {
+	cfg := &EndpointPoolConfig{
+		EndpointStrategy:         "failover",
+		EndpointFailureThreshold: 2,
+		EndpointOpenTimeout:      config.Duration(time.Hour),
+	}
+	pool, err := cfg.CreateEndpointPool([]string{"http://a", "http://b"}, nil)
+	require.NoError(t, err)
+
+	fail := func(int) error { return errors.New("failed") }
+	require.ErrorContains(t, pool.Do(fail), "failed")
+	require.ErrorContains(t, pool.Do(fail), "failed")
+
+	// All circuits are open now
+	require.ErrorIs(t, pool.Do(fail), ErrNoEndpointAvailable)
+	require.Equal(t, int64(stateOpen), pool.endpoints[0].stateVal.Get())
+
+	// Allow a single probe after the open timeout elapsed
+	now := time.Now().Add(2 * time.Hour)
+	ep := pool.endpoints[0]
+	require.True(t, ep.allow(now, pool.openTimeout))
+	require.False(t, ep.allow(now, pool.openTimeout))
+	require.Equal(t, int64(stateHalfOpen), ep.stateVal.Get())
+
+	// A failed probe opens the circuit again
+	ep.failure(now, pool.threshold)
+	require.False(t, ep.allow(now, pool.openTimeout))
+
+	// A successful probe closes the circuit
+	now = now.Add(2 * time.Hour)
+	require.True(t, ep.allow(now, pool.openTimeout))
+	ep.success(time.Millisecond)
+	require.True(t, ep.allow(now, pool.openTimeout))
+	require.Equal(t, int64(stateClosed), ep.stateVal.Get())
+}
+
+func TestEndpointPoolTransport(t *testing.T) {
+	var failedCalls atomic.Int64
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		failedCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	var received string
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received = r.Host + r.URL.Path + " " + string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer working.Close()
+
+	cfg := &EndpointPoolConfig{EndpointStrategy: "failover"}
+	pool, err := cfg.CreateEndpointPool([]string{failing.URL + "/api", working.URL + "/other"}, nil)
+	require.NoError(t, err)
+
+	client := &http.Client{}
+	pool.Wrap(client)
+	defer pool.Stop()
+
+	// Use a body that cannot be replayed
+	resp, err := client.Post(failing.URL+"/api/write", "text/plain", io.NopCloser(strings.NewReader("data")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, int64(1), failedCalls.Load())
+	require.Equal(t, strings.TrimPrefix(working.URL, "http://")+"/other/write data", received)
+
+	// The last failed response must be returned if all endpoints fail
+	working.Close()
+	resp, err = client.Post(failing.URL+"/api/write", "text/plain", strings.NewReader("data"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestEndpointPoolHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &EndpointPoolConfig{
+		EndpointFailureThreshold:    1,
+		EndpointOpenTimeout:         config.Duration(time.Hour),
+		EndpointHealthCheckPath:     "/health",
+		EndpointHealthCheckInterval: config.Duration(10 * time.Millisecond),
+	}
+	pool, err := cfg.CreateEndpointPool([]string{server.URL}, nil)
+	require.NoError(t, err)
+	pool.Start(server.Client())
+	defer pool.Stop()
+
+	ep := pool.endpoints[0]
+	require.Eventually(t, func() bool {
+		return ep.stateVal.Get() == stateOpen
+	}, time.Second, 10*time.Millisecond)
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return ep.stateVal.Get() == stateClosed
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,376 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+const (
+	// defaultMaxBodySize is the default maximum request body size, in bytes.
+	defaultMaxBodySize = 500 * 1024 * 1024
+
+	// DefaultRetryAfter is the default duration clients are asked to wait
+	// before retrying requests rejected due to backpressure
+	DefaultRetryAfter = 5 * time.Second
+)
+
+// Config contains the settings shared by plugins hosting a HTTP server
+type Config struct {
+	SocketMode    string          `toml:"socket_mode"`
+	ReadTimeout   config.Duration `toml:"read_timeout"`
+	WriteTimeout  config.Duration `toml:"write_timeout"`
+	MaxBodySize   config.Size     `toml:"max_body_size"`
+	RetryAfter    config.Duration `toml:"retry_after"`
+	BasicUsername string          `toml:"basic_username"`
+	BasicPassword config.Secret   `toml:"basic_password"`
+	common_tls.ServerConfig
+}
+
+// Server is a HTTP server listening on a TCP or unix socket
+type Server struct {
+	Config
+
+	url          *url.URL
+	server       *http.Server
+	backpressure telegraf.Backpressure
+	log          telegraf.Logger
+
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// HTTPError is an error with an associated HTTP status code to respond with
+type HTTPError struct {
+	Code int
+	Err  error
+}
+
+func (e *HTTPError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code to respond with for the given
+// error, defaulting to "400 Bad Request"
+func StatusCode(err error) int {
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		return herr.Code
+	}
+	return http.StatusBadRequest
+}
+
+// NewServer creates a server for the given address serving the handler. The
+// address might be prefixed with a "tcp://" or "unix://" scheme and defaults
+// to TCP. Requests are authenticated before being passed to the handler if
+// basic-authentication credentials are configured.
+func (cfg *Config) NewServer(address string, handler http.Handler, logger telegraf.Logger) (*Server, error) {
+	s := &Server{
+		Config: *cfg,
+		log:    logger,
+	}
+
+	if s.ReadTimeout < config.Duration(time.Second) {
+		s.ReadTimeout = config.Duration(10 * time.Second)
+	}
+	if s.WriteTimeout < config.Duration(time.Second) {
+		s.WriteTimeout = config.Duration(10 * time.Second)
+	}
+	if s.MaxBodySize == 0 {
+		s.MaxBodySize = config.Size(defaultMaxBodySize)
+	}
+	if s.RetryAfter <= 0 {
+		s.RetryAfter = config.Duration(DefaultRetryAfter)
+	}
+
+	u, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", u.Scheme)
+	}
+	s.url = u
+
+	tlsCfg, err := s.ServerConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("getting TLS config failed: %w", err)
+	}
+
+	if s.BasicUsername != "" || !s.BasicPassword.Empty() {
+		handler = s.authenticate(handler)
+	}
+
+	s.server = &http.Server{
+		Addr:         u.Host,
+		Handler:      handler,
+		ReadTimeout:  time.Duration(s.ReadTimeout),
+		WriteTimeout: time.Duration(s.WriteTimeout),
+		TLSConfig:    tlsCfg,
+	}
+
+	return s, nil
+}
+
+// Start opens the listener and serves requests in the background
+func (s *Server) Start() error {
+	listener, err := Listen(s.url, s.SocketMode, s.server.TLSConfig)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Errorf("Serve failed: %v", err)
+		}
+	}()
+
+	s.log.Infof("Listening on %s", listener.Addr().String())
+
+	return nil
+}
+
+// Close gracefully shuts down the server waiting for active requests to
+// finish for at most the write timeout
+func (s *Server) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.WriteTimeout))
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.log.Errorf("Shutting down server failed: %v", err)
+	}
+	s.wg.Wait()
+}
+
+// Address returns the address the server is listening on
+func (s *Server) Address() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// SetBackpressure sets the backpressure signal used by Throttle
+func (s *Server) SetBackpressure(bp telegraf.Backpressure) {
+	s.backpressure = bp
+}
+
+// Throttle rejects the request if the outputs signal backpressure and
+// returns true in this case, see the package-level Throttle function.
+func (s *Server) Throttle(res http.ResponseWriter) bool {
+	return Throttle(res, s.backpressure, time.Duration(s.RetryAfter))
+}
+
+// Throttle responds with "429 Too Many Requests" and a "Retry-After" header
+// if the given backpressure signal is throttled and returns true in this
+// case. Handlers should call this function before reading the request body.
+func Throttle(res http.ResponseWriter, bp telegraf.Backpressure, retryAfter time.Duration) bool {
+	if bp == nil || !bp.Throttled() {
+		return false
+	}
+
+	seconds := max(int64(retryAfter/time.Second), 1)
+	res.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	http.Error(res, "outputs cannot keep up, retry later", http.StatusTooManyRequests)
+	return true
+}
+
+// ReadBody reads the complete request body decoding it according to the
+// "Content-Encoding" header. Supported encodings are "gzip", "snappy" (block
+// format) and "identity". The size of the body is limited by the configured
+// maximum both before and after decompression.
+func (s *Server) ReadBody(res http.ResponseWriter, req *http.Request) ([]byte, error) {
+	defer req.Body.Close()
+
+	if req.ContentLength > int64(s.MaxBodySize) {
+		return nil, &HTTPError{
+			Code: http.StatusRequestEntityTooLarge,
+			Err:  fmt.Errorf("body size %d exceeds limit", req.ContentLength),
+		}
+	}
+	body := http.MaxBytesReader(res, req.Body, int64(s.MaxBodySize))
+
+	switch encoding := req.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+		return readAll(body)
+	case "gzip":
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("creating gzip reader failed: %w", err)
+		}
+		defer r.Close()
+		buf, err := readAll(io.LimitReader(r, int64(s.MaxBodySize)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) > int(s.MaxBodySize) {
+			return nil, &HTTPError{
+				Code: http.StatusRequestEntityTooLarge,
+				Err:  errors.New("decompressed body size exceeds limit"),
+			}
+		}
+		return buf, nil
+	case "snappy":
+		buf, err := readAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return s.DecodeSnappy(buf)
+	default:
+		return nil, &HTTPError{
+			Code: http.StatusUnsupportedMediaType,
+			Err:  fmt.Errorf("unsupported content encoding %q", encoding),
+		}
+	}
+}
+
+// DecodeSnappy decompresses the given snappy block making sure the size of
+// the decompressed data does not exceed the configured maximum body size
+func (s *Server) DecodeSnappy(buf []byte) ([]byte, error) {
+	size, err := snappy.DecodedLen(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy block: %w", err)
+	}
+	if size > int(s.MaxBodySize) {
+		return nil, &HTTPError{
+			Code: http.StatusRequestEntityTooLarge,
+			Err:  fmt.Errorf("decompressed body size %d exceeds limit", size),
+		}
+	}
+	decoded, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snappy block failed: %w", err)
+	}
+	return decoded, nil
+}
+
+func (s *Server) authenticate(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		username, password, ok := req.BasicAuth()
+		if !ok {
+			http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+
+		secret, err := s.BasicPassword.Get()
+		if err != nil {
+			s.log.Errorf("Getting password failed: %v", err)
+			http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		defer secret.Destroy()
+
+		if subtle.ConstantTimeCompare([]byte(username), []byte(s.BasicUsername)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), secret.Bytes()) != 1 {
+			http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(res, req)
+	})
+}
+
+// ParseAddress parses the given service address into an URL defaulting to
+// the "tcp" scheme if none is given
+func ParseAddress(address string) (*url.URL, error) {
+	protoRegex := regexp.MustCompile(`\w://`)
+	if !protoRegex.MatchString(address) {
+		address = "tcp://" + address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing address failed: %w", err)
+	}
+	return u, nil
+}
+
+// Listen opens a TCP or unix socket listener for the given URL. Existing unix
+// sockets are removed before listening and the permissions are set to the
+// given octal socket mode if not empty.
+func Listen(u *url.URL, socketMode string, tlsCfg *tls.Config) (net.Listener, error) {
+	address := u.Host
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6":
+	case "unix":
+		path := filepath.FromSlash(u.Path)
+		if runtime.GOOS == "windows" && strings.Contains(path, ":") {
+			path = strings.TrimPrefix(path, `\`)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing socket failed: %w", err)
+		}
+		address = path
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", u.Scheme)
+	}
+
+	var listener net.Listener
+	var err error
+	if tlsCfg != nil {
+		listener, err = tls.Listen(u.Scheme, address, tlsCfg)
+	} else {
+		listener, err = net.Listen(u.Scheme, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "unix" && socketMode != "" {
+		// Set permissions on socket
+		// Convert from octal in string to int
+		i, err := strconv.ParseUint(socketMode, 8, 32)
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("converting socket mode failed: %w", err)
+		}
+
+		perm := os.FileMode(uint32(i))
+		if err := os.Chmod(address, perm); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("changing socket permissions failed: %w", err)
+		}
+	}
+
+	return listener, nil
+}
+
+func readAll(r io.Reader) ([]byte, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, &HTTPError{Code: http.StatusRequestEntityTooLarge, Err: err}
+		}
+		return nil, fmt.Errorf("reading body failed: %w", err)
+	}
+	return buf, nil
+}
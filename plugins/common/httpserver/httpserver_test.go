@@ -0,0 +1,228 @@
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestServer(t *testing.T, cfg *Config, address string) *Server {
+	handler := http.NewServeMux()
+	var s *Server
+	handler.HandleFunc("/", func(res http.ResponseWriter, req *http.Request) {
+		body, err := s.ReadBody(res, req)
+		if err != nil {
+			http.Error(res, err.Error(), StatusCode(err))
+			return
+		}
+		_, _ = res.Write(body)
+	})
+
+	s, err := cfg.NewServer(address, handler, testutil.Logger{})
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func request(t *testing.T, client *http.Client, url, encoding string, body []byte) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(buf)
+}
+
+func TestUnknownProtocol(t *testing.T) {
+	cfg := &Config{}
+	_, err := cfg.NewServer("udp://:8080", http.NotFoundHandler(), testutil.Logger{})
+	require.ErrorContains(t, err, "unknown protocol")
+}
+
+func TestReadBody(t *testing.T) {
+	cfg := &Config{MaxBodySize: config.Size(64)}
+	s := newTestServer(t, cfg, "127.0.0.1:0")
+	url := "http://" + s.Address().String()
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, err := zw.Write([]byte("gzip data"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var gzipBomb bytes.Buffer
+	zw = gzip.NewWriter(&gzipBomb)
+	_, err = zw.Write(make([]byte, 1024))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		code     int
+		expected string
+	}{
+		{
+			name:     "identity",
+			body:     []byte("plain data"),
+			code:     http.StatusOK,
+			expected: "plain data",
+		},
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			body:     gzipped.Bytes(),
+			code:     http.StatusOK,
+			expected: "gzip data",
+		},
+		{
+			name:     "snappy",
+			encoding: "snappy",
+			body:     snappy.Encode(nil, []byte("snappy data")),
+			code:     http.StatusOK,
+			expected: "snappy data",
+		},
+		{
+			name: "body too large",
+			body: make([]byte, 128),
+			code: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "decompressed gzip too large",
+			encoding: "gzip",
+			body:     gzipBomb.Bytes(),
+			code:     http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "decompressed snappy too large",
+			encoding: "snappy",
+			body:     snappy.Encode(nil, make([]byte, 1024)),
+			code:     http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "invalid gzip",
+			encoding: "gzip",
+			body:     []byte("foo"),
+			code:     http.StatusBadRequest,
+		},
+		{
+			name:     "unsupported encoding",
+			encoding: "br",
+			body:     []byte("foo"),
+			code:     http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := request(t, http.DefaultClient, url, tt.encoding, tt.body)
+			require.Equal(t, tt.code, code)
+			if tt.code == http.StatusOK {
+				require.Equal(t, tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	cfg := &Config{
+		BasicUsername: "user",
+		BasicPassword: config.NewSecret([]byte("secret")),
+	}
+	s := newTestServer(t, cfg, "127.0.0.1:0")
+	url := "http://" + s.Address().String()
+
+	code, _ := request(t, http.DefaultClient, url, "", []byte("data"))
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "wrong")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows, as unix sockets are not supported")
+	}
+
+	sock := filepath.Join(t.TempDir(), "http.sock")
+	newTestServer(t, &Config{}, "unix://"+sock)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", sock)
+			},
+		},
+	}
+	code, body := request(t, client, "http://unix/", "", []byte("data"))
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "data", body)
+}
+
+func TestThrottle(t *testing.T) {
+	var s *Server
+	handler := http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		if s.Throttle(res) {
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	cfg := &Config{RetryAfter: config.Duration(10 * time.Second)}
+	s, err := cfg.NewServer("127.0.0.1:0", handler, testutil.Logger{})
+	require.NoError(t, err)
+	bp := &testutil.Backpressure{}
+	s.SetBackpressure(bp)
+	require.NoError(t, s.Start())
+	defer s.Close()
+	url := "http://" + s.Address().String()
+
+	resp, err := http.Post(url, "text/plain", bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	bp.Throttle(true)
+	resp, err = http.Post(url, "text/plain", bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "10", resp.Header.Get("Retry-After"))
+}
@@ -2,6 +2,8 @@ package postgresql
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	// Blank import required to register driver
@@ -35,6 +37,18 @@ func (p *Service) Start() error {
 	return nil
 }
 
+// Ping verifies the connection to the server, it requires the service to be
+// started
+func (p *Service) Ping() error {
+	if p.DB == nil {
+		return errors.New("service not started")
+	}
+	if err := p.DB.Ping(); err != nil {
+		return fmt.Errorf("connecting to %q failed: %w", p.SanitizedAddress, err)
+	}
+	return nil
+}
+
 func (p *Service) Stop() {
 	if p.DB != nil {
 		p.DB.Close()
@@ -0,0 +1,65 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Config specifies the protocol-buffer definition and the gRPC method to use
+type Config struct {
+	Files       []string `toml:"proto_files"`
+	ImportPaths []string `toml:"proto_import_paths"`
+	Method      string   `toml:"method"`
+}
+
+// LoadMethod parses the protocol-buffer files and returns the descriptor of
+// the configured method. The method can be given as "<service>/<method>" or
+// "<service>.<method>" with the service name including its package.
+func (c *Config) LoadMethod() (protoreflect.MethodDescriptor, error) {
+	if len(c.Files) == 0 {
+		return nil, errors.New("'proto_files' setting required")
+	}
+	if c.Method == "" {
+		return nil, errors.New("'method' setting required")
+	}
+
+	parser := protoparse.Parser{
+		ImportPaths:      c.ImportPaths,
+		InferImportPaths: true,
+	}
+	fds, err := parser.ParseFiles(c.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing protocol-buffer definition failed: %w", err)
+	}
+	registry, err := protodesc.NewFiles(desc.ToFileDescriptorSet(fds...))
+	if err != nil {
+		return nil, fmt.Errorf("constructing registry failed: %w", err)
+	}
+
+	name := strings.ReplaceAll(strings.TrimPrefix(c.Method, "/"), "/", ".")
+	d, err := registry.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("looking up method %q failed: %w", c.Method, err)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a method but a %T", c.Method, d)
+	}
+	if md.IsStreamingServer() {
+		return nil, fmt.Errorf("server-streaming method %q not supported", c.Method)
+	}
+
+	return md, nil
+}
+
+// FullMethodName returns the name of the method as used by gRPC, i.e.
+// "/<service>/<method>"
+func FullMethodName(md protoreflect.MethodDescriptor) string {
+	return "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+}
@@ -0,0 +1,461 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const timestampType = protoreflect.FullName("google.protobuf.Timestamp")
+
+// Mapping defines the representation of metrics in a protocol-buffer message.
+// All message fields are specified as dot-separated paths of field names
+// relative to the metric message, which is either the message itself or the
+// element of the repeated message field given by 'metrics'.
+type Mapping struct {
+	Metrics         string            `toml:"metrics"`
+	MetricName      string            `toml:"metric_name"`
+	Timestamp       string            `toml:"timestamp"`
+	TimestampFormat string            `toml:"timestamp_format"`
+	Tags            map[string]string `toml:"tags"`
+	Fields          map[string]string `toml:"fields"`
+	TagMap          string            `toml:"tag_map"`
+	FieldMap        string            `toml:"field_map"`
+
+	message   protoreflect.MessageDescriptor
+	metric    protoreflect.MessageDescriptor
+	metrics   fieldPath
+	name      fieldPath
+	timestamp fieldPath
+	tags      map[string]fieldPath
+	fields    map[string]fieldPath
+	tagMap    fieldPath
+	fieldMap  fieldPath
+}
+
+type fieldPath []protoreflect.FieldDescriptor
+
+// Init resolves the configured field paths for the given message type
+func (m *Mapping) Init(md protoreflect.MessageDescriptor) error {
+	if len(m.Fields) == 0 && m.FieldMap == "" {
+		return errors.New("either 'fields' or 'field_map' must be set")
+	}
+	if m.TimestampFormat == "" {
+		m.TimestampFormat = "unix_ns"
+	}
+
+	m.message = md
+	m.metric = md
+	if m.Metrics != "" {
+		fp, err := resolve(md, m.Metrics)
+		if err != nil {
+			return fmt.Errorf("resolving 'metrics' failed: %w", err)
+		}
+		fd := fp.leaf()
+		if !fd.IsList() || fd.Message() == nil {
+			return fmt.Errorf("'metrics' field %q is not a repeated message", m.Metrics)
+		}
+		m.metrics = fp
+		m.metric = fd.Message()
+	}
+
+	var err error
+	if m.MetricName != "" {
+		if m.name, err = m.resolveScalar(m.MetricName); err != nil {
+			return fmt.Errorf("resolving 'metric_name' failed: %w", err)
+		}
+	}
+
+	if m.Timestamp != "" {
+		fp, err := resolve(m.metric, m.Timestamp)
+		if err != nil {
+			return fmt.Errorf("resolving 'timestamp' failed: %w", err)
+		}
+		if fd := fp.leaf(); !isScalar(fd) && !isTimestamp(fd) {
+			return fmt.Errorf("'timestamp' field %q is neither a scalar nor a timestamp", m.Timestamp)
+		}
+		m.timestamp = fp
+	}
+
+	m.tags = make(map[string]fieldPath, len(m.Tags))
+	for key, path := range m.Tags {
+		if m.tags[key], err = m.resolveScalar(path); err != nil {
+			return fmt.Errorf("resolving tag %q failed: %w", key, err)
+		}
+	}
+	m.fields = make(map[string]fieldPath, len(m.Fields))
+	for key, path := range m.Fields {
+		if m.fields[key], err = m.resolveScalar(path); err != nil {
+			return fmt.Errorf("resolving field %q failed: %w", key, err)
+		}
+	}
+
+	if m.TagMap != "" {
+		if m.tagMap, err = m.resolveMap(m.TagMap); err != nil {
+			return fmt.Errorf("resolving 'tag_map' failed: %w", err)
+		}
+		if m.tagMap.leaf().MapValue().Kind() != protoreflect.StringKind {
+			return fmt.Errorf("'tag_map' field %q must have string values", m.TagMap)
+		}
+	}
+	if m.FieldMap != "" {
+		if m.fieldMap, err = m.resolveMap(m.FieldMap); err != nil {
+			return fmt.Errorf("resolving 'field_map' failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Batched returns true if a message contains multiple metrics
+func (m *Mapping) Batched() bool {
+	return len(m.metrics) > 0
+}
+
+// Encode converts the metric to a metric message
+func (m *Mapping) Encode(metric telegraf.Metric) (protoreflect.Message, error) {
+	msg := dynamicpb.NewMessage(m.metric)
+
+	if len(m.name) > 0 {
+		if err := m.name.set(msg, metric.Name()); err != nil {
+			return nil, fmt.Errorf("setting name failed: %w", err)
+		}
+	}
+
+	if len(m.timestamp) > 0 {
+		if err := m.setTimestamp(msg, metric.Time()); err != nil {
+			return nil, fmt.Errorf("setting timestamp failed: %w", err)
+		}
+	}
+
+	for _, tag := range metric.TagList() {
+		if fp, found := m.tags[tag.Key]; found {
+			if err := fp.set(msg, tag.Value); err != nil {
+				return nil, fmt.Errorf("setting tag %q failed: %w", tag.Key, err)
+			}
+		} else if len(m.tagMap) > 0 {
+			if err := m.tagMap.setEntry(msg, tag.Key, tag.Value); err != nil {
+				return nil, fmt.Errorf("setting tag %q failed: %w", tag.Key, err)
+			}
+		}
+	}
+
+	for _, field := range metric.FieldList() {
+		if fp, found := m.fields[field.Key]; found {
+			if err := fp.set(msg, field.Value); err != nil {
+				return nil, fmt.Errorf("setting field %q failed: %w", field.Key, err)
+			}
+		} else if len(m.fieldMap) > 0 {
+			if err := m.fieldMap.setEntry(msg, field.Key, field.Value); err != nil {
+				return nil, fmt.Errorf("setting field %q failed: %w", field.Key, err)
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+// Batch creates a message containing the given metric messages. This
+// function must only be called if the mapping is batched.
+func (m *Mapping) Batch(msgs []protoreflect.Message) protoreflect.Message {
+	batch := dynamicpb.NewMessage(m.message)
+	list := m.metrics.mutableParent(batch).Mutable(m.metrics.leaf()).List()
+	for _, msg := range msgs {
+		list.Append(protoreflect.ValueOfMessage(msg))
+	}
+	return batch
+}
+
+// Decode extracts the metrics of the given message. The given name is used
+// for metrics without a name field.
+func (m *Mapping) Decode(msg protoreflect.Message, name string) ([]telegraf.Metric, error) {
+	if !m.Batched() {
+		metric, err := m.decode(msg, name)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{metric}, nil
+	}
+
+	v, found := m.metrics.get(msg)
+	if !found {
+		return nil, nil
+	}
+	list := v.List()
+	metrics := make([]telegraf.Metric, 0, list.Len())
+	for i := range list.Len() {
+		metric, err := m.decode(list.Get(i).Message(), name)
+		if err != nil {
+			return nil, fmt.Errorf("decoding metric %d failed: %w", i, err)
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+func (m *Mapping) decode(msg protoreflect.Message, name string) (telegraf.Metric, error) {
+	if v, found := m.name.get(msg); found && v.String() != "" {
+		name = v.String()
+	}
+
+	timestamp := time.Now()
+	if v, found := m.timestamp.get(msg); found {
+		t, err := m.parseTimestamp(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timestamp failed: %w", err)
+		}
+		timestamp = t
+	}
+
+	tags := make(map[string]string, len(m.tags))
+	if v, found := m.tagMap.get(msg); found {
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			tags[k.String()] = v.String()
+			return true
+		})
+	}
+	for key, fp := range m.tags {
+		if v, found := fp.get(msg); found {
+			s, err := internal.ToString(fromValue(fp.leaf(), v))
+			if err != nil {
+				return nil, fmt.Errorf("converting tag %q failed: %w", key, err)
+			}
+			tags[key] = s
+		}
+	}
+
+	fields := make(map[string]interface{}, len(m.fields))
+	if v, found := m.fieldMap.get(msg); found {
+		vfd := m.fieldMap.leaf().MapValue()
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			fields[k.String()] = fromValue(vfd, v)
+			return true
+		})
+	}
+	for key, fp := range m.fields {
+		if v, found := fp.get(msg); found {
+			fields[key] = fromValue(fp.leaf(), v)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("message does not contain any field")
+	}
+
+	return metric.New(name, tags, fields, timestamp), nil
+}
+
+func (m *Mapping) setTimestamp(msg protoreflect.Message, t time.Time) error {
+	fd := m.timestamp.leaf()
+	if isTimestamp(fd) {
+		ts := m.timestamp.mutableParent(msg).Mutable(fd).Message()
+		fields := ts.Descriptor().Fields()
+		ts.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		ts.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+		return nil
+	}
+
+	var v interface{}
+	switch m.TimestampFormat {
+	case "unix":
+		v = t.Unix()
+	case "unix_ms":
+		v = t.UnixMilli()
+	case "unix_us":
+		v = t.UnixMicro()
+	case "unix_ns":
+		v = t.UnixNano()
+	default:
+		v = t.Format(m.TimestampFormat)
+	}
+	return m.timestamp.set(msg, v)
+}
+
+func (m *Mapping) parseTimestamp(v protoreflect.Value) (time.Time, error) {
+	fd := m.timestamp.leaf()
+	if isTimestamp(fd) {
+		ts := v.Message()
+		fields := ts.Descriptor().Fields()
+		seconds := ts.Get(fields.ByName("seconds")).Int()
+		nanos := ts.Get(fields.ByName("nanos")).Int()
+		return time.Unix(seconds, nanos), nil
+	}
+	return internal.ParseTimestamp(m.TimestampFormat, fromValue(fd, v), nil)
+}
+
+func (m *Mapping) resolveScalar(path string) (fieldPath, error) {
+	fp, err := resolve(m.metric, path)
+	if err != nil {
+		return nil, err
+	}
+	if !isScalar(fp.leaf()) {
+		return nil, fmt.Errorf("field %q is not a scalar", path)
+	}
+	return fp, nil
+}
+
+func (m *Mapping) resolveMap(path string) (fieldPath, error) {
+	fp, err := resolve(m.metric, path)
+	if err != nil {
+		return nil, err
+	}
+	fd := fp.leaf()
+	if !fd.IsMap() || fd.MapKey().Kind() != protoreflect.StringKind || fd.MapValue().Message() != nil {
+		return nil, fmt.Errorf("field %q is not a map with string keys and scalar values", path)
+	}
+	return fp, nil
+}
+
+// resolve looks up the fields of the dot-separated path starting at the
+// given message
+func resolve(md protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	parts := strings.Split(path, ".")
+	fp := make(fieldPath, 0, len(parts))
+	for i, part := range parts {
+		if md == nil {
+			return nil, fmt.Errorf("field %q is not a message", strings.Join(parts[:i], "."))
+		}
+		fd := md.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			return nil, fmt.Errorf("message %q has no field %q", md.FullName(), part)
+		}
+		if i < len(parts)-1 && fd.Cardinality() == protoreflect.Repeated {
+			return nil, fmt.Errorf("repeated field %q not supported within path", part)
+		}
+		fp = append(fp, fd)
+		md = fd.Message()
+	}
+	return fp, nil
+}
+
+func (fp fieldPath) leaf() protoreflect.FieldDescriptor {
+	return fp[len(fp)-1]
+}
+
+// get returns the value of the field if present in the message
+func (fp fieldPath) get(msg protoreflect.Message) (protoreflect.Value, bool) {
+	if len(fp) == 0 {
+		return protoreflect.Value{}, false
+	}
+	for _, fd := range fp[:len(fp)-1] {
+		if !msg.Has(fd) {
+			return protoreflect.Value{}, false
+		}
+		msg = msg.Get(fd).Message()
+	}
+	fd := fp.leaf()
+	if fd.HasPresence() && !msg.Has(fd) {
+		return protoreflect.Value{}, false
+	}
+	return msg.Get(fd), true
+}
+
+// mutableParent returns the message containing the leaf field creating all
+// intermediate messages
+func (fp fieldPath) mutableParent(msg protoreflect.Message) protoreflect.Message {
+	for _, fd := range fp[:len(fp)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	return msg
+}
+
+func (fp fieldPath) set(msg protoreflect.Message, v interface{}) error {
+	fd := fp.leaf()
+	pv, err := toValue(fd, v)
+	if err != nil {
+		return err
+	}
+	fp.mutableParent(msg).Set(fd, pv)
+	return nil
+}
+
+func (fp fieldPath) setEntry(msg protoreflect.Message, key string, v interface{}) error {
+	fd := fp.leaf()
+	pv, err := toValue(fd.MapValue(), v)
+	if err != nil {
+		return err
+	}
+	m := fp.mutableParent(msg).Mutable(fd).Map()
+	m.Set(protoreflect.ValueOfString(key).MapKey(), pv)
+	return nil
+}
+
+func isScalar(fd protoreflect.FieldDescriptor) bool {
+	return !fd.IsList() && !fd.IsMap() && fd.Message() == nil
+}
+
+func isTimestamp(fd protoreflect.FieldDescriptor) bool {
+	return !fd.IsList() && fd.Message() != nil && fd.Message().FullName() == timestampType
+}
+
+// toValue converts the value to the type of the given scalar field
+func toValue(fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := internal.ToBool(v)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if s, ok := v.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		n, err := internal.ToInt32(v)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := internal.ToInt32(v)
+		return protoreflect.ValueOfInt32(n), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := internal.ToInt64(v)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := internal.ToUint32(v)
+		return protoreflect.ValueOfUint32(n), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := internal.ToUint64(v)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := internal.ToFloat32(v)
+		return protoreflect.ValueOfFloat32(f), err
+	case protoreflect.DoubleKind:
+		f, err := internal.ToFloat64(v)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		s, err := internal.ToString(v)
+		return protoreflect.ValueOfString(s), err
+	case protoreflect.BytesKind:
+		s, err := internal.ToString(v)
+		return protoreflect.ValueOfBytes([]byte(s)), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field type %v", fd.Kind())
+}
+
+// fromValue converts the value of the given scalar field to a metric value
+func fromValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int64(v.Enum())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.BytesKind:
+		return string(v.Bytes())
+	}
+	return v.String()
+}
@@ -0,0 +1,223 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func loadMethod(t *testing.T, method string) protoreflect.MethodDescriptor {
+	cfg := &Config{
+		Files:  []string{"testdata/ingest.proto"},
+		Method: method,
+	}
+	md, err := cfg.LoadMethod()
+	require.NoError(t, err)
+	return md
+}
+
+func TestLoadMethod(t *testing.T) {
+	for _, name := range []string{"ingest.Ingest/Write", "/ingest.Ingest/Write", "ingest.Ingest.Write"} {
+		md := loadMethod(t, name)
+		require.Equal(t, "/ingest.Ingest/Write", FullMethodName(md))
+	}
+}
+
+func TestLoadMethodFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *Config
+		expected string
+	}{
+		{
+			name:     "missing files",
+			cfg:      &Config{Method: "ingest.Ingest/Write"},
+			expected: "'proto_files' setting required",
+		},
+		{
+			name:     "missing method",
+			cfg:      &Config{Files: []string{"testdata/ingest.proto"}},
+			expected: "'method' setting required",
+		},
+		{
+			name:     "unknown method",
+			cfg:      &Config{Files: []string{"testdata/ingest.proto"}, Method: "ingest.Ingest/Read"},
+			expected: "looking up method",
+		},
+		{
+			name:     "not a method",
+			cfg:      &Config{Files: []string{"testdata/ingest.proto"}, Method: "ingest.Sample"},
+			expected: "is not a method",
+		},
+		{
+			name:     "server streaming",
+			cfg:      &Config{Files: []string{"testdata/ingest.proto"}, Method: "ingest.Ingest/Subscribe"},
+			expected: "server-streaming method",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.LoadMethod()
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestMappingInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  *Mapping
+		expected string
+	}{
+		{
+			name:     "no fields",
+			mapping:  &Mapping{Metrics: "samples"},
+			expected: "either 'fields' or 'field_map' must be set",
+		},
+		{
+			name:     "metrics not repeated",
+			mapping:  &Mapping{Metrics: "samples.measurement", FieldMap: "values"},
+			expected: "repeated field \"samples\" not supported within path",
+		},
+		{
+			name:     "unknown field",
+			mapping:  &Mapping{Metrics: "samples", Fields: map[string]string{"value": "reading"}},
+			expected: "has no field \"reading\"",
+		},
+		{
+			name:     "field not scalar",
+			mapping:  &Mapping{Metrics: "samples", Fields: map[string]string{"value": "source"}},
+			expected: "is not a scalar",
+		},
+		{
+			name:     "invalid timestamp",
+			mapping:  &Mapping{Metrics: "samples", Timestamp: "labels", FieldMap: "values"},
+			expected: "neither a scalar nor a timestamp",
+		},
+		{
+			name:     "tag map not string",
+			mapping:  &Mapping{Metrics: "samples", TagMap: "values", FieldMap: "values"},
+			expected: "must have string values",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := loadMethod(t, "ingest.Ingest/Write")
+			require.ErrorContains(t, tt.mapping.Init(md.Input()), tt.expected)
+		})
+	}
+}
+
+func TestMappingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		mapping *Mapping
+	}{
+		{
+			name:   "batched",
+			method: "ingest.Ingest/Write",
+			mapping: &Mapping{
+				Metrics:    "samples",
+				MetricName: "measurement",
+				Timestamp:  "time",
+				TagMap:     "labels",
+				FieldMap:   "values",
+			},
+		},
+		{
+			name:   "explicit",
+			method: "ingest.Ingest/WriteSample",
+			mapping: &Mapping{
+				MetricName: "measurement",
+				Timestamp:  "count",
+				Tags:       map[string]string{"host": "source.host"},
+				TagMap:     "labels",
+				FieldMap:   "values",
+			},
+		},
+		{
+			name:   "string timestamp",
+			method: "ingest.Ingest/WriteSample",
+			mapping: &Mapping{
+				MetricName:      "measurement",
+				Timestamp:       "source.host",
+				TimestampFormat: time.RFC3339Nano,
+				TagMap:          "labels",
+				FieldMap:        "values",
+			},
+		},
+	}
+
+	input := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 42.5, "usage_user": 1.0},
+			time.Unix(1700000000, 123456789),
+		),
+		metric.New(
+			"mem",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"used": 1024.0},
+			time.Unix(1700000001, 0),
+		),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := loadMethod(t, tt.method)
+			require.NoError(t, tt.mapping.Init(md.Input()))
+
+			msgs := make([]protoreflect.Message, 0, len(input))
+			for _, m := range input {
+				msg, err := tt.mapping.Encode(m)
+				require.NoError(t, err)
+				msgs = append(msgs, msg)
+			}
+
+			var actual []telegraf.Metric
+			if tt.mapping.Batched() {
+				metrics, err := tt.mapping.Decode(tt.mapping.Batch(msgs), "test")
+				require.NoError(t, err)
+				actual = metrics
+			} else {
+				for _, msg := range msgs {
+					metrics, err := tt.mapping.Decode(msg, "test")
+					require.NoError(t, err)
+					actual = append(actual, metrics...)
+				}
+			}
+			testutil.RequireMetricsEqual(t, input, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestMappingConversion(t *testing.T) {
+	md := loadMethod(t, "ingest.Ingest/WriteSample")
+	mapping := &Mapping{
+		Fields:   map[string]string{"count": "count"},
+		FieldMap: "values",
+	}
+	require.NoError(t, mapping.Init(md.Input()))
+
+	// Values are converted to the type of the message field
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"count": "42", "value": int64(3)}, time.Unix(0, 0))
+	msg, err := mapping.Encode(m)
+	require.NoError(t, err)
+	actual, err := mapping.Decode(msg, "test")
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Equal(t, map[string]interface{}{"count": int64(42), "value": 3.0}, actual[0].Fields())
+
+	// Values not convertible must fail
+	m = metric.New("test", map[string]string{}, map[string]interface{}{"count": "foo"}, time.Unix(0, 0))
+	_, err = mapping.Encode(m)
+	require.ErrorContains(t, err, "setting field \"count\" failed")
+}
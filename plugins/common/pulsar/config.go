@@ -0,0 +1,70 @@
+package pulsar
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+// ClientConfig contains the settings for connecting to a Pulsar cluster
+// shared by the Pulsar plugins
+type ClientConfig struct {
+	ServiceURL        string          `toml:"service_url"`
+	Token             config.Secret   `toml:"token"`
+	ConnectionTimeout config.Duration `toml:"connection_timeout"`
+	OperationTimeout  config.Duration `toml:"operation_timeout"`
+	common_tls.ClientConfig
+}
+
+// NewClient creates a new Pulsar client using the given settings
+func (c *ClientConfig) NewClient(log telegraf.Logger) (pulsar.Client, error) {
+	if c.ServiceURL == "" {
+		c.ServiceURL = "pulsar://localhost:6650"
+	}
+
+	options := pulsar.ClientOptions{
+		URL:               c.ServiceURL,
+		ConnectionTimeout: time.Duration(c.ConnectionTimeout),
+		OperationTimeout:  time.Duration(c.OperationTimeout),
+		Logger:            &clientLogger{log: log},
+	}
+
+	// The Pulsar client expects the certificate authority as file and
+	// validates the server certificate itself
+	tlsCfg, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("creating TLS config failed: %w", err)
+	}
+	if tlsCfg != nil {
+		options.TLSTrustCertsFilePath = c.TLSCA
+		options.TLSAllowInsecureConnection = c.InsecureSkipVerify
+		options.TLSValidateHostname = !c.InsecureSkipVerify
+		options.TLSMinVersion = tlsCfg.MinVersion
+		options.TLSCipherSuites = tlsCfg.CipherSuites
+	}
+
+	switch {
+	case !c.Token.Empty():
+		options.Authentication = pulsar.NewAuthenticationTokenFromSupplier(func() (string, error) {
+			token, err := c.Token.Get()
+			if err != nil {
+				return "", fmt.Errorf("getting token failed: %w", err)
+			}
+			defer token.Destroy()
+			return token.String(), nil
+		})
+	case tlsCfg != nil && len(tlsCfg.Certificates) > 0:
+		cert := tlsCfg.Certificates[0]
+		options.Authentication = pulsar.NewAuthenticationFromTLSCertSupplier(func() (*tls.Certificate, error) {
+			return &cert, nil
+		})
+	}
+
+	return pulsar.NewClient(options)
+}
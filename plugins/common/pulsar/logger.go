@@ -0,0 +1,89 @@
+package pulsar
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/apache/pulsar-client-go/pulsar/log"
+
+	"github.com/influxdata/telegraf"
+)
+
+// clientLogger forwards the messages of the Pulsar client to the plugin's
+// logger. The client is very chatty on info level, so info messages are
+// logged as debug and debug messages as trace messages.
+type clientLogger struct {
+	log    telegraf.Logger
+	fields log.Fields
+}
+
+func (l *clientLogger) SubLogger(fields log.Fields) log.Logger {
+	return l.with(fields)
+}
+
+func (l *clientLogger) WithFields(fields log.Fields) log.Entry {
+	return l.with(fields)
+}
+
+func (l *clientLogger) WithField(name string, value interface{}) log.Entry {
+	return l.with(log.Fields{name: value})
+}
+
+func (l *clientLogger) WithError(err error) log.Entry {
+	return l.with(log.Fields{"error": err})
+}
+
+func (l *clientLogger) Debug(args ...interface{}) {
+	l.log.Trace(l.format(fmt.Sprint(args...)))
+}
+
+func (l *clientLogger) Info(args ...interface{}) {
+	l.log.Debug(l.format(fmt.Sprint(args...)))
+}
+
+func (l *clientLogger) Warn(args ...interface{}) {
+	l.log.Warn(l.format(fmt.Sprint(args...)))
+}
+
+func (l *clientLogger) Error(args ...interface{}) {
+	l.log.Error(l.format(fmt.Sprint(args...)))
+}
+
+func (l *clientLogger) Debugf(format string, args ...interface{}) {
+	l.log.Trace(l.format(fmt.Sprintf(format, args...)))
+}
+
+func (l *clientLogger) Infof(format string, args ...interface{}) {
+	l.log.Debug(l.format(fmt.Sprintf(format, args...)))
+}
+
+func (l *clientLogger) Warnf(format string, args ...interface{}) {
+	l.log.Warn(l.format(fmt.Sprintf(format, args...)))
+}
+
+func (l *clientLogger) Errorf(format string, args ...interface{}) {
+	l.log.Error(l.format(fmt.Sprintf(format, args...)))
+}
+
+func (l *clientLogger) with(fields log.Fields) *clientLogger {
+	merged := make(log.Fields, len(l.fields)+len(fields))
+	maps.Copy(merged, l.fields)
+	maps.Copy(merged, fields)
+	return &clientLogger{log: l.log, fields: merged}
+}
+
+// format appends the fields sorted by name to the message
+func (l *clientLogger) format(msg string) string {
+	if len(l.fields) == 0 {
+		return msg
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for _, k := range slices.Sorted(maps.Keys(l.fields)) {
+		fmt.Fprintf(&sb, " %s=%v", k, l.fields[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,205 @@
+package relabel
+
+import (
+	"crypto/md5" //nolint:gosec // md5 is used for hashing as in Prometheus, not for security
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// NameLabel is the label holding the metric name during relabeling
+const NameLabel = "__name__"
+
+// Config is a single relabeling rule with the semantics of Prometheus'
+// relabel_config.
+type Config struct {
+	SourceLabels []string `toml:"source_labels"`
+	Separator    *string  `toml:"separator"`
+	TargetLabel  string   `toml:"target_label"`
+	Regex        string   `toml:"regex"`
+	Modulus      uint64   `toml:"modulus"`
+	Replacement  *string  `toml:"replacement"`
+	Action       string   `toml:"action"`
+
+	regex       *regexp.Regexp
+	separator   string
+	replacement string
+}
+
+// Init validates the rule and sets the defaults
+func (c *Config) Init() error {
+	if c.Action == "" {
+		c.Action = "replace"
+	}
+	c.Action = strings.ToLower(c.Action)
+
+	c.separator = ";"
+	if c.Separator != nil {
+		c.separator = *c.Separator
+	}
+	c.replacement = "$1"
+	if c.Replacement != nil {
+		c.replacement = *c.Replacement
+	}
+	if c.Regex == "" {
+		c.Regex = "(.*)"
+	}
+
+	// Regular expressions are fully anchored as in Prometheus
+	re, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid 'regex' setting %q: %w", c.Regex, err)
+	}
+	c.regex = re
+
+	switch c.Action {
+	case "replace":
+		if c.TargetLabel == "" {
+			return errors.New("'target_label' required for action \"replace\"")
+		}
+	case "hashmod":
+		if c.TargetLabel == "" {
+			return errors.New("'target_label' required for action \"hashmod\"")
+		}
+		if c.Modulus == 0 {
+			return errors.New("'modulus' required for action \"hashmod\"")
+		}
+	case "keep", "drop":
+	case "labelmap":
+	case "labeldrop", "labelkeep":
+		if len(c.SourceLabels) > 0 || c.TargetLabel != "" {
+			return fmt.Errorf("'source_labels' and 'target_label' not allowed for action %q", c.Action)
+		}
+	default:
+		return fmt.Errorf("invalid 'action' setting %q", c.Action)
+	}
+
+	return nil
+}
+
+// Apply applies the rule to the given labels in place. It returns false if
+// the labels should be dropped.
+func (c *Config) Apply(labels map[string]string) bool {
+	values := make([]string, 0, len(c.SourceLabels))
+	for _, name := range c.SourceLabels {
+		values = append(values, labels[name])
+	}
+	value := strings.Join(values, c.separator)
+
+	switch c.Action {
+	case "replace":
+		indexes := c.regex.FindStringSubmatchIndex(value)
+		if indexes == nil {
+			break
+		}
+		target := string(c.regex.ExpandString(nil, c.TargetLabel, value, indexes))
+		if target == "" {
+			break
+		}
+		result := string(c.regex.ExpandString(nil, c.replacement, value, indexes))
+		if result == "" {
+			delete(labels, target)
+			break
+		}
+		labels[target] = result
+	case "keep":
+		return c.regex.MatchString(value)
+	case "drop":
+		return !c.regex.MatchString(value)
+	case "hashmod":
+		sum := md5.Sum([]byte(value)) //nolint:gosec // see import
+		mod := binary.BigEndian.Uint64(sum[8:]) % c.Modulus
+		labels[c.TargetLabel] = fmt.Sprintf("%d", mod)
+	case "labelmap":
+		mapped := make(map[string]string)
+		for name, v := range labels {
+			if c.regex.MatchString(name) {
+				mapped[c.regex.ReplaceAllString(name, c.replacement)] = v
+			}
+		}
+		for name, v := range mapped {
+			labels[name] = v
+		}
+	case "labeldrop":
+		for name := range labels {
+			if c.regex.MatchString(name) {
+				delete(labels, name)
+			}
+		}
+	case "labelkeep":
+		for name := range labels {
+			if !c.regex.MatchString(name) {
+				delete(labels, name)
+			}
+		}
+	}
+
+	return true
+}
+
+// Process applies all rules in order to the given labels. It returns false
+// if the labels should be dropped.
+func Process(rules []*Config, labels map[string]string) bool {
+	for _, r := range rules {
+		if !r.Apply(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Init initializes all given rules
+func Init(rules []*Config) error {
+	for i, r := range rules {
+		if err := r.Init(); err != nil {
+			return fmt.Errorf("relabel rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Labels returns the labels of the metric consisting of its tags and the
+// metric name as NameLabel.
+func Labels(m telegraf.Metric) map[string]string {
+	tags := m.TagList()
+	labels := make(map[string]string, len(tags)+1)
+	for _, t := range tags {
+		labels[t.Key] = t.Value
+	}
+	labels[NameLabel] = m.Name()
+	return labels
+}
+
+// Metric applies the rules to the given metric in place, updating its name and
+// tags. Labels with the reserved "__" prefix are removed after relabeling. It
+// returns false if the metric should be dropped.
+func Metric(rules []*Config, m telegraf.Metric) bool {
+	labels := Labels(m)
+	if !Process(rules, labels) {
+		return false
+	}
+
+	if name := labels[NameLabel]; name != "" {
+		m.SetName(name)
+	}
+	var removed []string
+	for _, t := range m.TagList() {
+		if _, found := labels[t.Key]; !found || strings.HasPrefix(t.Key, "__") {
+			removed = append(removed, t.Key)
+		}
+	}
+	for _, k := range removed {
+		m.RemoveTag(k)
+	}
+	for k, v := range labels {
+		if strings.HasPrefix(k, "__") {
+			continue
+		}
+		m.AddTag(k, v)
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package relabel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Config
+		expected string
+	}{
+		{
+			name:     "invalid action",
+			rule:     Config{Action: "foo"},
+			expected: `invalid 'action' setting "foo"`,
+		},
+		{
+			name:     "invalid regex",
+			rule:     Config{Action: "keep", Regex: "("},
+			expected: `invalid 'regex' setting "("`,
+		},
+		{
+			name:     "replace without target",
+			rule:     Config{SourceLabels: []string{"a"}},
+			expected: "'target_label' required",
+		},
+		{
+			name:     "hashmod without modulus",
+			rule:     Config{Action: "hashmod", TargetLabel: "shard"},
+			expected: "'modulus' required",
+		},
+		{
+			name:     "labeldrop with source labels",
+			rule:     Config{Action: "labeldrop", SourceLabels: []string{"a"}},
+			expected: "not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.rule.Init(), tt.expected)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Config
+		input    map[string]string
+		expected map[string]string
+		keep     bool
+	}{
+		{
+			name: "replace",
+			rule: Config{
+				SourceLabels: []string{"host", "port"},
+				Regex:        "(.+);(.+)",
+				TargetLabel:  "instance",
+				Replacement:  ptr("${1}:${2}"),
+			},
+			input:    map[string]string{"host": "a", "port": "80"},
+			expected: map[string]string{"host": "a", "port": "80", "instance": "a:80"},
+			keep:     true,
+		},
+		{
+			name: "replace without match",
+			rule: Config{
+				SourceLabels: []string{"host"},
+				Regex:        "b",
+				TargetLabel:  "instance",
+			},
+			input:    map[string]string{"host": "a"},
+			expected: map[string]string{"host": "a"},
+			keep:     true,
+		},
+		{
+			name: "replace with empty result deletes",
+			rule: Config{
+				SourceLabels: []string{"missing"},
+				TargetLabel:  "host",
+			},
+			input:    map[string]string{"host": "a"},
+			expected: map[string]string{},
+			keep:     true,
+		},
+		{
+			name: "regex is anchored",
+			rule: Config{
+				Action:       "keep",
+				SourceLabels: []string{"env"},
+				Regex:        "prod",
+			},
+			input: map[string]string{"env": "production"},
+			keep:  false,
+		},
+		{
+			name: "keep",
+			rule: Config{
+				Action:       "keep",
+				SourceLabels: []string{"env"},
+				Regex:        "prod|staging",
+			},
+			input:    map[string]string{"env": "staging"},
+			expected: map[string]string{"env": "staging"},
+			keep:     true,
+		},
+		{
+			name: "drop",
+			rule: Config{
+				Action:       "drop",
+				SourceLabels: []string{"env"},
+				Regex:        "dev",
+			},
+			input: map[string]string{"env": "dev"},
+			keep:  false,
+		},
+		{
+			name: "labelmap",
+			rule: Config{
+				Action:      "labelmap",
+				Regex:       "__meta_(.+)",
+				Replacement: ptr("$1"),
+			},
+			input:    map[string]string{"__meta_zone": "eu", "host": "a"},
+			expected: map[string]string{"__meta_zone": "eu", "zone": "eu", "host": "a"},
+			keep:     true,
+		},
+		{
+			name: "labeldrop",
+			rule: Config{
+				Action: "labeldrop",
+				Regex:  "tmp_.*",
+			},
+			input:    map[string]string{"tmp_a": "1", "tmp_b": "2", "host": "a"},
+			expected: map[string]string{"host": "a"},
+			keep:     true,
+		},
+		{
+			name: "labelkeep",
+			rule: Config{
+				Action: "labelkeep",
+				Regex:  "host|__name__",
+			},
+			input:    map[string]string{"__name__": "cpu", "host": "a", "other": "x"},
+			expected: map[string]string{"__name__": "cpu", "host": "a"},
+			keep:     true,
+		},
+		{
+			name: "hashmod",
+			rule: Config{
+				Action:       "hashmod",
+				SourceLabels: []string{"host"},
+				TargetLabel:  "shard",
+				Modulus:      8,
+			},
+			input:    map[string]string{"host": "a"},
+			expected: map[string]string{"host": "a", "shard": "1"},
+			keep:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.rule.Init())
+			keep := tt.rule.Apply(tt.input)
+			require.Equal(t, tt.keep, keep)
+			if keep {
+				require.Equal(t, tt.expected, tt.input)
+			}
+		})
+	}
+}
+
+func TestMetric(t *testing.T) {
+	rules := []*Config{
+		{
+			SourceLabels: []string{NameLabel},
+			Regex:        "node_(.+)",
+			TargetLabel:  NameLabel,
+		},
+		{
+			Action: "labeldrop",
+			Regex:  "job",
+		},
+		{
+			SourceLabels: []string{"instance"},
+			Regex:        "([^:]+):.*",
+			TargetLabel:  "__tmp_host",
+		},
+		{
+			SourceLabels: []string{"__tmp_host"},
+			TargetLabel:  "host",
+		},
+	}
+	require.NoError(t, Init(rules))
+
+	m := metric.New(
+		"node_cpu",
+		map[string]string{"instance": "example.org:9100", "job": "node"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	require.True(t, Metric(rules, m))
+	require.Equal(t, "cpu", m.Name())
+	require.Equal(t, map[string]string{"instance": "example.org:9100", "host": "example.org"}, m.Tags())
+
+	drop := []*Config{{Action: "drop", SourceLabels: []string{NameLabel}, Regex: "cpu"}}
+	require.NoError(t, Init(drop))
+	require.False(t, Metric(drop, m))
+}
@@ -47,8 +47,9 @@ type Socket struct {
 	tlsCfg        *tls.Config
 	log           telegraf.Logger
 
-	splitter bufio.SplitFunc
-	listener listener
+	splitter     bufio.SplitFunc
+	backpressure telegraf.Backpressure
+	listener     listener
 }
 
 func (cfg *Config) NewSocket(address string, splitcfg *SplitConfig, logger telegraf.Logger) (*Socket, error) {
@@ -106,6 +107,7 @@ func (s *Socket) Setup() error {
 			s.splitter,
 			s.log,
 		)
+		l.Backpressure = s.backpressure
 
 		if err := l.setupTCP(s.url, s.tlsCfg); err != nil {
 			return err
@@ -117,6 +119,7 @@ func (s *Socket) Setup() error {
 			s.splitter,
 			s.log,
 		)
+		l.Backpressure = s.backpressure
 
 		if err := l.setupUnix(s.url, s.tlsCfg, s.SocketMode); err != nil {
 			return err
@@ -146,6 +149,7 @@ func (s *Socket) Setup() error {
 			s.splitter,
 			s.log,
 		)
+		l.Backpressure = s.backpressure
 
 		if err := l.setupVsock(s.url); err != nil {
 			return err
@@ -158,6 +162,13 @@ func (s *Socket) Setup() error {
 	return nil
 }
 
+// SetBackpressure sets the signal used to pause reading from stream
+// connections while the outputs cannot keep up. The function must be called
+// before Setup.
+func (s *Socket) SetBackpressure(bp telegraf.Backpressure) {
+	s.backpressure = bp
+}
+
 func (s *Socket) Listen(onData CallbackData, onError CallbackError) {
 	s.listener.listenData(onData, onError)
 }
@@ -38,6 +38,7 @@ type streamListener struct {
 	ReadTimeout     config.Duration
 	KeepAlivePeriod *config.Duration
 	Splitter        bufio.SplitFunc
+	Backpressure    telegraf.Backpressure
 	Log             telegraf.Logger
 
 	listener    net.Listener
@@ -282,7 +283,7 @@ func (l *streamListener) handleReaderConn(ctx context.Context, conn net.Conn, on
 	if l.Splitter == nil {
 		reader = l.readAll
 	}
-	if err := reader(conn, onData); err != nil {
+	if err := reader(localCtx, conn, onData); err != nil {
 		if !errors.Is(err, io.EOF) && !errors.Is(err, syscall.ECONNRESET) {
 			if onError != nil {
 				onError(err)
@@ -326,7 +327,7 @@ func (l *streamListener) listenConnection(onConnection CallbackConnection, onErr
 	}()
 }
 
-func (l *streamListener) read(conn net.Conn, onData CallbackData) error {
+func (l *streamListener) read(ctx context.Context, conn net.Conn, onData CallbackData) error {
 	decoder, err := internal.NewStreamContentDecoder(l.Encoding, conn)
 	if err != nil {
 		return fmt.Errorf("creating decoder failed: %w", err)
@@ -340,6 +341,12 @@ func (l *streamListener) read(conn net.Conn, onData CallbackData) error {
 	}
 	scanner.Split(l.Splitter)
 	for {
+		// Stop reading from the connection as long as the outputs cannot keep
+		// up to push back on the sender via TCP flow-control.
+		if !l.waitForRelease(ctx) {
+			return nil
+		}
+
 		// Set the read deadline, if any, then start reading. The read
 		// will accept the deadline and return if no or insufficient data
 		// arrived in time. We need to set the deadline in every cycle as
@@ -384,12 +391,16 @@ func (l *streamListener) read(conn net.Conn, onData CallbackData) error {
 	return nil
 }
 
-func (l *streamListener) readAll(conn net.Conn, onData CallbackData) error {
+func (l *streamListener) readAll(ctx context.Context, conn net.Conn, onData CallbackData) error {
 	src := conn.RemoteAddr()
 	if l.path != "" {
 		src = &net.UnixAddr{Name: l.path, Net: "unix"}
 	}
 
+	if !l.waitForRelease(ctx) {
+		return nil
+	}
+
 	decoder, err := internal.NewStreamContentDecoder(l.Encoding, conn)
 	if err != nil {
 		return fmt.Errorf("creating decoder failed: %w", err)
@@ -419,6 +430,22 @@ func (l *streamListener) readAll(conn net.Conn, onData CallbackData) error {
 	return nil
 }
 
+// waitForRelease blocks as long as the outputs signal backpressure. The
+// function returns false if the context was cancelled while waiting.
+func (l *streamListener) waitForRelease(ctx context.Context) bool {
+	if l.Backpressure == nil || !l.Backpressure.Throttled() {
+		return true
+	}
+
+	l.Log.Debug("Pausing reads due to output backpressure")
+	select {
+	case <-l.Backpressure.Released():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func (l *streamListener) handleConnection(ctx context.Context, conn net.Conn, onConnection CallbackConnection) error {
 	defer l.wg.Done()
 
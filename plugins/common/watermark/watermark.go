@@ -0,0 +1,415 @@
+package watermark
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/delivery"
+)
+
+// Config contains the settings for incremental queries using the value of a
+// watermark column as query parameter
+type Config struct {
+	Column   string `toml:"watermark_column"`
+	Type     string `toml:"watermark_type"`
+	Initial  string `toml:"watermark_initial"`
+	PageSize int    `toml:"page_size"`
+	MaxPages int    `toml:"max_pages"`
+
+	initial interface{}
+}
+
+// Init checks the settings and applies defaults. Incremental queries are
+// disabled if no watermark column is set.
+func (cfg *Config) Init() error {
+	if cfg.Column == "" {
+		return nil
+	}
+
+	if cfg.Type == "" {
+		cfg.Type = "int"
+	}
+	switch cfg.Type {
+	case "int", "float", "time", "string":
+	default:
+		return fmt.Errorf("invalid 'watermark_type' setting %q", cfg.Type)
+	}
+
+	if cfg.PageSize < 0 {
+		return fmt.Errorf("invalid 'page_size' setting %d", cfg.PageSize)
+	}
+	if cfg.MaxPages < 0 {
+		return fmt.Errorf("invalid 'max_pages' setting %d", cfg.MaxPages)
+	}
+	if cfg.MaxPages == 0 {
+		cfg.MaxPages = 10
+	}
+	if cfg.PageSize == 0 {
+		cfg.MaxPages = 1
+	}
+
+	initial, err := cfg.parse(cfg.Initial)
+	if err != nil {
+		return fmt.Errorf("invalid 'watermark_initial' setting %q: %w", cfg.Initial, err)
+	}
+	cfg.initial = initial
+
+	return nil
+}
+
+// Enabled returns true if the query should be executed incrementally
+func (cfg *Config) Enabled() bool {
+	return cfg.Column != ""
+}
+
+// Args returns the query parameters for the given watermark value, i.e. the
+// watermark followed by the page size if pagination is enabled
+func (cfg *Config) Args(value interface{}) []interface{} {
+	if cfg.PageSize > 0 {
+		return []interface{}{value, cfg.PageSize}
+	}
+	return []interface{}{value}
+}
+
+// Convert converts the value of the watermark column returned by the database
+// driver to the configured type
+func (cfg *Config) Convert(value interface{}) (interface{}, error) {
+	switch cfg.Type {
+	case "int":
+		return internal.ToInt64(value)
+	case "float":
+		return internal.ToFloat64(value)
+	case "time":
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return time.Parse(time.RFC3339Nano, v)
+		case []byte:
+			return time.Parse(time.RFC3339Nano, string(v))
+		}
+		return nil, fmt.Errorf("cannot convert %v of type %T to time", value, value)
+	}
+	return internal.ToString(value)
+}
+
+// Max returns the larger of the two watermark values with nil being smaller
+// than any other value. Both values must be converted to the configured type.
+func (cfg *Config) Max(a, b interface{}) interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	var less bool
+	switch cfg.Type {
+	case "int":
+		less = a.(int64) < b.(int64)
+	case "float":
+		less = a.(float64) < b.(float64)
+	case "time":
+		less = a.(time.Time).Before(b.(time.Time))
+	default:
+		less = a.(string) < b.(string)
+	}
+	if less {
+		return b
+	}
+	return a
+}
+
+func (cfg *Config) parse(s string) (interface{}, error) {
+	switch cfg.Type {
+	case "int":
+		if s == "" {
+			return int64(0), nil
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case "float":
+		if s == "" {
+			return float64(0), nil
+		}
+		return strconv.ParseFloat(s, 64)
+	case "time":
+		if s == "" {
+			return time.Unix(0, 0).UTC(), nil
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	return s, nil
+}
+
+func (cfg *Config) format(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// State is the persistable form of the committed watermarks keyed by the
+// registered query key
+type State struct {
+	Queries map[string]string `json:"queries"`
+}
+
+type query struct {
+	cfg *Config
+	delivery.Sequence[interface{}]
+}
+
+// Watermarks keeps track of the watermarks of incremental queries. A
+// watermark is only committed after all rows up to that watermark were
+// processed by the outputs, providing at-least-once semantics across
+// restarts. Similar to file checkpoints, rows rejected by an output do not
+// block the watermark as querying them again would fail in the same way.
+type Watermarks struct {
+	queries map[string]*query
+	tracker *delivery.Tracker[interface{}]
+	log     telegraf.Logger
+
+	acc  telegraf.TrackingAccumulator
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	sync.Mutex
+}
+
+// PageFunc queries a single page of an incremental query starting at the
+// given watermark. It returns the resulting metrics, the number of rows
+// received and the largest watermark value of the rows.
+type PageFunc func(current interface{}) (metrics []telegraf.Metric, rows int, latest interface{}, err error)
+
+// New creates an empty set of watermarks
+func New(log telegraf.Logger) *Watermarks {
+	return &Watermarks{
+		queries: make(map[string]*query),
+		tracker: delivery.NewTracker[interface{}](),
+		log:     log,
+	}
+}
+
+// Start tracks the delivery of the incremental query results sent to the
+// given accumulator. The number of results waiting for delivery is limited
+// to the maximum number of pages of each registered query. Start must be
+// called after registering the queries.
+func (w *Watermarks) Start(acc telegraf.Accumulator) {
+	var maxUndelivered int
+	for _, q := range w.queries {
+		maxUndelivered += q.cfg.MaxPages
+	}
+	if maxUndelivered == 0 {
+		return
+	}
+
+	w.acc = acc.WithTracking(maxUndelivered)
+	w.done = make(chan struct{})
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-w.done:
+				return
+			case info := <-w.acc.Delivered():
+				w.Delivered(info)
+			}
+		}
+	}()
+}
+
+// Stop ends tracking the delivery of query results
+func (w *Watermarks) Stop() {
+	if w.done != nil {
+		close(w.done)
+		w.wg.Wait()
+		w.done = nil
+	}
+}
+
+// Query runs the incremental query identified by the given key with the
+// current watermark and sends the resulting metrics with tracking. If
+// pagination is enabled, the query is repeated until a page is not full or
+// the maximum number of pages is reached. The query is skipped if the
+// outputs did not yet process the maximum number of pages.
+func (w *Watermarks) Query(key string, fn PageFunc) error {
+	w.Lock()
+	q, found := w.queries[key]
+	w.Unlock()
+	if !found {
+		return fmt.Errorf("query %q not registered", key)
+	}
+	cfg := q.cfg
+
+	for page := 1; page <= cfg.MaxPages; page++ {
+		if w.Pending(key) >= cfg.MaxPages {
+			w.log.Debugf("Too many undelivered pages, skipping query %q", key)
+			return nil
+		}
+
+		current := w.Current(key)
+		metrics, rows, latest, err := fn(current)
+		if err != nil {
+			return err
+		}
+		w.log.Debugf("Received %d rows for page %d of query %q starting at watermark %v", rows, page, key, current)
+
+		if rows == 0 {
+			return nil
+		}
+		if latest == nil {
+			return fmt.Errorf("watermark column %q not found in result of query %q", cfg.Column, key)
+		}
+		latest = cfg.Max(current, latest)
+
+		if len(metrics) > 0 {
+			id := w.acc.AddTrackingMetricGroup(metrics)
+			w.Track(id, key, latest)
+		} else {
+			w.Advance(key, latest)
+		}
+
+		if rows < cfg.PageSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Register starts tracking the watermark of the query identified by the
+// given key starting at the initial value of the configuration. Keys must be
+// unique as they identify the watermarks in the persisted state.
+func (w *Watermarks) Register(key string, cfg *Config) error {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, found := w.queries[key]; found {
+		return fmt.Errorf("duplicate watermark key %q", key)
+	}
+	q := &query{cfg: cfg}
+	q.Committed = cfg.initial
+	w.queries[key] = q
+	return nil
+}
+
+// Current returns the watermark to use for the next query. This is the
+// watermark tracked last, or the committed one if no rows are waiting for
+// delivery.
+func (w *Watermarks) Current(key string) interface{} {
+	w.Lock()
+	defer w.Unlock()
+
+	q, found := w.queries[key]
+	if !found {
+		return nil
+	}
+	return q.Latest()
+}
+
+// Pending returns the number of query results waiting for delivery
+func (w *Watermarks) Pending(key string) int {
+	w.Lock()
+	defer w.Unlock()
+
+	if q, found := w.queries[key]; found {
+		return q.Pending()
+	}
+	return 0
+}
+
+// Track registers rows up to the given watermark sent to the outputs with
+// the given tracking ID. The watermark is committed once the tracking ID and
+// all previous ones of the query are delivered.
+func (w *Watermarks) Track(id telegraf.TrackingID, key string, value interface{}) {
+	w.Lock()
+	defer w.Unlock()
+
+	var seq *delivery.Sequence[interface{}]
+	if q, found := w.queries[key]; found {
+		seq = &q.Sequence
+	}
+	w.tracker.Track(id, seq, value)
+}
+
+// Advance moves the watermark of the query to the given value without any
+// rows requiring delivery, e.g. for rows not producing any field.
+func (w *Watermarks) Advance(key string, value interface{}) {
+	w.Lock()
+	defer w.Unlock()
+
+	if q, found := w.queries[key]; found {
+		q.Advance(value)
+	}
+}
+
+// Delivered handles the delivery notification of tracked rows and commits
+// the watermark of the corresponding query if possible
+func (w *Watermarks) Delivered(info telegraf.DeliveryInfo) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.tracker.Delivered(info.ID())
+}
+
+// Committed returns the committed watermark of the query
+func (w *Watermarks) Committed(key string) (interface{}, bool) {
+	w.Lock()
+	defer w.Unlock()
+
+	q, found := w.queries[key]
+	if !found {
+		return nil, false
+	}
+	return q.Committed, true
+}
+
+// GetState returns a copy of the committed watermarks
+func (w *Watermarks) GetState() State {
+	w.Lock()
+	defer w.Unlock()
+
+	state := State{Queries: make(map[string]string, len(w.queries))}
+	for key, q := range w.queries {
+		state.Queries[key] = q.cfg.format(q.Committed)
+	}
+	return state
+}
+
+// SetState restores the committed watermarks of the registered queries.
+// Watermarks of unknown queries, e.g. due to a modified query, are ignored.
+func (w *Watermarks) SetState(state State) error {
+	w.Lock()
+	defer w.Unlock()
+
+	w.tracker.Reset()
+	var errs []error
+	for key, q := range w.queries {
+		q.Reset(q.Committed)
+
+		s, found := state.Queries[key]
+		if !found {
+			continue
+		}
+		value, err := q.cfg.parse(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid watermark %q for query %q: %w", s, key, err))
+			continue
+		}
+		q.Committed = value
+	}
+	return errors.Join(errs...)
+}
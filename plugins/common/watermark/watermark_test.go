@@ -0,0 +1,214 @@
+package watermark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+type deliveryInfo struct {
+	id telegraf.TrackingID
+}
+
+func (d *deliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (*deliveryInfo) Delivered() bool {
+	return true
+}
+
+func TestConfigInit(t *testing.T) {
+	cfg := &Config{}
+	require.NoError(t, cfg.Init())
+	require.False(t, cfg.Enabled())
+
+	cfg = &Config{Column: "id"}
+	require.NoError(t, cfg.Init())
+	require.True(t, cfg.Enabled())
+	require.Equal(t, "int", cfg.Type)
+	require.Equal(t, 1, cfg.MaxPages)
+	require.Equal(t, []interface{}{int64(0)}, cfg.Args(cfg.initial))
+
+	cfg = &Config{Column: "id", PageSize: 100}
+	require.NoError(t, cfg.Init())
+	require.Equal(t, 10, cfg.MaxPages)
+	require.Equal(t, []interface{}{int64(0), 100}, cfg.Args(cfg.initial))
+
+	cfg = &Config{Column: "ts", Type: "time", Initial: "2024-01-02T03:04:05Z"}
+	require.NoError(t, cfg.Init())
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), cfg.initial)
+
+	for _, cfg := range []*Config{
+		{Column: "id", Type: "foo"},
+		{Column: "id", Initial: "abc"},
+		{Column: "id", PageSize: -1},
+		{Column: "id", MaxPages: -1},
+		{Column: "ts", Type: "time", Initial: "yesterday"},
+	} {
+		require.Error(t, cfg.Init())
+	}
+}
+
+func TestConvert(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tests := []struct {
+		typ      string
+		input    interface{}
+		expected interface{}
+	}{
+		{typ: "int", input: int32(42), expected: int64(42)},
+		{typ: "int", input: []byte("42"), expected: int64(42)},
+		{typ: "float", input: "4.2", expected: float64(4.2)},
+		{typ: "time", input: ts, expected: ts},
+		{typ: "time", input: []byte("2024-01-02T03:04:05.000000006Z"), expected: ts},
+		{typ: "string", input: []byte("abc"), expected: "abc"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Column: "x", Type: tt.typ}
+		require.NoError(t, cfg.Init())
+		actual, err := cfg.Convert(tt.input)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, actual)
+	}
+
+	cfg := &Config{Column: "x", Type: "time"}
+	require.NoError(t, cfg.Init())
+	_, err := cfg.Convert(int64(42))
+	require.Error(t, err)
+}
+
+func TestMax(t *testing.T) {
+	cfg := &Config{Column: "x", Type: "time"}
+	require.NoError(t, cfg.Init())
+
+	early := time.Unix(10, 0)
+	late := time.Unix(20, 0)
+	require.Equal(t, late, cfg.Max(early, late))
+	require.Equal(t, late, cfg.Max(late, early))
+	require.Equal(t, early, cfg.Max(nil, early))
+
+	cfg = &Config{Column: "x", Type: "string"}
+	require.NoError(t, cfg.Init())
+	require.Equal(t, "b", cfg.Max("b", "a"))
+}
+
+func TestCommitInOrder(t *testing.T) {
+	cfg := &Config{Column: "id"}
+	require.NoError(t, cfg.Init())
+
+	w := New(testutil.Logger{})
+	require.NoError(t, w.Register("q", cfg))
+	require.Error(t, w.Register("q", cfg))
+	require.Equal(t, int64(0), w.Current("q"))
+
+	w.Track(1, "q", int64(10))
+	w.Track(2, "q", int64(20))
+	require.Equal(t, int64(20), w.Current("q"))
+	require.Equal(t, 2, w.Pending("q"))
+
+	// Delivering the second page must not commit as the first is pending
+	w.Delivered(&deliveryInfo{id: 2})
+	committed, found := w.Committed("q")
+	require.True(t, found)
+	require.Equal(t, int64(0), committed)
+
+	w.Delivered(&deliveryInfo{id: 1})
+	committed, _ = w.Committed("q")
+	require.Equal(t, int64(20), committed)
+	require.Zero(t, w.Pending("q"))
+
+	// Deliveries arriving before tracking started
+	w.Delivered(&deliveryInfo{id: 3})
+	w.Track(3, "q", int64(30))
+	committed, _ = w.Committed("q")
+	require.Equal(t, int64(30), committed)
+
+	w.Advance("q", int64(35))
+	require.Equal(t, map[string]string{"q": "35"}, w.GetState().Queries)
+}
+
+func TestState(t *testing.T) {
+	cfg := &Config{Column: "ts", Type: "time"}
+	require.NoError(t, cfg.Init())
+
+	w := New(testutil.Logger{})
+	require.NoError(t, w.Register("q", cfg))
+	w.Track(1, "q", time.Unix(100, 0).UTC())
+
+	state := State{Queries: map[string]string{
+		"q":       "2024-01-02T03:04:05Z",
+		"removed": "42",
+	}}
+	require.NoError(t, w.SetState(state))
+	require.Zero(t, w.Pending("q"))
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), w.Current("q"))
+	require.Equal(t, map[string]string{"q": "2024-01-02T03:04:05Z"}, w.GetState().Queries)
+
+	// Early deliveries from before restoring the state must be ignored
+	w.Delivered(&deliveryInfo{id: 2})
+	require.NoError(t, w.SetState(state))
+	w.Track(2, "q", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, 1, w.Pending("q"))
+
+	require.Error(t, w.SetState(State{Queries: map[string]string{"q": "foo"}}))
+}
+
+func TestQueryPaging(t *testing.T) {
+	cfg := &Config{Column: "id", PageSize: 2, MaxPages: 3}
+	require.NoError(t, cfg.Init())
+
+	w := New(testutil.Logger{})
+	require.NoError(t, w.Register("q", cfg))
+
+	var acc testutil.Accumulator
+	w.Start(&acc)
+	defer w.Stop()
+
+	// Serve pages of two rows and a final, partial page
+	pages := map[int64][]int64{0: {1, 2}, 2: {3, 4}, 4: {5}}
+	var queried []interface{}
+	page := func(current interface{}) ([]telegraf.Metric, int, interface{}, error) {
+		queried = append(queried, current)
+		var metrics []telegraf.Metric
+		var latest interface{}
+		for _, id := range pages[current.(int64)] {
+			metrics = append(metrics, metric.New("test", nil, map[string]interface{}{"id": id}, time.Unix(0, 0)))
+			latest = cfg.Max(latest, id)
+		}
+		return metrics, len(metrics), latest, nil
+	}
+
+	require.NoError(t, w.Query("q", page))
+	require.Equal(t, []interface{}{int64(0), int64(2), int64(4)}, queried)
+	require.Len(t, acc.GetTelegrafMetrics(), 5)
+	require.Equal(t, int64(5), w.Current("q"))
+
+	// All pages are pending so the query must be skipped
+	queried = nil
+	require.NoError(t, w.Query("q", page))
+	require.Empty(t, queried)
+
+	// Delivering the pages commits the watermark
+	for _, m := range acc.GetTelegrafMetrics() {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool {
+		committed, _ := w.Committed("q")
+		return committed == int64(5)
+	}, time.Second, 10*time.Millisecond)
+
+	// Missing watermark values must be reported
+	err := w.Query("q", func(interface{}) ([]telegraf.Metric, int, interface{}, error) {
+		return nil, 1, nil, nil
+	})
+	require.ErrorContains(t, err, "watermark column \"id\" not found")
+
+	require.ErrorContains(t, w.Query("unknown", page), "not registered")
+}
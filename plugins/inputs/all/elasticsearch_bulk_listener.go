@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.elasticsearch_bulk_listener
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/elasticsearch_bulk_listener" // register plugin
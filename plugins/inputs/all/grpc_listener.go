@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.grpc_listener
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/grpc_listener" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.log_metrics
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/log_metrics" // register plugin
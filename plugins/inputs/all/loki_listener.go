@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.loki_listener
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/loki_listener" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.prometheus_remote_write
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/prometheus_remote_write" // register plugin
@@ -23,6 +23,7 @@ import (
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/selfstat"
@@ -70,6 +71,12 @@ type DirectoryMonitor struct {
 	fileRegexesToMatch  []*regexp.Regexp
 	fileRegexesToIgnore []*regexp.Regexp
 	filesToProcess      chan string
+	checkpoints         *checkpoint.Checkpoints
+
+	// Files read completely but waiting for the delivery of their metrics
+	// mapped to the directory to move them to
+	finishing      map[string]string
+	finishingMutex sync.Mutex
 }
 
 func (*DirectoryMonitor) SampleConfig() string {
@@ -120,6 +127,8 @@ func (monitor *DirectoryMonitor) Init() error {
 	monitor.sem = semaphore.NewWeighted(int64(monitor.MaxBufferedMetrics))
 	monitor.context, monitor.cancel = context.WithCancel(context.Background())
 	monitor.filesToProcess = make(chan string, monitor.FileQueueSize)
+	monitor.checkpoints = checkpoint.New()
+	monitor.finishing = make(map[string]string)
 
 	// Establish file matching / exclusion regexes.
 	for _, matcher := range monitor.FilesToMonitor {
@@ -149,7 +158,9 @@ func (monitor *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
 	// Use tracking to determine when more metrics can be added without overflowing the outputs.
 	monitor.acc = acc.WithTracking(monitor.MaxBufferedMetrics)
 	go func() {
-		for range monitor.acc.Delivered() {
+		for info := range monitor.acc.Delivered() {
+			monitor.checkpoints.Delivered(info)
+			monitor.finishDelivered()
 			monitor.sem.Release(1)
 		}
 	}()
@@ -164,6 +175,19 @@ func (monitor *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (monitor *DirectoryMonitor) GetState() interface{} {
+	return monitor.checkpoints.GetState()
+}
+
+func (monitor *DirectoryMonitor) SetState(state interface{}) error {
+	offsetsState, ok := state.(checkpoint.State)
+	if !ok {
+		return errors.New("state has to be of type 'checkpoint.State'")
+	}
+	monitor.checkpoints.SetState(offsetsState)
+	return nil
+}
+
 func (monitor *DirectoryMonitor) Gather(_ telegraf.Accumulator) error {
 	processFile := func(path string) error {
 		// We've been cancelled via Stop().
@@ -243,11 +267,10 @@ func (monitor *DirectoryMonitor) monitor() {
 			continue
 		}
 
+		// The file stays in use until it is moved away after all of its
+		// metrics are delivered
 		monitor.read(filePath)
 
-		// We've finished reading the file and moved it away, delete it from files in use.
-		monitor.filesInUse.Delete(filePath)
-
 		// Keep track of how many files still to process
 		monitor.filesQueuedDir.Set(int64(len(monitor.filesToProcess)))
 	}
@@ -277,6 +300,14 @@ func (monitor *DirectoryMonitor) read(filePath string) {
 	err := monitor.ingestFile(filePath)
 	var pathErr *os.PathError
 	if errors.As(err, &pathErr) {
+		monitor.filesInUse.Delete(filePath)
+		return
+	}
+
+	// We've been cancelled via Stop() so leave the file in place to resume
+	// reading at the last delivered position.
+	if errors.Is(err, context.Canceled) {
+		monitor.filesInUse.Delete(filePath)
 		return
 	}
 
@@ -285,18 +316,53 @@ func (monitor *DirectoryMonitor) read(filePath string) {
 		monitor.Log.Errorf("Error while reading file: %q: %v", filePath, err)
 		monitor.filesDropped.Incr(1)
 		monitor.filesDroppedDir.Incr(1)
-		if monitor.ErrorDirectory != "" {
-			monitor.moveFile(filePath, monitor.ErrorDirectory)
-		}
+		monitor.finish(filePath, monitor.ErrorDirectory)
 		return
 	}
 
 	// File is finished, move it to the 'finished' directory.
-	monitor.moveFile(filePath, monitor.FinishedDirectory)
 	monitor.filesProcessed.Incr(1)
 	monitor.filesProcessedDir.Incr(1)
+	monitor.finish(filePath, monitor.FinishedDirectory)
 }
 
+// finish moves the file to the given directory once all metrics read from
+// the file are delivered. An empty directory leaves the file in place.
+func (monitor *DirectoryMonitor) finish(filePath, dstBaseDir string) {
+	monitor.finishingMutex.Lock()
+	defer monitor.finishingMutex.Unlock()
+
+	monitor.finishing[filePath] = dstBaseDir
+	monitor.finishFile(filePath, dstBaseDir)
+}
+
+// finishDelivered moves the files whose metrics were all delivered
+func (monitor *DirectoryMonitor) finishDelivered() {
+	monitor.finishingMutex.Lock()
+	defer monitor.finishingMutex.Unlock()
+
+	for filePath, dstBaseDir := range monitor.finishing {
+		monitor.finishFile(filePath, dstBaseDir)
+	}
+}
+
+// finishFile moves the file if no metrics are waiting for delivery anymore.
+// This assumes the finishing lock is held.
+func (monitor *DirectoryMonitor) finishFile(filePath, dstBaseDir string) {
+	if monitor.checkpoints.Pending(filePath) {
+		return
+	}
+	delete(monitor.finishing, filePath)
+
+	// The position is not required anymore as the file is moved away.
+	monitor.checkpoints.Remove(filePath)
+	if dstBaseDir != "" {
+		monitor.moveFile(filePath, dstBaseDir)
+	}
+	monitor.filesInUse.Delete(filePath)
+}
+
+// ingestFile reads the given file starting at the last delivered position
 func (monitor *DirectoryMonitor) ingestFile(filePath string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -304,6 +370,11 @@ func (monitor *DirectoryMonitor) ingestFile(filePath string) error {
 	}
 	defer file.Close()
 
+	offset, err := monitor.checkpoints.OpenStream(filePath)
+	if err != nil {
+		return err
+	}
+
 	parser, err := monitor.parserFunc()
 	if err != nil {
 		return fmt.Errorf("creating parser: %w", err)
@@ -320,51 +391,102 @@ func (monitor *DirectoryMonitor) ingestFile(filePath string) error {
 		reader = file
 	}
 
-	return monitor.parseFile(parser, reader, file.Name())
+	// Skip the data already delivered
+	if offset > 0 {
+		monitor.Log.Debugf("Resuming %q at offset %d", filePath, offset)
+		if err := monitor.skip(parser, reader, offset); err != nil {
+			return fmt.Errorf("skipping to offset %d failed: %w", offset, err)
+		}
+	}
+
+	return monitor.parseFile(parser, reader, file.Name(), offset)
 }
 
-func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Reader, fileName string) error {
+// skip discards the data up to the given offset. When parsing line-by-line,
+// the leading lines not producing metrics, e.g. CSV headers or skipped rows,
+// are fed to the parser to restore its state.
+func (monitor *DirectoryMonitor) skip(parser telegraf.Parser, reader io.Reader, offset int64) error {
+	limited := &io.LimitedReader{R: reader, N: offset}
+	if monitor.ParseMethod == "line-by-line" {
+		scanner := bufio.NewScanner(limited)
+		for scanner.Scan() {
+			metrics, err := parser.Parse(scanner.Bytes())
+			if err != nil && !errors.Is(err, parsers.ErrEOF) {
+				return err
+			}
+			if len(metrics) > 0 {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, limited); err != nil {
+		return err
+	}
+	if limited.N > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Reader, fileName string, offset int64) error {
 	var splitter bufio.SplitFunc
 
 	// Decide on how to split the file
 	switch monitor.ParseMethod {
 	case "at-once":
-		return monitor.parseAtOnce(parser, reader, fileName)
+		return monitor.parseAtOnce(parser, reader, fileName, offset)
 	case "line-by-line":
 		splitter = bufio.ScanLines
 	default:
 		return fmt.Errorf("unknown parse method %q", monitor.ParseMethod)
 	}
 
+	// Keep track of the position after each line
+	position := offset
 	scanner := bufio.NewScanner(reader)
-	scanner.Split(splitter)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := splitter(data, atEOF)
+		position += int64(advance)
+		return advance, token, err
+	})
 
+	start := offset
 	for scanner.Scan() {
 		metrics, err := monitor.parseMetrics(parser, scanner.Bytes(), fileName)
 		if err != nil {
 			return err
 		}
 
-		if err := monitor.sendMetrics(metrics); err != nil {
+		if err := monitor.sendMetrics(fileName, start, position, metrics); err != nil {
 			return err
 		}
+		start = position
 	}
 
 	return scanner.Err()
 }
 
-func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, fileName string) error {
+func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, fileName string, offset int64) error {
 	bytes, err := io.ReadAll(reader)
 	if err != nil {
 		return err
 	}
 
+	// All data of the file was already delivered
+	if offset > 0 && len(bytes) == 0 {
+		return nil
+	}
+
 	metrics, err := monitor.parseMetrics(parser, bytes, fileName)
 	if err != nil {
 		return err
 	}
 
-	return monitor.sendMetrics(metrics)
+	return monitor.sendMetrics(fileName, offset, offset+int64(len(bytes)), metrics)
 }
 
 func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byte, fileName string) (metrics []telegraf.Metric, err error) {
@@ -391,14 +513,28 @@ func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byt
 	return metrics, err
 }
 
-func (monitor *DirectoryMonitor) sendMetrics(metrics []telegraf.Metric) error {
+// sendMetrics adds the metrics read from the file between the start and end
+// position. The end position is committed once all metrics are delivered.
+func (monitor *DirectoryMonitor) sendMetrics(fileName string, start, end int64, metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		monitor.checkpoints.Advance(fileName, end)
+		return nil
+	}
+
 	// Report the metrics for the file.
-	for _, m := range metrics {
+	for i, m := range metrics {
 		// Block until metric can be written.
 		if err := monitor.sem.Acquire(monitor.context, 1); err != nil {
 			return err
 		}
-		monitor.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+		id := monitor.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+
+		// Only the last metric completes the data read
+		position := start
+		if i == len(metrics)-1 {
+			position = end
+		}
+		monitor.checkpoints.Track(id, fileName, position)
 	}
 	return nil
 }
@@ -7,12 +7,16 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -84,17 +88,16 @@ func TestCSVGZImport(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(6)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read both files once.
 	require.Len(t, acc.Metrics, 6)
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvFile))
 
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvGzFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvGzFile))
 }
 
 func TestCSVGZImportWithHeader(t *testing.T) {
@@ -155,17 +158,16 @@ func TestCSVGZImportWithHeader(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(6)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read both files once.
 	require.Len(t, acc.Metrics, 6)
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvFile))
 
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvGzFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvGzFile))
 }
 
 func TestMultipleJSONFileImports(t *testing.T) {
@@ -212,6 +214,7 @@ func TestMultipleJSONFileImports(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(5)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read each JSON line once to a single metric.
@@ -259,6 +262,7 @@ func TestFileTag(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read each JSON line once to a single metric.
@@ -323,14 +327,14 @@ hello,80,test_name2`
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read both files once.
 	require.Len(t, acc.Metrics, 1)
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvFile))
 	for _, m := range acc.Metrics {
 		for key, value := range m.Tags {
 			require.Equal(t, "line1", key)
@@ -394,14 +398,14 @@ hello,80,test_name2`
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read both files once.
 	require.Len(t, acc.Metrics, 1)
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvFile))
 	for _, m := range acc.Metrics {
 		for key, value := range m.Tags {
 			require.Equal(t, "line1", key)
@@ -463,14 +467,14 @@ hello,80,test_name2`
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	// Verify that we read both files once.
 	require.Len(t, acc.Metrics, 1)
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testCsvFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testCsvFile))
 	for _, m := range acc.Metrics {
 		for key, value := range m.Tags {
 			require.Equal(t, "line1", key)
@@ -526,6 +530,7 @@ func TestParseCompleteFile(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	require.NoError(t, acc.FirstError())
@@ -592,6 +597,7 @@ func TestParseSubdirectories(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(2)
+	acceptAll(&acc)
 	r.Stop()
 
 	require.NoError(t, acc.FirstError())
@@ -599,10 +605,8 @@ func TestParseSubdirectories(t *testing.T) {
 	testutil.RequireMetricEqual(t, testutil.TestMetric(100.1), acc.GetTelegrafMetrics()[0], testutil.IgnoreTime())
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testJSONFile))
-	require.NoError(t, err)
-	_, err = os.Stat(filepath.Join(finishedDirectory, "sub", testJSONFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testJSONFile))
+	requireFinished(t, filepath.Join(finishedDirectory, "sub", testJSONFile))
 }
 
 func TestParseSubdirectoriesFilesIgnore(t *testing.T) {
@@ -670,6 +674,7 @@ func TestParseSubdirectoriesFilesIgnore(t *testing.T) {
 	err = r.Gather(&acc)
 	require.NoError(t, err)
 	acc.Wait(1)
+	acceptAll(&acc)
 	r.Stop()
 
 	require.NoError(t, acc.FirstError())
@@ -677,6 +682,162 @@ func TestParseSubdirectoriesFilesIgnore(t *testing.T) {
 	testutil.RequireMetricEqual(t, testutil.TestMetric(100.1), acc.GetTelegrafMetrics()[0], testutil.IgnoreTime())
 
 	// File should have gone back to the test directory, as we configured.
-	_, err = os.Stat(filepath.Join(finishedDirectory, testJSONFile))
-	require.NoError(t, err)
+	requireFinished(t, filepath.Join(finishedDirectory, testJSONFile))
+}
+
+func TestResumeFromState(t *testing.T) {
+	acc := testutil.Accumulator{}
+
+	// Establish process directory and finished directory.
+	finishedDirectory := t.TempDir()
+	processDirectory := t.TempDir()
+
+	r := DirectoryMonitor{
+		Directory:          processDirectory,
+		FinishedDirectory:  finishedDirectory,
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+	r.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+
+	// Write a file with the first line already delivered
+	lines := []string{
+		"test value=1i 1730478201000000000\n",
+		"test value=2i 1730478211000000000\n",
+	}
+	testFile := filepath.Join(processDirectory, "test.influx")
+	require.NoError(t, os.WriteFile(testFile, []byte(lines[0]+lines[1]), 0640))
+
+	var pi telegraf.StatefulPlugin = &r
+	require.NoError(t, pi.SetState(checkpoint.State{
+		Files: map[string]checkpoint.Entry{testFile: {Offset: int64(len(lines[0]))}},
+	}))
+
+	require.NoError(t, r.Start(&acc))
+	require.NoError(t, r.Gather(&acc))
+	acc.Wait(1)
+	acceptAll(&acc)
+	r.Stop()
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(2)}, time.Unix(1730478211, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// File should be moved and the position forgotten
+	requireFinished(t, filepath.Join(finishedDirectory, "test.influx"))
+	require.Empty(t, pi.GetState().(checkpoint.State).Files)
+}
+
+func TestMoveAfterDelivery(t *testing.T) {
+	acc := testutil.Accumulator{}
+
+	finishedDirectory := t.TempDir()
+	processDirectory := t.TempDir()
+
+	r := DirectoryMonitor{
+		Directory:          processDirectory,
+		FinishedDirectory:  finishedDirectory,
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+	r.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+
+	testFile := filepath.Join(processDirectory, "test.influx")
+	require.NoError(t, os.WriteFile(testFile, []byte("test value=1i 1730478201000000000\n"), 0640))
+
+	require.NoError(t, r.Start(&acc))
+	defer r.Stop()
+	require.NoError(t, r.Gather(&acc))
+	acc.Wait(1)
+
+	// The file and its position must be kept until the metrics are delivered
+	require.Never(t, func() bool {
+		_, err := os.Stat(filepath.Join(finishedDirectory, "test.influx"))
+		return err == nil
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	require.FileExists(t, testFile)
+	require.Contains(t, r.GetState().(checkpoint.State).Files, testFile)
+
+	acceptAll(&acc)
+	requireFinished(t, filepath.Join(finishedDirectory, "test.influx"))
+	require.Empty(t, r.GetState().(checkpoint.State).Files)
+}
+
+func TestResumeCSVWithHeader(t *testing.T) {
+	acc := testutil.Accumulator{}
+
+	finishedDirectory := t.TempDir()
+	processDirectory := t.TempDir()
+
+	r := DirectoryMonitor{
+		Directory:          processDirectory,
+		FinishedDirectory:  finishedDirectory,
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+	r.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &csv.Parser{
+			HeaderRowCount: 1,
+			SkipRows:       1,
+			MetricName:     "csv",
+		}
+		err := parser.Init()
+		return parser, err
+	})
+
+	// Write a file with the first data row already delivered
+	header := "This is some garbage to be skipped\nthing,color\n"
+	rows := []string{"sky,blue\n", "grass,green\n"}
+	testFile := filepath.Join(processDirectory, "test.csv")
+	require.NoError(t, os.WriteFile(testFile, []byte(header+rows[0]+rows[1]), 0640))
+
+	require.NoError(t, r.SetState(checkpoint.State{
+		Files: map[string]checkpoint.Entry{testFile: {Offset: int64(len(header) + len(rows[0]))}},
+	}))
+
+	require.NoError(t, r.Start(&acc))
+	require.NoError(t, r.Gather(&acc))
+	acc.Wait(1)
+	acceptAll(&acc)
+	r.Stop()
+
+	// The header must still be applied when resuming within the file
+	expected := []telegraf.Metric{
+		metric.New("csv", map[string]string{}, map[string]interface{}{"thing": "grass", "color": "green"}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+	requireFinished(t, filepath.Join(finishedDirectory, "test.csv"))
+}
+
+// acceptAll marks all metrics as delivered to let the plugin finish the files
+func acceptAll(acc *testutil.Accumulator) {
+	for _, m := range acc.GetTelegrafMetrics() {
+		m.Accept()
+	}
+}
+
+// requireFinished waits for the file to be moved to the given path
+func requireFinished(t *testing.T, path string) {
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
 }
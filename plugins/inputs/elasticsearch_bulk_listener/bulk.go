@@ -0,0 +1,99 @@
+package elasticsearch_bulk_listener
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// action is a single operation of a bulk request
+type action struct {
+	op       string
+	index    string
+	id       string
+	document map[string]interface{}
+	err      error
+}
+
+type actionMeta struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// parseBulk parses the newline-delimited JSON body of a bulk request, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
+// The "index" and "create" operations are followed by the document, "update"
+// operations by a partial document or script and "delete" operations do not
+// have a source line. Errors of individual documents are recorded in the
+// action while structural errors of the request are returned.
+func parseBulk(body []byte, defaultIndex string) ([]action, error) {
+	lines := bytes.Split(body, []byte("\n"))
+
+	var actions []action
+	for i := 0; i < len(lines); i++ {
+		line := bytes.TrimSpace(lines[i])
+		if len(line) == 0 {
+			continue
+		}
+
+		var header map[string]actionMeta
+		if err := json.Unmarshal(line, &header); err != nil {
+			return nil, fmt.Errorf("malformed action/metadata line [%d]: %w", i+1, err)
+		}
+		if len(header) != 1 {
+			return nil, fmt.Errorf("malformed action/metadata line [%d], expected a single action", i+1)
+		}
+
+		var a action
+		for op, meta := range header {
+			a = action{op: op, index: meta.Index, id: meta.ID}
+		}
+		if a.index == "" {
+			a.index = defaultIndex
+		}
+
+		switch a.op {
+		case "index", "create", "update":
+		case "delete":
+			if a.index == "" {
+				a.err = errors.New("index is missing")
+			}
+			actions = append(actions, a)
+			continue
+		default:
+			return nil, fmt.Errorf("malformed action/metadata line [%d], unknown action %q", i+1, a.op)
+		}
+
+		// Consume the source line of the action
+		i++
+		if i >= len(lines) || len(bytes.TrimSpace(lines[i])) == 0 {
+			return nil, fmt.Errorf("missing source for action/metadata line [%d]", i)
+		}
+		source := bytes.TrimSpace(lines[i])
+
+		switch {
+		case a.index == "":
+			a.err = errors.New("index is missing")
+		case a.op == "update":
+			var update struct {
+				Doc map[string]interface{} `json:"doc"`
+			}
+			if err := json.Unmarshal(source, &update); err != nil {
+				a.err = fmt.Errorf("failed to parse update: %w", err)
+			} else if update.Doc == nil {
+				a.err = errors.New("only partial document updates are supported")
+			}
+			a.document = update.Doc
+		default:
+			if err := json.Unmarshal(source, &a.document); err != nil {
+				a.err = fmt.Errorf("failed to parse document: %w", err)
+			} else if a.document == nil {
+				a.err = errors.New("document must be an object")
+			}
+		}
+		actions = append(actions, a)
+	}
+
+	return actions, nil
+}
@@ -0,0 +1,273 @@
+//go:generate ../../../tools/config_includer/generator
+//go:generate ../../../tools/readme_config_includer/generator
+package elasticsearch_bulk_listener
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/httpserver"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	parsers_json "github.com/influxdata/telegraf/plugins/parsers/json"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type ElasticsearchBulkListener struct {
+	ServiceAddress  string          `toml:"service_address"`
+	Version         string          `toml:"elasticsearch_version"`
+	TagKeys         []string        `toml:"tag_keys"`
+	TimestampKey    string          `toml:"timestamp_key"`
+	TimestampFormat string          `toml:"timestamp_format"`
+	Log             telegraf.Logger `toml:"-"`
+	httpserver.Config
+
+	server       *httpserver.Server
+	backpressure telegraf.Backpressure
+	acc          telegraf.Accumulator
+}
+
+type bulkResponse struct {
+	Took   int64                       `json:"took"`
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	Index   string     `json:"_index"`
+	ID      string     `json:"_id"`
+	Version int        `json:"_version,omitempty"`
+	Result  string     `json:"result,omitempty"`
+	Status  int        `json:"status"`
+	Error   *errorInfo `json:"error,omitempty"`
+}
+
+type errorInfo struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (*ElasticsearchBulkListener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *ElasticsearchBulkListener) Init() error {
+	if e.ServiceAddress == "" {
+		e.ServiceAddress = ":9200"
+	}
+	if e.Version == "" {
+		e.Version = "8.17.0"
+	}
+	if e.TimestampKey == "" {
+		e.TimestampKey = "@timestamp"
+	}
+	if e.TimestampFormat == "" {
+		e.TimestampFormat = "rfc3339"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", e.serveInfo)
+	mux.HandleFunc("/_bulk", e.serveBulk)
+	mux.HandleFunc("/{index}/_bulk", e.serveBulk)
+
+	// Clients of version 7.14 and later refuse to talk to servers not
+	// identifying as Elasticsearch
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Elastic-Product", "Elasticsearch")
+		mux.ServeHTTP(res, req)
+	})
+
+	server, err := e.Config.NewServer(e.ServiceAddress, handler, e.Log)
+	if err != nil {
+		return err
+	}
+	server.SetBackpressure(e.backpressure)
+	e.server = server
+
+	return nil
+}
+
+func (e *ElasticsearchBulkListener) SetBackpressure(bp telegraf.Backpressure) {
+	e.backpressure = bp
+}
+
+func (e *ElasticsearchBulkListener) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+	return e.server.Start()
+}
+
+func (*ElasticsearchBulkListener) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (e *ElasticsearchBulkListener) Stop() {
+	e.server.Close()
+}
+
+// serveInfo responds with the cluster information used by clients to check
+// the server version before sending data
+func (e *ElasticsearchBulkListener) serveInfo(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		e.fail(res, http.StatusMethodNotAllowed, "method_not_allowed", "only GET and HEAD requests are accepted")
+		return
+	}
+
+	info := map[string]interface{}{
+		"name":         "telegraf",
+		"cluster_name": "telegraf",
+		"cluster_uuid": "telegraf",
+		"version": map[string]interface{}{
+			"number":                              e.Version,
+			"build_flavor":                        "default",
+			"minimum_wire_compatibility_version":  e.Version,
+			"minimum_index_compatibility_version": e.Version,
+		},
+		"tagline": "You Know, for Search",
+	}
+	e.respond(res, http.StatusOK, info)
+}
+
+func (e *ElasticsearchBulkListener) serveBulk(res http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		e.fail(res, http.StatusMethodNotAllowed, "method_not_allowed", "only POST and PUT requests are accepted")
+		return
+	}
+	if e.server.Throttle(res) {
+		return
+	}
+
+	body, err := e.server.ReadBody(res, req)
+	if err != nil {
+		e.fail(res, httpserver.StatusCode(err), "parse_exception", err.Error())
+		return
+	}
+
+	actions, err := parseBulk(body, req.PathValue("index"))
+	if err != nil {
+		e.fail(res, http.StatusBadRequest, "illegal_argument_exception", err.Error())
+		return
+	}
+
+	response := bulkResponse{Items: make([]map[string]bulkItemResult, 0, len(actions))}
+	for _, a := range actions {
+		result := bulkItemResult{Index: a.index, ID: a.id}
+		if result.ID == "" && a.op != "delete" {
+			result.ID = generateID()
+		}
+
+		if a.err == nil && a.op != "delete" {
+			a.err = e.addDocument(a.index, a.document)
+		}
+
+		switch {
+		case a.err != nil:
+			result.Status = http.StatusBadRequest
+			result.Error = &errorInfo{Type: "mapper_parsing_exception", Reason: a.err.Error()}
+			response.Errors = true
+			e.Log.Debugf("Rejecting %s action for index %q: %v", a.op, a.index, a.err)
+		case a.op == "delete":
+			// Metrics cannot be deleted so report the document as not found
+			result.Status = http.StatusNotFound
+			result.Result = "not_found"
+			result.Version = 1
+		case a.op == "update":
+			result.Status = http.StatusOK
+			result.Result = "updated"
+			result.Version = 1
+		default:
+			result.Status = http.StatusCreated
+			result.Result = "created"
+			result.Version = 1
+		}
+		response.Items = append(response.Items, map[string]bulkItemResult{a.op: result})
+	}
+	response.Took = time.Since(start).Milliseconds()
+
+	e.respond(res, http.StatusOK, response)
+}
+
+func (e *ElasticsearchBulkListener) addDocument(index string, document map[string]interface{}) error {
+	f := parsers_json.JSONFlattener{}
+	if err := f.FullFlattenJSON("", document, true, true); err != nil {
+		return err
+	}
+	fields := f.Fields
+
+	timestamp := time.Now()
+	if v, found := fields[e.TimestampKey]; found {
+		t, err := internal.ParseTimestamp(e.TimestampFormat, v, nil)
+		if err != nil {
+			return fmt.Errorf("parsing timestamp %q failed: %w", v, err)
+		}
+		timestamp = t
+		delete(fields, e.TimestampKey)
+	}
+
+	tags := map[string]string{"index": index}
+	for _, key := range e.TagKeys {
+		if v, found := fields[key]; found {
+			tags[key] = fmt.Sprint(v)
+			delete(fields, key)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	e.acc.AddFields("elasticsearch_bulk", fields, tags, timestamp)
+
+	return nil
+}
+
+func (e *ElasticsearchBulkListener) fail(res http.ResponseWriter, code int, errType, reason string) {
+	e.Log.Debugf("Request failed with status %d: %s", code, reason)
+	e.respond(res, code, map[string]interface{}{
+		"error":  errorInfo{Type: errType, Reason: reason},
+		"status": code,
+	})
+}
+
+func (e *ElasticsearchBulkListener) respond(res http.ResponseWriter, code int, body interface{}) {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		e.Log.Errorf("Encoding response failed: %v", err)
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(code)
+	if _, err := res.Write(buf); err != nil {
+		e.Log.Debugf("Writing response failed: %v", err)
+	}
+}
+
+// generateID creates a random document ID similar to the ones generated by
+// Elasticsearch for documents without ID
+func generateID() string {
+	var buf [15]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+func init() {
+	inputs.Add("elasticsearch_bulk_listener", func() telegraf.Input {
+		return &ElasticsearchBulkListener{
+			ServiceAddress:  ":9200",
+			Version:         "8.17.0",
+			TimestampKey:    "@timestamp",
+			TimestampFormat: "rfc3339",
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package elasticsearch_bulk_listener
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestPlugin(t *testing.T, tagKeys ...string) (*ElasticsearchBulkListener, *testutil.Accumulator, string) {
+	plugin := &ElasticsearchBulkListener{
+		ServiceAddress: "127.0.0.1:0",
+		TagKeys:        tagKeys,
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	t.Cleanup(plugin.Stop)
+
+	return plugin, &acc, "http://" + plugin.server.Address().String()
+}
+
+func postBulk(t *testing.T, url, body string) (int, bulkResponse) {
+	resp, err := http.Post(url, "application/x-ndjson", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "Elasticsearch", resp.Header.Get("X-Elastic-Product"))
+
+	buf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response bulkResponse
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.Unmarshal(buf, &response))
+	}
+	return resp.StatusCode, response
+}
+
+func TestInfo(t *testing.T) {
+	_, _, url := newTestPlugin(t)
+
+	resp, err := http.Get(url + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Elasticsearch", resp.Header.Get("X-Elastic-Product"))
+
+	var info struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.Equal(t, "8.17.0", info.Version.Number)
+}
+
+func TestBulk(t *testing.T) {
+	_, acc, url := newTestPlugin(t, "host_name")
+
+	body := `{"index":{"_index":"filebeat","_id":"1"}}
+{"@timestamp":"2023-11-14T22:13:20.5Z","message":"first line","host":{"name":"server01"},"log":{"offset":42}}
+{"create":{}}
+{"@timestamp":"2023-11-14T22:13:21Z","message":"second line","enabled":true}
+{"update":{"_id":"1"}}
+{"doc":{"message":"updated line"}}
+{"delete":{"_id":"1"}}
+`
+	code, response := postBulk(t, url+"/logs/_bulk", body)
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, response.Errors)
+	require.Len(t, response.Items, 4)
+
+	require.Equal(t, "filebeat", response.Items[0]["index"].Index)
+	require.Equal(t, "1", response.Items[0]["index"].ID)
+	require.Equal(t, http.StatusCreated, response.Items[0]["index"].Status)
+	require.Equal(t, "logs", response.Items[1]["create"].Index)
+	require.NotEmpty(t, response.Items[1]["create"].ID)
+	require.Equal(t, http.StatusCreated, response.Items[1]["create"].Status)
+	require.Equal(t, http.StatusOK, response.Items[2]["update"].Status)
+	require.Equal(t, http.StatusNotFound, response.Items[3]["delete"].Status)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"elasticsearch_bulk",
+			map[string]string{"index": "filebeat", "host_name": "server01"},
+			map[string]interface{}{"message": "first line", "log_offset": float64(42)},
+			time.Unix(1700000000, 500000000),
+		),
+		metric.New(
+			"elasticsearch_bulk",
+			map[string]string{"index": "logs"},
+			map[string]interface{}{"message": "second line", "enabled": true},
+			time.Unix(1700000001, 0),
+		),
+		metric.New(
+			"elasticsearch_bulk",
+			map[string]string{"index": "logs"},
+			map[string]interface{}{"message": "updated line"},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// Check the timestamps separately as the last metric uses the receive time
+	actual := acc.GetTelegrafMetrics()
+	require.Equal(t, expected[0].Time().UnixNano(), actual[0].Time().UnixNano())
+	require.Equal(t, expected[1].Time().UnixNano(), actual[1].Time().UnixNano())
+}
+
+func TestBulkItemErrors(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	body := `{"index":{}}
+{"message":"no index"}
+{"index":{"_index":"logs"}}
+{"@timestamp":"yesterday","message":"invalid timestamp"}
+{"update":{"_index":"logs","_id":"1"}}
+{"script":{"source":"ctx._source.counter += 1"}}
+{"index":{"_index":"logs"}}
+{"message":"valid"}
+`
+	code, response := postBulk(t, url+"/_bulk", body)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, response.Errors)
+	require.Len(t, response.Items, 4)
+	require.Equal(t, http.StatusBadRequest, response.Items[0]["index"].Status)
+	require.NotNil(t, response.Items[0]["index"].Error)
+	require.Equal(t, http.StatusBadRequest, response.Items[1]["index"].Status)
+	require.Equal(t, http.StatusBadRequest, response.Items[2]["update"].Status)
+	require.Equal(t, http.StatusCreated, response.Items[3]["index"].Status)
+	require.Nil(t, response.Items[3]["index"].Error)
+
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "invalid action",
+			body: "{\"index\":\n",
+		},
+		{
+			name: "unknown action",
+			body: "{\"upsert\":{}}\n{}\n",
+		},
+		{
+			name: "missing source",
+			body: "{\"index\":{\"_index\":\"logs\"}}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := postBulk(t, url+"/_bulk", tt.body)
+			require.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+
+	resp, err := http.Get(url + "/_bulk")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
@@ -2,7 +2,9 @@
 package file
 
 import (
+	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +16,8 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/common/encoding"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,16 +27,25 @@ var sampleConfig string
 
 var once sync.Once
 
+// Maximum number of reads in incremental mode waiting for delivery
+const maxUndeliveredReads = 1000
+
 type File struct {
 	Files             []string        `toml:"files"`
 	FileTag           string          `toml:"file_tag"`
 	FilePathTag       string          `toml:"file_path_tag"`
 	CharacterEncoding string          `toml:"character_encoding"`
+	Incremental       bool            `toml:"incremental"`
 	Log               telegraf.Logger `toml:"-"`
 
-	parserFunc telegraf.ParserFunc
-	filenames  []string
-	decoder    *encoding.Decoder
+	parserFunc  telegraf.ParserFunc
+	filenames   []string
+	decoder     *encoding.Decoder
+	checkpoints *checkpoint.Checkpoints
+	acc         telegraf.TrackingAccumulator
+	sem         chan struct{}
+	done        chan struct{}
+	wg          sync.WaitGroup
 }
 
 func (*File) SampleConfig() string {
@@ -40,11 +53,61 @@ func (*File) SampleConfig() string {
 }
 
 func (f *File) Init() error {
+	f.checkpoints = checkpoint.New()
+
 	var err error
 	f.decoder, err = encoding.NewDecoder(f.CharacterEncoding)
 	return err
 }
 
+func (f *File) Start(acc telegraf.Accumulator) error {
+	if !f.Incremental {
+		return nil
+	}
+
+	f.acc = acc.WithTracking(maxUndeliveredReads)
+	f.sem = make(chan struct{}, maxUndeliveredReads)
+	f.done = make(chan struct{})
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			select {
+			case <-f.done:
+				return
+			case info := <-f.acc.Delivered():
+				f.checkpoints.Delivered(info)
+				<-f.sem
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (f *File) Stop() {
+	if f.done == nil {
+		return
+	}
+	close(f.done)
+	f.wg.Wait()
+	f.done = nil
+}
+
+func (f *File) GetState() interface{} {
+	return f.checkpoints.GetState()
+}
+
+func (f *File) SetState(state interface{}) error {
+	offsetsState, ok := state.(checkpoint.State)
+	if !ok {
+		return errors.New("state has to be of type 'checkpoint.State'")
+	}
+	f.checkpoints.SetState(offsetsState)
+	return nil
+}
+
 func (f *File) SetParserFunc(fn telegraf.ParserFunc) {
 	f.parserFunc = fn
 }
@@ -55,21 +118,14 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 	for _, k := range f.filenames {
-		metrics, err := f.readMetric(k)
-		if err != nil {
-			return err
-		}
-
-		for _, m := range metrics {
-			if f.FileTag != "" {
-				m.AddTag(f.FileTag, filepath.Base(k))
+		if f.Incremental {
+			if err := f.readIncremental(k); err != nil {
+				return err
 			}
-			if f.FilePathTag != "" {
-				if absPath, err := filepath.Abs(k); err == nil {
-					m.AddTag(f.FilePathTag, absPath)
-				}
-			}
-			acc.AddMetric(m)
+			continue
+		}
+		if err := f.readMetric(acc, k); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -93,33 +149,131 @@ func (f *File) refreshFilePaths() error {
 	return nil
 }
 
-func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
+func (f *File) readMetric(acc telegraf.Accumulator, filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer file.Close()
 
+	parser, err := f.parserFunc()
+	if err != nil {
+		return fmt.Errorf("could not instantiate parser: %w", err)
+	}
+
+	var count int
+	addMetric := func(m telegraf.Metric) error {
+		f.addTags(m, filename)
+		acc.AddMetric(m)
+		count++
+		return nil
+	}
+
 	r, _ := utfbom.Skip(f.decoder.Reader(file))
-	fileContents, err := io.ReadAll(r)
+
+	// Use the streaming interface if possible to avoid reading large files
+	// into memory at once
+	if err := models.ParseStream(parser, r, addMetric); err != nil {
+		return fmt.Errorf("could not parse %q: %w", filename, err)
+	}
+
+	if count == 0 {
+		once.Do(func() {
+			f.Log.Debug(internal.NoMetricsCreatedMsg)
+		})
+	}
+	return nil
+}
+
+// readIncremental reads the complete lines appended to the file since the last
+// delivered position and advances the position once the resulting metrics are
+// delivered
+func (f *File) readIncremental(filename string) error {
+	// Skip the file if too many reads are waiting for delivery
+	select {
+	case f.sem <- struct{}{}:
+	default:
+		f.Log.Debugf("Too many undelivered reads, skipping %q", filename)
+		return nil
+	}
+	release := true
+	defer func() {
+		if release {
+			<-f.sem
+		}
+	}()
+
+	offset, err := f.checkpoints.Open(filename, false)
 	if err != nil {
-		return nil, fmt.Errorf("could not read %q: %w", filename, err)
+		return err
 	}
-	parser, err := f.parserFunc()
+
+	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("could not instantiate parser: %w", err)
+		return err
 	}
-	metrics, err := parser.Parse(fileContents)
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking to offset %d in %q failed: %w", offset, filename, err)
+	}
+
+	// Determine the raw length of a BOM at the beginning of the file as the
+	// decoder converts all BOMs to the utf-8 BOM
+	r, enc := utfbom.Skip(f.decoder.Reader(file))
+	var bomLength int64
+	if enc != utfbom.Unknown {
+		bomLength = int64(encoding.EncodedLength(f.CharacterEncoding, "\ufeff"))
+	}
+
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return metrics, fmt.Errorf("could not parse %q: %w", filename, err)
+		return fmt.Errorf("could not read %q: %w", filename, err)
 	}
 
+	// Only consume complete lines to not split data still being written
+	idx := bytes.LastIndexByte(data, '\n')
+	if idx < 0 {
+		return nil
+	}
+	data = data[:idx+1]
+	end := offset + bomLength + int64(encoding.EncodedLength(f.CharacterEncoding, string(data)))
+
+	parser, err := f.parserFunc()
+	if err != nil {
+		return fmt.Errorf("could not instantiate parser: %w", err)
+	}
+	metrics, err := parser.Parse(data)
+	if err != nil {
+		return fmt.Errorf("could not parse %q: %w", filename, err)
+	}
 	if len(metrics) == 0 {
 		once.Do(func() {
 			f.Log.Debug(internal.NoMetricsCreatedMsg)
 		})
+		f.checkpoints.Advance(filename, end)
+		return nil
+	}
+
+	for _, m := range metrics {
+		f.addTags(m, filename)
+	}
+	id := f.acc.AddTrackingMetricGroup(metrics)
+	f.checkpoints.Track(id, filename, end)
+	release = false
+
+	return nil
+}
+
+func (f *File) addTags(m telegraf.Metric, filename string) {
+	if f.FileTag != "" {
+		m.AddTag(f.FileTag, filepath.Base(filename))
+	}
+	if f.FilePathTag != "" {
+		if absPath, err := filepath.Abs(filename); err == nil {
+			m.AddTag(f.FilePathTag, absPath)
+		}
 	}
-	return metrics, err
 }
 
 func init() {
@@ -16,8 +16,10 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -464,3 +466,58 @@ func TestCSVBehavior(t *testing.T) {
 	actual := acc.GetTelegrafMetrics()
 	testutil.RequireMetricsEqual(t, expected, actual, options...)
 }
+
+func TestIncremental(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "input.influx")
+	require.NoError(t, os.WriteFile(fn, []byte("test value=1i 1730478201000000000\ntest value=2i"), 0600))
+
+	plugin := &File{
+		Files:       []string{fn},
+		Incremental: true,
+		Log:         testutil.Logger{},
+	}
+	plugin.SetParserFunc(func() (telegraf.Parser, error) {
+		p := &influx.Parser{}
+		err := p.Init()
+		return p, err
+	})
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Only complete lines must be read
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	// Data waiting for delivery must not be read again
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(" 1730478211000000000\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, plugin.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(1730478201, 0)),
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(2)}, time.Unix(1730478211, 0)),
+	}
+	actual := acc.GetTelegrafMetrics()
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// The position must only advance after delivery
+	info, err := os.Stat(fn)
+	require.NoError(t, err)
+	state, ok := plugin.GetState().(checkpoint.State)
+	require.True(t, ok, "state is not a checkpoint.State")
+	require.Zero(t, state.Files[fn].Offset)
+
+	for _, m := range actual {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool {
+		state := plugin.GetState().(checkpoint.State)
+		return state.Files[fn].Offset == info.Size()
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,198 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package grpc_listener
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // Blank import to allow gzip encoding
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type GRPCListener struct {
+	ServiceAddress string           `toml:"service_address"`
+	MaxMsgSize     config.Size      `toml:"max_msg_size"`
+	Timeout        config.Duration  `toml:"timeout"`
+	Mapping        protobuf.Mapping `toml:"mapping"`
+	Log            telegraf.Logger  `toml:"-"`
+	protobuf.Config
+	tls.ServerConfig
+
+	method   protoreflect.MethodDescriptor
+	acc      telegraf.Accumulator
+	listener net.Listener
+	server   *grpc.Server
+	wg       sync.WaitGroup
+}
+
+func (*GRPCListener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GRPCListener) Init() error {
+	if g.ServiceAddress == "" {
+		return errors.New("'service_address' setting required")
+	}
+
+	method, err := g.Config.LoadMethod()
+	if err != nil {
+		return err
+	}
+	if err := g.Mapping.Init(method.Input()); err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+	g.method = method
+
+	return nil
+}
+
+func (g *GRPCListener) Start(acc telegraf.Accumulator) error {
+	g.acc = acc
+
+	var options []grpc.ServerOption
+	tlsConfig, err := g.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if g.Timeout > 0 {
+		options = append(options, grpc.ConnectionTimeout(time.Duration(g.Timeout)))
+	}
+	if g.MaxMsgSize > 0 {
+		options = append(options, grpc.MaxRecvMsgSize(int(g.MaxMsgSize)))
+	}
+
+	// Register a service only containing the configured method
+	desc := &grpc.ServiceDesc{
+		ServiceName: string(g.method.Parent().FullName()),
+		HandlerType: (*interface{})(nil),
+		Metadata:    g.method.ParentFile().Path(),
+	}
+	if g.method.IsStreamingClient() {
+		desc.Streams = []grpc.StreamDesc{{
+			StreamName:    string(g.method.Name()),
+			Handler:       g.handleStream,
+			ClientStreams: true,
+		}}
+	} else {
+		desc.Methods = []grpc.MethodDesc{{
+			MethodName: string(g.method.Name()),
+			Handler:    g.handleUnary,
+		}}
+	}
+	g.server = grpc.NewServer(options...)
+	g.server.RegisterService(desc, g)
+
+	g.listener, err = net.Listen("tcp", g.ServiceAddress)
+	if err != nil {
+		return err
+	}
+	g.Log.Infof("Listening on %s", g.listener.Addr())
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := g.server.Serve(g.listener); err != nil {
+			acc.AddError(fmt.Errorf("serving gRPC requests failed: %w", err))
+		}
+	}()
+
+	return nil
+}
+
+func (*GRPCListener) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (g *GRPCListener) Stop() {
+	if g.server != nil {
+		g.server.Stop()
+	}
+	g.wg.Wait()
+}
+
+func (g *GRPCListener) handleUnary(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+	msg := dynamicpb.NewMessage(g.method.Input())
+	if err := dec(msg); err != nil {
+		return nil, err
+	}
+
+	metrics, err := g.decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metrics {
+		g.acc.AddMetric(m)
+	}
+
+	return dynamicpb.NewMessage(g.method.Output()), nil
+}
+
+func (g *GRPCListener) handleStream(_ interface{}, stream grpc.ServerStream) error {
+	// Only add the metrics once the stream completed successfully as the
+	// client resends all messages of the stream on errors
+	var metrics []telegraf.Metric
+	for {
+		msg := dynamicpb.NewMessage(g.method.Input())
+		if err := stream.RecvMsg(msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		m, err := g.decode(msg)
+		if err != nil {
+			return err
+		}
+		metrics = append(metrics, m...)
+	}
+
+	for _, m := range metrics {
+		g.acc.AddMetric(m)
+	}
+
+	return stream.SendMsg(dynamicpb.NewMessage(g.method.Output()))
+}
+
+// decode extracts the metrics from the message and returns an error with
+// the "INVALID_ARGUMENT" status code if the message is invalid
+func (g *GRPCListener) decode(msg protoreflect.Message) ([]telegraf.Metric, error) {
+	metrics, err := g.Mapping.Decode(msg, "grpc_listener")
+	if err != nil {
+		err = fmt.Errorf("decoding message failed: %w", err)
+		g.acc.AddError(err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return metrics, nil
+}
+
+func init() {
+	inputs.Add("grpc_listener", func() telegraf.Input {
+		return &GRPCListener{
+			ServiceAddress: ":50051",
+		}
+	})
+}
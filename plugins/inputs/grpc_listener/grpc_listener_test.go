@@ -0,0 +1,189 @@
+package grpc_listener
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newPlugin(t *testing.T, method string) *GRPCListener {
+	creator, found := inputs.Inputs["grpc_listener"]
+	require.True(t, found)
+	plugin := creator().(*GRPCListener)
+	plugin.ServiceAddress = "127.0.0.1:0"
+	plugin.Files = []string{"testdata/ingest.proto"}
+	plugin.Method = method
+	plugin.Log = testutil.Logger{}
+	return plugin
+}
+
+func dial(t *testing.T, plugin *GRPCListener) *grpc.ClientConn {
+	conn, err := grpc.NewClient(plugin.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*GRPCListener)
+		expected string
+	}{
+		{
+			name:     "missing address",
+			modify:   func(g *GRPCListener) { g.ServiceAddress = "" },
+			expected: "'service_address' setting required",
+		},
+		{
+			name:     "missing files",
+			modify:   func(g *GRPCListener) { g.Files = nil },
+			expected: "'proto_files' setting required",
+		},
+		{
+			name:     "server streaming",
+			modify:   func(g *GRPCListener) { g.Method = "ingest.Ingest/Subscribe" },
+			expected: "server-streaming method",
+		},
+		{
+			name:     "invalid mapping",
+			modify:   func(g *GRPCListener) { g.Mapping.Metrics = "unknown" },
+			expected: "invalid mapping",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, "ingest.Ingest/Write")
+			plugin.Mapping.FieldMap = "values"
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestUnary(t *testing.T) {
+	plugin := newPlugin(t, "ingest.Ingest/Write")
+	plugin.Mapping = protobuf.Mapping{
+		Metrics:    "samples",
+		MetricName: "measurement",
+		Timestamp:  "time",
+		Tags:       map[string]string{"host": "source.host"},
+		FieldMap:   "values",
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "b"}, map[string]interface{}{"used": 23.0}, time.Unix(2, 0)),
+	}
+	msgs := make([]protoreflect.Message, 0, len(expected))
+	for _, m := range expected {
+		msg, err := plugin.Mapping.Encode(m)
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+
+	conn := dial(t, plugin)
+	response := dynamicpb.NewMessage(plugin.method.Output())
+	err := conn.Invoke(context.Background(), "/ingest.Ingest/Write", plugin.Mapping.Batch(msgs).Interface(), response)
+	require.NoError(t, err)
+
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestStream(t *testing.T) {
+	plugin := newPlugin(t, "ingest.Ingest/Stream")
+	plugin.Mapping = protobuf.Mapping{
+		Timestamp:       "count",
+		TimestampFormat: "unix",
+		FieldMap:        "values",
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	expected := []telegraf.Metric{
+		metric.New("grpc_listener", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		metric.New("grpc_listener", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+	}
+
+	conn := dial(t, plugin)
+	desc := &grpc.StreamDesc{ClientStreams: true}
+	stream, err := conn.NewStream(context.Background(), desc, "/ingest.Ingest/Stream")
+	require.NoError(t, err)
+	for _, m := range expected {
+		msg, err := plugin.Mapping.Encode(m)
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(msg.Interface()))
+	}
+
+	// No metrics must be added before the stream is finished
+	time.Sleep(100 * time.Millisecond)
+	require.Zero(t, acc.NMetrics())
+
+	require.NoError(t, stream.CloseSend())
+	require.NoError(t, stream.RecvMsg(dynamicpb.NewMessage(plugin.method.Output())))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestInvalidMessage(t *testing.T) {
+	plugin := newPlugin(t, "ingest.Ingest/WriteSample")
+	plugin.Mapping = protobuf.Mapping{
+		Timestamp:       "measurement",
+		TimestampFormat: "unix",
+		FieldMap:        "values",
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	msg := dynamicpb.NewMessage(plugin.method.Input())
+	msg.Set(plugin.method.Input().Fields().ByName("measurement"), protoreflect.ValueOfString("foo"))
+
+	conn := dial(t, plugin)
+	response := dynamicpb.NewMessage(plugin.method.Output())
+	err := conn.Invoke(context.Background(), "/ingest.Ingest/WriteSample", msg, response)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Zero(t, acc.NMetrics())
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.FirstError(), "parsing timestamp failed")
+}
+
+func TestUnknownMethod(t *testing.T) {
+	plugin := newPlugin(t, "ingest.Ingest/Write")
+	plugin.Mapping.Metrics = "samples"
+	plugin.Mapping.FieldMap = "values"
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	conn := dial(t, plugin)
+	msg := dynamicpb.NewMessage(plugin.method.Input())
+	response := dynamicpb.NewMessage(plugin.method.Output())
+	err := conn.Invoke(context.Background(), "/ingest.Ingest/WriteSample", msg, response)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -42,6 +44,7 @@ type HTTP struct {
 
 	Headers            map[string]*config.Secret `toml:"headers"`
 	SuccessStatusCodes []int                     `toml:"success_status_codes"`
+	Discovery          *discovery.Discovery      `toml:"discovery"`
 	Log                telegraf.Logger           `toml:"-"`
 
 	common_http.HTTPClientConfig
@@ -79,6 +82,12 @@ func (h *HTTP) Init() error {
 	if len(h.SuccessStatusCodes) == 0 {
 		h.SuccessStatusCodes = []int{200}
 	}
+
+	if h.Discovery != nil {
+		if err := h.Discovery.Init(h.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -90,6 +99,19 @@ func (*HTTP) Start(telegraf.Accumulator) error {
 	return nil
 }
 
+// Probe checks that all configured URLs are reachable and respond with one of
+// the expected status codes
+func (h *HTTP) Probe() error {
+	for _, u := range h.URLs {
+		resp, err := h.request(u)
+		if err != nil {
+			return fmt.Errorf("[url=%s]: %w", u, err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
 func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 	for _, u := range h.URLs {
@@ -102,6 +124,22 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 		}(u)
 	}
 
+	if h.Discovery != nil {
+		targets, err := h.Discovery.Targets(context.Background())
+		if err != nil {
+			acc.AddError(err)
+		}
+		for _, t := range targets {
+			wg.Add(1)
+			go func(target discovery.Target) {
+				defer wg.Done()
+				if err := h.gatherURL(discovery.NewAccumulator(acc, target), target.Endpoint); err != nil {
+					acc.AddError(fmt.Errorf("[url=%s]: %w", target.Endpoint, err))
+				}
+			}(t)
+		}
+	}
+
 	wg.Wait()
 
 	return nil
@@ -123,16 +161,56 @@ func (h *HTTP) Stop() {
 //
 //	error: Any error that may have occurred
 func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
+	resp, err := h.request(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Instantiate a new parser for the new data to avoid trouble with stateful parsers
+	parser, err := h.parserFunc()
+	if err != nil {
+		return fmt.Errorf("instantiating parser failed: %w", err)
+	}
+
+	var count int
+	addMetric := func(metric telegraf.Metric) error {
+		if !metric.HasTag("url") {
+			metric.AddTag("url", url)
+		}
+		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		count++
+		return nil
+	}
+
+	// Use the streaming interface if possible to avoid reading large
+	// responses into memory at once
+	if err := models.ParseStream(parser, resp.Body, addMetric); err != nil {
+		return fmt.Errorf("parsing metrics failed: %w", err)
+	}
+
+	if count == 0 {
+		once.Do(func() {
+			h.Log.Debug(internal.NoMetricsCreatedMsg)
+		})
+	}
+
+	return nil
+}
+
+// request sends the configured request to the given URL and checks the
+// response status. The caller is responsible for closing the response body.
+func (h *HTTP) request(url string) (*http.Response, error) {
 	body := makeRequestBodyReader(h.ContentEncoding, h.Body)
 	request, err := http.NewRequest(h.Method, url, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !h.Token.Empty() {
 		token, err := h.Token.Get()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		bearer := "Bearer " + strings.TrimSpace(token.String())
 		token.Destroy()
@@ -140,7 +218,7 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 	} else if h.TokenFile != "" {
 		token, err := os.ReadFile(h.TokenFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		bearer := "Bearer " + strings.Trim(string(token), "\n")
 		request.Header.Set("Authorization", bearer)
@@ -153,7 +231,7 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 	for k, v := range h.Headers {
 		secret, err := v.Get()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		headerVal := secret.String()
@@ -167,14 +245,13 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 	}
 
 	if err := h.setRequestAuth(request); err != nil {
-		return err
+		return nil, err
 	}
 
 	resp, err := h.client.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	responseHasSuccessCode := false
 	for _, statusCode := range h.SuccessStatusCodes {
@@ -185,41 +262,14 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 	}
 
 	if !responseHasSuccessCode {
-		return fmt.Errorf("received status code %d (%s), expected any value out of %v",
+		resp.Body.Close()
+		return nil, fmt.Errorf("received status code %d (%s), expected any value out of %v",
 			resp.StatusCode,
 			http.StatusText(resp.StatusCode),
 			h.SuccessStatusCodes)
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading body failed: %w", err)
-	}
-
-	// Instantiate a new parser for the new data to avoid trouble with stateful parsers
-	parser, err := h.parserFunc()
-	if err != nil {
-		return fmt.Errorf("instantiating parser failed: %w", err)
-	}
-	metrics, err := parser.Parse(b)
-	if err != nil {
-		return fmt.Errorf("parsing metrics failed: %w", err)
-	}
-
-	if len(metrics) == 0 {
-		once.Do(func() {
-			h.Log.Debug(internal.NoMetricsCreatedMsg)
-		})
-	}
-
-	for _, metric := range metrics {
-		if !metric.HasTag("url") {
-			metric.AddTag("url", url)
-		}
-		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-	}
-
-	return nil
+	return resp, nil
 }
 
 func (h *HTTP) setRequestAuth(request *http.Request) error {
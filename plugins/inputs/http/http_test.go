@@ -19,6 +19,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/oauth"
 	httpplugin "github.com/influxdata/telegraf/plugins/inputs/http"
@@ -193,6 +195,31 @@ func TestSuccessStatusCodes(t *testing.T) {
 	require.NoError(t, acc.GatherError(plugin.Gather))
 }
 
+func TestProbe(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/endpoint" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fakeServer.Close()
+
+	plugin := &httpplugin.HTTP{
+		URLs: []string{fakeServer.URL + "/endpoint"},
+		Log:  testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Probe())
+
+	plugin = &httpplugin.HTTP{
+		URLs: []string{fakeServer.URL + "/endpoint", fakeServer.URL + "/missing"},
+		Log:  testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.ErrorContains(t, plugin.Probe(), "received status code 404")
+}
+
 func TestMethod(t *testing.T) {
 	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
@@ -544,3 +571,56 @@ func TestConnectionOverUnixSocket(t *testing.T) {
 	require.NoError(t, acc.GatherError(plugin.Gather))
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
 }
+
+func TestDiscoveredTargets(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/static":
+			_, _ = w.Write([]byte("static value=1 0\n"))
+		case "/discovered":
+			_, _ = w.Write([]byte("discovered value=2 0\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	addr := strings.TrimPrefix(fakeServer.URL, "http://")
+	targetsFile := filepath.Join(t.TempDir(), "targets.json")
+	targets := fmt.Sprintf(`[{"targets": [%q], "labels": {"env": "test"}}]`, addr)
+	require.NoError(t, os.WriteFile(targetsFile, []byte(targets), 0600))
+
+	plugin := &httpplugin.HTTP{
+		URLs: []string{fakeServer.URL + "/static"},
+		Discovery: &discovery.Discovery{
+			Template: "http://{{.Address}}/discovered",
+			Files:    []*discovery.FileProvider{{Files: []string{targetsFile}}},
+		},
+		Log: testutil.Logger{},
+	}
+	plugin.SetParserFunc(func() (telegraf.Parser, error) {
+		p := &influx.Parser{}
+		err := p.Init()
+		return p, err
+	})
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"discovered",
+			map[string]string{"url": "http://" + addr + "/discovered", "env": "test"},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"static",
+			map[string]string{"url": fakeServer.URL + "/static"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
@@ -7,15 +7,10 @@ import (
 	"crypto/tls"
 	_ "embed"
 	"errors"
-	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
-	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,6 +22,7 @@ import (
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/common/httpserver"
 	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -77,7 +73,8 @@ type HTTPListenerV2 struct {
 	url      *url.URL
 
 	telegraf.Parser
-	acc telegraf.Accumulator
+	acc          telegraf.Accumulator
+	backpressure telegraf.Backpressure
 }
 
 // timeFunc provides a timestamp for the metrics
@@ -93,16 +90,12 @@ func (h *HTTPListenerV2) Init() error {
 		return err
 	}
 
-	protoRegex := regexp.MustCompile(`\w://`)
-	if !protoRegex.MatchString(h.ServiceAddress) {
-		h.ServiceAddress = "tcp://" + h.ServiceAddress
-	}
-
-	u, err := url.Parse(h.ServiceAddress)
+	u, err := httpserver.ParseAddress(h.ServiceAddress)
 	if err != nil {
-		return fmt.Errorf("parsing address failed: %w", err)
+		return err
 	}
 
+	h.ServiceAddress = u.String()
 	h.url = u
 	h.tlsConf = tlsConf
 
@@ -113,54 +106,21 @@ func (h *HTTPListenerV2) Init() error {
 	return nil
 }
 
+func (h *HTTPListenerV2) SetBackpressure(bp telegraf.Backpressure) {
+	h.backpressure = bp
+}
+
 func (h *HTTPListenerV2) SetParser(parser telegraf.Parser) {
 	h.Parser = parser
 }
 
 func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
-	u := h.url
-	address := u.Host
-	switch u.Scheme {
-	case "tcp":
-	case "unix":
-		path := filepath.FromSlash(u.Path)
-		if runtime.GOOS == "windows" && strings.Contains(path, ":") {
-			path = strings.TrimPrefix(path, `\`)
-		}
-		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("removing socket failed: %w", err)
-		}
-		address = path
-	default:
-		return fmt.Errorf("unknown protocol %q", u.Scheme)
-	}
-
-	var listener net.Listener
-	var err error
-	if h.tlsConf != nil {
-		listener, err = tls.Listen(u.Scheme, address, h.tlsConf)
-	} else {
-		listener, err = net.Listen(u.Scheme, address)
-	}
+	listener, err := httpserver.Listen(h.url, h.SocketMode, h.tlsConf)
 	if err != nil {
 		return err
 	}
 	h.listener = listener
 
-	if u.Scheme == "unix" && h.SocketMode != "" {
-		// Set permissions on socket
-		// Convert from octal in string to int
-		i, err := strconv.ParseUint(h.SocketMode, 8, 32)
-		if err != nil {
-			return fmt.Errorf("converting socket mode failed: %w", err)
-		}
-
-		perm := os.FileMode(uint32(i))
-		if err := os.Chmod(address, perm); err != nil {
-			return fmt.Errorf("changing socket permissions failed: %w", err)
-		}
-	}
-
 	if h.MaxBodySize == 0 {
 		h.MaxBodySize = config.Size(defaultMaxBodySize)
 	}
@@ -264,6 +224,14 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	// Ask the client to retry later if the outputs cannot keep up
+	if h.backpressure != nil && h.backpressure.Throttled() {
+		if err := tooManyRequests(res, httpserver.DefaultRetryAfter); err != nil {
+			h.Log.Debugf("error in too-many-requests: %v", err)
+		}
+		return
+	}
+
 	var bytes []byte
 	var ok bool
 
@@ -397,6 +365,14 @@ func methodNotAllowed(res http.ResponseWriter) error {
 	return err
 }
 
+func tooManyRequests(res http.ResponseWriter, retryAfter time.Duration) error {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Retry-After", strconv.FormatInt(int64(retryAfter/time.Second), 10))
+	res.WriteHeader(http.StatusTooManyRequests)
+	_, err := res.Write([]byte(`{"error":"http: outputs cannot keep up, retry later"}`))
+	return err
+}
+
 func badRequest(res http.ResponseWriter) error {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusBadRequest)
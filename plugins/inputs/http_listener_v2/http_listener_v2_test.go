@@ -776,3 +776,30 @@ func mustReadHugeMetric() []byte {
 
 // The term 'master_repl' used here is archaic language from redis
 var hugeMetric = mustReadHugeMetric()
+
+func TestWriteHTTPBackpressure(t *testing.T) {
+	listener, err := newTestHTTPListenerV2()
+	require.NoError(t, err)
+	bp := &testutil.Backpressure{}
+	listener.SetBackpressure(bp)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Init())
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	bp.Throttle(true)
+	resp, err := http.Post(createURL(listener, "http", "/write", "db=mydb"), "", bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "5", resp.Header.Get("Retry-After"))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	bp.Throttle(false)
+	resp, err = http.Post(createURL(listener, "http", "/write", "db=mydb"), "", bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	acc.Wait(1)
+}
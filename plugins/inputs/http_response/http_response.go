@@ -2,6 +2,7 @@
 package http_response
 
 import (
+	"context"
 	_ "embed"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/common/cookie"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -60,10 +62,13 @@ type HTTPResponse struct {
 	tls.ClientConfig
 	cookie.CookieAuthConfig
 
+	Discovery *discovery.Discovery `toml:"discovery"`
+
 	Log telegraf.Logger `toml:"-"`
 
 	compiledStringMatch *regexp.Regexp
 	clients             []client
+	discovered          map[string]client
 }
 
 type client struct {
@@ -99,33 +104,48 @@ func (h *HTTPResponse) Init() error {
 	}
 
 	if len(h.URLs) == 0 {
-		if h.Address == "" {
-			h.URLs = []string{"http://localhost"}
-		} else {
+		if h.Address != "" {
 			h.URLs = []string{h.Address}
+		} else if h.Discovery == nil {
+			h.URLs = []string{"http://localhost"}
 		}
 	}
 
 	h.clients = make([]client, 0, len(h.URLs))
 	for _, u := range h.URLs {
-		addr, err := url.Parse(u)
+		c, err := h.newClient(u)
 		if err != nil {
-			return fmt.Errorf("%q is not a valid address: %w", u, err)
+			return err
 		}
+		h.clients = append(h.clients, c)
+	}
 
-		if addr.Scheme != "http" && addr.Scheme != "https" {
-			return fmt.Errorf("%q is not a valid address: only http and https types are supported", u)
+	if h.Discovery != nil {
+		if err := h.Discovery.Init(h.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
 		}
+		h.discovered = make(map[string]client)
+	}
 
-		cl, err := h.createHTTPClient(*addr)
-		if err != nil {
-			return err
-		}
+	return nil
+}
+
+func (h *HTTPResponse) newClient(u string) (client, error) {
+	addr, err := url.Parse(u)
+	if err != nil {
+		return client{}, fmt.Errorf("%q is not a valid address: %w", u, err)
+	}
 
-		h.clients = append(h.clients, client{httpClient: cl, address: u})
+	if addr.Scheme != "http" && addr.Scheme != "https" {
+		return client{}, fmt.Errorf("%q is not a valid address: only http and https types are supported", u)
 	}
 
-	return nil
+	cl, err := h.createHTTPClient(*addr)
+	if err != nil {
+		return client{}, err
+	}
+
+	return client{httpClient: cl, address: u}, nil
 }
 
 // Gather gets all metric fields and tags and returns any errors it encounters
@@ -146,9 +166,42 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		acc.AddFields("http_response", fields, tags)
 	}
 
+	if h.Discovery != nil {
+		h.gatherDiscovered(acc)
+	}
+
 	return nil
 }
 
+func (h *HTTPResponse) gatherDiscovered(acc telegraf.Accumulator) {
+	targets, err := h.Discovery.Targets(context.Background())
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	// Create clients for new targets and remove the ones of vanished targets
+	current := make(map[string]client, len(targets))
+	for _, t := range targets {
+		c, found := h.discovered[t.Endpoint]
+		if !found {
+			c, err = h.newClient(t.Endpoint)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+		}
+		current[t.Endpoint] = c
+
+		fields, tags, err := h.httpGather(c)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		discovery.NewAccumulator(acc, t).AddFields("http_response", fields, tags)
+	}
+	h.discovered = current
+}
+
 // Set the proxy. A configured proxy overwrites the system-wide proxy.
 func getProxyFunc(httpProxy string) func(*http.Request) (*url.URL, error) {
 	if httpProxy == "" {
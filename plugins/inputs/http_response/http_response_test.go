@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -1479,3 +1481,45 @@ func parseURL(t *testing.T, address string) url.URL {
 	require.NotNil(t, u)
 	return *u
 }
+
+func TestDiscoveredTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var sdResponse string
+	sd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(sdResponse))
+	}))
+	defer sd.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	sdResponse = fmt.Sprintf(`[{"targets": [%q], "labels": {"team": "web"}}]`, addr)
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		ResponseTimeout: config.Duration(time.Second * 2),
+		Discovery: &discovery.Discovery{
+			Template: "http://{{.Address}}/health",
+			HTTP:     []*discovery.HTTPProvider{{URL: sd.URL}},
+		},
+	}
+	require.NoError(t, h.Init())
+	require.Empty(t, h.clients)
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, ts.URL+"/health", acc.Metrics[0].Tags["server"])
+	require.Equal(t, "web", acc.Metrics[0].Tags["team"])
+	require.Len(t, h.discovered, 1)
+
+	// Vanished targets must be removed
+	sdResponse = "[]"
+	h.Discovery.RefreshInterval = config.Duration(time.Nanosecond)
+	acc.ClearMetrics()
+	require.NoError(t, h.Gather(&acc))
+	require.Empty(t, acc.Metrics)
+	require.Empty(t, h.discovered)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/httpserver"
 	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
@@ -55,6 +56,7 @@ type InfluxDBV2Listener struct {
 
 	Log telegraf.Logger `toml:"-"`
 
+	backpressure        telegraf.Backpressure
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	trackingMetricCount map[telegraf.TrackingID]int64
@@ -120,6 +122,10 @@ func (h *InfluxDBV2Listener) Init() error {
 	return nil
 }
 
+func (h *InfluxDBV2Listener) SetBackpressure(bp telegraf.Backpressure) {
+	h.backpressure = bp
+}
+
 func (*InfluxDBV2Listener) Gather(telegraf.Accumulator) error {
 	return nil
 }
@@ -267,6 +273,14 @@ func (h *InfluxDBV2Listener) handleWrite() http.HandlerFunc {
 			return
 		}
 
+		// Ask the client to retry later if the outputs cannot keep up
+		if h.backpressure != nil && h.backpressure.Throttled() {
+			if err := tooManyRequests(res, httpserver.DefaultRetryAfter); err != nil {
+				h.Log.Debugf("error in too-many-requests: %v", err)
+			}
+			return
+		}
+
 		bucket := req.URL.Query().Get("bucket")
 
 		body := req.Body
@@ -407,6 +421,21 @@ func tooLarge(res http.ResponseWriter, maxLength int64) error {
 	return err
 }
 
+func tooManyRequests(res http.ResponseWriter, retryAfter time.Duration) error {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Influxdb-Error", "http: outputs cannot keep up, retry later")
+	res.Header().Set("Retry-After", strconv.FormatInt(int64(retryAfter/time.Second), 10))
+	res.WriteHeader(http.StatusTooManyRequests)
+	b, err := json.Marshal(map[string]string{
+		"code":    "too many requests",
+		"message": "http: outputs cannot keep up, retry later"})
+	if err != nil {
+		return err
+	}
+	_, err = res.Write(b)
+	return err
+}
+
 func badRequest(res http.ResponseWriter, code BadRequestCode, errString string) error {
 	res.Header().Set("Content-Type", "application/json")
 	if errString == "" {
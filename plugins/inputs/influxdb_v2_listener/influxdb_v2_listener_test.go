@@ -666,3 +666,29 @@ func TestRateLimitedConnectionRejectsBatchesLargerThanMaxUndeliveredMetrics(t *t
 }
 
 // The term 'master_repl' used here is archaic language from redis
+
+func TestWriteBackpressure(t *testing.T) {
+	listener := newTestListener()
+	bp := &testutil.Backpressure{}
+	listener.SetBackpressure(bp)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Init())
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	bp.Throttle(true)
+	resp, err := http.Post(createURL(listener, "http", "/api/v2/write", "bucket=mybucket"), "", bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "5", resp.Header.Get("Retry-After"))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	bp.Throttle(false)
+	resp, err = http.Post(createURL(listener, "http", "/api/v2/write", "bucket=mybucket"), "", bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	acc.Wait(1)
+}
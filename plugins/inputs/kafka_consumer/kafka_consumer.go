@@ -41,6 +41,7 @@ type KafkaConsumer struct {
 	MaxUndeliveredMessages               int             `toml:"max_undelivered_messages"`
 	MaxProcessingTime                    config.Duration `toml:"max_processing_time"`
 	Offset                               string          `toml:"offset"`
+	IsolationLevel                       string          `toml:"isolation_level"`
 	BalanceStrategy                      string          `toml:"balance_strategy"`
 	Topics                               []string        `toml:"topics"`
 	TopicRegexps                         []string        `toml:"topic_regexps"`
@@ -167,6 +168,22 @@ func (k *KafkaConsumer) Init() error {
 		return fmt.Errorf("invalid offset %q", k.Offset)
 	}
 
+	switch k.IsolationLevel {
+	case "read_uncommitted", "":
+		cfg.Consumer.IsolationLevel = sarama.ReadUncommitted
+	case "read_committed":
+		// Transactions require Kafka version 0.11 or later
+		if !cfg.Version.IsAtLeast(sarama.V0_11_0_0) {
+			if k.Version != "" {
+				return fmt.Errorf("isolation level %q requires kafka_version 0.11.0.0 or later", k.IsolationLevel)
+			}
+			cfg.Version = sarama.V0_11_0_0
+		}
+		cfg.Consumer.IsolationLevel = sarama.ReadCommitted
+	default:
+		return fmt.Errorf("invalid isolation level %q", k.IsolationLevel)
+	}
+
 	switch strings.ToLower(k.BalanceStrategy) {
 	case "range", "":
 		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
@@ -138,6 +138,34 @@ func TestInit(t *testing.T) {
 			},
 			initError: true,
 		},
+		{
+			name: "read committed",
+			plugin: &KafkaConsumer{
+				IsolationLevel: "read_committed",
+				Log:            testutil.Logger{},
+			},
+			check: func(t *testing.T, plugin *KafkaConsumer) {
+				require.Equal(t, sarama.ReadCommitted, plugin.config.Consumer.IsolationLevel)
+				require.True(t, plugin.config.Version.IsAtLeast(sarama.V0_11_0_0))
+			},
+		},
+		{
+			name: "read committed with old version",
+			plugin: &KafkaConsumer{
+				IsolationLevel: "read_committed",
+				Version:        "0.10.2.0",
+				Log:            testutil.Logger{},
+			},
+			initError: true,
+		},
+		{
+			name: "invalid isolation level",
+			plugin: &KafkaConsumer{
+				IsolationLevel: "serializable",
+				Log:            testutil.Logger{},
+			},
+			initError: true,
+		},
 		{
 			name: "default tls without tls config",
 			plugin: &KafkaConsumer{
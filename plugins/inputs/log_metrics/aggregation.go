@@ -0,0 +1,104 @@
+//go:build !solaris
+
+package log_metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+type fieldStats struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+type series struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*fieldStats
+}
+
+// aggregation computes the statistics of the numeric fields of the extracted
+// metrics per series within an interval
+type aggregation struct {
+	series map[uint64]*series
+	sync.Mutex
+}
+
+func newAggregation() *aggregation {
+	return &aggregation{series: make(map[uint64]*series)}
+}
+
+func (a *aggregation) add(m telegraf.Metric) {
+	a.Lock()
+	defer a.Unlock()
+
+	id := m.HashID()
+	s, found := a.series[id]
+	if !found {
+		s = &series{
+			name:   m.Name(),
+			tags:   m.Tags(),
+			fields: make(map[string]*fieldStats),
+		}
+		a.series[id] = s
+	}
+
+	for _, field := range m.FieldList() {
+		v, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+		stats, found := s.fields[field.Key]
+		if !found {
+			s.fields[field.Key] = &fieldStats{count: 1, sum: v, min: v, max: v}
+			continue
+		}
+		stats.count++
+		stats.sum += v
+		stats.min = min(stats.min, v)
+		stats.max = max(stats.max, v)
+	}
+}
+
+// flush returns the metrics of the statistics and resets the aggregation
+func (a *aggregation) flush(t time.Time) []telegraf.Metric {
+	a.Lock()
+	defer a.Unlock()
+
+	metrics := make([]telegraf.Metric, 0, len(a.series))
+	for _, s := range a.series {
+		if len(s.fields) == 0 {
+			continue
+		}
+		fields := make(map[string]interface{}, 5*len(s.fields))
+		for k, stats := range s.fields {
+			fields[k+"_count"] = stats.count
+			fields[k+"_sum"] = stats.sum
+			fields[k+"_min"] = stats.min
+			fields[k+"_max"] = stats.max
+			fields[k+"_mean"] = stats.sum / float64(stats.count)
+		}
+		metrics = append(metrics, metric.New(s.name, s.tags, fields, t))
+	}
+	a.series = make(map[uint64]*series)
+
+	return metrics
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,330 @@
+//go:generate ../../../tools/readme_config_includer/generator
+//go:build !solaris
+
+package log_metrics
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/tail"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const maxUndeliveredLines = 1000
+
+type LogMetrics struct {
+	Files         []string        `toml:"files"`
+	FromBeginning bool            `toml:"from_beginning"`
+	WatchMethod   string          `toml:"watch_method"`
+	Patterns      []*pattern      `toml:"pattern"`
+	Log           telegraf.Logger `toml:"-"`
+
+	tailers      map[string]*tail.Tail
+	checkpoints  *checkpoint.Checkpoints
+	aggregations []*aggregation
+
+	linesTotal     atomic.Uint64
+	linesUnmatched atomic.Uint64
+	linesMatched   []atomic.Uint64
+	parseErrors    atomic.Uint64
+
+	acc  telegraf.TrackingAccumulator
+	sem  chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+	sync.Mutex
+}
+
+// generation counts the (re-)opens of a tailed file to detect rotation and
+// truncation while receiving lines
+type generation struct {
+	count uint64
+	sync.Mutex
+}
+
+func (g *generation) opened() {
+	g.Lock()
+	defer g.Unlock()
+	g.count++
+}
+
+func (g *generation) current() uint64 {
+	g.Lock()
+	defer g.Unlock()
+	return g.count
+}
+
+func (*LogMetrics) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *LogMetrics) Init() error {
+	if len(l.Files) == 0 {
+		return errors.New("no files configured")
+	}
+	if len(l.Patterns) == 0 {
+		return errors.New("no patterns configured")
+	}
+
+	switch l.WatchMethod {
+	case "":
+		l.WatchMethod = "inotify"
+	case "inotify", "poll":
+	default:
+		return fmt.Errorf("invalid 'watch_method' setting %q", l.WatchMethod)
+	}
+
+	l.aggregations = make([]*aggregation, len(l.Patterns))
+	for i, p := range l.Patterns {
+		if err := p.init(l.Log); err != nil {
+			return fmt.Errorf("pattern %d: %w", i+1, err)
+		}
+		if p.Aggregate {
+			l.aggregations[i] = newAggregation()
+		}
+	}
+	l.linesMatched = make([]atomic.Uint64, len(l.Patterns))
+	l.checkpoints = checkpoint.New()
+
+	return nil
+}
+
+func (l *LogMetrics) GetState() interface{} {
+	return l.checkpoints.GetState()
+}
+
+func (l *LogMetrics) SetState(state interface{}) error {
+	offsetsState, ok := state.(checkpoint.State)
+	if !ok {
+		return errors.New("state has to be of type 'checkpoint.State'")
+	}
+	l.checkpoints.SetState(offsetsState)
+	return nil
+}
+
+func (l *LogMetrics) Start(acc telegraf.Accumulator) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.acc = acc.WithTracking(maxUndeliveredLines)
+	l.sem = make(chan struct{}, maxUndeliveredLines)
+	l.done = make(chan struct{})
+	l.tailers = make(map[string]*tail.Tail)
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for {
+			select {
+			case <-l.done:
+				return
+			case info := <-l.acc.Delivered():
+				l.checkpoints.Delivered(info)
+				<-l.sem
+			}
+		}
+	}()
+
+	l.tailNewFiles(!l.FromBeginning)
+
+	return nil
+}
+
+func (l *LogMetrics) Gather(acc telegraf.Accumulator) error {
+	l.Lock()
+	defer l.Unlock()
+
+	// Always start at the beginning of files appearing while running
+	l.tailNewFiles(false)
+
+	now := time.Now()
+	acc.AddCounter("log_metrics", map[string]interface{}{
+		"lines_total":     l.linesTotal.Load(),
+		"lines_unmatched": l.linesUnmatched.Load(),
+		"parse_errors":    l.parseErrors.Load(),
+	}, nil, now)
+	for i, p := range l.Patterns {
+		acc.AddCounter("log_metrics", map[string]interface{}{
+			"lines_matched": l.linesMatched[i].Load(),
+		}, map[string]string{"pattern": p.Name}, now)
+	}
+
+	// Output the aggregated values of the last interval
+	for _, a := range l.aggregations {
+		if a == nil {
+			continue
+		}
+		for _, m := range a.flush(now) {
+			acc.AddMetric(m)
+		}
+	}
+
+	return nil
+}
+
+func (l *LogMetrics) Stop() {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, t := range l.tailers {
+		if err := t.Stop(); err != nil {
+			l.Log.Errorf("Stopping tail on %q failed: %v", t.Filename, err)
+		}
+	}
+	close(l.done)
+	l.wg.Wait()
+}
+
+// tailNewFiles starts tailing files matching the globs not being tailed yet.
+// Assumes the lock is held.
+func (l *LogMetrics) tailNewFiles(fromEnd bool) {
+	for _, pattern := range l.Files {
+		g, err := globpath.Compile(pattern)
+		if err != nil {
+			l.Log.Errorf("Glob %q failed to compile: %v", pattern, err)
+			continue
+		}
+
+		for _, file := range g.Match() {
+			if _, found := l.tailers[file]; found {
+				continue
+			}
+
+			// Always start over if configured, otherwise resume at the last
+			// delivered position if any
+			var offset int64
+			if l.FromBeginning {
+				err = l.checkpoints.Reset(file)
+			} else {
+				offset, err = l.checkpoints.Open(file, fromEnd)
+			}
+			if err != nil {
+				l.Log.Errorf("Determining offset of %q failed: %v", file, err)
+				continue
+			}
+
+			gen := &generation{}
+			tailer, err := tail.TailFile(file,
+				tail.Config{
+					ReOpen:    true,
+					Follow:    true,
+					Location:  &tail.SeekInfo{Whence: 0, Offset: offset},
+					MustExist: true,
+					Poll:      l.WatchMethod == "poll",
+					Logger:    tail.DiscardingLogger,
+					OpenReaderFunc: func(rd io.Reader) io.Reader {
+						gen.opened()
+						return rd
+					},
+				})
+			if err != nil {
+				l.Log.Errorf("Tailing %q failed: %v", file, err)
+				continue
+			}
+			l.Log.Debugf("Tail added for %q at offset %d", file, offset)
+
+			l.wg.Add(1)
+			go l.receiver(tailer, gen, offset)
+			l.tailers[file] = tailer
+		}
+	}
+}
+
+// receiver processes the lines of the tailed file
+func (l *LogMetrics) receiver(tailer *tail.Tail, gen *generation, offset int64) {
+	defer l.wg.Done()
+
+	var opened uint64
+	for line := range tailer.Lines {
+		if line.Err != nil {
+			l.Log.Errorf("Tailing %q failed: %v", tailer.Filename, line.Err)
+			continue
+		}
+
+		// Restart at the beginning if the file was reopened due to rotation
+		// or truncation
+		if current := gen.current(); current != opened {
+			if opened > 0 {
+				if err := l.checkpoints.Reset(tailer.Filename); err != nil {
+					l.Log.Debugf("Resetting offset of %q failed: %v", tailer.Filename, err)
+				}
+				offset = 0
+			}
+			opened = current
+		}
+		offset += int64(len(line.Text)) + 1
+
+		// Fix up files with Windows line endings.
+		text := strings.TrimRight(line.Text, "\r")
+		m := l.process(text, tailer.Filename)
+		if m == nil {
+			l.checkpoints.Advance(tailer.Filename, offset)
+			continue
+		}
+
+		// Wait for room to add metrics
+		select {
+		case <-l.done:
+			return
+		case l.sem <- struct{}{}:
+		}
+		id := l.acc.AddTrackingMetric(m)
+		l.checkpoints.Track(id, tailer.Filename, offset)
+	}
+}
+
+// process applies the patterns in order to the line and returns the metric
+// of the first matching pattern unless the pattern is aggregated
+func (l *LogMetrics) process(line, path string) telegraf.Metric {
+	if line == "" {
+		return nil
+	}
+	l.linesTotal.Add(1)
+
+	for i, p := range l.Patterns {
+		m, matched, err := p.extract(line)
+		if err != nil {
+			l.parseErrors.Add(1)
+			l.Log.Debugf("Applying pattern %q to %q failed: %v", p.Name, line, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		l.linesMatched[i].Add(1)
+
+		if m == nil {
+			return nil
+		}
+		m.AddTag("path", path)
+
+		if a := l.aggregations[i]; a != nil {
+			a.add(m)
+			return nil
+		}
+		return m
+	}
+	l.linesUnmatched.Add(1)
+
+	return nil
+}
+
+func init() {
+	inputs.Add("log_metrics", func() telegraf.Input {
+		return &LogMetrics{}
+	})
+}
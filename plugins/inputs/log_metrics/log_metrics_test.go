@@ -0,0 +1,252 @@
+package log_metrics
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func watchMethod() string {
+	if runtime.GOOS == "windows" {
+		return "poll"
+	}
+	return "inotify"
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []*pattern
+		expected string
+	}{
+		{
+			name:     "no patterns",
+			expected: "no patterns configured",
+		},
+		{
+			name:     "missing name",
+			patterns: []*pattern{{Pattern: "foo"}},
+			expected: "pattern 1: 'name' required",
+		},
+		{
+			name:     "invalid format",
+			patterns: []*pattern{{Name: "foo", Format: "json"}},
+			expected: `pattern 1: invalid 'format' setting "json"`,
+		},
+		{
+			name:     "invalid regex",
+			patterns: []*pattern{{Name: "foo", Format: "regex", Pattern: "("}},
+			expected: `pattern 1: invalid 'pattern' setting "("`,
+		},
+		{
+			name:     "missing grok pattern",
+			patterns: []*pattern{{Name: "foo"}},
+			expected: "pattern 1: 'pattern' required for grok format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &LogMetrics{
+				Files:    []string{"foo.log"},
+				Patterns: tt.patterns,
+				Log:      testutil.Logger{},
+			}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestRouting(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "app.log")
+	content := "GET /index.html 200 12\n" +
+		"level=info msg=started workers=4\n" +
+		"2024-11-01 12:00:00 ERROR disk full\n" +
+		"something completely different\n" +
+		"POST /api 500 230\n"
+	require.NoError(t, os.WriteFile(fn, []byte(content), 0600))
+
+	plugin := &LogMetrics{
+		Files:         []string{fn},
+		FromBeginning: true,
+		WatchMethod:   watchMethod(),
+		Patterns: []*pattern{
+			{
+				Name:    "request",
+				Format:  "regex",
+				Pattern: `^(?P<method>[A-Z]+) (?P<url>\S+) (?P<status>\d+) (?P<duration>\d+)$`,
+				TagKeys: []string{"method", "status"},
+			},
+			{
+				Name:    "event",
+				Format:  "logfmt",
+				Match:   "^level=",
+				TagKeys: []string{"level"},
+			},
+			{
+				Name:           "error",
+				Pattern:        "%{MYTIME} %{LOGLEVEL:level:tag} %{GREEDYDATA:message}",
+				CustomPatterns: "MYTIME %{TIMESTAMP_ISO8601}",
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 4
+	}, 3*time.Second, 10*time.Millisecond)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"request",
+			map[string]string{"method": "GET", "status": "200", "path": fn},
+			map[string]interface{}{"url": "/index.html", "duration": int64(12)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"event",
+			map[string]string{"level": "info", "path": fn},
+			map[string]interface{}{"msg": "started", "workers": int64(4)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"error",
+			map[string]string{"level": "ERROR", "path": fn},
+			map[string]interface{}{"message": "disk full"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"request",
+			map[string]string{"method": "POST", "status": "500", "path": fn},
+			map[string]interface{}{"url": "/api", "duration": int64(230)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	// Check the counters
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	expectedCounters := []telegraf.Metric{
+		metric.New(
+			"log_metrics",
+			map[string]string{},
+			map[string]interface{}{
+				"lines_total":     uint64(5),
+				"lines_unmatched": uint64(1),
+				"parse_errors":    uint64(0),
+			},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"log_metrics",
+			map[string]string{"pattern": "request"},
+			map[string]interface{}{"lines_matched": uint64(2)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"log_metrics",
+			map[string]string{"pattern": "event"},
+			map[string]interface{}{"lines_matched": uint64(1)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"log_metrics",
+			map[string]string{"pattern": "error"},
+			map[string]interface{}{"lines_matched": uint64(1)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expectedCounters, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestAggregation(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "app.log")
+	content := "GET 200 10\nGET 200 30\nGET 404 5\nPOST 200 20\n"
+	require.NoError(t, os.WriteFile(fn, []byte(content), 0600))
+
+	plugin := &LogMetrics{
+		Files:         []string{fn},
+		FromBeginning: true,
+		WatchMethod:   watchMethod(),
+		Patterns: []*pattern{
+			{
+				Name:      "request",
+				Format:    "regex",
+				Pattern:   `^(?P<method>GET) (?P<status>\d+) (?P<duration>\d+)$`,
+				TagKeys:   []string{"method", "status"},
+				Aggregate: true,
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return plugin.linesTotal.Load() >= 4
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// No metrics must be produced for the lines themselves
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	require.NoError(t, plugin.Gather(&acc))
+	expected := []telegraf.Metric{
+		metric.New(
+			"request",
+			map[string]string{"method": "GET", "status": "200", "path": fn},
+			map[string]interface{}{
+				"duration_count": int64(2),
+				"duration_sum":   float64(40),
+				"duration_min":   float64(10),
+				"duration_max":   float64(30),
+				"duration_mean":  float64(20),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"request",
+			map[string]string{"method": "GET", "status": "404", "path": fn},
+			map[string]interface{}{
+				"duration_count": int64(1),
+				"duration_sum":   float64(5),
+				"duration_min":   float64(5),
+				"duration_max":   float64(5),
+				"duration_mean":  float64(5),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	var actual []telegraf.Metric
+	for _, m := range acc.GetTelegrafMetrics() {
+		if m.Name() == "request" {
+			actual = append(actual, m)
+		}
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// The aggregation must be reset after each interval
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	for _, m := range acc.GetTelegrafMetrics() {
+		require.Equal(t, "log_metrics", m.Name())
+	}
+}
@@ -0,0 +1,174 @@
+//go:build !solaris
+
+package log_metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
+)
+
+type pattern struct {
+	Name               string   `toml:"name"`
+	Format             string   `toml:"format"`
+	Match              string   `toml:"match"`
+	Pattern            string   `toml:"pattern"`
+	CustomPatterns     string   `toml:"custom_patterns"`
+	CustomPatternFiles []string `toml:"custom_pattern_files"`
+	Timezone           string   `toml:"timezone"`
+	TagKeys            []string `toml:"tag_keys"`
+	Aggregate          bool     `toml:"aggregate"`
+
+	match     *regexp.Regexp
+	extractor func(line string) (telegraf.Metric, bool, error)
+	tagFilter filter.Filter
+}
+
+func (p *pattern) init(log telegraf.Logger) error {
+	if p.Name == "" {
+		return errors.New("'name' required")
+	}
+
+	if p.Format == "" {
+		p.Format = "grok"
+	}
+	if err := choice.Check(p.Format, []string{"grok", "regex", "logfmt"}); err != nil {
+		return fmt.Errorf("invalid 'format' setting %q", p.Format)
+	}
+
+	if p.Match != "" {
+		re, err := regexp.Compile(p.Match)
+		if err != nil {
+			return fmt.Errorf("invalid 'match' setting %q: %w", p.Match, err)
+		}
+		p.match = re
+	}
+
+	var err error
+	if p.tagFilter, err = filter.Compile(p.TagKeys); err != nil {
+		return fmt.Errorf("invalid 'tag_keys' setting: %w", err)
+	}
+
+	switch p.Format {
+	case "grok":
+		return p.initGrok(log)
+	case "regex":
+		return p.initRegex()
+	case "logfmt":
+		return p.initLogfmt()
+	}
+	return nil
+}
+
+func (p *pattern) initGrok(log telegraf.Logger) error {
+	if p.Pattern == "" {
+		return errors.New("'pattern' required for grok format")
+	}
+
+	parser := &grok.Parser{
+		Patterns:           []string{p.Pattern},
+		CustomPatterns:     p.CustomPatterns,
+		CustomPatternFiles: p.CustomPatternFiles,
+		Measurement:        p.Name,
+		Timezone:           p.Timezone,
+		Log:                log,
+	}
+	if err := parser.Init(); err != nil {
+		return fmt.Errorf("initializing grok parser failed: %w", err)
+	}
+
+	p.extractor = func(line string) (telegraf.Metric, bool, error) {
+		m, err := parser.ParseLine(line)
+		if err != nil {
+			return nil, false, err
+		}
+		return m, m != nil, nil
+	}
+	return nil
+}
+
+func (p *pattern) initRegex() error {
+	if p.Pattern == "" {
+		return errors.New("'pattern' required for regex format")
+	}
+
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid 'pattern' setting %q: %w", p.Pattern, err)
+	}
+	names := re.SubexpNames()
+
+	p.extractor = func(line string) (telegraf.Metric, bool, error) {
+		groups := re.FindStringSubmatch(line)
+		if groups == nil {
+			return nil, false, nil
+		}
+
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+		for i, name := range names {
+			if name == "" || groups[i] == "" {
+				continue
+			}
+			if p.tagFilter != nil && p.tagFilter.Match(name) {
+				tags[name] = groups[i]
+			} else {
+				fields[name] = convert(groups[i])
+			}
+		}
+		if len(fields) == 0 {
+			return nil, true, nil
+		}
+		return metric.New(p.Name, tags, fields, time.Now()), true, nil
+	}
+	return nil
+}
+
+func (p *pattern) initLogfmt() error {
+	parser := &logfmt.Parser{TagKeys: p.TagKeys}
+	if err := parser.Init(); err != nil {
+		return fmt.Errorf("initializing logfmt parser failed: %w", err)
+	}
+
+	p.extractor = func(line string) (telegraf.Metric, bool, error) {
+		// Lines not in logfmt format are not matched by the pattern
+		m, err := parser.ParseLine(line)
+		if err != nil {
+			return nil, false, nil //nolint:nilerr // decoding errors mean the line does not match
+		}
+		m.SetName(p.Name)
+		return m, true, nil
+	}
+	return nil
+}
+
+// extract applies the pattern to the given line and returns if the line was
+// matched and the extracted metric if any
+func (p *pattern) extract(line string) (telegraf.Metric, bool, error) {
+	if p.match != nil && !p.match.MatchString(line) {
+		return nil, false, nil
+	}
+	return p.extractor(line)
+}
+
+func convert(value string) interface{} {
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseBool(value); err == nil {
+		return v
+	}
+	return value
+}
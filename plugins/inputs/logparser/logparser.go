@@ -5,7 +5,8 @@ package logparser
 
 import (
 	_ "embed"
-	"fmt"
+	"errors"
+	"io"
 	"strings"
 	"sync"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 )
@@ -22,12 +24,14 @@ import (
 var sampleConfig string
 
 var (
-	offsets      = make(map[string]int64)
+	offsets      = checkpoint.State{}
 	offsetsMutex = new(sync.Mutex)
 )
 
 const (
 	defaultWatchMethod = "inotify"
+
+	maxUndeliveredLines = 1000
 )
 
 type LogParser struct {
@@ -37,13 +41,14 @@ type LogParser struct {
 	GrokConfig    grokConfig      `toml:"grok"`
 	Log           telegraf.Logger `toml:"-"`
 
-	tailers map[string]*tail.Tail
-	offsets map[string]int64
-	lines   chan logEntry
-	done    chan struct{}
-	wg      sync.WaitGroup
+	tailers     map[string]*tail.Tail
+	checkpoints *checkpoint.Checkpoints
+	lines       chan logEntry
+	done        chan struct{}
+	wg          sync.WaitGroup
 
-	acc telegraf.Accumulator
+	acc telegraf.TrackingAccumulator
+	sem chan struct{}
 
 	sync.Mutex
 	grokParser telegraf.Parser
@@ -60,8 +65,28 @@ type grokConfig struct {
 }
 
 type logEntry struct {
-	path string
-	line string
+	path   string
+	line   string
+	offset int64
+}
+
+// generation counts the (re-)opens of a tailed file to detect rotation and
+// truncation while receiving lines
+type generation struct {
+	count uint64
+	sync.Mutex
+}
+
+func (g *generation) opened() {
+	g.Lock()
+	defer g.Unlock()
+	g.count++
+}
+
+func (g *generation) current() uint64 {
+	g.Lock()
+	defer g.Unlock()
+	return g.count
 }
 
 func (*LogParser) SampleConfig() string {
@@ -70,6 +95,22 @@ func (*LogParser) SampleConfig() string {
 
 func (l *LogParser) Init() error {
 	l.Log.Warnf(`The logparser plugin is deprecated; please use the 'tail' input with the 'grok' data_format`)
+	if l.checkpoints == nil {
+		l.checkpoints = checkpoint.New()
+	}
+	return nil
+}
+
+func (l *LogParser) GetState() interface{} {
+	return l.checkpoints.GetState()
+}
+
+func (l *LogParser) SetState(state interface{}) error {
+	offsetsState, ok := state.(checkpoint.State)
+	if !ok {
+		return errors.New("state has to be of type 'checkpoint.State'")
+	}
+	l.checkpoints.SetState(offsetsState)
 	return nil
 }
 
@@ -77,7 +118,8 @@ func (l *LogParser) Start(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
-	l.acc = acc
+	l.acc = acc.WithTracking(maxUndeliveredLines)
+	l.sem = make(chan struct{}, maxUndeliveredLines)
 	l.lines = make(chan logEntry, 1000)
 	l.done = make(chan struct{})
 	l.tailers = make(map[string]*tail.Tail)
@@ -104,16 +146,26 @@ func (l *LogParser) Start(acc telegraf.Accumulator) error {
 	l.grokParser = &parser
 	models.SetLoggerOnPlugin(l.grokParser, l.Log)
 
-	l.wg.Add(1)
+	l.wg.Add(2)
 	go l.parser()
+	go func() {
+		defer l.wg.Done()
+		for {
+			select {
+			case <-l.done:
+				return
+			case info := <-l.acc.Delivered():
+				l.checkpoints.Delivered(info)
+				<-l.sem
+			}
+		}
+	}()
 
 	l.tailNewFiles(l.FromBeginning)
 
-	// clear offsets
-	l.offsets = make(map[string]int64)
 	// assumption that once Start is called, all parallel plugins have already been initialized
 	offsetsMutex.Lock()
-	offsets = make(map[string]int64)
+	offsets = checkpoint.State{}
 	offsetsMutex.Unlock()
 
 	return nil
@@ -134,16 +186,6 @@ func (l *LogParser) Stop() {
 	defer l.Unlock()
 
 	for _, t := range l.tailers {
-		if !l.FromBeginning {
-			// store offset for resume
-			offset, err := t.Tell()
-			if err == nil {
-				l.offsets[t.Filename] = offset
-				l.Log.Debugf("Recording offset %d for file: %v", offset, t.Filename)
-			} else {
-				l.acc.AddError(fmt.Errorf("error recording offset for file %s", t.Filename))
-			}
-		}
 		err := t.Stop()
 
 		// message for a stopped tailer
@@ -156,10 +198,14 @@ func (l *LogParser) Stop() {
 	close(l.done)
 	l.wg.Wait()
 
-	// persist offsets
+	// persist the delivered offsets
+	state := l.checkpoints.GetState()
 	offsetsMutex.Lock()
-	for k, v := range l.offsets {
-		offsets[k] = v
+	if offsets.Files == nil {
+		offsets.Files = make(map[string]checkpoint.Entry, len(state.Files))
+	}
+	for k, v := range state.Files {
+		offsets.Files[k] = v
 	}
 	offsetsMutex.Unlock()
 }
@@ -187,30 +233,33 @@ func (l *LogParser) tailNewFiles(fromBeginning bool) {
 				continue
 			}
 
-			var seek *tail.SeekInfo
-			if !fromBeginning {
-				if offset, ok := l.offsets[file]; ok {
-					l.Log.Debugf("Using offset %d for file: %v", offset, file)
-					seek = &tail.SeekInfo{
-						Whence: 0,
-						Offset: offset,
-					}
-				} else {
-					seek = &tail.SeekInfo{
-						Whence: 2,
-						Offset: 0,
-					}
-				}
+			// Always start over if configured, otherwise resume at the last
+			// delivered position if any
+			var offset int64
+			if l.FromBeginning {
+				err = l.checkpoints.Reset(file)
+			} else {
+				offset, err = l.checkpoints.Open(file, !fromBeginning)
+			}
+			if err != nil {
+				l.acc.AddError(err)
+				continue
 			}
+			l.Log.Debugf("Using offset %d for file: %v", offset, file)
 
+			gen := &generation{}
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
 					Follow:    true,
-					Location:  seek,
+					Location:  &tail.SeekInfo{Whence: 0, Offset: offset},
 					MustExist: true,
 					Poll:      poll,
 					Logger:    tail.DiscardingLogger,
+					OpenReaderFunc: func(rd io.Reader) io.Reader {
+						gen.opened()
+						return rd
+					},
 				})
 			if err != nil {
 				l.acc.AddError(err)
@@ -221,7 +270,7 @@ func (l *LogParser) tailNewFiles(fromBeginning bool) {
 
 			// create a goroutine for each "tailer"
 			l.wg.Add(1)
-			go l.receiver(tailer)
+			go l.receiver(tailer, gen, offset)
 			l.tailers[file] = tailer
 		}
 	}
@@ -229,9 +278,10 @@ func (l *LogParser) tailNewFiles(fromBeginning bool) {
 
 // receiver is launched as a goroutine to continuously watch a tailed logfile
 // for changes and send any log lines down the l.lines channel.
-func (l *LogParser) receiver(tailer *tail.Tail) {
+func (l *LogParser) receiver(tailer *tail.Tail, gen *generation, offset int64) {
 	defer l.wg.Done()
 
+	var opened uint64
 	var line *tail.Line
 	for line = range tailer.Lines {
 		if line.Err != nil {
@@ -240,12 +290,26 @@ func (l *LogParser) receiver(tailer *tail.Tail) {
 			continue
 		}
 
+		// Restart at the beginning if the file was reopened due to rotation
+		// or truncation
+		if current := gen.current(); current != opened {
+			if opened > 0 {
+				if err := l.checkpoints.Reset(tailer.Filename); err != nil {
+					l.Log.Debugf("Resetting offset of file %s failed: %v", tailer.Filename, err)
+				}
+				offset = 0
+			}
+			opened = current
+		}
+		offset += int64(len(line.Text)) + 1
+
 		// Fix up files with Windows line endings.
 		text := strings.TrimRight(line.Text, "\r")
 
 		entry := logEntry{
-			path: tailer.Filename,
-			line: text,
+			path:   tailer.Filename,
+			line:   text,
+			offset: offset,
 		}
 
 		select {
@@ -270,33 +334,40 @@ func (l *LogParser) parser() {
 			return
 		case entry = <-l.lines:
 			if entry.line == "" || entry.line == "\n" {
+				l.checkpoints.Advance(entry.path, entry.offset)
 				continue
 			}
 		}
 		m, err = l.grokParser.ParseLine(entry.line)
-		if err == nil {
-			if m != nil {
-				tags := m.Tags()
-				tags["path"] = entry.path
-				l.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
-			}
-		} else {
+		if err != nil {
 			l.Log.Errorf("Error parsing log line: %s", err.Error())
 		}
+		if m == nil {
+			l.checkpoints.Advance(entry.path, entry.offset)
+			continue
+		}
+		m.AddTag("path", entry.path)
+
+		// Wait for room to add metrics
+		select {
+		case <-l.done:
+			return
+		case l.sem <- struct{}{}:
+		}
+		id := l.acc.AddTrackingMetric(m)
+		l.checkpoints.Track(id, entry.path, entry.offset)
 	}
 }
 
 func newLogParser() *LogParser {
 	offsetsMutex.Lock()
-	offsetsCopy := make(map[string]int64, len(offsets))
-	for k, v := range offsets {
-		offsetsCopy[k] = v
-	}
+	checkpoints := checkpoint.New()
+	checkpoints.SetState(offsets)
 	offsetsMutex.Unlock()
 
 	return &LogParser{
 		WatchMethod: defaultWatchMethod,
-		offsets:     offsetsCopy,
+		checkpoints: checkpoints,
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -23,6 +24,8 @@ func TestStartNoParsers(t *testing.T) {
 		Files:         []string{filepath.Join(testdataDir, "*.log")},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	require.NoError(t, logparser.Start(&acc))
 }
@@ -38,6 +41,8 @@ func TestGrokParseLogFilesNonExistPattern(t *testing.T) {
 		},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	err := logparser.Start(&acc)
 	require.Error(t, err)
@@ -55,6 +60,8 @@ func TestGrokParseLogFiles(t *testing.T) {
 		Files:         []string{filepath.Join(testdataDir, "*.log")},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	require.NoError(t, logparser.Start(&acc))
 	acc.Wait(3)
@@ -133,6 +140,8 @@ func TestGrokParseLogFilesAppearLater(t *testing.T) {
 		},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	require.NoError(t, logparser.Start(&acc))
 
@@ -176,6 +185,8 @@ func TestGrokParseLogFilesOneBad(t *testing.T) {
 		},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	acc.SetDebug(true)
 	require.NoError(t, logparser.Start(&acc))
@@ -208,6 +219,8 @@ func TestGrokParseLogFiles_TimestampInEpochMilli(t *testing.T) {
 		Files:         []string{filepath.Join(testdataDir, "test_c.log")},
 	}
 
+	require.NoError(t, logparser.Init())
+
 	acc := testutil.Accumulator{}
 	acc.SetDebug(true)
 	require.NoError(t, logparser.Start(&acc))
@@ -237,3 +250,49 @@ func getTestdataDir() string {
 
 	return filepath.Join(dir, "testdata")
 }
+
+func TestStatePersistence(t *testing.T) {
+	lines := []string{
+		"1568723594631 1.25 200 192.168.1.1 5.432µs 101\n",
+		"1568723594632 2.5 404 192.168.1.2 1.234µs 102\n",
+	}
+	content := lines[0] + lines[1]
+	fn := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(fn, []byte(content), 0600))
+
+	logparser := &LogParser{
+		Log: testutil.Logger{},
+		GrokConfig: grokConfig{
+			Patterns:           []string{"%{TEST_LOG_C}"},
+			CustomPatternFiles: []string{filepath.Join(testdataDir, "test-patterns")},
+		},
+		Files: []string{fn},
+	}
+	require.NoError(t, logparser.Init())
+
+	// Skip the first line using the "persisted" state
+	var pi telegraf.StatefulPlugin = logparser
+	require.NoError(t, pi.SetState(checkpoint.State{
+		Files: map[string]checkpoint.Entry{fn: {Offset: int64(len(lines[0]))}},
+	}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, logparser.Start(&acc))
+	defer logparser.Stop()
+	acc.Wait(1)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "404", metrics[0].Tags()["response_code"])
+
+	// The offset must only advance after delivery
+	state, ok := pi.GetState().(checkpoint.State)
+	require.True(t, ok, "state is not a checkpoint.State")
+	require.EqualValues(t, len(lines[0]), state.Files[fn].Offset)
+
+	metrics[0].Accept()
+	require.Eventually(t, func() bool {
+		state := pi.GetState().(checkpoint.State)
+		return state.Files[fn].Offset == int64(len(content))
+	}, time.Second, 10*time.Millisecond)
+}
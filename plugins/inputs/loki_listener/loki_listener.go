@@ -0,0 +1,153 @@
+//go:generate ../../../tools/config_includer/generator
+//go:generate ../../../tools/readme_config_includer/generator
+package loki_listener
+
+import (
+	_ "embed"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/httpserver"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type LokiListener struct {
+	ServiceAddress string          `toml:"service_address"`
+	Path           string          `toml:"path"`
+	Log            telegraf.Logger `toml:"-"`
+	httpserver.Config
+
+	server       *httpserver.Server
+	backpressure telegraf.Backpressure
+	acc          telegraf.Accumulator
+}
+
+func (*LokiListener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *LokiListener) Init() error {
+	if l.ServiceAddress == "" {
+		l.ServiceAddress = ":3100"
+	}
+	if l.Path == "" {
+		l.Path = "/loki/api/v1/push"
+	}
+	if !strings.HasPrefix(l.Path, "/") {
+		return fmt.Errorf("invalid 'path' setting %q", l.Path)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(l.Path, l.servePush)
+
+	server, err := l.Config.NewServer(l.ServiceAddress, mux, l.Log)
+	if err != nil {
+		return err
+	}
+	server.SetBackpressure(l.backpressure)
+	l.server = server
+
+	return nil
+}
+
+func (l *LokiListener) SetBackpressure(bp telegraf.Backpressure) {
+	l.backpressure = bp
+}
+
+func (l *LokiListener) Start(acc telegraf.Accumulator) error {
+	l.acc = acc
+	return l.server.Start()
+}
+
+func (*LokiListener) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (l *LokiListener) Stop() {
+	l.server.Close()
+}
+
+func (l *LokiListener) servePush(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		http.Error(res, "only POST requests are accepted", http.StatusMethodNotAllowed)
+		return
+	}
+	if l.server.Throttle(res) {
+		return
+	}
+
+	// Loki defaults to protobuf for requests without content-type
+	contentType := "application/x-protobuf"
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			l.fail(res, fmt.Errorf("invalid content type %q: %w", ct, err), http.StatusUnsupportedMediaType)
+			return
+		}
+		contentType = mt
+	}
+
+	body, err := l.server.ReadBody(res, req)
+	if err != nil {
+		l.fail(res, err, httpserver.StatusCode(err))
+		return
+	}
+
+	var streams []stream
+	switch contentType {
+	case "application/x-protobuf":
+		// Protobuf payloads are always snappy block compressed without
+		// announcing it in the content-encoding header
+		if req.Header.Get("Content-Encoding") == "" {
+			body, err = l.server.DecodeSnappy(body)
+			if err != nil {
+				l.fail(res, err, httpserver.StatusCode(err))
+				return
+			}
+		}
+		streams, err = decodeProtobuf(body)
+	case "application/json":
+		streams, err = decodeJSON(body)
+	default:
+		l.fail(res, fmt.Errorf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		l.fail(res, fmt.Errorf("decoding request failed: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	for _, s := range streams {
+		for _, e := range s.entries {
+			fields := make(map[string]interface{}, len(e.metadata)+1)
+			for k, v := range e.metadata {
+				fields[k] = v
+			}
+			fields["message"] = e.line
+			l.acc.AddFields("loki", fields, s.labels, e.timestamp)
+		}
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (l *LokiListener) fail(res http.ResponseWriter, err error, code int) {
+	l.Log.Debugf("Request failed with status %d: %v", code, err)
+	http.Error(res, err.Error(), code)
+}
+
+func init() {
+	inputs.Add("loki_listener", func() telegraf.Input {
+		return &LokiListener{
+			ServiceAddress: ":3100",
+			Path:           "/loki/api/v1/push",
+		}
+	})
+}
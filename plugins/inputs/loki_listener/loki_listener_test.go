@@ -0,0 +1,277 @@
+package loki_listener
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/httpserver"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestPlugin(t *testing.T) (*LokiListener, *testutil.Accumulator, string) {
+	plugin := &LokiListener{
+		ServiceAddress: "127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	t.Cleanup(plugin.Stop)
+
+	return plugin, &acc, "http://" + plugin.server.Address().String() + "/loki/api/v1/push"
+}
+
+func post(t *testing.T, url, contentType, contentEncoding string, body []byte) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp
+}
+
+// encodePushRequest creates a "logproto.PushRequest" protobuf message
+func encodePushRequest(labels string, entries ...[]byte) []byte {
+	var s []byte
+	s = protowire.AppendTag(s, 1, protowire.BytesType)
+	s = protowire.AppendString(s, labels)
+	for _, e := range entries {
+		s = protowire.AppendTag(s, 2, protowire.BytesType)
+		s = protowire.AppendBytes(s, e)
+	}
+
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	return protowire.AppendBytes(buf, s)
+}
+
+func encodeEntry(ts time.Time, line string, metadata ...string) []byte {
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(ts.Unix()))
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(ts.Nanosecond()))
+
+	var e []byte
+	e = protowire.AppendTag(e, 1, protowire.BytesType)
+	e = protowire.AppendBytes(e, timestamp)
+	e = protowire.AppendTag(e, 2, protowire.BytesType)
+	e = protowire.AppendString(e, line)
+	for i := 0; i+1 < len(metadata); i += 2 {
+		var pair []byte
+		pair = protowire.AppendTag(pair, 1, protowire.BytesType)
+		pair = protowire.AppendString(pair, metadata[i])
+		pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+		pair = protowire.AppendString(pair, metadata[i+1])
+		e = protowire.AppendTag(e, 3, protowire.BytesType)
+		e = protowire.AppendBytes(e, pair)
+	}
+	return e
+}
+
+func TestInitFail(t *testing.T) {
+	plugin := &LokiListener{
+		Path: "push",
+		Log:  testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid 'path' setting")
+
+	plugin.Path = "/push"
+	plugin.ServiceAddress = "udp://:3100"
+	require.ErrorContains(t, plugin.Init(), "unknown protocol")
+}
+
+func TestProtobuf(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	ts := time.Unix(1700000000, 123456789)
+	msg := encodePushRequest(
+		`{job="varlogs", filename="/var/log/syslog", msg="quoted \"value\", here"}`,
+		encodeEntry(ts, "first line"),
+		encodeEntry(ts.Add(time.Second), "second line", "trace_id", "0242ac120002"),
+	)
+
+	resp := post(t, url, "application/x-protobuf", "", snappy.Encode(nil, msg))
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	tags := map[string]string{
+		"job":      "varlogs",
+		"filename": "/var/log/syslog",
+		"msg":      `quoted "value", here`,
+	}
+	expected := []telegraf.Metric{
+		metric.New("loki", tags, map[string]interface{}{"message": "first line"}, ts),
+		metric.New(
+			"loki",
+			tags,
+			map[string]interface{}{"message": "second line", "trace_id": "0242ac120002"},
+			ts.Add(time.Second),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestJSON(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	body := []byte(`{"streams": [
+		{
+			"stream": {"job": "fluent-bit", "level": "info"},
+			"values": [
+				["1700000000000000000", "first line"],
+				["1700000001000000000", "second line", {"trace_id": "0242ac120002"}]
+			]
+		}
+	]}`)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	resp := post(t, url, "application/json; charset=utf-8", "", body)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = post(t, url, "application/json", "gzip", buf.Bytes())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	tags := map[string]string{"job": "fluent-bit", "level": "info"}
+	batch := []telegraf.Metric{
+		metric.New("loki", tags, map[string]interface{}{"message": "first line"}, time.Unix(1700000000, 0)),
+		metric.New(
+			"loki",
+			tags,
+			map[string]interface{}{"message": "second line", "trace_id": "0242ac120002"},
+			time.Unix(1700000001, 0),
+		),
+	}
+	expected := append(batch, batch...)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestInvalidRequests(t *testing.T) {
+	plugin := &LokiListener{
+		ServiceAddress: "127.0.0.1:0",
+		Config:         httpserver.Config{MaxBodySize: config.Size(1024)},
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	url := "http://" + plugin.server.Address().String() + "/loki/api/v1/push"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		expected    int
+	}{
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        []byte("foo"),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "invalid snappy",
+			contentType: "application/x-protobuf",
+			body:        []byte("foo"),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid labels",
+			contentType: "application/x-protobuf",
+			body:        snappy.Encode(nil, encodePushRequest(`job="test"`)),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        []byte(`{"streams": [{"values": [["now", "line"]]}]}`),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "decompressed size exceeded",
+			contentType: "application/x-protobuf",
+			body:        snappy.Encode(nil, make([]byte, 2048)),
+			expected:    http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, url, tt.contentType, "", tt.body)
+			require.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels(`{}`)
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	labels, err = parseLabels(`{ a="1",b = "x,y=z" , c="ä"}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1", "b": "x,y=z", "c": "ä"}, labels)
+
+	for _, input := range []string{`{a=1}`, `{a="1" b="2"}`, `{="1"}`, `a="1"`} {
+		_, err := parseLabels(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	bp := &testutil.Backpressure{}
+	plugin := &LokiListener{
+		ServiceAddress: "127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	plugin.SetBackpressure(bp)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	url := "http://" + plugin.server.Address().String() + "/loki/api/v1/push"
+
+	body := []byte(`{"streams": [{"stream": {"job": "test"}, "values": [["1700000000000000000", "line"]]}]}`)
+
+	bp.Throttle(true)
+	resp := post(t, url, "application/json", "", body)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "5", resp.Header.Get("Retry-After"))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	bp.Throttle(false)
+	resp = post(t, url, "application/json", "", body)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+}
@@ -0,0 +1,256 @@
+package loki_listener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// entry is a single log line of a stream
+type entry struct {
+	timestamp time.Time
+	line      string
+	metadata  map[string]string
+}
+
+// stream is a set of log lines sharing the same labels
+type stream struct {
+	labels  map[string]string
+	entries []entry
+}
+
+// decodeProtobuf decodes a "logproto.PushRequest" message. The message is
+// decoded by hand to avoid depending on the Loki code base, see
+// https://github.com/grafana/loki/blob/main/pkg/push/push.proto
+func decodeProtobuf(buf []byte) ([]stream, error) {
+	var streams []stream
+	err := walkMessage(buf, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		s, err := decodeStream(value)
+		if err != nil {
+			return fmt.Errorf("decoding stream %d failed: %w", len(streams), err)
+		}
+		streams = append(streams, s)
+		return nil
+	})
+	return streams, err
+}
+
+func decodeStream(buf []byte) (stream, error) {
+	var s stream
+	err := walkMessage(buf, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			labels, err := parseLabels(string(value))
+			if err != nil {
+				return err
+			}
+			s.labels = labels
+		case 2:
+			e, err := decodeEntry(value)
+			if err != nil {
+				return fmt.Errorf("decoding entry %d failed: %w", len(s.entries), err)
+			}
+			s.entries = append(s.entries, e)
+		}
+		return nil
+	})
+	return s, err
+}
+
+func decodeEntry(buf []byte) (entry, error) {
+	var e entry
+	var seconds, nanos int64
+	err := walkMessage(buf, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			return walkMessage(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ != protowire.VarintType {
+					return nil
+				}
+				v, _ := protowire.ConsumeVarint(value)
+				switch num {
+				case 1:
+					seconds = int64(v)
+				case 2:
+					nanos = int64(int32(v))
+				}
+				return nil
+			})
+		case 2:
+			e.line = string(value)
+		case 3:
+			var name, val string
+			err := walkMessage(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ != protowire.BytesType {
+					return nil
+				}
+				switch num {
+				case 1:
+					name = string(value)
+				case 2:
+					val = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if e.metadata == nil {
+				e.metadata = make(map[string]string)
+			}
+			e.metadata[name] = val
+		}
+		return nil
+	})
+	e.timestamp = time.Unix(seconds, nanos)
+	return e, err
+}
+
+// walkMessage calls the given function for each field of the protobuf
+// message with the raw value. Varint values are passed in their encoded form
+// and length-delimited values without their length prefix.
+func walkMessage(buf []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(buf)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			value, n = v, m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, buf)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value = buf[:n]
+		}
+		buf = buf[n:]
+
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLabels parses a label set in the Prometheus notation used by Loki,
+// e.g. `{job="varlogs", host="server01"}`
+func parseLabels(s string) (map[string]string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid labels %q", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+
+	labels := make(map[string]string)
+	for s != "" {
+		name, rest, found := strings.Cut(s, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid label %q", s)
+		}
+		rest = strings.TrimSpace(rest)
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for label %q: %w", name, err)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for label %q: %w", name, err)
+		}
+		labels[name] = value
+
+		s = strings.TrimSpace(rest[len(quoted):])
+		if s == "" {
+			break
+		}
+		if !strings.HasPrefix(s, ",") {
+			return nil, fmt.Errorf("expected ',' after label %q", name)
+		}
+		s = strings.TrimSpace(s[1:])
+	}
+	return labels, nil
+}
+
+type jsonPushRequest struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+// decodeJSON decodes a push request in the JSON notation, e.g.
+// {"streams": [{"stream": {"job": "test"}, "values": [["<unix ns>", "line", {"meta": "data"}]]}]}
+func decodeJSON(buf []byte) ([]stream, error) {
+	var req jsonPushRequest
+	if err := json.Unmarshal(buf, &req); err != nil {
+		return nil, err
+	}
+
+	streams := make([]stream, 0, len(req.Streams))
+	for i, rs := range req.Streams {
+		s := stream{
+			labels:  rs.Stream,
+			entries: make([]entry, 0, len(rs.Values)),
+		}
+		for j, v := range rs.Values {
+			e, err := decodeJSONEntry(v)
+			if err != nil {
+				return nil, fmt.Errorf("decoding value %d of stream %d failed: %w", j, i, err)
+			}
+			s.entries = append(s.entries, e)
+		}
+		streams = append(streams, s)
+	}
+	return streams, nil
+}
+
+func decodeJSONEntry(value []json.RawMessage) (entry, error) {
+	var e entry
+	if len(value) < 2 || len(value) > 3 {
+		return e, errors.New("expected timestamp, line and optional structured metadata")
+	}
+
+	var ts string
+	if err := json.Unmarshal(value[0], &ts); err != nil {
+		return e, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return e, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	e.timestamp = time.Unix(0, ns)
+
+	if err := json.Unmarshal(value[1], &e.line); err != nil {
+		return e, fmt.Errorf("invalid line: %w", err)
+	}
+
+	if len(value) == 3 {
+		if err := json.Unmarshal(value[2], &e.metadata); err != nil {
+			return e, fmt.Errorf("invalid structured metadata: %w", err)
+		}
+	}
+	return e, nil
+}
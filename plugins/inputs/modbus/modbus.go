@@ -217,6 +217,29 @@ func (m *Modbus) Init() error {
 	return nil
 }
 
+// Probe checks that the controller is reachable and all configured slaves
+// respond to the configured requests
+func (m *Modbus) Probe() error {
+	if !m.isConnected {
+		if err := m.connect(); err != nil {
+			return fmt.Errorf("connecting to controller %q failed: %w", m.Controller, err)
+		}
+	}
+
+	for slaveID, requests := range m.requests {
+		if err := m.readSlaveData(slaveID, requests); err != nil {
+			return fmt.Errorf("slave %d on controller %q: %w", slaveID, m.Controller, err)
+		}
+	}
+
+	// Disconnect after read if configured
+	if m.Workarounds.CloseAfterGather {
+		return m.disconnect()
+	}
+
+	return nil
+}
+
 func (m *Modbus) Gather(acc telegraf.Accumulator) error {
 	if !m.isConnected {
 		if err := m.connect(); err != nil {
@@ -247,6 +247,34 @@ func TestRetryFailExhausted(t *testing.T) {
 	require.ErrorContains(t, acc.FirstError(), `slave 1 on controller "tcp://localhost:1502": modbus: exception '6' (server device busy)`)
 }
 
+func TestProbe(t *testing.T) {
+	serv := mbserver.NewServer()
+	require.NoError(t, serv.ListenTCP("localhost:1502"))
+	defer serv.Close()
+
+	modbus := Modbus{
+		Name:       "TestProbe",
+		Controller: "tcp://localhost:1502",
+		Log:        testutil.Logger{Quiet: true},
+	}
+	modbus.SlaveID = 1
+	modbus.Coils = []fieldDefinition{
+		{
+			Name:    "coil",
+			Address: []uint16{0},
+		},
+	}
+	require.NoError(t, modbus.Init())
+	require.NoError(t, modbus.Probe())
+
+	// Make the read on coils fail preventing the plugin from working
+	serv.RegisterFunctionHandler(1,
+		func(*mbserver.Server, mbserver.Framer) ([]byte, *mbserver.Exception) {
+			return nil, &mbserver.IllegalFunction
+		})
+	require.ErrorContains(t, modbus.Probe(), `slave 1 on controller "tcp://localhost:1502"`)
+}
+
 func TestRetryFailIllegal(t *testing.T) {
 	maxretries := 2
 
@@ -2,6 +2,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"errors"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/mysql/v1"
@@ -67,6 +69,10 @@ type Mysql struct {
 	Log                                 telegraf.Logger  `toml:"-"`
 	tls.ClientConfig
 
+	// Discovery of additional servers
+	Discovery *discovery.Discovery `toml:"discovery"`
+
+	tlsID               string
 	lastT               time.Time
 	getStatusQuery      string
 	loggedConvertFields map[string]bool
@@ -88,7 +94,7 @@ func (m *Mysql) Init() error {
 		m.getStatusQuery = slaveStatusQuery
 	}
 	// Default to localhost if nothing specified.
-	if len(m.Servers) == 0 {
+	if len(m.Servers) == 0 && m.Discovery == nil {
 		s := config.NewSecret([]byte(localhost))
 		m.Servers = append(m.Servers, &s)
 	}
@@ -103,13 +109,13 @@ func (m *Mysql) Init() error {
 	if err != nil {
 		return fmt.Errorf("cannot create UUID: %w", err)
 	}
-	tlsid := "custom-" + tlsuuid.String()
+	m.tlsID = "custom-" + tlsuuid.String()
 	tlsConfig, err := m.ClientConfig.TLSConfig()
 	if err != nil {
 		return fmt.Errorf("registering TLS config: %w", err)
 	}
 	if tlsConfig != nil {
-		if err := mysql.RegisterTLSConfig(tlsid, tlsConfig); err != nil {
+		if err := mysql.RegisterTLSConfig(m.tlsID, tlsConfig); err != nil {
 			return err
 		}
 	}
@@ -123,31 +129,79 @@ func (m *Mysql) Init() error {
 		dsn := dsnSecret.String()
 		dsnSecret.Destroy()
 
-		// Reference the custom TLS config of _THIS_ plugin instance
-		if tlsRe.MatchString(dsn) {
-			dsn = tlsRe.ReplaceAllString(dsn, "${1}tls="+tlsid+"${2}")
+		adapted, err := m.adaptDSN(dsn)
+		if err != nil {
+			return err
 		}
 
-		conf, err := mysql.ParseDSN(dsn)
-		if err != nil {
-			return fmt.Errorf("parsing %q failed: %w", dsn, err)
+		if err := server.Set([]byte(adapted)); err != nil {
+			return fmt.Errorf("replacing server %q failed: %w", dsn, err)
 		}
 
-		// Set the default timeout if none specified
-		if conf.Timeout == 0 {
-			conf.Timeout = time.Second * 5
+		m.Servers[i] = server
+	}
+
+	if m.Discovery != nil {
+		if err := m.Discovery.Init(m.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
 		}
+	}
 
-		if err := server.Set([]byte(conf.FormatDSN())); err != nil {
-			return fmt.Errorf("replacing server %q failed: %w", dsn, err)
+	return nil
+}
+
+// Probe checks that all configured servers are reachable and accept the
+// credentials
+func (m *Mysql) Probe() error {
+	for _, server := range m.Servers {
+		if err := probeServer(server); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		m.Servers[i] = server
+func probeServer(server *config.Secret) error {
+	dsnSecret, err := server.Get()
+	if err != nil {
+		return err
 	}
+	dsn := dsnSecret.String()
+	dsnSecret.Destroy()
 
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("probing %q failed: %w", getDSNTag(dsn), err)
+	}
 	return nil
 }
 
+// adaptDSN references the TLS config of the plugin instance and sets the
+// default timeout in the given DSN
+func (m *Mysql) adaptDSN(dsn string) (string, error) {
+	// Reference the custom TLS config of _THIS_ plugin instance
+	if tlsRe.MatchString(dsn) {
+		dsn = tlsRe.ReplaceAllString(dsn, "${1}tls="+m.tlsID+"${2}")
+	}
+
+	conf, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parsing %q failed: %w", dsn, err)
+	}
+
+	// Set the default timeout if none specified
+	if conf.Timeout == 0 {
+		conf.Timeout = time.Second * 5
+	}
+
+	return conf.FormatDSN(), nil
+}
+
 func (m *Mysql) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 
@@ -160,6 +214,27 @@ func (m *Mysql) Gather(acc telegraf.Accumulator) error {
 		}(server)
 	}
 
+	if m.Discovery != nil {
+		targets, err := m.Discovery.Targets(context.Background())
+		if err != nil {
+			acc.AddError(err)
+		}
+		for _, t := range targets {
+			dsn, err := m.adaptDSN(t.Endpoint)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+			wg.Add(1)
+			go func(dsn string, acc telegraf.Accumulator) {
+				defer wg.Done()
+				server := config.NewSecret([]byte(dsn))
+				defer server.Destroy()
+				acc.AddError(m.gatherServer(&server, acc))
+			}(dsn, discovery.NewAccumulator(acc, t))
+		}
+	}
+
 	wg.Wait()
 	return nil
 }
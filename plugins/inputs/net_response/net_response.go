@@ -3,6 +3,7 @@ package net_response
 
 import (
 	"bufio"
+	"context"
 	_ "embed"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -37,6 +39,9 @@ type NetResponse struct {
 	Send        string          `toml:"send"`
 	Expect      string          `toml:"expect"`
 	Protocol    string          `toml:"protocol"`
+
+	Discovery *discovery.Discovery `toml:"discovery"`
+	Log       telegraf.Logger      `toml:"-"`
 }
 
 func (*NetResponse) SampleConfig() string {
@@ -58,6 +63,20 @@ func (n *NetResponse) Init() error {
 	if n.Protocol == "udp" && n.Expect == "" {
 		return errors.New("expected string cannot be empty")
 	}
+	if err := choice.Check(n.Protocol, []string{"tcp", "udp"}); err != nil {
+		return fmt.Errorf("config option protocol: %w", err)
+	}
+
+	if n.Discovery != nil {
+		if err := n.Discovery.Init(n.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
+		}
+		// The address is optional if targets are discovered
+		if n.Address == "" {
+			return nil
+		}
+	}
+
 	// Prepare host and port
 	host, port, err := net.SplitHostPort(n.Address)
 	if err != nil {
@@ -70,16 +89,34 @@ func (n *NetResponse) Init() error {
 		return errors.New("bad port in config option address")
 	}
 
-	if err := choice.Check(n.Protocol, []string{"tcp", "udp"}); err != nil {
-		return fmt.Errorf("config option protocol: %w", err)
+	return nil
+}
+
+func (n *NetResponse) Gather(acc telegraf.Accumulator) error {
+	if n.Address != "" {
+		if err := n.gatherAddress(acc, n.Address); err != nil {
+			return err
+		}
+	}
+
+	if n.Discovery != nil {
+		targets, err := n.Discovery.Targets(context.Background())
+		if err != nil {
+			acc.AddError(err)
+		}
+		for _, t := range targets {
+			if err := n.gatherAddress(discovery.NewAccumulator(acc, t), t.Endpoint); err != nil {
+				acc.AddError(fmt.Errorf("[address=%s]: %w", t.Endpoint, err))
+			}
+		}
 	}
 
 	return nil
 }
 
-func (n *NetResponse) Gather(acc telegraf.Accumulator) error {
+func (n *NetResponse) gatherAddress(acc telegraf.Accumulator, address string) error {
 	// Prepare host and port
-	host, port, err := net.SplitHostPort(n.Address)
+	host, port, err := net.SplitHostPort(address)
 	if err != nil {
 		return err
 	}
@@ -92,13 +129,13 @@ func (n *NetResponse) Gather(acc telegraf.Accumulator) error {
 	// Gather data
 	switch n.Protocol {
 	case "tcp":
-		returnTags, fields, err = n.tcpGather()
+		returnTags, fields, err = n.tcpGather(address)
 		if err != nil {
 			return err
 		}
 		tags["protocol"] = "tcp"
 	case "udp":
-		returnTags, fields, err = n.udpGather()
+		returnTags, fields, err = n.udpGather(address)
 		if err != nil {
 			return err
 		}
@@ -114,14 +151,14 @@ func (n *NetResponse) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (n *NetResponse) tcpGather() (map[string]string, map[string]interface{}, error) {
+func (n *NetResponse) tcpGather(address string) (map[string]string, map[string]interface{}, error) {
 	// Prepare returns
 	tags := make(map[string]string)
 	fields := make(map[string]interface{})
 	// Start Timer
 	start := time.Now()
 	// Connecting
-	conn, err := net.DialTimeout("tcp", n.Address, time.Duration(n.Timeout))
+	conn, err := net.DialTimeout("tcp", address, time.Duration(n.Timeout))
 	// Stop timer
 	responseTime := time.Since(start).Seconds()
 	// Handle error
@@ -177,14 +214,14 @@ func (n *NetResponse) tcpGather() (map[string]string, map[string]interface{}, er
 	return tags, fields, nil
 }
 
-func (n *NetResponse) udpGather() (map[string]string, map[string]interface{}, error) {
+func (n *NetResponse) udpGather(address string) (map[string]string, map[string]interface{}, error) {
 	// Prepare returns
 	tags := make(map[string]string)
 	fields := make(map[string]interface{})
 	// Start Timer
 	start := time.Now()
 	// Resolving
-	udpAddr, err := net.ResolveUDPAddr("udp", n.Address)
+	udpAddr, err := net.ResolveUDPAddr("udp", address)
 	// Handle error
 	if err != nil {
 		setResult(connectionFailed, fields, tags, n.Expect)
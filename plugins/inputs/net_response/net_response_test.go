@@ -1,12 +1,16 @@
 package net_response
 
 import (
+	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/require"
@@ -339,3 +343,46 @@ func tcpServer(t *testing.T, wg *sync.WaitGroup) {
 		return
 	}
 }
+
+func TestDiscoveredTargets(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	targetsFile := filepath.Join(t.TempDir(), "targets.yaml")
+	targets := fmt.Sprintf("- targets: [%q]\n  labels:\n    service: echo\n", listener.Addr().String())
+	require.NoError(t, os.WriteFile(targetsFile, []byte(targets), 0600))
+
+	c := NetResponse{
+		Protocol: "tcp",
+		Discovery: &discovery.Discovery{
+			Files: []*discovery.FileProvider{{Files: []string{targetsFile}}},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"result":   "success",
+		"server":   "127.0.0.1",
+		"port":     port,
+		"protocol": "tcp",
+		"service":  "echo",
+	}, acc.Metrics[0].Tags)
+}
@@ -31,6 +31,12 @@ func (o *OpcUA) Init() (err error) {
 	return err
 }
 
+// Probe checks that the server is reachable and the configured nodes can be
+// read. The established connection is reused for gathering.
+func (o *OpcUA) Probe() error {
+	return o.client.ensureConnected()
+}
+
 func (o *OpcUA) Gather(acc telegraf.Accumulator) error {
 	// Will (re)connect if the client is disconnected
 	metrics, err := o.client.currentValues()
@@ -47,6 +47,11 @@ func (p *Postgresql) Start(_ telegraf.Accumulator) error {
 	return p.service.Start()
 }
 
+// Probe checks that the server is reachable and accepts the credentials
+func (p *Postgresql) Probe() error {
+	return p.service.Ping()
+}
+
 func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 	var query string
 	if len(p.Databases) == 0 && len(p.IgnoredDatabases) == 0 {
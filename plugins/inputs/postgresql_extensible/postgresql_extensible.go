@@ -4,6 +4,7 @@ package postgresql_extensible
 import (
 	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -14,7 +15,9 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/common/postgresql"
+	"github.com/influxdata/telegraf/plugins/common/watermark"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -30,7 +33,8 @@ type Postgresql struct {
 	Log                telegraf.Logger `toml:"-"`
 	postgresql.Config
 
-	service *postgresql.Service
+	service    *postgresql.Service
+	watermarks *watermark.Watermarks
 }
 
 type query struct {
@@ -43,6 +47,7 @@ type query struct {
 	Tagvalue    string `toml:"tagvalue"`
 	Measurement string `toml:"measurement"`
 	Timestamp   string `toml:"timestamp"`
+	watermark.Config
 
 	additionalTags map[string]bool
 }
@@ -56,6 +61,8 @@ func (*Postgresql) SampleConfig() string {
 }
 
 func (p *Postgresql) Init() error {
+	p.watermarks = watermark.New(p.Log)
+
 	// Set defaults for the queries
 	for i, q := range p.Query {
 		if q.Sqlquery == "" {
@@ -89,6 +96,18 @@ func (p *Postgresql) Init() error {
 			}
 		}
 		p.Query[i] = q
+
+		// Setup incremental queries
+		if err := p.Query[i].Config.Init(); err != nil {
+			return err
+		}
+		// The watermarks are identified by the measurement to keep the state
+		// when modifying the query text
+		if p.Query[i].Enabled() {
+			if err := p.watermarks.Register(p.Query[i].Measurement, &p.Query[i].Config); err != nil {
+				return fmt.Errorf("incremental queries require distinct 'measurement' settings: %w", err)
+			}
+		}
 	}
 	p.Config.IsPgBouncer = !p.PreparedStatements
 
@@ -102,7 +121,11 @@ func (p *Postgresql) Init() error {
 	return nil
 }
 
-func (p *Postgresql) Start(_ telegraf.Accumulator) error {
+func (p *Postgresql) Start(acc telegraf.Accumulator) error {
+	// Track the delivery of incremental query results to advance the
+	// watermarks
+	p.watermarks.Start(acc)
+
 	return p.service.Start()
 }
 
@@ -122,6 +145,10 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 	// Query is not run if Database version does not match the query version.
 	for _, q := range p.Query {
 		if q.MinVersion <= dbVersion && (q.MaxVersion == 0 || q.MaxVersion > dbVersion) {
+			if q.Enabled() {
+				acc.AddError(p.gatherIncremental(q, timestamp))
+				continue
+			}
 			acc.AddError(p.gatherMetricsFromQuery(acc, q, timestamp))
 		}
 	}
@@ -129,9 +156,22 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 }
 
 func (p *Postgresql) Stop() {
+	p.watermarks.Stop()
 	p.service.Stop()
 }
 
+func (p *Postgresql) GetState() interface{} {
+	return p.watermarks.GetState()
+}
+
+func (p *Postgresql) SetState(state interface{}) error {
+	watermarksState, ok := state.(watermark.State)
+	if !ok {
+		return errors.New("state has to be of type 'watermark.State'")
+	}
+	return p.watermarks.SetState(watermarksState)
+}
+
 func (p *Postgresql) gatherMetricsFromQuery(acc telegraf.Accumulator, q query, timestamp time.Time) error {
 	rows, err := p.service.DB.Query(q.Sqlquery)
 	if err != nil {
@@ -154,7 +194,52 @@ func (p *Postgresql) gatherMetricsFromQuery(acc telegraf.Accumulator, q query, t
 	return nil
 }
 
+// gatherIncremental runs the query page by page with the current watermark
+// as parameter and sends the resulting metrics to the outputs with tracking
+func (p *Postgresql) gatherIncremental(q query, timestamp time.Time) error {
+	return p.watermarks.Query(q.Measurement, func(current interface{}) ([]telegraf.Metric, int, interface{}, error) {
+		metrics, latest, err := p.queryPage(q, timestamp, current)
+		return metrics, len(metrics), latest, err
+	})
+}
+
+func (p *Postgresql) queryPage(q query, timestamp time.Time, current interface{}) ([]telegraf.Metric, interface{}, error) {
+	rows, err := p.service.DB.Query(q.Sqlquery, q.Args(current)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metrics []telegraf.Metric
+	var latest interface{}
+	for rows.Next() {
+		m, value, err := p.parseRow(rows, columns, q, timestamp)
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics = append(metrics, m)
+		latest = q.Max(latest, value)
+	}
+	return metrics, latest, rows.Err()
+}
+
 func (p *Postgresql) accRow(acc telegraf.Accumulator, row scanner, columns []string, q query, timestamp time.Time) error {
+	m, _, err := p.parseRow(row, columns, q, timestamp)
+	if err != nil {
+		return err
+	}
+	acc.AddMetric(m)
+	return nil
+}
+
+// parseRow converts the row to a metric and returns the value of the
+// watermark column if any
+func (p *Postgresql) parseRow(row scanner, columns []string, q query, timestamp time.Time) (telegraf.Metric, interface{}, error) {
 	// this is where we'll store the column name with its *interface{}
 	columnMap := make(map[string]*interface{})
 
@@ -170,7 +255,7 @@ func (p *Postgresql) accRow(acc telegraf.Accumulator, row scanner, columns []str
 
 	// deconstruct array of variables and send to Scan
 	if err := row.Scan(columnVars...); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var dbname bytes.Buffer
@@ -192,6 +277,7 @@ func (p *Postgresql) accRow(acc telegraf.Accumulator, row scanner, columns []str
 		"db":     dbname.String(),
 	}
 
+	var latest interface{}
 	fields := make(map[string]interface{})
 	for col, val := range columnMap {
 		p.Log.Debugf("Column: %s = %T: %v\n", col, *val, *val)
@@ -200,6 +286,14 @@ func (p *Postgresql) accRow(acc telegraf.Accumulator, row scanner, columns []str
 			continue
 		}
 
+		if q.Enabled() && col == q.Column {
+			v, err := q.Convert(*val)
+			if err != nil {
+				return nil, nil, fmt.Errorf("converting watermark column %q failed: %w", col, err)
+			}
+			latest = v
+		}
+
 		if col == q.Timestamp {
 			if v, ok := (*val).(time.Time); ok {
 				timestamp = v
@@ -223,8 +317,7 @@ func (p *Postgresql) accRow(acc telegraf.Accumulator, row scanner, columns []str
 			fields[col] = *val
 		}
 	}
-	acc.AddFields(q.Measurement, fields, tags, timestamp)
-	return nil
+	return metric.New(q.Measurement, tags, fields, timestamp), latest, nil
 }
 
 func init() {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/plugins/common/postgresql"
+	"github.com/influxdata/telegraf/plugins/common/watermark"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -237,6 +238,30 @@ func TestPostgresqlFieldOutputIntegration(t *testing.T) {
 	}
 }
 
+func TestPostgresqlIncrementalIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	acc := queryRunner(t, []query{{
+		Sqlquery:    "SELECT i AS id, i * 10 AS value FROM generate_series(1, 5) AS i WHERE i > $1 ORDER BY i LIMIT $2",
+		Measurement: "events",
+		Config: watermark.Config{
+			Column:   "id",
+			PageSize: 2,
+			MaxPages: 2,
+		},
+	}})
+
+	// Only two pages must be collected in a single gather cycle
+	require.Len(t, acc.Metrics, 4)
+	for i, m := range acc.Metrics {
+		require.Equal(t, "events", m.Measurement)
+		require.EqualValues(t, i+1, m.Fields["id"])
+		require.EqualValues(t, (i+1)*10, m.Fields["value"])
+	}
+}
+
 func TestPostgresqlSqlScript(t *testing.T) {
 	q := []query{{
 		Script:     "testdata/test.sql",
@@ -0,0 +1,336 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
+)
+
+const (
+	defaultPodScrapeInterval    = 60
+	defaultCacheRefreshInterval = 60
+	defaultPodPort              = 9102
+)
+
+type consulConfig struct {
+	// Address of the Consul agent. The address must contain a hostname or an IP address
+	// and optionally a port (format: "host:port").
+	Enabled       bool            `toml:"enabled"`
+	Agent         string          `toml:"agent"`
+	QueryInterval config.Duration `toml:"query_interval"`
+	Queries       []*consulQuery  `toml:"query"`
+}
+
+// One Consul service discovery query
+type consulQuery struct {
+	// A name of the searched services (not ID)
+	ServiceName string `toml:"name"`
+
+	// A tag of the searched services
+	ServiceTag string `toml:"tag"`
+
+	// A DC of the searched services
+	ServiceDc string `toml:"dc"`
+
+	// A template URL of the Prometheus gathering interface. The hostname part
+	// of the URL will be replaced by discovered address and port.
+	ServiceURL string `toml:"url"`
+
+	// Extra tags to add to metrics found in Consul
+	ServiceExtraTags map[string]string `toml:"tags"`
+
+	serviceURLTemplate       *template.Template
+	serviceExtraTagsTemplate map[string]*template.Template
+	discovery                *discovery.Discovery
+}
+
+// initPodDiscovery sets up the discovery of the pods to scrape using the
+// Kubernetes settings of the plugin
+func (p *Prometheus) initPodDiscovery() {
+	interval := p.PodScrapeInterval
+	if interval == 0 {
+		interval = defaultPodScrapeInterval
+	}
+
+	provider := &discovery.KubernetesProvider{
+		KubeConfig:    p.KubeConfig,
+		Namespace:     p.PodNamespace,
+		LabelSelector: p.KubernetesLabelSelector,
+		FieldSelector: p.KubernetesFieldSelector,
+		// The scrape URL including the port is determined for each pod using
+		// the annotations or settings
+		Port:           defaultPodPort,
+		WithNamespaces: p.nsAnnotationPass != nil || p.nsAnnotationDrop != nil,
+	}
+	if p.isNodeScrapeScope {
+		provider.NodeIP = p.NodeIP
+	} else {
+		// Keep the pods in sync using a watch on the API server
+		refresh := p.CacheRefreshInterval
+		if refresh == 0 {
+			refresh = defaultCacheRefreshInterval
+		}
+		provider.Watch = true
+		provider.ResyncInterval = config.Duration(time.Duration(refresh) * time.Minute)
+	}
+
+	p.podDiscovery = &discovery.Discovery{
+		RefreshInterval: config.Duration(time.Duration(interval) * time.Second),
+		// Identify the targets by pod as the address is not used for scraping
+		Template:   `{{index .Labels "namespace"}}/{{index .Labels "pod_name"}}`,
+		Kubernetes: []*discovery.KubernetesProvider{provider},
+	}
+}
+
+// initConsulDiscovery sets up the discovery of the Consul services to scrape
+// for each query. Queries with invalid templates are skipped.
+func (p *Prometheus) initConsulDiscovery() {
+	queries := make([]*consulQuery, 0, len(p.ConsulConfig.Queries))
+	for _, q := range p.ConsulConfig.Queries {
+		serviceURLTemplate, err := template.New("URL").Parse(q.ServiceURL)
+		if err != nil {
+			p.Log.Errorf("Could not parse the Consul query URL template (%s), skipping it. Error: %s", q.ServiceURL, err)
+			continue
+		}
+		q.serviceURLTemplate = serviceURLTemplate
+
+		// Allow to use join function in tags
+		templateFunctions := template.FuncMap{"join": strings.Join}
+		// Parse the tag value templates
+		q.serviceExtraTagsTemplate = make(map[string]*template.Template)
+		for tagName, tagTemplateString := range q.ServiceExtraTags {
+			tagTemplate, err := template.New(tagName).Funcs(templateFunctions).Parse(tagTemplateString)
+			if err != nil {
+				p.Log.Errorf("Could not parse the Consul query Extra Tag template (%s), skipping it. Error: %s", tagTemplateString, err)
+				continue
+			}
+			q.serviceExtraTagsTemplate[tagName] = tagTemplate
+		}
+
+		q.discovery = &discovery.Discovery{
+			RefreshInterval: p.ConsulConfig.QueryInterval,
+			Consul: []*discovery.ConsulProvider{{
+				Agent:      p.ConsulConfig.Agent,
+				Service:    q.ServiceName,
+				Tag:        q.ServiceTag,
+				Datacenter: q.ServiceDc,
+			}},
+		}
+		queries = append(queries, q)
+	}
+	p.ConsulConfig.Queries = queries
+}
+
+// discoveredURLs adds the URLs of the pods and Consul services discovered
+// using the Kubernetes and Consul settings of the plugin
+func (p *Prometheus) discoveredURLs(ctx context.Context, urls map[string]urlAndAddress) {
+	if p.podDiscovery != nil {
+		targets, err := p.podDiscovery.Targets(ctx)
+		if err != nil {
+			p.Log.Errorf("Discovering pods failed: %v", err)
+		}
+		for _, t := range targets {
+			pod, ok := t.Source.(*discovery.KubernetesPod)
+			if !ok {
+				continue
+			}
+			uaa, err := p.podURL(pod)
+			if err != nil {
+				p.Log.Errorf("Could not parse URL of pod %q: %s", t.Endpoint, err)
+				continue
+			}
+			if uaa != nil {
+				urls[uaa.url.String()] = *uaa
+			}
+		}
+	}
+
+	for _, q := range p.ConsulConfig.Queries {
+		if q.discovery == nil {
+			continue
+		}
+		targets, err := q.discovery.Targets(ctx)
+		if err != nil {
+			p.Log.Errorf("Unable to refresh Consul services: %v", err)
+		}
+		p.Log.Debugf("Queried Consul for Service (%s, %s) and found %d instances", q.ServiceName, q.ServiceTag, len(targets))
+		for _, t := range targets {
+			service, ok := t.Source.(*api.CatalogService)
+			if !ok {
+				continue
+			}
+			uaa, err := p.getConsulServiceURL(q, service)
+			if err != nil {
+				p.Log.Warnf("Unable to get scrape URLs from Consul for Service (%s, %s): %s", q.ServiceName, q.ServiceTag, err)
+				continue
+			}
+			urls[uaa.url.String()] = *uaa
+		}
+	}
+}
+
+// podURL returns the scrape target of the given pod or nil if the pod should
+// not be scraped
+func (p *Prometheus) podURL(source *discovery.KubernetesPod) (*urlAndAddress, error) {
+	pod := source.Pod
+	if !shouldScrapePod(pod, p) || !namespaceAnnotationMatch(source.Namespace, p) {
+		return nil, nil
+	}
+
+	targetURL, err := getScrapeURL(pod, p)
+	if err != nil || targetURL == nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(pod.Annotations)+len(pod.Labels)+2)
+
+	// add annotation as metrics tags, subject to include/exclude filters
+	for k, v := range pod.Annotations {
+		if models.ShouldPassFilters(p.podAnnotationIncludeFilter, p.podAnnotationExcludeFilter, k) {
+			tags[k] = v
+		}
+	}
+
+	tags["pod_name"] = pod.Name
+	podNamespace := "namespace"
+	if p.PodNamespaceLabelName != "" {
+		podNamespace = p.PodNamespaceLabelName
+	}
+	tags[podNamespace] = pod.Namespace
+
+	// add labels as metrics tags, subject to include/exclude filters
+	for k, v := range pod.Labels {
+		if models.ShouldPassFilters(p.podLabelIncludeFilter, p.podLabelExcludeFilter, k) {
+			tags[k] = v
+		}
+	}
+
+	return &urlAndAddress{
+		url:         addressToURL(targetURL, targetURL.Hostname()),
+		address:     targetURL.Hostname(),
+		originalURL: targetURL,
+		tags:        tags,
+	}, nil
+}
+
+func shouldScrapePod(pod *corev1.Pod, p *Prometheus) bool {
+	switch p.MonitorKubernetesPodsMethod {
+	case monitorMethodAnnotations: // must have 'true' annotation to be scraped
+		return pod.Annotations != nil && pod.Annotations["prometheus.io/scrape"] == "true"
+	case monitorMethodSettings: // will be scraped regardless of annotation
+		return true
+	case monitorMethodSettingsAndAnnotations: // will be scraped unless opts out with 'false' annotation
+		return pod.Annotations == nil || pod.Annotations["prometheus.io/scrape"] != "false"
+	}
+	return false
+}
+
+func namespaceAnnotationMatch(ns *corev1.Namespace, p *Prometheus) bool {
+	// In case of no filtering or any issues with acquiring namespace information
+	// just let it pass trough...
+	if (p.nsAnnotationPass == nil && p.nsAnnotationDrop == nil) || ns == nil {
+		return true
+	}
+
+	tags := make([]*telegraf.Tag, 0, len(ns.Annotations))
+	for k, v := range ns.Annotations {
+		tags = append(tags, &telegraf.Tag{Key: k, Value: v})
+	}
+	return models.ShouldTagsPass(p.nsAnnotationPass, p.nsAnnotationDrop, tags)
+}
+
+func getScrapeURL(pod *corev1.Pod, p *Prometheus) (*url.URL, error) {
+	ip := pod.Status.PodIP
+	if ip == "" {
+		// return as if scrape was disabled, we will be notified again once the pod
+		// has an IP
+		return nil, nil
+	}
+
+	var scheme, pathAndQuery, port string
+
+	if p.MonitorKubernetesPodsMethod == monitorMethodSettings ||
+		p.MonitorKubernetesPodsMethod == monitorMethodSettingsAndAnnotations {
+		scheme = p.MonitorKubernetesPodsScheme
+		pathAndQuery = p.MonitorKubernetesPodsPath
+		port = strconv.Itoa(p.MonitorKubernetesPodsPort)
+	}
+
+	if p.MonitorKubernetesPodsMethod == monitorMethodAnnotations ||
+		p.MonitorKubernetesPodsMethod == monitorMethodSettingsAndAnnotations {
+		if ann := pod.Annotations["prometheus.io/scheme"]; ann != "" {
+			scheme = ann
+		}
+		if ann := pod.Annotations["prometheus.io/path"]; ann != "" {
+			pathAndQuery = ann
+		}
+		if ann := pod.Annotations["prometheus.io/port"]; ann != "" {
+			port = ann
+		}
+	}
+
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	if port == "" || port == "0" {
+		port = strconv.Itoa(defaultPodPort)
+	}
+
+	if pathAndQuery == "" {
+		pathAndQuery = "/metrics"
+	}
+
+	base, err := url.Parse(pathAndQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	base.Scheme = scheme
+	base.Host = net.JoinHostPort(ip, port)
+
+	return base, nil
+}
+
+func (p *Prometheus) getConsulServiceURL(q *consulQuery, s *api.CatalogService) (*urlAndAddress, error) {
+	var buffer bytes.Buffer
+	err := q.serviceURLTemplate.Execute(&buffer, s)
+	if err != nil {
+		return nil, err
+	}
+	serviceURL, err := url.Parse(buffer.String())
+	if err != nil {
+		return nil, err
+	}
+
+	extraTags := make(map[string]string)
+	for tagName, tagTemplate := range q.serviceExtraTagsTemplate {
+		buffer.Reset()
+		err = tagTemplate.Execute(&buffer, s)
+		if err != nil {
+			return nil, err
+		}
+		extraTags[tagName] = buffer.String()
+	}
+
+	p.Log.Debugf("Will scrape metrics from Consul Service %s", serviceURL.String())
+
+	return &urlAndAddress{
+		url:         serviceURL,
+		originalURL: serviceURL,
+		tags:        extraTags,
+	}, nil
+}
@@ -1,15 +1,17 @@
 package prometheus
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/tools/cache"
 
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -19,12 +21,11 @@ func initPrometheus() *Prometheus {
 	prom.MonitorKubernetesPodsPort = 9102
 	prom.MonitorKubernetesPodsPath = "/metrics"
 	prom.MonitorKubernetesPodsMethod = monitorMethodAnnotations
-	prom.kubernetesPods = map[podID]urlAndAddress{}
 	return prom
 }
 
 func TestScrapeURLNoAnnotations(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}}
+	prom := initPrometheus()
 	p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{}}
 	p.Annotations = map[string]string{}
 	url, err := getScrapeURL(p, prom)
@@ -57,7 +58,7 @@ func TestScrapeURLScrapeConfigCustom(t *testing.T) {
 }
 
 func TestScrapeURLAnnotations(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}}
+	prom := initPrometheus()
 	p := pod()
 	url, err := getScrapeURL(p, prom)
 	require.NoError(t, err)
@@ -129,12 +130,15 @@ func TestScrapeURLAnnotationsCustomPathWithFragment(t *testing.T) {
 }
 
 func TestAddPod(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
+	prom := initPrometheus()
 
 	p := pod()
 	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-	require.Len(t, prom.kubernetesPods, 1)
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
+	require.NoError(t, err)
+	require.NotNil(t, uaa)
+	require.Equal(t, "http://127.0.0.1:9102/metrics", uaa.url.String())
+	require.Equal(t, "127.0.0.1", uaa.address)
 }
 
 func TestAddPodScrapeConfig(t *testing.T) {
@@ -143,130 +147,146 @@ func TestAddPodScrapeConfig(t *testing.T) {
 
 	p := pod()
 	p.Annotations = map[string]string{}
-	registerPod(p, prom)
-	require.Len(t, prom.kubernetesPods, 1)
-}
-
-func TestAddMultipleDuplicatePods(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
-
-	p := pod()
-	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-	p.Name = "Pod2"
-	registerPod(p, prom)
-
-	urls, err := prom.getAllURLs()
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
 	require.NoError(t, err)
-	require.Len(t, urls, 1)
-}
-
-func TestAddMultiplePods(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
-
-	p := pod()
-	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-	p.Name = "Pod2"
-	p.Status.PodIP = "127.0.0.2"
-	registerPod(p, prom)
-	require.Len(t, prom.kubernetesPods, 2)
+	require.NotNil(t, uaa)
 }
 
-func TestDeletePods(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
+func TestSkipPodWithoutAnnotation(t *testing.T) {
+	prom := initPrometheus()
 
 	p := pod()
-	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-
-	id, err := cache.MetaNamespaceKeyFunc(p)
+	p.Annotations = map[string]string{"prometheus.io/scrape": "false"}
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
 	require.NoError(t, err)
-	unregisterPod(podID(id), prom)
-	require.Empty(t, prom.kubernetesPods)
+	require.Nil(t, uaa)
 }
 
 func TestKeepDefaultNamespaceLabelName(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
+	prom := initPrometheus()
 
 	p := pod()
 	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-
-	id, err := cache.MetaNamespaceKeyFunc(p)
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
 	require.NoError(t, err)
-	tags := prom.kubernetesPods[podID(id)].tags
-	require.Equal(t, "default", tags["namespace"])
+	require.Equal(t, "default", uaa.tags["namespace"])
 }
 
 func TestChangeNamespaceLabelName(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, PodNamespaceLabelName: "pod_namespace", kubernetesPods: map[podID]urlAndAddress{}}
+	prom := initPrometheus()
+	prom.PodNamespaceLabelName = "pod_namespace"
 
 	p := pod()
 	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
-	registerPod(p, prom)
-
-	id, err := cache.MetaNamespaceKeyFunc(p)
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
 	require.NoError(t, err)
-	tags := prom.kubernetesPods[podID(id)].tags
-	require.Equal(t, "default", tags["pod_namespace"])
-	require.Equal(t, "", tags["namespace"])
+	require.Equal(t, "default", uaa.tags["pod_namespace"])
+	require.Equal(t, "", uaa.tags["namespace"])
 }
 
-func TestPodHasMatchingNamespace(t *testing.T) {
-	prom := &Prometheus{Log: testutil.Logger{}, PodNamespace: "default"}
+func TestNamespaceAnnotationFilter(t *testing.T) {
+	prom := &Prometheus{
+		Log:                     testutil.Logger{},
+		MonitorPods:             true,
+		NamespaceAnnotationPass: map[string][]string{"team": {"a"}},
+	}
+	require.NoError(t, prom.Init())
+	require.True(t, prom.podDiscovery.Kubernetes[0].WithNamespaces)
 
-	pod := pod()
-	pod.Name = "Pod1"
-	pod.Namespace = "default"
-	shouldMatch := podHasMatchingNamespace(pod, prom)
-	require.True(t, shouldMatch)
+	p := pod()
+	p.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{"team": "a"}}}
+	uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p, Namespace: ns})
+	require.NoError(t, err)
+	require.NotNil(t, uaa)
 
-	pod.Name = "Pod2"
-	pod.Namespace = "namespace"
-	shouldNotMatch := podHasMatchingNamespace(pod, prom)
-	require.False(t, shouldNotMatch)
+	ns.Annotations["team"] = "b"
+	uaa, err = prom.podURL(&discovery.KubernetesPod{Pod: p, Namespace: ns})
+	require.NoError(t, err)
+	require.Nil(t, uaa)
 }
 
-func TestPodHasMatchingLabelSelector(t *testing.T) {
-	labelSelectorString := "label0==label0,label1=label1,label2!=label,label3 in (label1,label2, label3),label4 notin (label1, label2,label3),label5,!label6"
-	prom := &Prometheus{Log: testutil.Logger{}, KubernetesLabelSelector: labelSelectorString}
-
-	pod := pod()
-	pod.Labels = make(map[string]string)
-	pod.Labels["label0"] = "label0"
-	pod.Labels["label1"] = "label1"
-	pod.Labels["label2"] = "label2"
-	pod.Labels["label3"] = "label3"
-	pod.Labels["label4"] = "label4"
-	pod.Labels["label5"] = "label5"
-
-	labelSelector, err := labels.Parse(prom.KubernetesLabelSelector)
-	require.NoError(t, err)
-	require.True(t, podHasMatchingLabelSelector(pod, labelSelector))
+func TestPodDiscoverySettings(t *testing.T) {
+	t.Setenv("NODE_IP", "10.0.0.1")
+	prom := &Prometheus{
+		Log:                     testutil.Logger{},
+		MonitorPods:             true,
+		PodScrapeScope:          "node",
+		PodScrapeInterval:       30,
+		PodNamespace:            "default",
+		KubeConfig:              "/etc/kube/config",
+		KubernetesLabelSelector: "app=web",
+		KubernetesFieldSelector: "spec.nodeName=node-0",
+	}
+	require.NoError(t, prom.Init())
+
+	require.NotNil(t, prom.podDiscovery)
+	require.Equal(t, config.Duration(30*time.Second), prom.podDiscovery.RefreshInterval)
+	require.Len(t, prom.podDiscovery.Kubernetes, 1)
+	provider := prom.podDiscovery.Kubernetes[0]
+	require.Equal(t, "/etc/kube/config", provider.KubeConfig)
+	require.Equal(t, "default", provider.Namespace)
+	require.Equal(t, "app=web", provider.LabelSelector)
+	require.Equal(t, "spec.nodeName=node-0", provider.FieldSelector)
+	require.Equal(t, "10.0.0.1", provider.NodeIP)
+	require.False(t, provider.WithNamespaces)
 }
 
-func TestPodHasMatchingFieldSelector(t *testing.T) {
-	fieldSelectorString := "status.podIP=127.0.0.1,spec.restartPolicy=Always,spec.NodeName!=nodeName"
-	prom := &Prometheus{Log: testutil.Logger{}, KubernetesFieldSelector: fieldSelectorString}
-	pod := pod()
-	pod.Spec.RestartPolicy = "Always"
-	pod.Spec.NodeName = "node1000"
+func TestConsulDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/catalog/service/web" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"Node": "n1", "Address": "10.0.0.1", "ServiceName": "web", "ServicePort": 8080,
+			 "ServiceTags": ["prod", "canary"], "ServiceMeta": {"metrics_path": "stats"}}
+		]`))
+	}))
+	defer server.Close()
+
+	prom := &Prometheus{
+		Log: testutil.Logger{},
+		ConsulConfig: consulConfig{
+			Enabled: true,
+			Agent:   server.URL,
+			Queries: []*consulQuery{
+				{
+					ServiceName: "web",
+					ServiceURL: `http://{{if ne .ServiceAddress ""}}{{.ServiceAddress}}{{else}}{{.Address}}{{end}}:{{.ServicePort}}/` +
+						`{{with .ServiceMeta.metrics_path}}{{.}}{{else}}metrics{{end}}`,
+					ServiceExtraTags: map[string]string{
+						"host": "{{.Node}}",
+						"tags": `{{join .ServiceTags ","}}`,
+					},
+				},
+				{
+					ServiceName: "invalid",
+					ServiceURL:  "{{.Foo",
+				},
+			},
+		},
+	}
+	require.NoError(t, prom.Init())
+	require.Len(t, prom.ConsulConfig.Queries, 1)
+	require.NoError(t, prom.Start(nil))
+	defer prom.Stop()
 
-	fieldSelector, err := fields.ParseSelector(prom.KubernetesFieldSelector)
+	urls, err := prom.getAllURLs()
 	require.NoError(t, err)
-	require.True(t, podHasMatchingFieldSelector(pod, fieldSelector))
+	require.Len(t, urls, 1)
+	uaa, found := urls["http://10.0.0.1:8080/stats"]
+	require.True(t, found)
+	require.Equal(t, map[string]string{"host": "n1", "tags": "prod,canary"}, uaa.tags)
 }
 
 func TestInvalidFieldSelector(t *testing.T) {
-	fieldSelectorString := "status.podIP=127.0.0.1,spec.restartPolicy=Always,spec.NodeName!=nodeName,spec.nodeName"
-	prom := &Prometheus{Log: testutil.Logger{}, KubernetesFieldSelector: fieldSelectorString}
-	pod := pod()
-	pod.Spec.RestartPolicy = "Always"
-	pod.Spec.NodeName = "node1000"
-
-	_, err := fields.ParseSelector(prom.KubernetesFieldSelector)
-	require.Error(t, err)
+	prom := &Prometheus{
+		Log:                     testutil.Logger{},
+		MonitorPods:             true,
+		KubernetesFieldSelector: "status.podIP=127.0.0.1,spec.restartPolicy=Always,spec.NodeName!=nodeName,spec.nodeName",
+	}
+	require.Error(t, prom.Init())
 }
 
 func TestAnnotationFilters(t *testing.T) {
@@ -300,15 +320,15 @@ func TestAnnotationFilters(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
+			prom := initPrometheus()
 			prom.PodAnnotationInclude = tc.include
 			prom.PodAnnotationExclude = tc.exclude
 			require.NoError(t, prom.initFilters())
-			registerPod(p, prom)
-			for _, pd := range prom.kubernetesPods {
-				for _, tagKey := range tc.expectedTags {
-					require.Contains(t, pd.tags, tagKey)
-				}
+			uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
+			require.NoError(t, err)
+			require.NotNil(t, uaa)
+			for _, tagKey := range tc.expectedTags {
+				require.Contains(t, uaa.tags, tagKey)
 			}
 		})
 	}
@@ -345,15 +365,15 @@ func TestLabelFilters(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			prom := &Prometheus{Log: testutil.Logger{}, kubernetesPods: map[podID]urlAndAddress{}}
+			prom := initPrometheus()
 			prom.PodLabelInclude = tc.include
 			prom.PodLabelExclude = tc.exclude
 			require.NoError(t, prom.initFilters())
-			registerPod(p, prom)
-			for _, pd := range prom.kubernetesPods {
-				for _, tagKey := range tc.expectedTags {
-					require.Contains(t, pd.tags, tagKey)
-				}
+			uaa, err := prom.podURL(&discovery.KubernetesPod{Pod: p})
+			require.NoError(t, err)
+			require.NotNil(t, uaa)
+			for _, tagKey := range tc.expectedTags {
+				require.Contains(t, uaa.tags, tagKey)
 			}
 		})
 	}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -18,14 +19,15 @@ import (
 	"github.com/prometheus/common/expfmt"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/client-go/tools/cache"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/openmetrics"
 	parsers_prometheus "github.com/influxdata/telegraf/plugins/parsers/prometheus"
@@ -83,6 +85,13 @@ type Prometheus struct {
 	// Consul discovery
 	ConsulConfig consulConfig `toml:"consul"`
 
+	// Generic service discovery
+	Discovery *discovery.Discovery `toml:"discovery"`
+
+	// Prometheus-style relabeling
+	RelabelConfigs       []*relabel.Config `toml:"relabel_configs"`
+	MetricRelabelConfigs []*relabel.Config `toml:"metric_relabel_configs"`
+
 	Log telegraf.Logger `toml:"-"`
 	common_http.HTTPClientConfig
 
@@ -90,27 +99,16 @@ type Prometheus struct {
 	headers     map[string]string
 	contentType string
 
-	nsStore          cache.Store
 	nsAnnotationPass []models.TagFilter
 	nsAnnotationDrop []models.TagFilter
 
-	// Should we scrape Kubernetes services for prometheus annotations
-	lock           sync.Mutex
-	kubernetesPods map[podID]urlAndAddress
-	cancel         context.CancelFunc
-	wg             sync.WaitGroup
-
-	// Only for monitor_kubernetes_pods=true and pod_scrape_scope="node"
-	podLabelSelector           labels.Selector
-	podFieldSelector           fields.Selector
+	// Discovery of the Kubernetes pods to scrape for prometheus annotations
+	podDiscovery               *discovery.Discovery
 	isNodeScrapeScope          bool
 	podAnnotationIncludeFilter filter.Filter
 	podAnnotationExcludeFilter filter.Filter
 	podLabelIncludeFilter      filter.Filter
 	podLabelExcludeFilter      filter.Filter
-
-	// List of consul services to scrape
-	consulServices map[string]urlAndAddress
 }
 
 type urlAndAddress struct {
@@ -118,13 +116,10 @@ type urlAndAddress struct {
 	url         *url.URL
 	address     string
 	tags        map[string]string
-	namespace   string
 }
 
 type monitorMethod string
 
-type podID string
-
 func (*Prometheus) SampleConfig() string {
 	return sampleConfig
 }
@@ -176,26 +171,25 @@ func (p *Prometheus) Init() error {
 		p.MonitorKubernetesPodsMethod = monitorMethodAnnotations
 	}
 
-	// Parse label and field selectors - will be used to filter pods after cAdvisor call
-	var err error
-	p.podLabelSelector, err = labels.Parse(p.KubernetesLabelSelector)
+	// Check the label and field selectors - will be used to filter pods
+	podLabelSelector, err := labels.Parse(p.KubernetesLabelSelector)
 	if err != nil {
 		return fmt.Errorf("error parsing the specified label selector(s): %w", err)
 	}
-	p.podFieldSelector, err = fields.ParseSelector(p.KubernetesFieldSelector)
+	podFieldSelector, err := fields.ParseSelector(p.KubernetesFieldSelector)
 	if err != nil {
 		return fmt.Errorf("error parsing the specified field selector(s): %w", err)
 	}
-	isValid, invalidSelector := fieldSelectorIsSupported(p.podFieldSelector)
+	isValid, invalidSelector := fieldSelectorIsSupported(podFieldSelector)
 	if !isValid {
 		return fmt.Errorf("the field selector %q is not supported for pods", invalidSelector)
 	}
 
 	if p.KubernetesLabelSelector != "" {
-		p.Log.Debugf("Using the label selector: %v", p.podLabelSelector)
+		p.Log.Debugf("Using the label selector: %v", podLabelSelector)
 	}
 	if p.KubernetesFieldSelector != "" {
-		p.Log.Debugf("Using the field selector: %v", p.podFieldSelector)
+		p.Log.Debugf("Using the field selector: %v", podFieldSelector)
 	}
 
 	for k, vs := range p.NamespaceAnnotationPass {
@@ -247,25 +241,43 @@ func (p *Prometheus) Init() error {
 		"Accept":     acceptHeader,
 	}
 
-	p.kubernetesPods = make(map[podID]urlAndAddress)
+	if p.MonitorPods {
+		p.initPodDiscovery()
+	}
+	if p.ConsulConfig.Enabled {
+		p.initConsulDiscovery()
+	}
+
+	if p.Discovery != nil {
+		if err := p.Discovery.Init(p.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
+		}
+	}
+
+	if err := relabel.Init(p.RelabelConfigs); err != nil {
+		return fmt.Errorf("invalid 'relabel_configs': %w", err)
+	}
+	if err := relabel.Init(p.MetricRelabelConfigs); err != nil {
+		return fmt.Errorf("invalid 'metric_relabel_configs': %w", err)
+	}
 
 	return nil
 }
 
-// Start will start the Kubernetes and/or Consul scraping if enabled in the configuration
+// Start will connect to Kubernetes and/or Consul if scraping is enabled in
+// the configuration
 func (p *Prometheus) Start(_ telegraf.Accumulator) error {
-	var ctx context.Context
-	p.wg = sync.WaitGroup{}
-	ctx, p.cancel = context.WithCancel(context.Background())
-
-	if p.ConsulConfig.Enabled && len(p.ConsulConfig.Queries) > 0 {
-		if err := p.startConsul(ctx); err != nil {
-			return err
+	if p.podDiscovery != nil {
+		if err := p.podDiscovery.Init(p.Log); err != nil {
+			return fmt.Errorf("initializing pod discovery failed: %w", err)
 		}
 	}
-	if p.MonitorPods {
-		if err := p.startK8s(ctx); err != nil {
-			return err
+	for _, q := range p.ConsulConfig.Queries {
+		if q.discovery == nil {
+			continue
+		}
+		if err := q.discovery.Init(p.Log); err != nil {
+			return fmt.Errorf("initializing Consul discovery for service %q failed: %w", q.ServiceName, err)
 		}
 	}
 	return nil
@@ -298,9 +310,9 @@ func (p *Prometheus) Gather(acc telegraf.Accumulator) error {
 }
 
 func (p *Prometheus) Stop() {
-	p.cancel()
-	p.wg.Wait()
-
+	if p.podDiscovery != nil {
+		p.podDiscovery.Stop()
+	}
 	if p.client != nil {
 		p.client.CloseIdleConnections()
 	}
@@ -358,7 +370,7 @@ func addressToURL(u *url.URL, address string) *url.URL {
 }
 
 func (p *Prometheus) getAllURLs() (map[string]urlAndAddress, error) {
-	allURLs := make(map[string]urlAndAddress, len(p.URLs)+len(p.consulServices)+len(p.kubernetesPods))
+	allURLs := make(map[string]urlAndAddress, len(p.URLs))
 	for _, u := range p.URLs {
 		address, err := url.Parse(u)
 		if err != nil {
@@ -368,19 +380,26 @@ func (p *Prometheus) getAllURLs() (map[string]urlAndAddress, error) {
 		allURLs[address.String()] = urlAndAddress{url: address, originalURL: address}
 	}
 
-	p.lock.Lock()
-	defer p.lock.Unlock()
-	// add all services collected from consul
-	for k, v := range p.consulServices {
-		allURLs[k] = v
-	}
-	// loop through all pods scraped via the prometheus annotation on the pods
-	for _, v := range p.kubernetesPods {
-		if namespaceAnnotationMatch(v.namespace, p) {
-			allURLs[v.url.String()] = v
+	// add all targets of the generic service discovery
+	if p.Discovery != nil {
+		targets, err := p.Discovery.Targets(context.Background())
+		if err != nil {
+			p.Log.Errorf("Discovering targets failed: %v", err)
+		}
+		for _, t := range targets {
+			address, err := url.Parse(t.Endpoint)
+			if err != nil {
+				p.Log.Errorf("Could not parse discovered %q, skipping it. Error: %s", t.Endpoint, err.Error())
+				continue
+			}
+			allURLs[address.String()] = urlAndAddress{url: address, originalURL: address, tags: t.Tags()}
 		}
 	}
 
+	// add all pods scraped via the prometheus annotation on the pods and all
+	// services collected from consul
+	p.discoveredURLs(context.Background(), allURLs)
+
 	for _, service := range p.KubernetesServices {
 		address, err := url.Parse(service)
 		if err != nil {
@@ -401,12 +420,70 @@ func (p *Prometheus) getAllURLs() (map[string]urlAndAddress, error) {
 			}
 		}
 	}
-	return allURLs, nil
+
+	if len(p.RelabelConfigs) == 0 {
+		return allURLs, nil
+	}
+	return p.relabelTargets(allURLs), nil
+}
+
+// relabelTargets applies the target relabeling rules to all targets. Each
+// target is represented by its tags and the reserved __address__, __scheme__,
+// __metrics_path__ and __param_<name> labels. Targets might be dropped or
+// their URL rewritten.
+func (p *Prometheus) relabelTargets(targets map[string]urlAndAddress) map[string]urlAndAddress {
+	relabeled := make(map[string]urlAndAddress, len(targets))
+	for _, target := range targets {
+		targetLabels := make(map[string]string, len(target.tags)+3)
+		for k, v := range target.tags {
+			targetLabels[k] = v
+		}
+		targetLabels["__address__"] = target.url.Host
+		targetLabels["__scheme__"] = target.url.Scheme
+		targetLabels["__metrics_path__"] = target.url.Path
+		for k, v := range target.url.Query() {
+			if len(v) > 0 {
+				targetLabels["__param_"+k] = v[0]
+			}
+		}
+
+		if !relabel.Process(p.RelabelConfigs, targetLabels) {
+			continue
+		}
+
+		u := *target.url
+		u.Host = targetLabels["__address__"]
+		u.Scheme = targetLabels["__scheme__"]
+		u.Path = targetLabels["__metrics_path__"]
+		query := u.Query()
+		tags := make(map[string]string, len(targetLabels))
+		for k, v := range targetLabels {
+			if name, found := strings.CutPrefix(k, "__param_"); found {
+				query.Set(name, v)
+				continue
+			}
+			if strings.HasPrefix(k, "__") {
+				continue
+			}
+			tags[k] = v
+		}
+		u.RawQuery = query.Encode()
+		if u.Host == "" {
+			p.Log.Errorf("Relabeling removed the address of target %q, skipping it", target.url)
+			continue
+		}
+
+		if target.originalURL == target.url {
+			target.originalURL = &u
+		}
+		target.url = &u
+		target.tags = tags
+		relabeled[u.String()] = target
+	}
+	return relabeled
 }
 
 func (p *Prometheus) gatherURL(u urlAndAddress, acc telegraf.Accumulator) (map[string]interface{}, map[string]string, error) {
-	var req *http.Request
-	var uClient *http.Client
 	requestFields := make(map[string]interface{})
 	tags := make(map[string]string, len(u.tags)+2)
 	if p.URLTag != "" {
@@ -419,89 +496,13 @@ func (p *Prometheus) gatherURL(u urlAndAddress, acc telegraf.Accumulator) (map[s
 		tags[k] = v
 	}
 
-	if u.url.Scheme == "unix" {
-		path := u.url.Query().Get("path")
-		if path == "" {
-			path = "/metrics"
-		}
-
-		var err error
-		addr := "http://localhost" + path
-		req, err = http.NewRequest("GET", addr, nil)
-		if err != nil {
-			return nil, nil, fmt.Errorf("unable to create new request %q: %w", addr, err)
-		}
-
-		//nolint:errcheck // ignore error because it's been handled before getting here
-		tlsCfg, _ := p.HTTPClientConfig.TLSConfig()
-		uClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig:   tlsCfg,
-				DisableKeepAlives: true,
-				Dial: func(string, string) (net.Conn, error) {
-					c, err := net.Dial("unix", u.url.Path)
-					return c, err
-				},
-			},
-		}
-	} else {
-		if u.url.Path == "" {
-			u.url.Path = "/metrics"
-		}
-		var err error
-		req, err = http.NewRequest("GET", u.url.String(), nil)
-		if err != nil {
-			return nil, nil, fmt.Errorf("unable to create new request %q: %w", u.url.String(), err)
-		}
-	}
-
-	p.addHeaders(req)
-
-	if p.BearerToken != "" {
-		token, err := os.ReadFile(p.BearerToken)
-		if err != nil {
-			return nil, nil, err
-		}
-		req.Header.Set("Authorization", "Bearer "+string(token))
-	} else if !p.BearerTokenString.Empty() {
-		token, err := p.BearerTokenString.Get()
-		if err != nil {
-			return nil, nil, fmt.Errorf("getting token secret failed: %w", err)
-		}
-		req.Header.Set("Authorization", "Bearer "+token.String())
-		token.Destroy()
-	} else if !p.Username.Empty() || !p.Password.Empty() {
-		username, err := p.Username.Get()
-		if err != nil {
-			return nil, nil, fmt.Errorf("getting username secret failed: %w", err)
-		}
-		password, err := p.Password.Get()
-		if err != nil {
-			return nil, nil, fmt.Errorf("getting password secret failed: %w", err)
-		}
-		req.SetBasicAuth(username.String(), password.String())
-		username.Destroy()
-		password.Destroy()
-	}
-
-	for key, value := range p.HTTPHeaders {
-		if strings.EqualFold(key, "host") {
-			req.Host = value
-		} else {
-			req.Header.Set(key, value)
-		}
+	req, uClient, err := p.newRequest(u)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var err error
-	var resp *http.Response
-	var start time.Time
-	if u.url.Scheme != "unix" {
-		start = time.Now()
-		resp, err = p.client.Do(req)
-	} else {
-		start = time.Now()
-		resp, err = uClient.Do(req)
-	}
+	start := time.Now()
+	resp, err := uClient.Do(req)
 	end := time.Since(start).Seconds()
 	if err != nil {
 		return requestFields, tags, fmt.Errorf("error making HTTP request to %q: %w", u.url, err)
@@ -580,23 +581,206 @@ func (p *Prometheus) gatherURL(u urlAndAddress, acc telegraf.Accumulator) (map[s
 			tags[k] = v
 		}
 
-		switch metric.Type() {
-		case telegraf.Counter:
-			acc.AddCounter(metric.Name(), metric.Fields(), tags, metric.Time())
-		case telegraf.Gauge:
-			acc.AddGauge(metric.Name(), metric.Fields(), tags, metric.Time())
-		case telegraf.Summary:
-			acc.AddSummary(metric.Name(), metric.Fields(), tags, metric.Time())
-		case telegraf.Histogram:
-			acc.AddHistogram(metric.Name(), metric.Fields(), tags, metric.Time())
-		default:
-			acc.AddFields(metric.Name(), metric.Fields(), tags, metric.Time())
+		if len(p.MetricRelabelConfigs) == 0 {
+			addMetric(acc, metric.Type(), metric.Name(), metric.Fields(), tags, metric.Time())
+			continue
+		}
+
+		// With metric version 2 the Prometheus metric names are stored as
+		// fields so the rules have to be applied to each field
+		if p.MetricVersion == 2 {
+			for _, g := range p.relabelFields(metric, tags) {
+				addMetric(acc, metric.Type(), metric.Name(), g.fields, g.tags, metric.Time())
+			}
+			continue
+		}
+
+		name := metric.Name()
+		if !relabelMetric(p.MetricRelabelConfigs, &name, tags) {
+			continue
 		}
+		addMetric(acc, metric.Type(), name, metric.Fields(), tags, metric.Time())
 	}
 
 	return requestFields, tags, nil
 }
 
+type fieldGroup struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// relabelFields applies the metric relabeling rules to each field of the
+// metric using the field name as metric name. Dropped fields are removed and
+// fields ending up with the same tags are grouped into one metric.
+func (p *Prometheus) relabelFields(metric telegraf.Metric, tags map[string]string) []*fieldGroup {
+	groups := make([]*fieldGroup, 0, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		name := field.Key
+		fieldTags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			fieldTags[k] = v
+		}
+		if !relabelMetric(p.MetricRelabelConfigs, &name, fieldTags) {
+			continue
+		}
+
+		var group *fieldGroup
+		for _, g := range groups {
+			if maps.Equal(g.tags, fieldTags) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &fieldGroup{tags: fieldTags, fields: make(map[string]interface{})}
+			groups = append(groups, group)
+		}
+		group.fields[name] = field.Value
+	}
+	return groups
+}
+
+// relabelMetric applies the metric relabeling rules to the given name and
+// tags and returns false if the metric should be dropped. Labels starting
+// with '__' are removed from the tags.
+func relabelMetric(rules []*relabel.Config, name *string, tags map[string]string) bool {
+	tags[relabel.NameLabel] = *name
+	if !relabel.Process(rules, tags) {
+		return false
+	}
+	if n := tags[relabel.NameLabel]; n != "" {
+		*name = n
+	}
+	for k := range tags {
+		if strings.HasPrefix(k, "__") {
+			delete(tags, k)
+		}
+	}
+	return true
+}
+
+func addMetric(acc telegraf.Accumulator, tp telegraf.ValueType, name string, values map[string]interface{}, tags map[string]string, ts time.Time) {
+	switch tp {
+	case telegraf.Counter:
+		acc.AddCounter(name, values, tags, ts)
+	case telegraf.Gauge:
+		acc.AddGauge(name, values, tags, ts)
+	case telegraf.Summary:
+		acc.AddSummary(name, values, tags, ts)
+	case telegraf.Histogram:
+		acc.AddHistogram(name, values, tags, ts)
+	default:
+		acc.AddFields(name, values, tags, ts)
+	}
+}
+
+// newRequest creates the scrape request for the given target including the
+// authentication and headers and returns the client to use for sending it
+func (p *Prometheus) newRequest(u urlAndAddress) (*http.Request, *http.Client, error) {
+	var req *http.Request
+	var uClient *http.Client
+	if u.url.Scheme == "unix" {
+		path := u.url.Query().Get("path")
+		if path == "" {
+			path = "/metrics"
+		}
+
+		var err error
+		addr := "http://localhost" + path
+		req, err = http.NewRequest("GET", addr, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create new request %q: %w", addr, err)
+		}
+
+		//nolint:errcheck // ignore error because it's been handled before getting here
+		tlsCfg, _ := p.HTTPClientConfig.TLSConfig()
+		uClient = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   tlsCfg,
+				DisableKeepAlives: true,
+				Dial: func(string, string) (net.Conn, error) {
+					c, err := net.Dial("unix", u.url.Path)
+					return c, err
+				},
+			},
+		}
+	} else {
+		uClient = p.client
+		if u.url.Path == "" {
+			u.url.Path = "/metrics"
+		}
+		var err error
+		req, err = http.NewRequest("GET", u.url.String(), nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create new request %q: %w", u.url.String(), err)
+		}
+	}
+
+	p.addHeaders(req)
+
+	if p.BearerToken != "" {
+		token, err := os.ReadFile(p.BearerToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+string(token))
+	} else if !p.BearerTokenString.Empty() {
+		token, err := p.BearerTokenString.Get()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting token secret failed: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.String())
+		token.Destroy()
+	} else if !p.Username.Empty() || !p.Password.Empty() {
+		username, err := p.Username.Get()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting username secret failed: %w", err)
+		}
+		password, err := p.Password.Get()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting password secret failed: %w", err)
+		}
+		req.SetBasicAuth(username.String(), password.String())
+		username.Destroy()
+		password.Destroy()
+	}
+
+	for key, value := range p.HTTPHeaders {
+		if strings.EqualFold(key, "host") {
+			req.Host = value
+		} else {
+			req.Header.Set(key, value)
+		}
+	}
+
+	return req, uClient, nil
+}
+
+// Probe checks that all configured URLs are reachable and respond with a
+// successful status code
+func (p *Prometheus) Probe() error {
+	for _, u := range p.URLs {
+		address, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("parsing %q failed: %w", u, err)
+		}
+		req, client, err := p.newRequest(urlAndAddress{url: address, originalURL: address})
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error making HTTP request to %q: %w", address, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%q returned HTTP status %q", address, resp.Status)
+		}
+	}
+	return nil
+}
+
 func (p *Prometheus) addHeaders(req *http.Request) {
 	for header, value := range p.headers {
 		req.Header.Add(header, value)
@@ -630,9 +814,7 @@ func fieldSelectorIsSupported(fieldSelector fields.Selector) (bool, string) {
 func init() {
 	inputs.Add("prometheus", func() telegraf.Input {
 		return &Prometheus{
-			kubernetesPods: make(map[podID]urlAndAddress),
-			consulServices: make(map[string]urlAndAddress),
-			URLTag:         "url",
+			URLTag: "url",
 		}
 	})
 }
@@ -17,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -569,6 +570,197 @@ func TestPrometheusGeneratesMetricsWithIgnoreTimestamp(t *testing.T) {
 	require.WithinDuration(t, time.Now(), m.Time, 5*time.Second)
 }
 
+func TestPrometheusRelabelTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/federate" || r.URL.Query().Get("match") != "up" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := fmt.Fprintln(w, sampleTextFormat); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	p := &Prometheus{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL + "/metrics", "http://dropped.example.com/metrics"},
+		RelabelConfigs: []*relabel.Config{
+			{
+				Action:       "drop",
+				SourceLabels: []string{"__address__"},
+				Regex:        "dropped.*",
+			},
+			{
+				SourceLabels: []string{"__metrics_path__"},
+				Regex:        "/metrics",
+				TargetLabel:  "__metrics_path__",
+				Replacement:  stringPtr("/federate"),
+			},
+			{
+				TargetLabel: "__param_match",
+				Replacement: stringPtr("up"),
+			},
+			{
+				SourceLabels: []string{"__scheme__"},
+				TargetLabel:  "scheme",
+			},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	require.True(t, acc.HasFloatField("test_metric", "value"))
+	require.Equal(t, "http", acc.TagValue("test_metric", "scheme"))
+	require.False(t, acc.HasTag("test_metric", "__scheme__"))
+}
+
+func TestPrometheusMetricRelabel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := fmt.Fprintln(w, sampleTextFormat); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	p := &Prometheus{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL},
+		MetricRelabelConfigs: []*relabel.Config{
+			{
+				Action:       "drop",
+				SourceLabels: []string{"__name__"},
+				Regex:        "go_.*",
+			},
+			{
+				SourceLabels: []string{"__name__", "label"},
+				Regex:        "test_(.*);(.*)",
+				TargetLabel:  "__name__",
+				Replacement:  stringPtr("${1}_${2}"),
+			},
+			{
+				Action: "labeldrop",
+				Regex:  "label",
+			},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	require.False(t, acc.HasMeasurement("go_goroutines"))
+	require.False(t, acc.HasMeasurement("go_gc_duration_seconds"))
+	require.False(t, acc.HasMeasurement("test_metric"))
+	require.True(t, acc.HasFloatField("metric_value", "value"))
+	require.False(t, acc.HasTag("metric_value", "label"))
+	require.False(t, acc.HasTag("metric_value", "__name__"))
+}
+
+func TestPrometheusMetricRelabelV2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := fmt.Fprintln(w, sampleTextFormat); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	p := &Prometheus{
+		Log:           testutil.Logger{},
+		URLs:          []string{ts.URL},
+		URLTag:        "",
+		MetricVersion: 2,
+		MetricRelabelConfigs: []*relabel.Config{
+			{
+				Action:       "drop",
+				SourceLabels: []string{"__name__"},
+				Regex:        "go_gc_.*_count",
+			},
+			{
+				SourceLabels: []string{"__name__"},
+				Regex:        "go_(.*)",
+				TargetLabel:  "__name__",
+				Replacement:  stringPtr("golang_${1}"),
+			},
+			{
+				SourceLabels: []string{"__name__"},
+				Regex:        "test_metric",
+				TargetLabel:  "kind",
+				Replacement:  stringPtr("test"),
+			},
+		},
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	require.True(t, acc.HasFloatField("prometheus", "golang_goroutines"))
+	require.True(t, acc.HasFloatField("prometheus", "golang_gc_duration_seconds"))
+	require.True(t, acc.HasFloatField("prometheus", "golang_gc_duration_seconds_sum"))
+	require.False(t, acc.HasField("prometheus", "go_goroutines"))
+	require.False(t, acc.HasField("prometheus", "go_gc_duration_seconds_count"))
+	require.False(t, acc.HasField("prometheus", "golang_gc_duration_seconds_count"))
+
+	for _, m := range acc.GetTelegrafMetrics() {
+		require.NotContains(t, m.Tags(), "__name__")
+		if _, found := m.GetField("test_metric"); found {
+			require.Equal(t, map[string]string{"kind": "test", "label": "value"}, m.Tags())
+		} else {
+			require.NotContains(t, m.Tags(), "kind")
+		}
+	}
+}
+
+func TestPrometheusRelabelInvalid(t *testing.T) {
+	p := &Prometheus{
+		Log:                  testutil.Logger{},
+		MetricRelabelConfigs: []*relabel.Config{{Action: "foo"}},
+	}
+	require.ErrorContains(t, p.Init(), "invalid 'metric_relabel_configs'")
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestPrometheusProbe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := fmt.Fprintln(w, sampleTextFormat); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	p := &Prometheus{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL},
+	}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Probe())
+
+	p = &Prometheus{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL + "/missing"},
+	}
+	require.NoError(t, p.Init())
+	require.ErrorContains(t, p.Probe(), "404 Not Found")
+}
+
 func TestUnsupportedFieldSelector(t *testing.T) {
 	fieldSelectorString := "spec.containerName=container"
 	prom := &Prometheus{Log: testutil.Logger{}, KubernetesFieldSelector: fieldSelectorString}
@@ -638,8 +830,9 @@ func TestInitConfigSelectors(t *testing.T) {
 	err := p.Init()
 	require.NoError(t, err)
 
-	require.NotNil(t, p.podLabelSelector)
-	require.NotNil(t, p.podFieldSelector)
+	require.NotNil(t, p.podDiscovery)
+	require.Equal(t, "app=test", p.podDiscovery.Kubernetes[0].LabelSelector)
+	require.Equal(t, "spec.nodeName=node-0", p.podDiscovery.Kubernetes[0].FieldSelector)
 }
 
 func TestPrometheusInternalOk(t *testing.T) {
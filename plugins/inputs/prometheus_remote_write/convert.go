@@ -0,0 +1,177 @@
+package prometheus_remote_write
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// measurement used for all metrics, compatible with the output of the
+// "prometheusremotewrite" parser
+const measurement = "prometheus_remote_write"
+
+// writeStats contains the number of samples and histograms written which
+// are reported to the client for protocol 2.0
+type writeStats struct {
+	samples    int
+	histograms int
+}
+
+// converter turns the time-series of a remote-write request into metrics
+type converter struct {
+	now     time.Time
+	metrics []telegraf.Metric
+	stats   writeStats
+}
+
+func (c *converter) convertV1(req *prompb.WriteRequest) error {
+	// Metadata is sent per metric-family, so collect the types first
+	types := make(map[string]telegraf.ValueType, len(req.Metadata))
+	for _, md := range req.Metadata {
+		switch md.Type {
+		case prompb.MetricMetadata_COUNTER:
+			types[md.MetricFamilyName] = telegraf.Counter
+		case prompb.MetricMetadata_GAUGE:
+			types[md.MetricFamilyName] = telegraf.Gauge
+		}
+	}
+
+	for _, ts := range req.Timeseries {
+		tags := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			tags[l.Name] = l.Value
+		}
+		name, err := metricName(tags)
+		if err != nil {
+			return err
+		}
+
+		vtype, found := types[name]
+		if !found {
+			vtype = types[strings.TrimSuffix(name, "_total")]
+		}
+
+		for _, s := range ts.Samples {
+			c.addSample(name, tags, s.Value, s.Timestamp, vtype)
+		}
+		for _, h := range ts.Histograms {
+			c.addHistogram(name, tags, h.ToFloatHistogram(), h.Timestamp)
+		}
+	}
+	return nil
+}
+
+func (c *converter) convertV2(req *writev2.Request) error {
+	symbols := req.Symbols
+	if len(symbols) > 0 && symbols[0] != "" {
+		return errors.New("first symbol must be an empty string")
+	}
+	symbol := func(ref uint32) (string, error) {
+		if int(ref) >= len(symbols) {
+			return "", fmt.Errorf("symbol reference %d out of range", ref)
+		}
+		return symbols[ref], nil
+	}
+
+	for _, ts := range req.Timeseries {
+		if len(ts.LabelsRefs)%2 != 0 {
+			return fmt.Errorf("odd number of label references %d", len(ts.LabelsRefs))
+		}
+		tags := make(map[string]string, len(ts.LabelsRefs)/2)
+		for i := 0; i < len(ts.LabelsRefs); i += 2 {
+			key, err := symbol(ts.LabelsRefs[i])
+			if err != nil {
+				return err
+			}
+			value, err := symbol(ts.LabelsRefs[i+1])
+			if err != nil {
+				return err
+			}
+			tags[key] = value
+		}
+		name, err := metricName(tags)
+		if err != nil {
+			return err
+		}
+
+		var vtype telegraf.ValueType
+		switch ts.Metadata.Type {
+		case writev2.Metadata_METRIC_TYPE_COUNTER:
+			vtype = telegraf.Counter
+		case writev2.Metadata_METRIC_TYPE_GAUGE:
+			vtype = telegraf.Gauge
+		}
+
+		for _, s := range ts.Samples {
+			c.addSample(name, tags, s.Value, s.Timestamp, vtype)
+		}
+		for _, h := range ts.Histograms {
+			c.addHistogram(name, tags, h.ToFloatHistogram(), h.Timestamp)
+		}
+	}
+	return nil
+}
+
+func metricName(tags map[string]string) (string, error) {
+	name := tags[model.MetricNameLabel]
+	if name == "" {
+		return "", fmt.Errorf("metric name %q not found in tag-set or empty", model.MetricNameLabel)
+	}
+	delete(tags, model.MetricNameLabel)
+	return name, nil
+}
+
+func (c *converter) timestamp(ms int64) time.Time {
+	if ms > 0 {
+		return time.UnixMilli(ms)
+	}
+	return c.now
+}
+
+func (c *converter) addSample(name string, tags map[string]string, value float64, ts int64, vtype telegraf.ValueType) {
+	c.stats.samples++
+
+	// Skip stale markers and other NaN values as they cannot be represented
+	if math.IsNaN(value) {
+		return
+	}
+	fields := map[string]interface{}{name: value}
+	c.metrics = append(c.metrics, metric.New(measurement, tags, fields, c.timestamp(ts), vtype))
+}
+
+// addHistogram converts a native histogram into its sum, count and the
+// cumulative buckets in the same way as the "prometheusremotewrite" parser
+func (c *converter) addHistogram(name string, tags map[string]string, h *histogram.FloatHistogram, ts int64) {
+	c.stats.histograms++
+
+	t := c.timestamp(ts)
+	c.metrics = append(c.metrics,
+		metric.New(measurement, tags, map[string]interface{}{name + "_sum": h.Sum}, t),
+		metric.New(measurement, tags, map[string]interface{}{name + "_count": h.Count}, t),
+	)
+
+	var count float64
+	iter := h.AllBucketIterator()
+	for iter.Next() {
+		bucket := iter.At()
+		count += bucket.Count
+
+		bucketTags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			bucketTags[k] = v
+		}
+		bucketTags[name+"_le"] = strconv.FormatFloat(bucket.Upper, 'g', -1, 64)
+		c.metrics = append(c.metrics, metric.New(measurement, bucketTags, map[string]interface{}{name: count}, t))
+	}
+}
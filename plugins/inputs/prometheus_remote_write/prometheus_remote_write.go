@@ -0,0 +1,384 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package prometheus_remote_write
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	protoMsgV1          = "prometheus.WriteRequest"
+	protoMsgV2          = "io.prometheus.write.v2.Request"
+
+	headerSamplesWritten    = "X-Prometheus-Remote-Write-Samples-Written"
+	headerHistogramsWritten = "X-Prometheus-Remote-Write-Histograms-Written"
+	headerExemplarsWritten  = "X-Prometheus-Remote-Write-Exemplars-Written"
+)
+
+type PrometheusRemoteWrite struct {
+	ServiceAddress        string          `toml:"service_address"`
+	Path                  string          `toml:"path"`
+	ReadTimeout           config.Duration `toml:"read_timeout"`
+	WriteTimeout          config.Duration `toml:"write_timeout"`
+	MaxBodySize           config.Size     `toml:"max_body_size"`
+	MaxUndeliveredMetrics int             `toml:"max_undelivered_metrics"`
+	RetryAfter            config.Duration `toml:"retry_after"`
+	BasicUsername         string          `toml:"basic_username"`
+	BasicPassword         config.Secret   `toml:"basic_password"`
+	Log                   telegraf.Logger `toml:"-"`
+	common_tls.ServerConfig
+
+	server       *http.Server
+	listener     net.Listener
+	backpressure telegraf.Backpressure
+	wg           sync.WaitGroup
+
+	acc         telegraf.TrackingAccumulator
+	cancel      context.CancelFunc
+	pending     map[telegraf.TrackingID]int
+	undelivered int
+	sync.Mutex
+
+	requestsV1        selfstat.Stat
+	requestsV2        selfstat.Stat
+	requestsFailed    selfstat.Stat
+	requestsThrottled selfstat.Stat
+}
+
+func (*PrometheusRemoteWrite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PrometheusRemoteWrite) Init() error {
+	if p.ServiceAddress == "" {
+		p.ServiceAddress = ":9201"
+	}
+	if p.Path == "" {
+		p.Path = "/api/v1/write"
+	}
+	if !strings.HasPrefix(p.Path, "/") {
+		return fmt.Errorf("invalid 'path' setting %q", p.Path)
+	}
+	if p.MaxUndeliveredMetrics < 1 {
+		return fmt.Errorf("invalid 'max_undelivered_metrics' setting %d", p.MaxUndeliveredMetrics)
+	}
+	if p.ReadTimeout < config.Duration(time.Second) {
+		p.ReadTimeout = config.Duration(10 * time.Second)
+	}
+	if p.WriteTimeout < config.Duration(time.Second) {
+		p.WriteTimeout = config.Duration(10 * time.Second)
+	}
+	if p.MaxBodySize == 0 {
+		p.MaxBodySize = config.Size(32 * 1024 * 1024)
+	}
+
+	tlsConf, err := p.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(p.Path, p.serveWrite)
+	p.server = &http.Server{
+		Addr:         p.ServiceAddress,
+		Handler:      mux,
+		ReadTimeout:  time.Duration(p.ReadTimeout),
+		WriteTimeout: time.Duration(p.WriteTimeout),
+		TLSConfig:    tlsConf,
+	}
+
+	tags := map[string]string{"address": p.ServiceAddress}
+	p.requestsV1 = selfstat.Register("prometheus_remote_write", "requests_v1", tags)
+	p.requestsV2 = selfstat.Register("prometheus_remote_write", "requests_v2", tags)
+	p.requestsFailed = selfstat.Register("prometheus_remote_write", "requests_failed", tags)
+	p.requestsThrottled = selfstat.Register("prometheus_remote_write", "requests_throttled", tags)
+
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) SetBackpressure(bp telegraf.Backpressure) {
+	p.backpressure = bp
+}
+
+func (p *PrometheusRemoteWrite) Start(acc telegraf.Accumulator) error {
+	// Limit the number of in-flight groups to the number of undelivered
+	// metrics as each request produces at least one metric.
+	p.acc = acc.WithTracking(p.MaxUndeliveredMetrics)
+	p.pending = make(map[telegraf.TrackingID]int)
+	p.undelivered = 0
+
+	var listener net.Listener
+	var err error
+	if p.server.TLSConfig != nil {
+		listener, err = tls.Listen("tcp", p.ServiceAddress, p.server.TLSConfig)
+	} else {
+		listener, err = net.Listen("tcp", p.ServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
+	p.listener = listener
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.trackDeliveries(ctx)
+	}()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := p.server.Serve(p.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.Log.Errorf("Serve failed: %v", err)
+		}
+	}()
+
+	p.Log.Infof("Listening on %s", listener.Addr().String())
+
+	return nil
+}
+
+func (*PrometheusRemoteWrite) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Stop() {
+	if p.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.WriteTimeout))
+		defer cancel()
+		if err := p.server.Shutdown(ctx); err != nil {
+			p.Log.Errorf("Shutting down server failed: %v", err)
+		}
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// trackDeliveries releases the undelivered metrics of a request as soon as
+// the outputs accepted or dropped them
+func (p *PrometheusRemoteWrite) trackDeliveries(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-p.acc.Delivered():
+			p.Lock()
+			p.undelivered -= p.pending[info.ID()]
+			delete(p.pending, info.ID())
+			p.Unlock()
+		}
+	}
+}
+
+func (p *PrometheusRemoteWrite) serveWrite(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		http.Error(res, "only POST requests are accepted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !p.authenticate(req) {
+		http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+		return
+	}
+
+	// Reject the request early if the outputs of the agent cannot keep up
+	if p.backpressure != nil && p.backpressure.Throttled() {
+		p.throttle(res, "outputs cannot keep up, retry later")
+		return
+	}
+
+	// Negotiate the protocol version using the content-type and encoding.
+	// Requests without those headers are treated as protocol 1.0 to support
+	// older clients.
+	version, err := protocolVersion(req.Header.Get("Content-Type"))
+	if err != nil {
+		p.fail(res, err, http.StatusUnsupportedMediaType)
+		return
+	}
+	if enc := req.Header.Get("Content-Encoding"); enc != "" && enc != "snappy" {
+		p.fail(res, fmt.Errorf("unsupported content encoding %q, only snappy is accepted", enc), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Read the snappy block compressed body making sure the compressed and
+	// decompressed sizes do not exceed the limit before decoding.
+	body, err := io.ReadAll(http.MaxBytesReader(res, req.Body, int64(p.MaxBodySize)))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			p.fail(res, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		p.fail(res, fmt.Errorf("reading body failed: %w", err), http.StatusBadRequest)
+		return
+	}
+	size, err := snappy.DecodedLen(body)
+	if err != nil {
+		p.fail(res, fmt.Errorf("invalid snappy block: %w", err), http.StatusBadRequest)
+		return
+	}
+	if size > int(p.MaxBodySize) {
+		p.fail(res, fmt.Errorf("decompressed body size %d exceeds limit", size), http.StatusRequestEntityTooLarge)
+		return
+	}
+	buf, err := snappy.Decode(nil, body)
+	if err != nil {
+		p.fail(res, fmt.Errorf("decompressing body failed: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c := &converter{now: time.Now()}
+	switch version {
+	case protoMsgV1:
+		p.requestsV1.Incr(1)
+		var wr prompb.WriteRequest
+		if err := wr.Unmarshal(buf); err != nil {
+			p.fail(res, fmt.Errorf("decoding request failed: %w", err), http.StatusBadRequest)
+			return
+		}
+		err = c.convertV1(&wr)
+	case protoMsgV2:
+		p.requestsV2.Incr(1)
+		var wr writev2.Request
+		if err := wr.Unmarshal(buf); err != nil {
+			p.fail(res, fmt.Errorf("decoding request failed: %w", err), http.StatusBadRequest)
+			return
+		}
+		err = c.convertV2(&wr)
+	}
+	if err != nil {
+		p.fail(res, err, http.StatusBadRequest)
+		return
+	}
+
+	// Apply backpressure by asking the client to retry later if the outputs
+	// cannot keep up with the metrics received. A single request is always
+	// accepted to not block requests larger than the limit forever.
+	if len(c.metrics) > 0 {
+		p.Lock()
+		if p.undelivered > 0 && p.undelivered+len(c.metrics) > p.MaxUndeliveredMetrics {
+			p.Unlock()
+			p.throttle(res, "too many undelivered metrics")
+			return
+		}
+		id := p.acc.AddTrackingMetricGroup(c.metrics)
+		p.pending[id] = len(c.metrics)
+		p.undelivered += len(c.metrics)
+		p.Unlock()
+	}
+
+	if version == protoMsgV2 {
+		res.Header().Set(headerSamplesWritten, strconv.Itoa(c.stats.samples))
+		res.Header().Set(headerHistogramsWritten, strconv.Itoa(c.stats.histograms))
+		// Exemplars are not supported and thus dropped
+		res.Header().Set(headerExemplarsWritten, "0")
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// throttle asks the client to retry the request later
+func (p *PrometheusRemoteWrite) throttle(res http.ResponseWriter, reason string) {
+	p.requestsThrottled.Incr(1)
+	if p.RetryAfter > 0 {
+		seconds := max(int64(time.Duration(p.RetryAfter)/time.Second), 1)
+		res.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	http.Error(res, reason, http.StatusTooManyRequests)
+}
+
+func (p *PrometheusRemoteWrite) authenticate(req *http.Request) bool {
+	if p.BasicUsername == "" && p.BasicPassword.Empty() {
+		return true
+	}
+
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	secret, err := p.BasicPassword.Get()
+	if err != nil {
+		p.Log.Errorf("Getting password failed: %v", err)
+		return false
+	}
+	defer secret.Destroy()
+
+	return subtle.ConstantTimeCompare([]byte(username), []byte(p.BasicUsername)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), secret.Bytes()) == 1
+}
+
+func (p *PrometheusRemoteWrite) fail(res http.ResponseWriter, err error, code int) {
+	p.requestsFailed.Incr(1)
+	p.Log.Debugf("Request failed with status %d: %v", code, err)
+	http.Error(res, err.Error(), code)
+}
+
+// protocolVersion determines the protobuf message of the request from the
+// content-type header, e.g. "application/x-protobuf;proto=prometheus.WriteRequest"
+func protocolVersion(contentType string) (string, error) {
+	if contentType == "" {
+		return protoMsgV1, nil
+	}
+
+	parts := strings.Split(contentType, ";")
+	if strings.TrimSpace(parts[0]) != contentTypeProtobuf {
+		return "", fmt.Errorf("unsupported content type %q", contentType)
+	}
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			return "", fmt.Errorf("invalid content type parameter %q", param)
+		}
+		if key != "proto" {
+			continue
+		}
+		switch value {
+		case protoMsgV1, protoMsgV2:
+			return value, nil
+		}
+		return "", fmt.Errorf("unsupported protobuf message %q", value)
+	}
+	return protoMsgV1, nil
+}
+
+func init() {
+	inputs.Add("prometheus_remote_write", func() telegraf.Input {
+		return &PrometheusRemoteWrite{
+			ServiceAddress:        ":9201",
+			Path:                  "/api/v1/write",
+			MaxUndeliveredMetrics: 10000,
+			RetryAfter:            config.Duration(5 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,438 @@
+package prometheus_remote_write
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestPlugin(t *testing.T) (*PrometheusRemoteWrite, *testutil.Accumulator, string) {
+	plugin := &PrometheusRemoteWrite{
+		ServiceAddress:        "127.0.0.1:0",
+		MaxUndeliveredMetrics: 10000,
+		Log:                   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	t.Cleanup(plugin.Stop)
+
+	return plugin, &acc, "http://" + plugin.listener.Addr().String() + "/api/v1/write"
+}
+
+func post(t *testing.T, url, contentType string, body []byte) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, body)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "snappy")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp
+}
+
+func TestInitFail(t *testing.T) {
+	plugin := &PrometheusRemoteWrite{
+		Path:                  "/api/v1/write",
+		MaxUndeliveredMetrics: 0,
+		Log:                   testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid 'max_undelivered_metrics' setting")
+
+	plugin.MaxUndeliveredMetrics = 10
+	plugin.Path = "write"
+	require.ErrorContains(t, plugin.Init(), "invalid 'path' setting")
+}
+
+func TestProtocolV1(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	h := &histogram.FloatHistogram{
+		Count:           5,
+		Sum:             12.5,
+		Schema:          0,
+		ZeroThreshold:   0.001,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []float64{2, 3},
+	}
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "http_requests_total"},
+					{Name: "job", Value: "app"},
+				},
+				Samples: []prompb.Sample{{Value: 42, Timestamp: 1700000000000}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "temperature"},
+					{Name: "job", Value: "app"},
+				},
+				Samples: []prompb.Sample{{Value: 21.5, Timestamp: 1700000000000}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "latency"},
+				},
+				Histograms: []prompb.Histogram{prompb.FromFloatHistogram(1700000000000, h)},
+			},
+		},
+		Metadata: []prompb.MetricMetadata{
+			{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "http_requests"},
+			{Type: prompb.MetricMetadata_GAUGE, MetricFamilyName: "temperature"},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, "application/x-protobuf", body)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, resp.Header.Get(headerSamplesWritten))
+
+	ts := time.UnixMilli(1700000000000)
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "app"},
+			map[string]interface{}{"http_requests_total": float64(42)},
+			ts,
+			telegraf.Counter,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "app"},
+			map[string]interface{}{"temperature": 21.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"latency_sum": 12.5},
+			ts,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"latency_count": float64(5)},
+			ts,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"latency_le": "1"},
+			map[string]interface{}{"latency": float64(2)},
+			ts,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"latency_le": "2"},
+			map[string]interface{}{"latency": float64(5)},
+			ts,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestProtocolV2(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	h := &histogram.Histogram{
+		Count:           3,
+		Sum:             4,
+		Schema:          0,
+		PositiveSpans:   []histogram.Span{{Offset: 1, Length: 1}},
+		PositiveBuckets: []int64{3},
+	}
+	req := &writev2.Request{
+		Symbols: []string{"", "__name__", "http_requests_total", "job", "app", "help text", "latency"},
+		Timeseries: []writev2.TimeSeries{
+			{
+				LabelsRefs: []uint32{1, 2, 3, 4},
+				Samples:    []writev2.Sample{{Value: 42, Timestamp: 1700000000000}},
+				Exemplars:  []writev2.Exemplar{{Value: 1, Timestamp: 1700000000000}},
+				Metadata: writev2.Metadata{
+					Type:    writev2.Metadata_METRIC_TYPE_COUNTER,
+					HelpRef: 5,
+				},
+			},
+			{
+				LabelsRefs: []uint32{1, 6},
+				Histograms: []writev2.Histogram{writev2.FromIntHistogram(1700000000000, h)},
+				Metadata:   writev2.Metadata{Type: writev2.Metadata_METRIC_TYPE_HISTOGRAM},
+			},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, "application/x-protobuf;proto=io.prometheus.write.v2.Request", body)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(headerSamplesWritten))
+	require.Equal(t, "1", resp.Header.Get(headerHistogramsWritten))
+	require.Equal(t, "0", resp.Header.Get(headerExemplarsWritten))
+
+	ts := time.UnixMilli(1700000000000)
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "app"},
+			map[string]interface{}{"http_requests_total": float64(42)},
+			ts,
+			telegraf.Counter,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"latency_sum": float64(4)},
+			ts,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"latency_count": float64(3)},
+			ts,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"latency_le": "2"},
+			map[string]interface{}{"latency": float64(3)},
+			ts,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestInvalidRequests(t *testing.T) {
+	_, acc, url := newTestPlugin(t)
+
+	valid, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	unnamed, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "job", Value: "app"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	badRefs, err := (&writev2.Request{
+		Symbols:    []string{"", "__name__", "up"},
+		Timeseries: []writev2.TimeSeries{{LabelsRefs: []uint32{1, 5}}},
+	}).Marshal()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		encoding    string
+		body        []byte
+		expected    int
+	}{
+		{
+			name:     "wrong method",
+			method:   http.MethodGet,
+			expected: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "wrong content type",
+			contentType: "application/json",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unknown protobuf message",
+			contentType: "application/x-protobuf;proto=io.prometheus.write.v3.Request",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "wrong encoding",
+			contentType: "application/x-protobuf",
+			encoding:    "gzip",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "not snappy encoded",
+			contentType: "application/x-protobuf",
+			body:        valid,
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "missing metric name",
+			contentType: "application/x-protobuf",
+			body:        snappy.Encode(nil, unnamed),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid symbol reference",
+			contentType: "application/x-protobuf;proto=io.prometheus.write.v2.Request",
+			body:        snappy.Encode(nil, badRefs),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "decompression bomb",
+			contentType: "application/x-protobuf",
+			body:        snappy.Encode(nil, make([]byte, 33*1024*1024)),
+			expected:    http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, url, bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestBackpressure(t *testing.T) {
+	plugin := &PrometheusRemoteWrite{
+		ServiceAddress:        "127.0.0.1:0",
+		MaxUndeliveredMetrics: 2,
+		RetryAfter:            config.Duration(3 * time.Second),
+		Log:                   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	url := "http://" + plugin.listener.Addr().String() + "/api/v1/write"
+
+	body, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	// Fill up the undelivered metrics
+	require.Equal(t, http.StatusNoContent, post(t, url, "", body).StatusCode)
+	require.Equal(t, http.StatusNoContent, post(t, url, "", body).StatusCode)
+
+	resp := post(t, url, "", body)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "3", resp.Header.Get("Retry-After"))
+
+	// Deliver the metrics and retry
+	for _, m := range acc.GetTelegrafMetrics() {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool {
+		return post(t, url, "", body).StatusCode == http.StatusNoContent
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestAgentBackpressure(t *testing.T) {
+	bp := &testutil.Backpressure{}
+	plugin := &PrometheusRemoteWrite{
+		ServiceAddress:        "127.0.0.1:0",
+		MaxUndeliveredMetrics: 10,
+		RetryAfter:            config.Duration(3 * time.Second),
+		Log:                   testutil.Logger{},
+	}
+	plugin.SetBackpressure(bp)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	url := "http://" + plugin.listener.Addr().String() + "/api/v1/write"
+
+	body, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	bp.Throttle(true)
+	resp := post(t, url, "", body)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "3", resp.Header.Get("Retry-After"))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	bp.Throttle(false)
+	require.Equal(t, http.StatusNoContent, post(t, url, "", body).StatusCode)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+}
+
+func TestBasicAuth(t *testing.T) {
+	plugin := &PrometheusRemoteWrite{
+		ServiceAddress:        "127.0.0.1:0",
+		MaxUndeliveredMetrics: 10,
+		BasicUsername:         "user",
+		BasicPassword:         config.NewSecret([]byte("secret")),
+		Log:                   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	url := "http://" + plugin.listener.Addr().String() + "/api/v1/write"
+
+	body, err := (&prompb.WriteRequest{}).Marshal()
+	require.NoError(t, err)
+
+	for _, password := range []string{"wrong", "secret"} {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, body)))
+		require.NoError(t, err)
+		req.SetBasicAuth("user", password)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		if password == "secret" {
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		} else {
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	}
+}
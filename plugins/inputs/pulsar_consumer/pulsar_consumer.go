@@ -0,0 +1,278 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package pulsar_consumer
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_pulsar "github.com/influxdata/telegraf/plugins/common/pulsar"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var once sync.Once
+
+var subscriptionTypes = map[string]pulsar.SubscriptionType{
+	"exclusive":  pulsar.Exclusive,
+	"shared":     pulsar.Shared,
+	"failover":   pulsar.Failover,
+	"key_shared": pulsar.KeyShared,
+}
+
+var initialPositions = map[string]pulsar.SubscriptionInitialPosition{
+	"latest":   pulsar.SubscriptionPositionLatest,
+	"earliest": pulsar.SubscriptionPositionEarliest,
+}
+
+type empty struct{}
+type semaphore chan empty
+
+// consumer is the part of the Pulsar consumer used by the plugin
+type consumer interface {
+	Chan() <-chan pulsar.ConsumerMessage
+	Ack(pulsar.Message) error
+	Nack(pulsar.Message)
+	Close()
+}
+
+type PulsarConsumer struct {
+	Topics                 []string        `toml:"topics"`
+	TopicsPattern          string          `toml:"topics_pattern"`
+	TopicTag               string          `toml:"topic_tag"`
+	SubscriptionName       string          `toml:"subscription_name"`
+	SubscriptionType       string          `toml:"subscription_type"`
+	InitialPosition        string          `toml:"initial_position"`
+	ConsumerName           string          `toml:"consumer_name"`
+	ReceiverQueueSize      int             `toml:"receiver_queue_size"`
+	MaxUndeliveredMessages int             `toml:"max_undelivered_messages"`
+	NackRedeliveryDelay    config.Duration `toml:"nack_redelivery_delay"`
+	MaxRedeliveries        uint32          `toml:"max_redeliveries"`
+	DeadLetterTopic        string          `toml:"dead_letter_topic"`
+	Log                    telegraf.Logger `toml:"-"`
+	common_pulsar.ClientConfig
+
+	parser   telegraf.Parser
+	client   pulsar.Client
+	consumer consumer
+	messages map[telegraf.TrackingID]pulsar.Message
+	wg       sync.WaitGroup
+	cancel   context.CancelFunc
+}
+
+func (*PulsarConsumer) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PulsarConsumer) SetParser(parser telegraf.Parser) {
+	p.parser = parser
+}
+
+func (p *PulsarConsumer) Init() error {
+	if len(p.Topics) == 0 && p.TopicsPattern == "" {
+		return errors.New("either 'topics' or 'topics_pattern' must be set")
+	}
+	if len(p.Topics) > 0 && p.TopicsPattern != "" {
+		return errors.New("'topics' and 'topics_pattern' cannot be used at the same time")
+	}
+
+	if p.SubscriptionName == "" {
+		return errors.New("'subscription_name' setting required")
+	}
+	if _, found := subscriptionTypes[p.SubscriptionType]; !found {
+		return fmt.Errorf("invalid 'subscription_type' setting %q", p.SubscriptionType)
+	}
+	if _, found := initialPositions[p.InitialPosition]; !found {
+		return fmt.Errorf("invalid 'initial_position' setting %q", p.InitialPosition)
+	}
+
+	if p.MaxUndeliveredMessages < 1 {
+		return fmt.Errorf("invalid 'max_undelivered_messages' setting %d", p.MaxUndeliveredMessages)
+	}
+	if p.DeadLetterTopic != "" && p.MaxRedeliveries == 0 {
+		return errors.New("'dead_letter_topic' requires 'max_redeliveries' to be set")
+	}
+
+	return nil
+}
+
+func (p *PulsarConsumer) Start(acc telegraf.Accumulator) error {
+	client, err := p.ClientConfig.NewClient(p.Log)
+	if err != nil {
+		return &internal.StartupError{
+			Err:   fmt.Errorf("creating client failed: %w", err),
+			Retry: true,
+		}
+	}
+
+	options := pulsar.ConsumerOptions{
+		Topics:                      p.Topics,
+		TopicsPattern:               p.TopicsPattern,
+		SubscriptionName:            p.SubscriptionName,
+		Type:                        subscriptionTypes[p.SubscriptionType],
+		SubscriptionInitialPosition: initialPositions[p.InitialPosition],
+		Name:                        p.ConsumerName,
+		ReceiverQueueSize:           p.ReceiverQueueSize,
+		NackRedeliveryDelay:         time.Duration(p.NackRedeliveryDelay),
+	}
+	if p.MaxRedeliveries > 0 {
+		// The client counts the first delivery as well
+		options.DLQ = &pulsar.DLQPolicy{
+			MaxDeliveries:   p.MaxRedeliveries + 1,
+			DeadLetterTopic: p.DeadLetterTopic,
+		}
+	}
+
+	c, err := client.Subscribe(options)
+	if err != nil {
+		client.Close()
+		return &internal.StartupError{
+			Err:   fmt.Errorf("subscribing as %q failed: %w", p.SubscriptionName, err),
+			Retry: true,
+		}
+	}
+	p.client = client
+	p.start(acc, c)
+
+	return nil
+}
+
+// start processes the messages of the given consumer in the background
+func (p *PulsarConsumer) start(acc telegraf.Accumulator, c consumer) {
+	p.consumer = c
+	p.messages = make(map[telegraf.TrackingID]pulsar.Message)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.process(ctx, acc.WithTracking(p.MaxUndeliveredMessages))
+	}()
+}
+
+func (*PulsarConsumer) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *PulsarConsumer) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+
+	// Messages not yet acknowledged are redelivered by the broker after the
+	// consumer is closed
+	if p.consumer != nil {
+		p.consumer.Close()
+		p.consumer = nil
+	}
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
+
+// process reads messages from the consumer and adds them to the accumulator
+// while limiting the number of messages not yet delivered to the outputs
+func (p *PulsarConsumer) process(ctx context.Context, acc telegraf.TrackingAccumulator) {
+	sem := make(semaphore, p.MaxUndeliveredMessages)
+	msgs := p.consumer.Chan()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case track := <-acc.Delivered():
+			if p.onDelivery(track) {
+				<-sem
+			}
+		case sem <- empty{}:
+			select {
+			case <-ctx.Done():
+				return
+			case track := <-acc.Delivered():
+				if p.onDelivery(track) {
+					<-sem
+					<-sem
+				}
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				if err := p.onMessage(acc, msg.Message); err != nil {
+					acc.AddError(err)
+					<-sem
+				}
+			}
+		}
+	}
+}
+
+func (p *PulsarConsumer) onMessage(acc telegraf.TrackingAccumulator, msg pulsar.Message) error {
+	metrics, err := p.parser.Parse(msg.Payload())
+	if err != nil {
+		// Acknowledge the message as it will never be processable
+		if err := p.consumer.Ack(msg); err != nil {
+			p.Log.Errorf("Unable to acknowledge message %v: %v", msg.ID(), err)
+		}
+		return fmt.Errorf("parsing message %v of topic %q failed: %w", msg.ID(), msg.Topic(), err)
+	}
+	if len(metrics) == 0 {
+		once.Do(func() {
+			p.Log.Debug(internal.NoMetricsCreatedMsg)
+		})
+	}
+
+	if p.TopicTag != "" {
+		for _, m := range metrics {
+			m.AddTag(p.TopicTag, msg.Topic())
+		}
+	}
+
+	id := acc.AddTrackingMetricGroup(metrics)
+	p.messages[id] = msg
+	return nil
+}
+
+func (p *PulsarConsumer) onDelivery(track telegraf.DeliveryInfo) bool {
+	msg, found := p.messages[track.ID()]
+	if !found {
+		return false
+	}
+	delete(p.messages, track.ID())
+
+	// Messages not delivered to the outputs are negatively acknowledged so
+	// the broker redelivers them after the redelivery delay
+	if !track.Delivered() {
+		p.consumer.Nack(msg)
+		return true
+	}
+	if err := p.consumer.Ack(msg); err != nil {
+		p.Log.Errorf("Unable to acknowledge message %v: %v", msg.ID(), err)
+	}
+	return true
+}
+
+func init() {
+	inputs.Add("pulsar_consumer", func() telegraf.Input {
+		return &PulsarConsumer{
+			SubscriptionName:       "telegraf",
+			SubscriptionType:       "shared",
+			InitialPosition:        "latest",
+			MaxUndeliveredMessages: 1000,
+			NackRedeliveryDelay:    config.Duration(time.Minute),
+		}
+	})
+}
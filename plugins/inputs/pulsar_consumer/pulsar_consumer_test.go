@@ -0,0 +1,294 @@
+package pulsar_consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newPlugin(t *testing.T) *PulsarConsumer {
+	creator, found := inputs.Inputs["pulsar_consumer"]
+	require.True(t, found)
+	plugin := creator().(*PulsarConsumer)
+	plugin.Log = testutil.Logger{}
+
+	parser := &influx.Parser{}
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+
+	return plugin
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*PulsarConsumer)
+		expected string
+	}{
+		{
+			name:     "missing topics",
+			modify:   func(*PulsarConsumer) {},
+			expected: "either 'topics' or 'topics_pattern' must be set",
+		},
+		{
+			name: "topics and pattern",
+			modify: func(p *PulsarConsumer) {
+				p.Topics = []string{"telegraf"}
+				p.TopicsPattern = "telegraf-.*"
+			},
+			expected: "cannot be used at the same time",
+		},
+		{
+			name: "missing subscription name",
+			modify: func(p *PulsarConsumer) {
+				p.Topics = []string{"telegraf"}
+				p.SubscriptionName = ""
+			},
+			expected: "'subscription_name' setting required",
+		},
+		{
+			name: "invalid subscription type",
+			modify: func(p *PulsarConsumer) {
+				p.Topics = []string{"telegraf"}
+				p.SubscriptionType = "foo"
+			},
+			expected: "invalid 'subscription_type' setting",
+		},
+		{
+			name: "invalid initial position",
+			modify: func(p *PulsarConsumer) {
+				p.Topics = []string{"telegraf"}
+				p.InitialPosition = "foo"
+			},
+			expected: "invalid 'initial_position' setting",
+		},
+		{
+			name: "dead letter topic without redeliveries",
+			modify: func(p *PulsarConsumer) {
+				p.Topics = []string{"telegraf"}
+				p.DeadLetterTopic = "dlq"
+			},
+			expected: "'dead_letter_topic' requires 'max_redeliveries'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t)
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	plugin := newPlugin(t)
+	plugin.Topics = []string{"telegraf"}
+	plugin.TopicTag = "topic"
+	require.NoError(t, plugin.Init())
+
+	c := &mockConsumer{messages: make(chan pulsar.ConsumerMessage, 10)}
+	var acc testutil.Accumulator
+	plugin.start(&acc, c)
+	defer plugin.Stop()
+
+	c.send("1", "cpu value=1 1")
+	c.send("2", "invalid")
+	c.send("3", "cpu value=3 3")
+
+	// The message failing to parse must be acknowledged immediately
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() == 2
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Len(t, acc.Errors, 1)
+	require.Equal(t, []string{"2"}, c.acknowledged())
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"topic": "telegraf"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{"topic": "telegraf"}, map[string]interface{}{"value": 3.0}, time.Unix(0, 3)),
+	}
+	actual := acc.GetTelegrafMetrics()
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Messages must be acknowledged if the metrics were written and
+	// negatively acknowledged otherwise
+	actual[0].Accept()
+	actual[1].Reject()
+	require.Eventually(t, func() bool {
+		return len(c.acknowledged()) == 2 && len(c.negativelyAcknowledged()) == 1
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Equal(t, []string{"2", "1"}, c.acknowledged())
+	require.Equal(t, []string{"3"}, c.negativelyAcknowledged())
+}
+
+func TestMaxUndeliveredMessages(t *testing.T) {
+	plugin := newPlugin(t)
+	plugin.Topics = []string{"telegraf"}
+	plugin.MaxUndeliveredMessages = 1
+	require.NoError(t, plugin.Init())
+
+	c := &mockConsumer{messages: make(chan pulsar.ConsumerMessage, 10)}
+	var acc testutil.Accumulator
+	plugin.start(&acc, c)
+	defer plugin.Stop()
+
+	c.send("1", "cpu value=1 1")
+	c.send("2", "cpu value=2 2")
+
+	// The second message must not be processed before the first one was
+	// delivered
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() == 1
+	}, 3*time.Second, 100*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, uint64(1), acc.NMetrics())
+
+	acc.GetTelegrafMetrics()[0].Accept()
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() == 2
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Equal(t, []string{"1"}, c.acknowledged())
+}
+
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	container := testutil.Container{
+		Image:        "apachepulsar/pulsar",
+		Cmd:          []string{"bin/pulsar", "standalone"},
+		ExposedPorts: []string{"6650", "8080"},
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort(nat.Port("6650")),
+			wait.ForHTTP("/admin/v2/namespaces/public/default").WithPort("8080"),
+		),
+	}
+	require.NoError(t, container.Start(), "failed to start container")
+	defer container.Terminate()
+	url := fmt.Sprintf("pulsar://%s:%s", container.Address, container.Ports["6650"])
+
+	plugin := newPlugin(t)
+	plugin.ServiceURL = url
+	plugin.Topics = []string{"telegraf"}
+	plugin.InitialPosition = "earliest"
+	plugin.NackRedeliveryDelay = config.Duration(time.Second)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	client, err := pulsar.NewClient(pulsar.ClientOptions{URL: url})
+	require.NoError(t, err)
+	defer client.Close()
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{Topic: "telegraf"})
+	require.NoError(t, err)
+	defer producer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, payload := range []string{"cpu value=1 1", "cpu value=2 2"} {
+		_, err := producer.Send(ctx, &pulsar.ProducerMessage{Payload: []byte(payload)})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// Rejected metrics must be redelivered by the broker
+	metrics := acc.GetTelegrafMetrics()
+	metrics[0].Accept()
+	metrics[1].Reject()
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 3
+	}, 10*time.Second, 100*time.Millisecond)
+
+	expected := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2))
+	testutil.RequireMetricEqual(t, expected, acc.GetTelegrafMetrics()[2])
+}
+
+// mockConsumer delivers the messages sent and records the acknowledgements
+// using the message keys
+type mockConsumer struct {
+	messages chan pulsar.ConsumerMessage
+	acks     []string
+	nacks    []string
+
+	sync.Mutex
+}
+
+func (c *mockConsumer) send(key, payload string) {
+	c.messages <- pulsar.ConsumerMessage{
+		Message: &mockMessage{key: key, payload: []byte(payload)},
+	}
+}
+
+func (c *mockConsumer) Chan() <-chan pulsar.ConsumerMessage {
+	return c.messages
+}
+
+func (c *mockConsumer) Ack(msg pulsar.Message) error {
+	c.Lock()
+	defer c.Unlock()
+	c.acks = append(c.acks, msg.Key())
+	return nil
+}
+
+func (c *mockConsumer) Nack(msg pulsar.Message) {
+	c.Lock()
+	defer c.Unlock()
+	c.nacks = append(c.nacks, msg.Key())
+}
+
+func (*mockConsumer) Close() {}
+
+func (c *mockConsumer) acknowledged() []string {
+	c.Lock()
+	defer c.Unlock()
+	return append([]string(nil), c.acks...)
+}
+
+func (c *mockConsumer) negativelyAcknowledged() []string {
+	c.Lock()
+	defer c.Unlock()
+	return append([]string(nil), c.nacks...)
+}
+
+// mockMessage implements the parts of a message used by the plugin, calling
+// any other method panics
+type mockMessage struct {
+	pulsar.Message
+	key     string
+	payload []byte
+}
+
+func (m *mockMessage) Key() string {
+	return m.key
+}
+
+func (m *mockMessage) Payload() []byte {
+	return m.payload
+}
+
+func (*mockMessage) Topic() string {
+	return "telegraf"
+}
+
+func (*mockMessage) ID() pulsar.MessageID {
+	return pulsar.EarliestMessageID()
+}
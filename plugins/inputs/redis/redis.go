@@ -7,6 +7,7 @@ import (
 	_ "embed"
 	"fmt"
 	"io"
+	"net"
 	"net/url"
 	"reflect"
 	"regexp"
@@ -18,6 +19,7 @@ import (
 	"github.com/go-redis/redis/v8"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -42,10 +44,13 @@ type Redis struct {
 
 	tls.ClientConfig
 
+	Discovery *discovery.Discovery `toml:"discovery"`
+
 	Log telegraf.Logger `toml:"-"`
 
-	clients   []client
-	connected bool
+	clients    []client
+	discovered map[string]client
+	connected  bool
 }
 
 type redisCommand struct {
@@ -188,6 +193,13 @@ func (r *Redis) Init() error {
 		}
 	}
 
+	if r.Discovery != nil {
+		if err := r.Discovery.Init(r.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
+		}
+		r.discovered = make(map[string]client)
+	}
+
 	return nil
 }
 
@@ -195,6 +207,25 @@ func (*Redis) Start(telegraf.Accumulator) error {
 	return nil
 }
 
+// Probe checks that all configured servers are reachable and accept the
+// credentials
+func (r *Redis) Probe() error {
+	if err := r.connect(); err != nil {
+		return err
+	}
+	for _, c := range r.clients {
+		if err := c.info().Err(); err != nil {
+			tags := c.baseTags()
+			address := tags["socket"]
+			if address == "" {
+				address = net.JoinHostPort(tags["server"], tags["port"])
+			}
+			return fmt.Errorf("probing server %q failed: %w", address, err)
+		}
+	}
+	return nil
+}
+
 func (r *Redis) Gather(acc telegraf.Accumulator) error {
 	if !r.connected {
 		err := r.connect()
@@ -214,6 +245,10 @@ func (r *Redis) Gather(acc telegraf.Accumulator) error {
 		}(cl)
 	}
 
+	if r.Discovery != nil {
+		r.gatherDiscovered(acc, &wg)
+	}
+
 	wg.Wait()
 	return nil
 }
@@ -226,6 +261,11 @@ func (r *Redis) Stop() {
 			r.Log.Errorf("error closing client: %v", err)
 		}
 	}
+	for _, c := range r.discovered {
+		if err := c.close(); err != nil {
+			r.Log.Errorf("error closing client: %v", err)
+		}
+	}
 }
 
 func (r *Redis) connect() error {
@@ -233,77 +273,124 @@ func (r *Redis) connect() error {
 		return nil
 	}
 
-	if len(r.Servers) == 0 {
+	if len(r.Servers) == 0 && r.Discovery == nil {
 		r.Servers = []string{"tcp://localhost:6379"}
 	}
 
 	r.clients = make([]client, 0, len(r.Servers))
 	for _, serv := range r.Servers {
-		if !strings.HasPrefix(serv, "tcp://") && !strings.HasPrefix(serv, "unix://") {
-			r.Log.Warn("Server URL found without scheme; please update your configuration file")
-			serv = "tcp://" + serv
-		}
-
-		u, err := url.Parse(serv)
+		c, err := r.newClient(serv)
 		if err != nil {
-			return fmt.Errorf("unable to parse to address %q: %w", serv, err)
+			return err
 		}
+		r.clients = append(r.clients, c)
+	}
 
-		username := ""
-		password := ""
-		if u.User != nil {
-			username = u.User.Username()
-			pw, ok := u.User.Password()
-			if ok {
-				password = pw
-			}
-		}
-		if len(r.Username) > 0 {
-			username = r.Username
-		}
-		if len(r.Password) > 0 {
-			password = r.Password
-		}
+	r.connected = true
+	return nil
+}
 
-		var address string
-		if u.Scheme == "unix" {
-			address = u.Path
-		} else {
-			address = u.Host
-		}
+func (r *Redis) newClient(serv string) (client, error) {
+	if !strings.HasPrefix(serv, "tcp://") && !strings.HasPrefix(serv, "unix://") {
+		r.Log.Warn("Server URL found without scheme; please update your configuration file")
+		serv = "tcp://" + serv
+	}
 
-		tlsConfig, err := r.ClientConfig.TLSConfig()
-		if err != nil {
-			return err
+	u, err := url.Parse(serv)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse to address %q: %w", serv, err)
+	}
+
+	username := ""
+	password := ""
+	if u.User != nil {
+		username = u.User.Username()
+		pw, ok := u.User.Password()
+		if ok {
+			password = pw
 		}
+	}
+	if len(r.Username) > 0 {
+		username = r.Username
+	}
+	if len(r.Password) > 0 {
+		password = r.Password
+	}
+
+	var address string
+	if u.Scheme == "unix" {
+		address = u.Path
+	} else {
+		address = u.Host
+	}
+
+	tlsConfig, err := r.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
 
-		client := redis.NewClient(
-			&redis.Options{
-				Addr:      address,
-				Username:  username,
-				Password:  password,
-				Network:   u.Scheme,
-				PoolSize:  1,
-				TLSConfig: tlsConfig,
-			},
-		)
+	client := redis.NewClient(
+		&redis.Options{
+			Addr:      address,
+			Username:  username,
+			Password:  password,
+			Network:   u.Scheme,
+			PoolSize:  1,
+			TLSConfig: tlsConfig,
+		},
+	)
+
+	tags := make(map[string]string, 2)
+	if u.Scheme == "unix" {
+		tags["socket"] = u.Path
+	} else {
+		tags["server"] = u.Hostname()
+		tags["port"] = u.Port()
+	}
+
+	return &redisClient{
+		client: client,
+		tags:   tags,
+	}, nil
+}
 
-		tags := make(map[string]string, 2)
-		if u.Scheme == "unix" {
-			tags["socket"] = u.Path
-		} else {
-			tags["server"] = u.Hostname()
-			tags["port"] = u.Port()
+// gatherDiscovered gathers all discovered servers, connecting to new ones and
+// closing the connection to vanished servers.
+func (r *Redis) gatherDiscovered(acc telegraf.Accumulator, wg *sync.WaitGroup) {
+	targets, err := r.Discovery.Targets(context.Background())
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	current := make(map[string]client, len(targets))
+	for _, t := range targets {
+		c, found := r.discovered[t.Endpoint]
+		if !found {
+			c, err = r.newClient(t.Endpoint)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
 		}
+		current[t.Endpoint] = c
 
-		r.clients = append(r.clients, &redisClient{
-			client: client,
-			tags:   tags,
-		})
+		wg.Add(1)
+		go func(client client, acc telegraf.Accumulator) {
+			defer wg.Done()
+			acc.AddError(gatherServer(client, acc))
+			acc.AddError(r.gatherCommandValues(client, acc))
+		}(c, discovery.NewAccumulator(acc, t))
 	}
 
-	r.connected = true
-	return nil
+	for endpoint, c := range r.discovered {
+		if _, found := current[endpoint]; found {
+			continue
+		}
+		if err := c.close(); err != nil {
+			r.Log.Errorf("error closing client: %v", err)
+		}
+	}
+	r.discovered = current
 }
 
 func (r *Redis) gatherCommandValues(client client, acc telegraf.Accumulator) error {
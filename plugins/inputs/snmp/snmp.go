@@ -2,21 +2,28 @@
 package snmp
 
 import (
+	"context"
 	_ "embed"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/gosnmp/gosnmp"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal/snmp"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
+// sysUpTimeOID is the OID of the sysUpTime.0 object available on all agents
+const sysUpTimeOID = ".1.3.6.1.2.1.1.3.0"
+
 type Snmp struct {
 	// The SNMP agent to query. Format is [SCHEME://]ADDR[:PORT] (e.g.
 	// udp://1.2.3.4:161).  If the scheme is not specified then "udp" is used.
@@ -35,9 +42,13 @@ type Snmp struct {
 	Name   string       `toml:"name"`
 	Fields []snmp.Field `toml:"field"`
 
+	// Discovery of additional agents
+	Discovery *discovery.Discovery `toml:"discovery"`
+
 	Log telegraf.Logger `toml:"-"`
 
-	connectionCache []snmp.Connection
+	connectionCache       []snmp.Connection
+	discoveredConnections map[string]snmp.Connection
 
 	translator snmp.Translator
 }
@@ -88,6 +99,31 @@ func (s *Snmp) Init() error {
 		})
 	}
 
+	if s.Discovery != nil {
+		if err := s.Discovery.Init(s.Log); err != nil {
+			return fmt.Errorf("initializing discovery failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Probe checks that all configured agents respond by querying the standard
+// sysUpTime object
+func (s *Snmp) Probe() error {
+	for i, agent := range s.Agents {
+		gs, err := s.getConnection(i)
+		if err != nil {
+			return fmt.Errorf("agent %s: %w", agent, err)
+		}
+		pkt, err := gs.Get([]string{sysUpTimeOID})
+		if err != nil {
+			return fmt.Errorf("agent %s: probing failed: %w", agent, err)
+		}
+		if pkt.Error != gosnmp.NoError {
+			return fmt.Errorf("agent %s: probing failed: %s", agent, pkt.Error)
+		}
+	}
 	return nil
 }
 
@@ -102,30 +138,60 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
 				return
 			}
+			s.gatherAgent(acc, gs, agent)
+		}(i, agent)
+	}
 
-			// First is the top-level fields. We treat the fields as table prefixes with an empty index.
-			t := snmp.Table{
-				Name:   s.Name,
-				Fields: s.Fields,
+	if s.Discovery != nil {
+		targets, err := s.Discovery.Targets(context.Background())
+		if err != nil {
+			acc.AddError(err)
+		}
+
+		// Reuse the connections of known targets and drop vanished ones
+		current := make(map[string]snmp.Connection, len(targets))
+		for _, t := range targets {
+			gs, err := s.getDiscoveredConnection(t.Endpoint)
+			if gs != nil {
+				current[t.Endpoint] = gs
 			}
-			topTags := make(map[string]string)
-			if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
-				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
+			if err != nil {
+				acc.AddError(fmt.Errorf("agent %s: %w", t.Endpoint, err))
+				continue
 			}
 
-			// Now is the real tables.
-			for _, t := range s.Tables {
-				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
-					acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
-				}
-			}
-		}(i, agent)
+			wg.Add(1)
+			go func(acc telegraf.Accumulator, agent string) {
+				defer wg.Done()
+				s.gatherAgent(acc, gs, agent)
+			}(discovery.NewAccumulator(acc, t), t.Endpoint)
+		}
+		s.discoveredConnections = current
 	}
 	wg.Wait()
 
 	return nil
 }
 
+func (s *Snmp) gatherAgent(acc telegraf.Accumulator, gs snmp.Connection, agent string) {
+	// First is the top-level fields. We treat the fields as table prefixes with an empty index.
+	t := snmp.Table{
+		Name:   s.Name,
+		Fields: s.Fields,
+	}
+	topTags := make(map[string]string)
+	if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
+		acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
+	}
+
+	// Now is the real tables.
+	for _, t := range s.Tables {
+		if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
+			acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
+		}
+	}
+}
+
 func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmp.Connection, t snmp.Table, topTags map[string]string, walk bool) error {
 	rt, err := t.Build(gs, walk)
 	if err != nil {
@@ -189,6 +255,29 @@ func (s *Snmp) getConnection(idx int) (snmp.Connection, error) {
 	return gs, nil
 }
 
+// getDiscoveredConnection returns the cached connection for the given
+// discovered agent or creates a new one.
+func (s *Snmp) getDiscoveredConnection(agent string) (snmp.Connection, error) {
+	if gs, found := s.discoveredConnections[agent]; found {
+		if err := gs.Reconnect(); err != nil {
+			return gs, fmt.Errorf("reconnecting: %w", err)
+		}
+		return gs, nil
+	}
+
+	gs, err := snmp.NewWrapper(s.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := gs.SetAgent(agent); err != nil {
+		return nil, err
+	}
+	if err := gs.Connect(); err != nil {
+		return gs, fmt.Errorf("setting up connection: %w", err)
+	}
+	return gs, nil
+}
+
 func init() {
 	inputs.Add("snmp", func() telegraf.Input {
 		return &Snmp{
@@ -1,6 +1,7 @@
 package snmp
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"path/filepath"
@@ -772,6 +773,28 @@ func TestGatherGosmi(t *testing.T) {
 	require.Equal(t, 123456, m2.Fields["myOtherField"])
 }
 
+type failingSNMPConnection struct {
+	testSNMPConnection
+}
+
+func (*failingSNMPConnection) Get([]string) (*gosnmp.SnmpPacket, error) {
+	return nil, errors.New("request timeout")
+}
+
+func TestProbe(t *testing.T) {
+	s := &Snmp{
+		Agents:          []string{"TestProbe"},
+		connectionCache: []snmp.Connection{tsc},
+	}
+	require.NoError(t, s.Probe())
+
+	s = &Snmp{
+		Agents:          []string{"TestProbe"},
+		connectionCache: []snmp.Connection{&failingSNMPConnection{}},
+	}
+	require.ErrorContains(t, s.Probe(), "agent TestProbe: probing failed: request timeout")
+}
+
 func TestGather_hostGosmi(t *testing.T) {
 	s := &Snmp{
 		Agents: []string{"TestGather"},
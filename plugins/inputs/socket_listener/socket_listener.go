@@ -26,8 +26,9 @@ type SocketListener struct {
 	socket.Config
 	socket.SplitConfig
 
-	socket *socket.Socket
-	parser telegraf.Parser
+	socket       *socket.Socket
+	parser       telegraf.Parser
+	backpressure telegraf.Backpressure
 }
 
 func (*SocketListener) SampleConfig() string {
@@ -43,11 +44,16 @@ func (sl *SocketListener) Init() error {
 	if err != nil {
 		return err
 	}
+	sock.SetBackpressure(sl.backpressure)
 	sl.socket = sock
 
 	return nil
 }
 
+func (sl *SocketListener) SetBackpressure(bp telegraf.Backpressure) {
+	sl.backpressure = bp
+}
+
 func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 	// Create the callbacks for parsing the data and recording issues
 	onData := func(_ net.Addr, data []byte, receiveTime time.Time) {
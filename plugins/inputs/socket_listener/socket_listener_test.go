@@ -348,6 +348,50 @@ func TestLargeReadBufferUnixgram(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
 }
 
+func TestBackpressureTCP(t *testing.T) {
+	bp := &testutil.Backpressure{}
+	plugin := &SocketListener{
+		ServiceAddress: "tcp://127.0.0.1:0",
+		SplitConfig: socket.SplitConfig{
+			SplittingStrategy: "newline",
+		},
+		Log: &testutil.Logger{},
+	}
+	parser := &influx.Parser{}
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+	plugin.SetBackpressure(bp)
+
+	// Start the plugin with the outputs signaling backpressure
+	bp.Throttle(true)
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	client, err := createClient(plugin.ServiceAddress, plugin.socket.Address(), nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("test value=1i 1700000000000000000\n"))
+	require.NoError(t, err)
+
+	// No data must be read as long as the backpressure is signaled
+	time.Sleep(100 * time.Millisecond)
+	require.Zero(t, acc.NMetrics())
+
+	// Releasing the backpressure continues reading
+	bp.Throttle(false)
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(1700000000, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
 func TestCases(t *testing.T) {
 	// Get all directories in testdata
 	folders, err := os.ReadDir("testcases")
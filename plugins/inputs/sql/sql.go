@@ -18,6 +18,8 @@ import (
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/watermark"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -43,6 +45,8 @@ type SQL struct {
 	driverName      string
 	db              *dbsql.DB
 	serverConnected bool
+
+	watermarks *watermark.Watermarks
 }
 
 type query struct {
@@ -61,6 +65,7 @@ type query struct {
 	FieldColumnsUint    []string `toml:"field_columns_uint"`
 	FieldColumnsBool    []string `toml:"field_columns_bool"`
 	FieldColumnsString  []string `toml:"field_columns_string"`
+	watermark.Config
 
 	statement         *dbsql.Stmt
 	tagFilter         filter.Filter
@@ -90,6 +95,8 @@ func (s *SQL) Init() error {
 		s.Timeout = config.Duration(5 * time.Second)
 	}
 
+	s.watermarks = watermark.New(s.Log)
+
 	if s.MaxIdleConnections == magicIdleCount {
 		// Determine the number by the number of queries + the golang default value
 		s.MaxIdleConnections = len(s.Queries) + 2
@@ -166,6 +173,18 @@ func (s *SQL) Init() error {
 		if q.Measurement == "" {
 			s.Queries[i].Measurement = "sql"
 		}
+
+		// Setup incremental queries
+		if err := s.Queries[i].Config.Init(); err != nil {
+			return err
+		}
+		// The watermarks are identified by the measurement to keep the state
+		// when modifying the query text
+		if s.Queries[i].Enabled() {
+			if err := s.watermarks.Register(s.Queries[i].Measurement, &s.Queries[i].Config); err != nil {
+				return fmt.Errorf("incremental queries require distinct 'measurement' settings: %w", err)
+			}
+		}
 	}
 
 	// Derive the sql-framework driver name from our config name. This abstracts the actual driver
@@ -216,11 +235,15 @@ func (s *SQL) Init() error {
 	return nil
 }
 
-func (s *SQL) Start(telegraf.Accumulator) error {
+func (s *SQL) Start(acc telegraf.Accumulator) error {
 	if err := s.setupConnection(); err != nil {
 		return err
 	}
 
+	// Track the delivery of incremental query results to advance the
+	// watermarks
+	s.watermarks.Start(acc)
+
 	if err := s.ping(); err != nil {
 		if s.DisconnectedServersBehavior == "error" {
 			return err
@@ -234,6 +257,19 @@ func (s *SQL) Start(telegraf.Accumulator) error {
 	return nil
 }
 
+// Probe checks that the database server is reachable and accepts the
+// credentials
+func (s *SQL) Probe() error {
+	if s.serverConnected {
+		return nil
+	}
+	if err := s.ping(); err != nil {
+		return err
+	}
+	s.prepareStatements()
+	return nil
+}
+
 func (s *SQL) Gather(acc telegraf.Accumulator) error {
 	// during plugin startup, it is possible that the server was not reachable.
 	// we try pinging the server in this collection cycle.
@@ -265,6 +301,8 @@ func (s *SQL) Gather(acc telegraf.Accumulator) error {
 }
 
 func (s *SQL) Stop() {
+	s.watermarks.Stop()
+
 	// Free the statements
 	for _, q := range s.Queries {
 		if q.statement != nil {
@@ -282,6 +320,18 @@ func (s *SQL) Stop() {
 	}
 }
 
+func (s *SQL) GetState() interface{} {
+	return s.watermarks.GetState()
+}
+
+func (s *SQL) SetState(state interface{}) error {
+	watermarksState, ok := state.(watermark.State)
+	if !ok {
+		return errors.New("state has to be of type 'watermark.State'")
+	}
+	return s.watermarks.SetState(watermarksState)
+}
+
 func (s *SQL) setupConnection() error {
 	// Connect to the database server
 	dsnSecret, err := s.Dsn.Get()
@@ -340,22 +390,13 @@ func (s *SQL) prepareStatements() {
 }
 
 func (s *SQL) executeQuery(ctx context.Context, acc telegraf.Accumulator, q query, tquery time.Time) error {
-	// Execute the query either prepared or unprepared
-	var rows *dbsql.Rows
-	if q.statement != nil {
-		// Use the previously prepared query
-		var err error
-		rows, err = q.statement.QueryContext(ctx)
-		if err != nil {
-			return err
-		}
-	} else {
-		// Fallback to unprepared query
-		var err error
-		rows, err = s.db.Query(q.Query)
-		if err != nil {
-			return err
-		}
+	if q.Enabled() {
+		return s.executeIncremental(ctx, q, tquery)
+	}
+
+	rows, err := s.query(ctx, q)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
@@ -364,12 +405,41 @@ func (s *SQL) executeQuery(ctx context.Context, acc telegraf.Accumulator, q quer
 	if err != nil {
 		return err
 	}
-	rowCount, err := q.parse(acc, rows, tquery, s.Log)
+	rowCount, _, err := q.parse(rows, tquery, s.Log, acc.AddMetric)
 	s.Log.Debugf("Received %d rows and %d columns for query %q", rowCount, len(columnNames), q.Query)
 
 	return err
 }
 
+// executeIncremental runs the query page by page with the current watermark
+// as parameter and sends the resulting metrics to the outputs with tracking
+func (s *SQL) executeIncremental(ctx context.Context, q query, tquery time.Time) error {
+	return s.watermarks.Query(q.Measurement, func(current interface{}) ([]telegraf.Metric, int, interface{}, error) {
+		rows, err := s.query(ctx, q, q.Args(current)...)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		defer rows.Close()
+
+		var metrics []telegraf.Metric
+		rowCount, latest, err := q.parse(rows, tquery, s.Log, func(m telegraf.Metric) {
+			metrics = append(metrics, m)
+		})
+		return metrics, rowCount, latest, err
+	})
+}
+
+func (s *SQL) query(ctx context.Context, q query, args ...interface{}) (*dbsql.Rows, error) {
+	// Execute the query either prepared or unprepared
+	if q.statement != nil {
+		// Use the previously prepared query
+		return q.statement.QueryContext(ctx, args...)
+	}
+
+	// Fallback to unprepared query
+	return s.db.Query(q.Query, args...)
+}
+
 func (s *SQL) checkDSN() error {
 	if s.Dsn.Empty() {
 		return errors.New("missing data source name (DSN) option")
@@ -377,10 +447,10 @@ func (s *SQL) checkDSN() error {
 	return nil
 }
 
-func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, logger telegraf.Logger) (int, error) {
+func (q *query) parse(rows *dbsql.Rows, t time.Time, logger telegraf.Logger, add func(telegraf.Metric)) (int, interface{}, error) {
 	columnNames, err := rows.Columns()
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	// Prepare the list of datapoints according to the received row
@@ -392,6 +462,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 	}
 
 	rowCount := 0
+	var latest interface{}
 	for rows.Next() {
 		measurement := q.Measurement
 		timestamp := t
@@ -400,10 +471,18 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 
 		// Do the parsing with (hopefully) automatic type conversion
 		if err := rows.Scan(columnDataPtr...); err != nil {
-			return 0, err
+			return 0, nil, err
 		}
 
 		for i, name := range columnNames {
+			if q.Enabled() && name == q.Column {
+				v, err := q.Convert(columnData[i])
+				if err != nil {
+					return 0, nil, fmt.Errorf("converting watermark column %q failed: %w", name, err)
+				}
+				latest = q.Max(latest, v)
+			}
+
 			if q.MeasurementColumn != "" && name == q.MeasurementColumn {
 				switch raw := columnData[i].(type) {
 				case string:
@@ -411,7 +490,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 				case []byte:
 					measurement = string(raw)
 				default:
-					return 0, fmt.Errorf("measurement column type \"%T\" unsupported", columnData[i])
+					return 0, nil, fmt.Errorf("measurement column type \"%T\" unsupported", columnData[i])
 				}
 			}
 
@@ -430,11 +509,11 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 				case fmt.Stringer:
 					fieldvalue = v.String()
 				default:
-					return 0, fmt.Errorf("time column %q of type \"%T\" unsupported", name, columnData[i])
+					return 0, nil, fmt.Errorf("time column %q of type \"%T\" unsupported", name, columnData[i])
 				}
 				if !skipParsing {
 					if timestamp, err = internal.ParseTimestamp(q.TimeFormat, fieldvalue, nil); err != nil {
-						return 0, fmt.Errorf("parsing time failed: %w", err)
+						return 0, nil, fmt.Errorf("parsing time failed: %w", err)
 					}
 				}
 			}
@@ -442,7 +521,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 			if q.tagFilter.Match(name) {
 				tagvalue, err := internal.ToString(columnData[i])
 				if err != nil {
-					return 0, fmt.Errorf("converting tag column %q failed: %w", name, err)
+					return 0, nil, fmt.Errorf("converting tag column %q failed: %w", name, err)
 				}
 				if v := strings.TrimSpace(tagvalue); v != "" {
 					tags[name] = v
@@ -453,7 +532,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 			if q.fieldFilterFloat.Match(name) {
 				v, err := internal.ToFloat64(columnData[i])
 				if err != nil {
-					return 0, fmt.Errorf("converting field column %q to float failed: %w", name, err)
+					return 0, nil, fmt.Errorf("converting field column %q to float failed: %w", name, err)
 				}
 				fields[name] = v
 				continue
@@ -464,7 +543,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 				if err != nil {
 					if err != nil {
 						if !errors.Is(err, internal.ErrOutOfRange) {
-							return 0, fmt.Errorf("converting field column %q to int failed: %w", name, err)
+							return 0, nil, fmt.Errorf("converting field column %q to int failed: %w", name, err)
 						}
 						logger.Warnf("field column %q: %v", name, err)
 					}
@@ -477,7 +556,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 				v, err := internal.ToUint64(columnData[i])
 				if err != nil {
 					if !errors.Is(err, internal.ErrOutOfRange) {
-						return 0, fmt.Errorf("converting field column %q to uint failed: %w", name, err)
+						return 0, nil, fmt.Errorf("converting field column %q to uint failed: %w", name, err)
 					}
 					logger.Warnf("field column %q: %v", name, err)
 				}
@@ -488,7 +567,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 			if q.fieldFilterBool.Match(name) {
 				v, err := internal.ToBool(columnData[i])
 				if err != nil {
-					return 0, fmt.Errorf("converting field column %q to bool failed: %w", name, err)
+					return 0, nil, fmt.Errorf("converting field column %q to bool failed: %w", name, err)
 				}
 				fields[name] = v
 				continue
@@ -497,7 +576,7 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 			if q.fieldFilterString.Match(name) {
 				v, err := internal.ToString(columnData[i])
 				if err != nil {
-					return 0, fmt.Errorf("converting field column %q to string failed: %w", name, err)
+					return 0, nil, fmt.Errorf("converting field column %q to string failed: %w", name, err)
 				}
 				fields[name] = v
 				continue
@@ -518,22 +597,24 @@ func (q *query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, t time.Time, l
 				case fmt.Stringer:
 					fieldvalue = v.String()
 				default:
-					return 0, fmt.Errorf("field column %q of type \"%T\" unsupported", name, columnData[i])
+					return 0, nil, fmt.Errorf("field column %q of type \"%T\" unsupported", name, columnData[i])
 				}
 				if fieldvalue != nil {
 					fields[name] = fieldvalue
 				}
 			}
 		}
-		acc.AddFields(measurement, fields, tags, timestamp)
+		if len(fields) > 0 {
+			add(metric.New(measurement, tags, fields, timestamp))
+		}
 		rowCount++
 	}
 
 	if err := rows.Err(); err != nil {
-		return rowCount, err
+		return rowCount, latest, err
 	}
 
-	return rowCount, nil
+	return rowCount, latest, nil
 }
 
 func init() {
@@ -0,0 +1,128 @@
+//go:build !mips && !mipsle && !mips64 && !ppc64 && !riscv64 && !loong64 && !mips64le && !(windows && (386 || arm))
+
+package sql
+
+import (
+	dbsql "database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/watermark"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestIncrementalSQLite(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "events.db")
+
+	db, err := dbsql.Open("sqlite", dbfile)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE events (id INTEGER PRIMARY KEY, host TEXT, value INTEGER)")
+	require.NoError(t, err)
+	insert := func(ids ...int) {
+		for _, id := range ids {
+			_, err := db.Exec("INSERT INTO events (id, host, value) VALUES (?, ?, ?)", id, "server", id*10)
+			require.NoError(t, err)
+		}
+	}
+	insert(1, 2, 3, 4, 5)
+
+	newPlugin := func() *SQL {
+		return &SQL{
+			Driver: "sqlite",
+			Dsn:    config.NewSecret([]byte(dbfile)),
+			Queries: []query{
+				{
+					Query:               "SELECT id, host, value FROM events WHERE id > ? ORDER BY id LIMIT ?",
+					TagColumnsInclude:   []string{"host"},
+					FieldColumnsExclude: []string{"host"},
+					Config: watermark.Config{
+						Column:   "id",
+						PageSize: 2,
+						MaxPages: 2,
+					},
+				},
+			},
+			Log: testutil.Logger{},
+		}
+	}
+	expected := func(ids ...int) []telegraf.Metric {
+		metrics := make([]telegraf.Metric, 0, len(ids))
+		for _, id := range ids {
+			metrics = append(metrics, metric.New(
+				"sql",
+				map[string]string{"host": "server"},
+				map[string]interface{}{"id": int64(id), "value": int64(id * 10)},
+				time.Unix(0, 0),
+			))
+		}
+		return metrics
+	}
+
+	plugin := newPlugin()
+	require.NoError(t, plugin.Init())
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// The first gather must only collect two pages
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	testutil.RequireMetricsEqual(t, expected(1, 2, 3, 4), acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	// Querying must be skipped as long as the pages are not delivered and the
+	// watermark must not advance
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.GetTelegrafMetrics(), 4)
+	require.Equal(t, map[string]string{"sql": "0"}, plugin.GetState().(watermark.State).Queries)
+
+	// Delivering the metrics commits the watermark
+	for _, m := range acc.GetTelegrafMetrics() {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool {
+		return plugin.GetState().(watermark.State).Queries["sql"] == "4"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	testutil.RequireMetricsEqual(t, expected(5), acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+	state := plugin.GetState()
+
+	// Restarting with the persisted state continues after the committed
+	// watermark, i.e. undelivered rows are queried again
+	plugin.Stop()
+	insert(6)
+
+	restarted := newPlugin()
+	require.NoError(t, restarted.Init())
+	require.NoError(t, restarted.SetState(state))
+	var accRestarted testutil.Accumulator
+	require.NoError(t, restarted.Start(&accRestarted))
+	defer restarted.Stop()
+
+	require.NoError(t, restarted.Gather(&accRestarted))
+	require.Empty(t, accRestarted.Errors)
+	testutil.RequireMetricsEqual(t, expected(5, 6), accRestarted.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestIncrementalDuplicateMeasurement(t *testing.T) {
+	incremental := query{
+		Query:  "SELECT id, value FROM events WHERE id > ?",
+		Config: watermark.Config{Column: "id"},
+	}
+	plugin := &SQL{
+		Driver:  "sqlite",
+		Dsn:     config.NewSecret([]byte(filepath.Join(t.TempDir(), "events.db"))),
+		Queries: []query{incremental, incremental},
+		Log:     testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "incremental queries require distinct 'measurement' settings")
+}
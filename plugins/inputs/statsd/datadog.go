@@ -21,9 +21,94 @@ const (
 	eventSuccess = "success"
 )
 
+// Cardinality levels of the tags added by origin detection in increasing order
+const (
+	cardinalityNone = iota
+	cardinalityLow
+	cardinalityOrchestrator
+	cardinalityHigh
+)
+
+var cardinalityLevels = map[string]int{
+	"none":         cardinalityNone,
+	"low":          cardinalityLow,
+	"orchestrator": cardinalityOrchestrator,
+	"high":         cardinalityHigh,
+}
+
+// origin contains the origin detection fields of a DogStatsD message
+type origin struct {
+	containerID  string
+	externalData string
+	cardinality  string
+}
+
+// parseField consumes the given field if it is an origin detection field
+// (container-ID, external data or cardinality), returning false otherwise.
+func (o *origin) parseField(field string) bool {
+	switch {
+	case strings.HasPrefix(field, "c:"):
+		o.containerID = field[2:]
+	case strings.HasPrefix(field, "e:"):
+		o.externalData = field[2:]
+	case strings.HasPrefix(field, "card:"):
+		o.cardinality = field[5:]
+	default:
+		return false
+	}
+	return true
+}
+
+// addOriginTags adds the tags identifying the origin of a message depending
+// on the requested cardinality. The container-ID and the container name are
+// added for "low" cardinality, the pod UID for "orchestrator" cardinality and
+// the process ID of the sender for "high" cardinality.
+func (s *Statsd) addOriginTags(tags map[string]string, o *origin, peer *peerInfo) {
+	if s.DataDogKeepContainerTag && o.containerID != "" {
+		tags["container"] = o.containerID
+	}
+	if !s.DataDogOriginDetection {
+		return
+	}
+
+	cardinality := o.cardinality
+	if cardinality == "" {
+		cardinality = s.DataDogOriginCardinality
+	}
+	level, found := cardinalityLevels[cardinality]
+	if !found {
+		level = cardinalityLow
+	}
+	if level < cardinalityLow {
+		return
+	}
+
+	// Prefer the information sent by the client over the peer credentials
+	if o.containerID != "" {
+		tags["container"] = o.containerID
+	} else if peer != nil && peer.container != "" {
+		tags["container"] = peer.container
+	}
+
+	// External data is injected by the Datadog admission controller in the
+	// form "it-<bool>,cn-<container name>,pu-<pod uid>"
+	for _, item := range strings.Split(o.externalData, ",") {
+		switch {
+		case strings.HasPrefix(item, "cn-"):
+			tags["container_name"] = item[3:]
+		case strings.HasPrefix(item, "pu-") && level >= cardinalityOrchestrator:
+			tags["pod_uid"] = item[3:]
+		}
+	}
+
+	if level >= cardinalityHigh && peer != nil && peer.pid > 0 {
+		tags["pid"] = strconv.FormatInt(int64(peer.pid), 10)
+	}
+}
+
 var uncommenter = strings.NewReplacer("\\n", "\n")
 
-func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname string) error {
+func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname string, peer *peerInfo) error {
 	// _e{title.length,text.length}:title|text
 	//  [
 	//   |d:date_happened
@@ -32,6 +117,9 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 	//   |t:alert_type
 	//   |s:source_type_name
 	//   |#tag1,tag2
+	//   |c:container_id
+	//   |e:external_data
+	//   |card:cardinality
 	//  ]
 	//
 	//
@@ -82,15 +170,20 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 	fields["priority"] = priorityNormal
 	ts := now
 	if len(message) < 2 {
+		s.addOriginTags(tags, &origin{}, peer)
 		s.acc.AddFields(name, fields, tags, ts)
 		return nil
 	}
 
+	var o origin
 	rawMetadataFields := strings.Split(message[1:], "|")
 	for i := range rawMetadataFields {
 		if len(rawMetadataFields[i]) < 2 {
 			return errors.New("too short metadata field")
 		}
+		if o.parseField(rawMetadataFields[i]) {
+			continue
+		}
 		switch rawMetadataFields[i][:2] {
 		case "d:":
 			ts, err := strconv.ParseInt(rawMetadataFields[i][2:], 10, 64)
@@ -132,6 +225,83 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 		delete(tags, "host")
 		tags["source"] = host
 	}
+	s.addOriginTags(tags, &o, peer)
+	s.acc.AddFields(name, fields, tags, ts)
+	return nil
+}
+
+func (s *Statsd) parseServiceCheckMessage(now time.Time, message, defaultHostname string, peer *peerInfo) error {
+	// _sc|name|status
+	//  [
+	//   |d:timestamp
+	//   |h:hostname
+	//   |#tag1,tag2
+	//   |c:container_id
+	//   |e:external_data
+	//   |card:cardinality
+	//   |m:service_check_message
+	//  ]
+	//
+	// status is 0 (ok), 1 (warning), 2 (critical) or 3 (unknown) and the
+	// message must be the last field.
+	rawFields := strings.Split(message, "|")
+	if len(rawFields) < 3 || rawFields[0] != "_sc" {
+		return errors.New("invalid service check format")
+	}
+
+	name := rawFields[1]
+	if name == "" {
+		return errors.New("invalid service check format: empty 'name' field")
+	}
+	status, err := strconv.ParseInt(rawFields[2], 10, 64)
+	if err != nil || status < 0 || status > 3 {
+		return fmt.Errorf("invalid service check status %q", rawFields[2])
+	}
+
+	tags := make(map[string]string)
+	fields := map[string]interface{}{"status": status}
+	if defaultHostname != "" {
+		tags["source"] = defaultHostname
+	}
+	ts := now
+
+	var o origin
+	rawMetadataFields := rawFields[3:]
+	for i := range rawMetadataFields {
+		if len(rawMetadataFields[i]) < 2 {
+			return errors.New("too short metadata field")
+		}
+		if o.parseField(rawMetadataFields[i]) {
+			continue
+		}
+		switch rawMetadataFields[i][:2] {
+		case "d:":
+			sec, err := strconv.ParseInt(rawMetadataFields[i][2:], 10, 64)
+			if err != nil {
+				continue
+			}
+			ts = time.Unix(sec, 0)
+		case "h:":
+			tags["source"] = rawMetadataFields[i][2:]
+		case "m:":
+			// The message is the last field and might contain pipes
+			fields["message"] = uncommenter.Replace(strings.Join(rawMetadataFields[i:], "|")[2:])
+		default:
+			if rawMetadataFields[i][0] != '#' {
+				return fmt.Errorf("unknown metadata type: %q", rawMetadataFields[i])
+			}
+			parseDataDogTags(tags, rawMetadataFields[i][1:])
+		}
+		if _, found := fields["message"]; found {
+			break
+		}
+	}
+	// Use source tag because host is reserved tag key in Telegraf.
+	if host, ok := tags["host"]; ok {
+		delete(tags, "host")
+		tags["source"] = host
+	}
+	s.addOriginTags(tags, &o, peer)
 	s.acc.AddFields(name, fields, tags, ts)
 	return nil
 }
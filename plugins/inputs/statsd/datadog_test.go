@@ -4,8 +4,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
 )
 
 func TestEventGather(t *testing.T) {
@@ -79,7 +81,7 @@ func TestEventGather(t *testing.T) {
 
 	for i := range tests {
 		t.Run(tests[i].name, func(t *testing.T) {
-			err := s.parseEventMessage(tests[i].now, tests[i].message, tests[i].hostname)
+			err := s.parseEventMessage(tests[i].now, tests[i].message, tests[i].hostname, nil)
 			if tests[i].err {
 				require.Error(t, err)
 			} else {
@@ -387,7 +389,7 @@ func TestEvents(t *testing.T) {
 	for i := range tests {
 		t.Run(tests[i].name, func(t *testing.T) {
 			acc.ClearMetrics()
-			err := s.parseEventMessage(tests[i].args.now, tests[i].args.message, tests[i].args.hostname)
+			err := s.parseEventMessage(tests[i].args.now, tests[i].args.message, tests[i].args.hostname, nil)
 			require.NoError(t, err)
 			m := acc.Metrics[0]
 			require.Equal(t, tests[i].expected.title, m.Measurement)
@@ -414,71 +416,338 @@ func TestEventError(t *testing.T) {
 	defer s.Stop()
 
 	// missing length header
-	err := s.parseEventMessage(now, "_e:title|text", "default-hostname")
+	err := s.parseEventMessage(now, "_e:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// greater length than packet
-	err = s.parseEventMessage(now, "_e{10,10}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{10,10}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// zero length
-	err = s.parseEventMessage(now, "_e{0,0}:a|a", "default-hostname")
+	err = s.parseEventMessage(now, "_e{0,0}:a|a", "default-hostname", nil)
 	require.Error(t, err)
 
 	// missing title or text length
-	err = s.parseEventMessage(now, "_e{5555:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5555:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// missing wrong len format
-	err = s.parseEventMessage(now, "_e{a,1}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{a,1}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e{1,a}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{1,a}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// missing title or text length
-	err = s.parseEventMessage(now, "_e{5,}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e{100,:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{100,:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e,100:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e,100:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e{,4}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{,4}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e{}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e{,}:title|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e{,}:title|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// not enough information
-	err = s.parseEventMessage(now, "_e|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e|text", "default-hostname", nil)
 	require.Error(t, err)
 
-	err = s.parseEventMessage(now, "_e:|text", "default-hostname")
+	err = s.parseEventMessage(now, "_e:|text", "default-hostname", nil)
 	require.Error(t, err)
 
 	// invalid timestamp
-	err = s.parseEventMessage(now, "_e{5,4}:title|text|d:abc", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,4}:title|text|d:abc", "default-hostname", nil)
 	require.NoError(t, err)
 
 	// invalid priority
-	err = s.parseEventMessage(now, "_e{5,4}:title|text|p:urgent", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,4}:title|text|p:urgent", "default-hostname", nil)
 	require.NoError(t, err)
 
 	// invalid priority
-	err = s.parseEventMessage(now, "_e{5,4}:title|text|p:urgent", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,4}:title|text|p:urgent", "default-hostname", nil)
 	require.NoError(t, err)
 
 	// invalid alert type
-	err = s.parseEventMessage(now, "_e{5,4}:title|text|t:test", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,4}:title|text|t:test", "default-hostname", nil)
 	require.NoError(t, err)
 
 	// unknown metadata
-	err = s.parseEventMessage(now, "_e{5,4}:title|text|x:1234", "default-hostname")
+	err = s.parseEventMessage(now, "_e{5,4}:title|text|x:1234", "default-hostname", nil)
 	require.Error(t, err)
 }
+
+func TestServiceCheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		message  string
+		expected telegraf.Metric
+	}{
+		{
+			name:    "minimal",
+			message: "_sc|my.check|0",
+			expected: testutil.MustMetric(
+				"my.check",
+				map[string]string{"source": "default-hostname"},
+				map[string]interface{}{"status": int64(0)},
+				now,
+			),
+		},
+		{
+			name:    "all fields",
+			message: "_sc|my.check|2|d:1700000000|h:myhost|#env:prod,live|m:disk is full|nearly",
+			expected: testutil.MustMetric(
+				"my.check",
+				map[string]string{"source": "myhost", "env": "prod", "live": "true"},
+				map[string]interface{}{"status": int64(2), "message": "disk is full|nearly"},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:    "host tag",
+			message: "_sc|my.check|1|#host:otherhost|c:abcdef",
+			expected: testutil.MustMetric(
+				"my.check",
+				map[string]string{"source": "otherhost", "container": "abcdef"},
+				map[string]interface{}{"status": int64(1)},
+				now,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc testutil.Accumulator
+			s := newTestStatsd()
+			s.acc = &acc
+			s.DataDogExtensions = true
+			s.DataDogKeepContainerTag = true
+
+			require.NoError(t, s.parseServiceCheckMessage(now, tt.message, "default-hostname", nil))
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestServiceCheckError(t *testing.T) {
+	now := time.Now()
+	s := newTestStatsd()
+	s.acc = &testutil.Accumulator{}
+
+	for _, message := range []string{
+		"_sc|my.check",
+		"_sc||0",
+		"_sc|my.check|5",
+		"_sc|my.check|ok",
+		"_sc|my.check|0|x:foo",
+		"_sc|my.check|0|x",
+	} {
+		require.Errorf(t, s.parseServiceCheckMessage(now, message, "", nil), "message %q", message)
+	}
+}
+
+func TestDataDogTimestamp(t *testing.T) {
+	s := newTestStatsd()
+	s.DataDogExtensions = true
+
+	lines := []string{
+		"my.gauge:42|g|#env:prod|T1700000000",
+		"my.counter:3|c|@0.5|T1700000010|#env:prod",
+		"my.counter:2|c|#env:prod",
+		"my.timer:10|ms|T1700000020",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line, nil))
+	}
+	require.ErrorIs(t, s.parseStatsdLine("my.gauge:1|g|Tabc", nil), errParsing)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	now := time.Now()
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"my_gauge",
+			map[string]string{"metric_type": "gauge", "env": "prod"},
+			map[string]interface{}{"value": float64(42)},
+			time.Unix(1700000000, 0),
+			telegraf.Gauge,
+		),
+		testutil.MustMetric(
+			"my_counter",
+			map[string]string{"metric_type": "counter", "env": "prod"},
+			map[string]interface{}{"value": int64(6)},
+			time.Unix(1700000010, 0),
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"my_counter",
+			map[string]string{"metric_type": "counter", "env": "prod"},
+			map[string]interface{}{"value": int64(2)},
+			now,
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"my_timer",
+			map[string]string{"metric_type": "timing"},
+			map[string]interface{}{
+				"count":  int64(1),
+				"lower":  float64(10),
+				"mean":   float64(10),
+				"median": float64(10),
+				"stddev": float64(0),
+				"sum":    float64(10),
+				"upper":  float64(10),
+			},
+			now,
+		),
+	}
+
+	// Check the timestamps of the non-aggregated metrics
+	actual := acc.GetTelegrafMetrics()
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics(), testutil.IgnoreTime())
+	for _, m := range actual {
+		if m.Name() == "my_gauge" {
+			require.Equal(t, time.Unix(1700000000, 0), m.Time())
+		}
+	}
+}
+
+func TestDataDogOriginDetection(t *testing.T) {
+	line := "my.counter:1|c|#env:prod|c:abcdef|e:it-false,cn-nginx,pu-1234"
+	peer := &peerInfo{pid: 42, container: "123456"}
+
+	tests := []struct {
+		name        string
+		line        string
+		cardinality string
+		peer        *peerInfo
+		expected    map[string]string
+	}{
+		{
+			name:        "none",
+			line:        line,
+			cardinality: "none",
+			peer:        peer,
+			expected:    map[string]string{},
+		},
+		{
+			name:        "low",
+			line:        line,
+			cardinality: "low",
+			peer:        peer,
+			expected:    map[string]string{"container": "abcdef", "container_name": "nginx"},
+		},
+		{
+			name:        "orchestrator",
+			line:        line,
+			cardinality: "orchestrator",
+			peer:        peer,
+			expected:    map[string]string{"container": "abcdef", "container_name": "nginx", "pod_uid": "1234"},
+		},
+		{
+			name:        "high",
+			line:        line,
+			cardinality: "high",
+			peer:        peer,
+			expected: map[string]string{
+				"container":      "abcdef",
+				"container_name": "nginx",
+				"pod_uid":        "1234",
+				"pid":            "42",
+			},
+		},
+		{
+			name:        "hint overrides default",
+			line:        line + "|card:none",
+			cardinality: "high",
+			peer:        peer,
+			expected:    map[string]string{},
+		},
+		{
+			name:        "peer container",
+			line:        "my.counter:1|c|#env:prod",
+			cardinality: "high",
+			peer:        peer,
+			expected:    map[string]string{"container": "123456", "pid": "42"},
+		},
+		{
+			name:        "no peer",
+			line:        "my.counter:1|c|#env:prod",
+			cardinality: "high",
+			expected:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStatsd()
+			s.DataDogExtensions = true
+			s.DataDogOriginDetection = true
+			s.DataDogOriginCardinality = tt.cardinality
+			require.NoError(t, s.Init())
+
+			require.NoError(t, s.parseStatsdLine(tt.line, tt.peer))
+
+			var acc testutil.Accumulator
+			require.NoError(t, s.Gather(&acc))
+
+			tt.expected["env"] = "prod"
+			tt.expected["metric_type"] = "counter"
+			expected := []telegraf.Metric{
+				testutil.MustMetric(
+					"my_counter",
+					tt.expected,
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(0, 0),
+					telegraf.Counter,
+				),
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestContainerIDFromCgroups(t *testing.T) {
+	id := "f76b5a1c03caa192580874b253c158010ade668cf03080a57aa8283919d56e75"
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "cgroup v1 docker",
+			content:  "12:pids:/docker/" + id + "\n11:memory:/docker/" + id + "\n",
+			expected: id,
+		},
+		{
+			name:     "cgroup v2 systemd",
+			content:  "0::/system.slice/docker-" + id + ".scope\n",
+			expected: id,
+		},
+		{
+			name:     "kubernetes",
+			content:  "0::/kubepods/besteffort/pod1234/" + id + "\n",
+			expected: id,
+		},
+		{
+			name:    "host process",
+			content: "0::/user.slice/user-1000.slice/session-2.scope\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, containerIDFromCgroups([]byte(tt.content)))
+		})
+	}
+}
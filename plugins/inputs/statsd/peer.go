@@ -0,0 +1,64 @@
+package statsd
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+// Container-IDs are 64 hex characters in the cgroup path of the process,
+// optionally with a runtime specific prefix or a ".scope" suffix.
+var containerIDPattern = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?$`)
+
+// peerInfo contains the identity of a process sending data via unix sockets
+type peerInfo struct {
+	pid       int32
+	container string
+}
+
+// peer resolves the identity of the process with the given PID caching the
+// container-ID lookups until the next gather cycle.
+func (s *Statsd) peer(pid int32) *peerInfo {
+	if pid <= 0 {
+		return nil
+	}
+
+	s.peerLock.Lock()
+	defer s.peerLock.Unlock()
+
+	if s.peers == nil {
+		s.peers = make(map[int32]*peerInfo)
+	}
+	if p, found := s.peers[pid]; found {
+		return p
+	}
+
+	p := &peerInfo{pid: pid}
+	cgroups, err := readCgroups(pid)
+	if err != nil {
+		s.Log.Debugf("Reading cgroups of PID %d failed: %v", pid, err)
+	} else {
+		p.container = containerIDFromCgroups(cgroups)
+	}
+	s.peers[pid] = p
+
+	return p
+}
+
+// containerIDFromCgroups extracts the container-ID from the content of a
+// /proc/<pid>/cgroup file, returning an empty string for processes not
+// running in a container.
+func containerIDFromCgroups(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// Lines are in the form "<hierarchy-ID>:<controllers>:<path>"
+		parts := bytes.SplitN(scanner.Bytes(), []byte(":"), 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if match := containerIDPattern.FindSubmatch(parts[2]); match != nil {
+			return string(match[1])
+		}
+	}
+	return ""
+}
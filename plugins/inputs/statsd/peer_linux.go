@@ -0,0 +1,75 @@
+//go:build linux
+
+package statsd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Size of the out-of-band buffer required to receive the peer credentials
+var credentialsOOBSize = unix.CmsgSpace(unix.SizeofUcred)
+
+// enablePeerCredentials requests the kernel to attach the credentials of the
+// sender to each datagram received on the given socket.
+func enablePeerCredentials(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PASSCRED, 1)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
+
+// peerPID returns the PID of the process connected to the given stream socket
+func peerPID(conn *net.UnixConn) (int32, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var cred *unix.Ucred
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, serr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if serr != nil {
+		return 0, serr
+	}
+	return cred.Pid, nil
+}
+
+// pidFromOOB extracts the PID of the sender from the out-of-band data of a
+// datagram received with peer credentials enabled.
+func pidFromOOB(oob []byte) (int32, error) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0, err
+	}
+	for i := range msgs {
+		cred, err := unix.ParseUnixCredentials(&msgs[i])
+		if err == nil {
+			return cred.Pid, nil
+		}
+	}
+	return 0, errors.New("no credentials found")
+}
+
+func readCgroups(pid int32) ([]byte, error) {
+	return os.ReadFile(filepath.Join(internal.GetProcPath(), strconv.FormatInt(int64(pid), 10), "cgroup"))
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package statsd
+
+import (
+	"errors"
+	"net"
+)
+
+var errPeerCredentialsUnsupported = errors.New("peer credentials are not supported on this platform")
+
+var credentialsOOBSize = 0
+
+func enablePeerCredentials(*net.UnixConn) error {
+	return errPeerCredentialsUnsupported
+}
+
+func peerPID(*net.UnixConn) (int32, error) {
+	return 0, errPeerCredentialsUnsupported
+}
+
+func pidFromOOB([]byte) (int32, error) {
+	return 0, errPeerCredentialsUnsupported
+}
+
+func readCgroups(int32) ([]byte, error) {
+	return nil, errPeerCredentialsUnsupported
+}
@@ -0,0 +1,149 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+)
+
+// state is the persisted aggregation state of the plugin allowing counters,
+// gauges and sets to survive restarts of Telegraf. Timings are not persisted
+// as their percentile estimates are only meaningful within an interval.
+type state struct {
+	Gauges   map[string]gaugeState   `json:"gauges,omitempty"`
+	Counters map[string]counterState `json:"counters,omitempty"`
+	Sets     map[string]setState     `json:"sets,omitempty"`
+}
+
+type seriesState struct {
+	Name      string            `json:"name"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type gaugeState struct {
+	seriesState
+	Fields map[string]float64 `json:"fields"`
+}
+
+type counterState struct {
+	seriesState
+	Fields      map[string]int64   `json:"fields"`
+	FloatFields map[string]float64 `json:"float_fields,omitempty"`
+}
+
+type setState struct {
+	seriesState
+	Fields map[string][]string `json:"fields"`
+}
+
+func (s *Statsd) GetState() interface{} {
+	s.Lock()
+	defer s.Unlock()
+
+	st := state{
+		Gauges:   make(map[string]gaugeState, len(s.gauges)),
+		Counters: make(map[string]counterState, len(s.counters)),
+		Sets:     make(map[string]setState, len(s.sets)),
+	}
+
+	for hash, cached := range s.gauges {
+		fields := make(map[string]float64, len(cached.fields))
+		for k, v := range cached.fields {
+			if fv, ok := v.(float64); ok {
+				fields[k] = fv
+			}
+		}
+		st.Gauges[hash] = gaugeState{
+			seriesState: seriesState{Name: cached.name, Tags: cached.tags, ExpiresAt: cached.expiresAt},
+			Fields:      fields,
+		}
+	}
+
+	for hash, cached := range s.counters {
+		cs := counterState{
+			seriesState: seriesState{Name: cached.name, Tags: cached.tags, ExpiresAt: cached.expiresAt},
+			Fields:      make(map[string]int64, len(cached.fields)),
+		}
+		for k, v := range cached.fields {
+			// Counters are converted to float when emitting float counters
+			switch v := v.(type) {
+			case int64:
+				cs.Fields[k] = v
+			case float64:
+				if cs.FloatFields == nil {
+					cs.FloatFields = make(map[string]float64)
+				}
+				cs.FloatFields[k] = v
+			}
+		}
+		st.Counters[hash] = cs
+	}
+
+	for hash, cached := range s.sets {
+		fields := make(map[string][]string, len(cached.fields))
+		for k, set := range cached.fields {
+			values := make([]string, 0, len(set))
+			for v := range set {
+				values = append(values, v)
+			}
+			fields[k] = values
+		}
+		st.Sets[hash] = setState{
+			seriesState: seriesState{Name: cached.name, Tags: cached.tags, ExpiresAt: cached.expiresAt},
+			Fields:      fields,
+		}
+	}
+
+	return st
+}
+
+func (s *Statsd) SetState(st interface{}) error {
+	restored, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.gauges == nil {
+		s.gauges = make(map[string]cachedgauge, len(restored.Gauges))
+	}
+	for hash, g := range restored.Gauges {
+		fields := make(map[string]interface{}, len(g.Fields))
+		for k, v := range g.Fields {
+			fields[k] = v
+		}
+		s.gauges[hash] = cachedgauge{name: g.Name, fields: fields, tags: g.Tags, expiresAt: g.ExpiresAt}
+	}
+
+	if s.counters == nil {
+		s.counters = make(map[string]cachedcounter, len(restored.Counters))
+	}
+	for hash, c := range restored.Counters {
+		fields := make(map[string]interface{}, len(c.Fields)+len(c.FloatFields))
+		for k, v := range c.Fields {
+			fields[k] = v
+		}
+		for k, v := range c.FloatFields {
+			fields[k] = v
+		}
+		s.counters[hash] = cachedcounter{name: c.Name, fields: fields, tags: c.Tags, expiresAt: c.ExpiresAt}
+	}
+
+	if s.sets == nil {
+		s.sets = make(map[string]cachedset, len(restored.Sets))
+	}
+	for hash, set := range restored.Sets {
+		fields := make(map[string]map[string]bool, len(set.Fields))
+		for k, values := range set.Fields {
+			fields[k] = make(map[string]bool, len(values))
+			for _, v := range values {
+				fields[k][v] = true
+			}
+		}
+		s.sets[hash] = cachedset{name: set.Name, fields: fields, tags: set.Tags, expiresAt: set.ExpiresAt}
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
@@ -43,9 +44,12 @@ type Statsd struct {
 	// Protocol used on listener - udp or tcp
 	Protocol string `toml:"protocol"`
 
-	// Address & Port to serve from
+	// Address & Port to serve from or path of unix sockets
 	ServiceAddress string `toml:"service_address"`
 
+	// Permissions of unix sockets in octal notation
+	SocketMode string `toml:"socket_mode"`
+
 	// Number of messages allowed to queue up in between calls to Gather. If this
 	// fills up, packets will get dropped until the next Gather interval is ran.
 	AllowedPendingMessages int `toml:"allowed_pending_messages"`
@@ -87,6 +91,15 @@ type Statsd struct {
 	// https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/?tab=metrics#dogstatsd-protocol-v12
 	DataDogKeepContainerTag bool `toml:"datadog_keep_container_tag"`
 
+	// Tag metrics, events and service checks with their origin using the
+	// origin detection fields of DogStatsD and the peer credentials of unix
+	// socket connections. The cardinality controls which tags are added if
+	// not specified in the message.
+	// Requires the DataDogExtension flag to be enabled.
+	// https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/?tab=metrics#dogstatsd-protocol-v13
+	DataDogOriginDetection   bool   `toml:"datadog_origin_detection"`
+	DataDogOriginCardinality string `toml:"datadog_origin_cardinality"`
+
 	// UDPPacketSize is deprecated, it's only here for legacy support
 	// we now always create 1 max size buffer and then copy only what we need
 	// into the in channel
@@ -123,18 +136,26 @@ type Statsd struct {
 	// gauges and counters map measurement/tags hash -> field name -> metrics
 	// sets and timings map measurement/tags hash -> metrics
 	// distributions aggregate measurement/tags and are published directly
+	// as are metrics with a client-side timestamp
 	gauges        map[string]cachedgauge
 	counters      map[string]cachedcounter
 	sets          map[string]cachedset
 	timings       map[string]cachedtimings
 	distributions []cacheddistributions
+	timestamped   []cachedtimestamped
 
 	// Protocol listeners
-	UDPlistener *net.UDPConn
-	TCPlistener *net.TCPListener
+	UDPlistener  *net.UDPConn
+	TCPlistener  *net.TCPListener
+	unixListener *net.UnixListener
+	unixConn     *net.UnixConn
+
+	// Cache of the identity of processes sending via unix sockets
+	peerLock sync.Mutex
+	peers    map[int32]*peerInfo
 
 	// track current connections so we can close them in Stop()
-	conns          map[string]*net.TCPConn
+	conns          map[string]net.Conn
 	graphiteParser *graphite.Parser
 	acc            telegraf.Accumulator
 	bufPool        sync.Pool // pool of byte slices to handle parsing
@@ -176,6 +197,7 @@ type input struct {
 	*bytes.Buffer
 	time.Time
 	Addr string
+	Peer *peerInfo
 }
 
 // One statsd metric, form is <bucket>:<value>|<mtype>|@<samplerate>
@@ -190,6 +212,7 @@ type metric struct {
 	mtype      string
 	additive   bool
 	samplerate float64
+	timestamp  time.Time
 	tags       map[string]string
 }
 
@@ -227,10 +250,39 @@ type cacheddistributions struct {
 	tags  map[string]string
 }
 
+type cachedtimestamped struct {
+	name      string
+	field     string
+	value     interface{}
+	tags      map[string]string
+	mtype     string
+	timestamp time.Time
+}
+
 func (*Statsd) SampleConfig() string {
 	return sampleConfig
 }
 
+func (s *Statsd) Init() error {
+	if s.DataDogOriginCardinality == "" {
+		s.DataDogOriginCardinality = "low"
+	}
+	if _, found := cardinalityLevels[s.DataDogOriginCardinality]; !found {
+		return fmt.Errorf("invalid 'datadog_origin_cardinality' setting %q", s.DataDogOriginCardinality)
+	}
+	if s.DataDogOriginDetection && !s.DataDogExtensions && !s.ParseDataDogTags {
+		s.Log.Warn("Origin detection requires 'datadog_extensions' to be enabled, ignoring setting")
+	}
+
+	if s.SocketMode != "" {
+		if _, err := strconv.ParseUint(s.SocketMode, 8, 32); err != nil {
+			return fmt.Errorf("invalid 'socket_mode' setting %q: %w", s.SocketMode, err)
+		}
+	}
+
+	return nil
+}
+
 func (s *Statsd) Start(ac telegraf.Accumulator) error {
 	if s.ParseDataDogTags {
 		s.DataDogExtensions = true
@@ -238,13 +290,21 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 
 	s.acc = ac
 
-	// Make data structures
+	// Make data structures keeping the aggregation state restored from a
+	// previous run
 	s.lastGatherTime = time.Now()
-	s.gauges = make(map[string]cachedgauge)
-	s.counters = make(map[string]cachedcounter)
-	s.sets = make(map[string]cachedset)
+	if s.gauges == nil {
+		s.gauges = make(map[string]cachedgauge)
+	}
+	if s.counters == nil {
+		s.counters = make(map[string]cachedcounter)
+	}
+	if s.sets == nil {
+		s.sets = make(map[string]cachedset)
+	}
 	s.timings = make(map[string]cachedtimings)
 	s.distributions = make([]cacheddistributions, 0)
+	s.timestamped = make([]cachedtimestamped, 0)
 
 	s.Lock()
 	defer s.Unlock()
@@ -270,7 +330,7 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 	s.in = make(chan input, s.AllowedPendingMessages)
 	s.done = make(chan struct{})
 	s.accept = make(chan bool, s.MaxTCPConnections)
-	s.conns = make(map[string]*net.TCPConn)
+	s.conns = make(map[string]net.Conn)
 	s.bufPool = sync.Pool{
 		New: func() interface{} {
 			return new(bytes.Buffer)
@@ -284,7 +344,8 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 		s.MetricSeparator = defaultSeparator
 	}
 
-	if s.isUDP() {
+	switch {
+	case s.isUDP():
 		address, err := net.ResolveUDPAddr(s.Protocol, s.ServiceAddress)
 		if err != nil {
 			return err
@@ -305,7 +366,58 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 				ac.AddError(err)
 			}
 		}()
-	} else {
+	case s.Protocol == "unix":
+		if err := s.removeSocket(); err != nil {
+			return err
+		}
+		listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: s.ServiceAddress, Net: "unix"})
+		if err != nil {
+			return err
+		}
+		if err := s.setSocketMode(); err != nil {
+			listener.Close()
+			return err
+		}
+
+		s.Log.Infof("Unix socket listening on %q", listener.Addr().String())
+		s.unixListener = listener
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.unixListen(listener); err != nil {
+				ac.AddError(err)
+			}
+		}()
+	case s.Protocol == "unixgram":
+		if err := s.removeSocket(); err != nil {
+			return err
+		}
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: s.ServiceAddress, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		if err := s.setSocketMode(); err != nil {
+			conn.Close()
+			return err
+		}
+		if s.DataDogOriginDetection {
+			if err := enablePeerCredentials(conn); err != nil {
+				s.Log.Warnf("Enabling peer credentials failed, origin detection will be limited: %v", err)
+			}
+		}
+
+		s.Log.Infof("Unix datagram socket listening on %q", conn.LocalAddr().String())
+		s.unixConn = conn
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.unixgramListen(conn); err != nil {
+				ac.AddError(err)
+			}
+		}()
+	default:
 		address, err := net.ResolveTCPAddr("tcp", s.ServiceAddress)
 		if err != nil {
 			return err
@@ -357,6 +469,17 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	}
 	s.distributions = make([]cacheddistributions, 0)
 
+	// Metrics with client-side timestamps are not aggregated
+	for _, m := range s.timestamped {
+		fields := map[string]interface{}{m.field: m.value}
+		if m.mtype == "c" {
+			acc.AddCounter(m.name, fields, m.tags, m.timestamp)
+		} else {
+			acc.AddGauge(m.name, fields, m.tags, m.timestamp)
+		}
+	}
+	s.timestamped = make([]cachedtimestamped, 0)
+
 	for _, m := range s.timings {
 		// Defining a template to parse field names for timers allows us to split
 		// out multiple fields per timer. In this case we prefix each stat with the
@@ -410,8 +533,10 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 		}
 
 		if s.FloatCounters {
-			for key := range m.fields {
-				m.fields[key] = float64(m.fields[key].(int64))
+			for key, v := range m.fields {
+				if v, ok := v.(int64); ok {
+					m.fields[key] = float64(v)
+				}
 			}
 		}
 		acc.AddCounter(m.name, m.fields, m.tags, now)
@@ -441,6 +566,11 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 
 	s.expireCachedMetrics()
 
+	// Refresh the identity of processes as PIDs might be reused
+	s.peerLock.Lock()
+	s.peers = nil
+	s.peerLock.Unlock()
+
 	s.lastGatherTime = now
 	return nil
 }
@@ -449,20 +579,32 @@ func (s *Statsd) Stop() {
 	s.Lock()
 	s.Log.Infof("Stopping the statsd service")
 	close(s.done)
-	if s.isUDP() {
+	switch {
+	case s.isUDP():
 		if s.UDPlistener != nil {
 			s.UDPlistener.Close()
 		}
-	} else {
+	case s.Protocol == "unixgram":
+		if s.unixConn != nil {
+			s.unixConn.Close()
+		}
+		if err := s.removeSocket(); err != nil {
+			s.Log.Errorf("Removing socket failed: %v", err)
+		}
+	default:
 		if s.TCPlistener != nil {
 			s.TCPlistener.Close()
 		}
+		if s.unixListener != nil {
+			// Closing the listener removes the socket file
+			s.unixListener.Close()
+		}
 
 		// Close all open TCP connections
 		//  - get all conns from the s.conns map and put into slice
 		//  - this is so the forget() function doesnt conflict with looping
 		//    over the s.conns map
-		var conns []*net.TCPConn
+		var conns []net.Conn
 		s.cleanup.Lock()
 		for _, conn := range s.conns {
 			conns = append(conns, conn)
@@ -507,26 +649,66 @@ func (s *Statsd) tcpListen(listener *net.TCPListener) error {
 				}
 			}
 
-			select {
-			case <-s.accept:
-				// not over connection limit, handle the connection properly.
-				s.wg.Add(1)
-				// generate a random id for this TCPConn
-				id, err := internal.RandomString(6)
+			if err := s.handle(conn, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// unixListen starts listening for connections on the configured unix socket.
+func (s *Statsd) unixListen(listener *net.UnixListener) error {
+	for {
+		select {
+		case <-s.done:
+			return nil
+		default:
+			conn, err := listener.AcceptUnix()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				return err
+			}
+
+			var peer *peerInfo
+			if s.DataDogOriginDetection {
+				pid, err := peerPID(conn)
 				if err != nil {
-					return err
+					s.Log.Debugf("Getting peer credentials failed: %v", err)
 				}
+				peer = s.peer(pid)
+			}
 
-				s.remember(id, conn)
-				go s.handler(conn, id)
-			default:
-				// We are over the connection limit, refuse & close.
-				s.refuser(conn)
+			if err := s.handle(conn, peer); err != nil {
+				return err
 			}
 		}
 	}
 }
 
+// handle starts a handler for the given connection if we are below the
+// connection limit and refuses the connection otherwise.
+func (s *Statsd) handle(conn net.Conn, peer *peerInfo) error {
+	select {
+	case <-s.accept:
+		// not over connection limit, handle the connection properly.
+		s.wg.Add(1)
+		// generate a random id for this connection
+		id, err := internal.RandomString(6)
+		if err != nil {
+			return err
+		}
+
+		s.remember(id, conn)
+		go s.handler(conn, id, peer)
+	default:
+		// We are over the connection limit, refuse & close.
+		s.refuser(conn)
+	}
+	return nil
+}
+
 // udpListen starts listening for UDP packets on the configured port.
 func (s *Statsd) udpListen(conn *net.UDPConn) error {
 	if s.ReadBufferSize > 0 {
@@ -549,33 +731,83 @@ func (s *Statsd) udpListen(conn *net.UDPConn) error {
 				}
 				return nil
 			}
-			s.Stats.UDPPacketsRecv.Incr(1)
-			s.Stats.UDPBytesRecv.Incr(int64(n))
-			b, ok := s.bufPool.Get().(*bytes.Buffer)
-			if !ok {
-				return errors.New("bufPool is not a bytes buffer")
+			if err := s.enqueueDatagram(buf[:n], addr.IP.String(), nil); err != nil {
+				return err
 			}
-			b.Reset()
-			b.Write(buf[:n])
-			select {
-			case s.in <- input{
-				Buffer: b,
-				Time:   time.Now(),
-				Addr:   addr.IP.String()}:
-				s.Stats.PendingMessages.Set(int64(len(s.in)))
-			default:
-				s.Stats.UDPPacketsDrop.Incr(1)
-				s.drops++
-				if s.drops == 1 || s.AllowedPendingMessages == 0 || s.drops%s.AllowedPendingMessages == 0 {
-					s.Log.Errorf("Statsd message queue full. "+
-						"We have dropped %d messages so far. "+
-						"You may want to increase allowed_pending_messages in the config", s.drops)
+		}
+	}
+}
+
+// unixgramListen starts listening for datagrams on the configured unix socket.
+func (s *Statsd) unixgramListen(conn *net.UnixConn) error {
+	if s.ReadBufferSize > 0 {
+		if err := conn.SetReadBuffer(s.ReadBufferSize); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, udpMaxPacketSize)
+	oob := make([]byte, credentialsOOBSize)
+	for {
+		select {
+		case <-s.done:
+			return nil
+		default:
+			n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					s.Log.Errorf("Error reading: %s", err.Error())
+					continue
 				}
+				return nil
+			}
+
+			var peer *peerInfo
+			if s.DataDogOriginDetection && oobn > 0 {
+				pid, err := pidFromOOB(oob[:oobn])
+				if err != nil {
+					s.Log.Debugf("Getting peer credentials failed: %v", err)
+				}
+				peer = s.peer(pid)
+			}
+
+			if err := s.enqueueDatagram(buf[:n], "", peer); err != nil {
+				return err
 			}
 		}
 	}
 }
 
+// enqueueDatagram copies the given datagram into the parser queue, dropping
+// it if the queue is full.
+func (s *Statsd) enqueueDatagram(data []byte, addr string, peer *peerInfo) error {
+	s.Stats.UDPPacketsRecv.Incr(1)
+	s.Stats.UDPBytesRecv.Incr(int64(len(data)))
+	b, ok := s.bufPool.Get().(*bytes.Buffer)
+	if !ok {
+		return errors.New("bufPool is not a bytes buffer")
+	}
+	b.Reset()
+	b.Write(data)
+	select {
+	case s.in <- input{
+		Buffer: b,
+		Time:   time.Now(),
+		Addr:   addr,
+		Peer:   peer}:
+		s.Stats.PendingMessages.Set(int64(len(s.in)))
+	default:
+		s.Stats.UDPPacketsDrop.Incr(1)
+		s.drops++
+		if s.drops == 1 || s.AllowedPendingMessages == 0 || s.drops%s.AllowedPendingMessages == 0 {
+			s.Log.Errorf("Statsd message queue full. "+
+				"We have dropped %d messages so far. "+
+				"You may want to increase allowed_pending_messages in the config", s.drops)
+		}
+	}
+	return nil
+}
+
 // parser monitors the s.in channel, if there is a packet ready, it parses the
 // packet into statsd strings and then calls parseStatsdLine, which parses a
 // single statsd metric into a struct.
@@ -594,15 +826,20 @@ func (s *Statsd) parser() error {
 				switch {
 				case line == "":
 				case s.DataDogExtensions && strings.HasPrefix(line, "_e"):
-					if err := s.parseEventMessage(in.Time, line, in.Addr); err != nil {
+					if err := s.parseEventMessage(in.Time, line, in.Addr, in.Peer); err != nil {
 						// Log the line causing the parsing error and continue
 						// with the next line to not stop the whole gathering
 						// process.
 						s.Log.Errorf("Parsing line failed: %v", err)
 						s.Log.Debugf("  line was: %s", line)
 					}
+				case s.DataDogExtensions && strings.HasPrefix(line, "_sc|"):
+					if err := s.parseServiceCheckMessage(in.Time, line, in.Addr, in.Peer); err != nil {
+						s.Log.Errorf("Parsing line failed: %v", err)
+						s.Log.Debugf("  line was: %s", line)
+					}
 				default:
-					if err := s.parseStatsdLine(line); err != nil {
+					if err := s.parseStatsdLine(line, in.Peer); err != nil {
 						if !errors.Is(err, errParsing) {
 							// Ignore parsing errors but error out on
 							// everything else...
@@ -619,30 +856,44 @@ func (s *Statsd) parser() error {
 
 // parseStatsdLine will parse the given statsd line, validating it as it goes.
 // If the line is valid, it will be cached for the next call to Gather()
-func (s *Statsd) parseStatsdLine(line string) error {
+func (s *Statsd) parseStatsdLine(line string, peer *peerInfo) error {
 	lineTags := make(map[string]string)
+	var timestamp time.Time
 	if s.DataDogExtensions {
 		recombinedSegments := make([]string, 0)
 		// datadog tags look like this:
 		// users.online:1|c|@0.5|#country:china,environment:production
 		// users.online:1|c|#sometagwithnovalue
+		// users.online:1|c|#sometag|c:<container-id>|T1656581400|card:low
 		// we will split on the pipe and remove any elements that are datadog
-		// tags, parse them, and rebuild the line sans the datadog tags
+		// tags, origin fields or timestamps, parse them, and rebuild the line
+		// sans the datadog extensions
+		var o origin
 		pipesplit := strings.Split(line, "|")
-		for _, segment := range pipesplit {
-			if len(segment) > 0 && segment[0] == '#' {
+		for i, segment := range pipesplit {
+			switch {
+			case i == 0:
+				// The bucket and value must not be mistaken for extensions
+				recombinedSegments = append(recombinedSegments, segment)
+			case len(segment) > 0 && segment[0] == '#':
 				// we have ourselves a tag; they are comma separated
 				parseDataDogTags(lineTags, segment[1:])
-			} else if len(segment) > 0 && strings.HasPrefix(segment, "c:") {
-				// This is optional container ID field
-				if s.DataDogKeepContainerTag {
-					lineTags["container"] = segment[2:]
+			case o.parseField(segment):
+				// This is an optional origin field
+			case len(segment) > 1 && segment[0] == 'T':
+				// This is an optional client-side timestamp in seconds
+				sec, err := strconv.ParseInt(segment[1:], 10, 64)
+				if err != nil {
+					s.Log.Errorf("Parsing timestamp, unable to parse metric: %s", line)
+					return errParsing
 				}
-			} else {
+				timestamp = time.Unix(sec, 0)
+			default:
 				recombinedSegments = append(recombinedSegments, segment)
 			}
 		}
 		line = strings.Join(recombinedSegments, "|")
+		s.addOriginTags(lineTags, &o, peer)
 	}
 
 	// Validate splitting the line on ":"
@@ -657,7 +908,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 
 	// Add a metric for each bit available
 	for _, bit := range bits {
-		m := metric{}
+		m := metric{timestamp: timestamp}
 
 		m.bucket = bucketName
 
@@ -860,6 +1111,28 @@ func (s *Statsd) aggregate(m metric) {
 	s.Lock()
 	defer s.Unlock()
 
+	// Gauges and counters with a client-side timestamp are published directly
+	// to preserve the timestamp, other types are aggregated as usual.
+	if !m.timestamp.IsZero() && (m.mtype == "g" || m.mtype == "c") {
+		cached := cachedtimestamped{
+			name:      m.name,
+			field:     m.field,
+			tags:      m.tags,
+			mtype:     m.mtype,
+			timestamp: m.timestamp,
+		}
+		switch {
+		case m.mtype == "g":
+			cached.value = m.floatvalue
+		case s.FloatCounters:
+			cached.value = float64(m.intvalue)
+		default:
+			cached.value = m.intvalue
+		}
+		s.timestamped = append(s.timestamped, cached)
+		return
+	}
+
 	switch m.mtype {
 	case "d":
 		if s.DataDogExtensions && s.DataDogDistributions {
@@ -909,11 +1182,16 @@ func (s *Statsd) aggregate(m metric) {
 			}
 		}
 		// check if the field exists
-		_, ok = cached.fields[m.field]
-		if !ok {
-			cached.fields[m.field] = int64(0)
+		// Counters already emitted as float or restored from the state might
+		// be stored as float
+		switch v := cached.fields[m.field].(type) {
+		case float64:
+			cached.fields[m.field] = v + float64(m.intvalue)
+		case int64:
+			cached.fields[m.field] = v + m.intvalue
+		default:
+			cached.fields[m.field] = m.intvalue
 		}
-		cached.fields[m.field] = cached.fields[m.field].(int64) + m.intvalue
 		cached.expiresAt = time.Now().Add(time.Duration(s.MaxTTL))
 		s.counters[m.hash] = cached
 	case "g":
@@ -960,8 +1238,8 @@ func (s *Statsd) aggregate(m metric) {
 	}
 }
 
-// handler handles a single TCP Connection
-func (s *Statsd) handler(conn *net.TCPConn, id string) {
+// handler handles a single TCP or unix socket connection
+func (s *Statsd) handler(conn net.Conn, id string, peer *peerInfo) {
 	s.Stats.CurrentConnections.Incr(1)
 	s.Stats.TotalConnections.Incr(1)
 	// connection cleanup function
@@ -1003,7 +1281,7 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 			b.WriteByte('\n')
 
 			select {
-			case s.in <- input{Buffer: b, Time: time.Now(), Addr: remoteIP}:
+			case s.in <- input{Buffer: b, Time: time.Now(), Addr: remoteIP, Peer: peer}:
 				s.Stats.PendingMessages.Set(int64(len(s.in)))
 			default:
 				s.drops++
@@ -1017,8 +1295,8 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 	}
 }
 
-// refuser refuses a TCP connection
-func (s *Statsd) refuser(conn *net.TCPConn) {
+// refuser refuses a TCP or unix socket connection
+func (s *Statsd) refuser(conn net.Conn) {
 	conn.Close()
 	s.Log.Infof("Refused TCP Connection from %s", conn.RemoteAddr())
 	s.Log.Warn("Maximum TCP Connections reached, you may want to adjust max_tcp_connections")
@@ -1031,13 +1309,38 @@ func (s *Statsd) forget(id string) {
 	delete(s.conns, id)
 }
 
-// remember a TCP connection
-func (s *Statsd) remember(id string, conn *net.TCPConn) {
+// remember a TCP or unix socket connection
+func (s *Statsd) remember(id string, conn net.Conn) {
 	s.cleanup.Lock()
 	defer s.cleanup.Unlock()
 	s.conns[id] = conn
 }
 
+// removeSocket removes a stale unix socket file left over from a previous run
+func (s *Statsd) removeSocket() error {
+	if err := os.Remove(s.ServiceAddress); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing socket failed: %w", err)
+	}
+	return nil
+}
+
+// setSocketMode sets the configured permissions on the unix socket file
+func (s *Statsd) setSocketMode() error {
+	if s.SocketMode == "" {
+		return nil
+	}
+
+	// Convert from octal in string to int
+	i, err := strconv.ParseUint(s.SocketMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("converting socket mode failed: %w", err)
+	}
+	if err := os.Chmod(s.ServiceAddress, os.FileMode(uint32(i))); err != nil {
+		return fmt.Errorf("changing socket permissions failed: %w", err)
+	}
+	return nil
+}
+
 // IsUDP returns true if the protocol is UDP, false otherwise.
 func (s *Statsd) isUDP() bool {
 	return strings.HasPrefix(s.Protocol, "udp")
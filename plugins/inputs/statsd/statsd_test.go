@@ -1,8 +1,13 @@
 package statsd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -139,7 +144,7 @@ func BenchmarkParser(b *testing.B) {
 
 	// send multiple messages to socket
 	for n := 0; n < b.N; n++ {
-		require.NoError(b, plugin.parseStatsdLine(testMsg))
+		require.NoError(b, plugin.parseStatsdLine(testMsg, nil))
 	}
 
 	plugin.Stop()
@@ -349,7 +354,7 @@ func TestParse_ValidLines(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoError(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoError(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 }
 
@@ -377,7 +382,7 @@ func TestParse_Gauges(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -449,7 +454,7 @@ func TestParse_Sets(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -491,7 +496,7 @@ func TestParse_Sets_SetsAsFloat(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -545,7 +550,7 @@ func TestParse_Counters(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -604,7 +609,7 @@ func TestParse_CountersAsFloat(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -708,7 +713,7 @@ func TestParse_Timings(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	require.NoError(t, s.Gather(acc))
@@ -739,7 +744,7 @@ func TestParse_Timings_TimingsAsFloat(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	require.NoError(t, s.Gather(acc))
@@ -774,7 +779,7 @@ func TestParse_Distributions(t *testing.T) {
 		}
 
 		for _, line := range validLines {
-			require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+			require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 		}
 
 		require.NoError(t, s.Gather(acc))
@@ -821,7 +826,7 @@ func TestParseScientificNotation(t *testing.T) {
 		"scientific.notation:4.6968460083008E-5|h",
 	}
 	for _, line := range sciNotationLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line [%s] should not have resulted in error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line [%s] should not have resulted in error", line)
 	}
 }
 
@@ -840,7 +845,7 @@ func TestParse_InvalidLines(t *testing.T) {
 		"invalid.value:1d1|c",
 	}
 	for _, line := range invalidLines {
-		require.Errorf(t, s.parseStatsdLine(line), "Parsing line %s should have resulted in an error", line)
+		require.Errorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should have resulted in an error", line)
 	}
 }
 
@@ -855,7 +860,7 @@ func TestParse_InvalidSampleRate(t *testing.T) {
 	}
 
 	for _, line := range invalidLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	counterValidations := []struct {
@@ -893,7 +898,7 @@ func TestParse_DefaultNameParsing(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -928,7 +933,7 @@ func TestParse_Template(t *testing.T) {
 	}
 
 	for _, line := range lines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -964,7 +969,7 @@ func TestParse_TemplateFilter(t *testing.T) {
 	}
 
 	for _, line := range lines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -1000,7 +1005,7 @@ func TestParse_TemplateSpecificity(t *testing.T) {
 	}
 
 	for _, line := range lines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	validations := []struct {
@@ -1042,7 +1047,7 @@ func TestParse_TemplateFields(t *testing.T) {
 	}
 
 	for _, line := range lines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	counterTests := []struct {
@@ -1279,7 +1284,7 @@ func TestParse_DataDogTags(t *testing.T) {
 			s := newTestStatsd()
 			s.DataDogExtensions = true
 
-			require.NoError(t, s.parseStatsdLine(tt.line))
+			require.NoError(t, s.parseStatsdLine(tt.line, nil))
 			require.NoError(t, s.Gather(&acc))
 
 			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics(),
@@ -1430,7 +1435,7 @@ func TestParse_DataDogContainerID(t *testing.T) {
 			s.DataDogExtensions = true
 			s.DataDogKeepContainerTag = tt.keep
 
-			require.NoError(t, s.parseStatsdLine(tt.line))
+			require.NoError(t, s.parseStatsdLine(tt.line, nil))
 			require.NoError(t, s.Gather(&acc))
 
 			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics(),
@@ -1505,7 +1510,7 @@ func TestParse_MeasurementsWithSameName(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	require.Lenf(t, s.counters, 2, "Expected 2 separate measurements, found %d", len(s.counters))
@@ -1517,8 +1522,8 @@ func TestCachesExpireAfterMaxTTL(t *testing.T) {
 	s.MaxTTL = config.Duration(10 * time.Millisecond)
 
 	acc := &testutil.Accumulator{}
-	require.NoError(t, s.parseStatsdLine("valid:45|c"))
-	require.NoError(t, s.parseStatsdLine("valid:45|c"))
+	require.NoError(t, s.parseStatsdLine("valid:45|c", nil))
+	require.NoError(t, s.parseStatsdLine("valid:45|c", nil))
 	require.NoError(t, s.Gather(acc))
 
 	// Max TTL goes by, our 'valid' entry is cleared.
@@ -1526,7 +1531,7 @@ func TestCachesExpireAfterMaxTTL(t *testing.T) {
 	require.NoError(t, s.Gather(acc))
 
 	// Now when we gather, we should have a counter that is reset to zero.
-	require.NoError(t, s.parseStatsdLine("valid:45|c"))
+	require.NoError(t, s.parseStatsdLine("valid:45|c", nil))
 	require.NoError(t, s.Gather(acc))
 
 	// Wait for the metrics to arrive
@@ -1615,11 +1620,11 @@ func TestParse_MeasurementsWithMultipleValues(t *testing.T) {
 	sMultiple := newTestStatsd()
 
 	for _, line := range singleLines {
-		require.NoErrorf(t, sSingle.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, sSingle.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	for _, line := range multipleLines {
-		require.NoErrorf(t, sMultiple.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, sMultiple.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 
 	require.Lenf(t, sSingle.timings, 3, "Expected 3 measurement, found %d", len(sSingle.timings))
@@ -1685,7 +1690,7 @@ func TestParse_TimingsMultipleFieldsWithTemplate(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 	require.NoError(t, s.Gather(acc))
 
@@ -1735,7 +1740,7 @@ func TestParse_TimingsMultipleFieldsWithoutTemplate(t *testing.T) {
 	}
 
 	for _, line := range validLines {
-		require.NoErrorf(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+		require.NoErrorf(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 	}
 	require.NoError(t, s.Gather(acc))
 
@@ -1780,7 +1785,7 @@ func BenchmarkParse(b *testing.B) {
 	}
 	for n := 0; n < b.N; n++ {
 		for _, line := range validLines {
-			err := s.parseStatsdLine(line)
+			err := s.parseStatsdLine(line, nil)
 			if err != nil {
 				b.Errorf("Parsing line %s should not have resulted in an error\n", line)
 			}
@@ -1805,7 +1810,7 @@ func BenchmarkParseWithTemplate(b *testing.B) {
 	}
 	for n := 0; n < b.N; n++ {
 		for _, line := range validLines {
-			err := s.parseStatsdLine(line)
+			err := s.parseStatsdLine(line, nil)
 			if err != nil {
 				b.Errorf("Parsing line %s should not have resulted in an error\n", line)
 			}
@@ -1830,7 +1835,7 @@ func BenchmarkParseWithTemplateAndFilter(b *testing.B) {
 	}
 	for n := 0; n < b.N; n++ {
 		for _, line := range validLines {
-			err := s.parseStatsdLine(line)
+			err := s.parseStatsdLine(line, nil)
 			if err != nil {
 				b.Errorf("Parsing line %s should not have resulted in an error\n", line)
 			}
@@ -1858,7 +1863,7 @@ func BenchmarkParseWith2TemplatesAndFilter(b *testing.B) {
 	}
 	for n := 0; n < b.N; n++ {
 		for _, line := range validLines {
-			err := s.parseStatsdLine(line)
+			err := s.parseStatsdLine(line, nil)
 			if err != nil {
 				b.Errorf("Parsing line %s should not have resulted in an error\n", line)
 			}
@@ -1886,7 +1891,7 @@ func BenchmarkParseWith2Templates3TagsAndFilter(b *testing.B) {
 	}
 	for n := 0; n < b.N; n++ {
 		for _, line := range validLines {
-			err := s.parseStatsdLine(line)
+			err := s.parseStatsdLine(line, nil)
 			if err != nil {
 				b.Errorf("Parsing line %s should not have resulted in an error\n", line)
 			}
@@ -1900,7 +1905,7 @@ func TestParse_Timings_Delete(t *testing.T) {
 	fakeacc := &testutil.Accumulator{}
 
 	line := "timing:100|ms"
-	require.NoError(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+	require.NoError(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 
 	require.Lenf(t, s.timings, 1, "Should be 1 timing, found %d", len(s.timings))
 
@@ -1916,7 +1921,7 @@ func TestParse_Gauges_Delete(t *testing.T) {
 	fakeacc := &testutil.Accumulator{}
 
 	line := "current.users:100|g"
-	require.NoError(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+	require.NoError(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 
 	require.NoError(t, testValidateGauge("current_users", 100, s.gauges))
 
@@ -1932,7 +1937,7 @@ func TestParse_Sets_Delete(t *testing.T) {
 	fakeacc := &testutil.Accumulator{}
 
 	line := "unique.user.ids:100|s"
-	require.NoError(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error", line)
+	require.NoError(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error", line)
 
 	require.NoError(t, testValidateSet("unique_user_ids", 1, s.sets))
 
@@ -1948,7 +1953,7 @@ func TestParse_Counters_Delete(t *testing.T) {
 	fakeacc := &testutil.Accumulator{}
 
 	line := "total.users:100|c"
-	require.NoError(t, s.parseStatsdLine(line), "Parsing line %s should not have resulted in an error\n", line)
+	require.NoError(t, s.parseStatsdLine(line, nil), "Parsing line %s should not have resulted in an error\n", line)
 
 	require.NoError(t, testValidateCounter("total_users", 100, s.counters))
 
@@ -2397,3 +2402,191 @@ func TestParse_DeltaCounter(t *testing.T) {
 
 	require.NoError(t, conn.Close())
 }
+
+func TestStatePersistence(t *testing.T) {
+	s := newTestStatsd()
+	s.DeleteCounters = false
+	s.DeleteSets = false
+	for _, line := range []string{
+		"requests:5|c",
+		"requests,region=eu:2|c",
+		"users:alice|s",
+		"users:bob|s",
+		"temperature:21.5|g",
+		"latency:10|ms",
+	} {
+		require.NoError(t, s.parseStatsdLine(line, nil))
+	}
+
+	// Serialize the state as the persister does
+	buf, err := json.Marshal(s.GetState())
+	require.NoError(t, err)
+	var restored state
+	require.NoError(t, json.Unmarshal(buf, &restored))
+
+	// Restore the state in a new instance before starting it
+	plugin := &Statsd{
+		Log:                 testutil.Logger{},
+		Protocol:            "udp",
+		ServiceAddress:      "localhost:0",
+		NumberWorkerThreads: 1,
+		MetricSeparator:     "_",
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.SetState(restored))
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, plugin.parseStatsdLine("requests:1|c", nil))
+	require.NoError(t, plugin.parseStatsdLine("users:carol|s", nil))
+	require.NoError(t, plugin.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"requests",
+			map[string]string{"metric_type": "counter"},
+			map[string]interface{}{"value": int64(6)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"requests",
+			map[string]string{"metric_type": "counter", "region": "eu"},
+			map[string]interface{}{"value": int64(2)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"users",
+			map[string]string{"metric_type": "set"},
+			map[string]interface{}{"value": int64(3)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"temperature",
+			map[string]string{"metric_type": "gauge"},
+			map[string]interface{}{"value": 21.5},
+			time.Unix(0, 0),
+			telegraf.Gauge,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestStatePersistenceFloatCounters(t *testing.T) {
+	s := newTestStatsd()
+	s.DeleteCounters = false
+	s.FloatCounters = true
+	require.NoError(t, s.parseStatsdLine("requests:2|c", nil))
+
+	// Emitting float counters converts the cached values to float
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	for hash, cached := range s.counters {
+		cached.fields["value"] = cached.fields["value"].(float64) + 0.5
+		s.counters[hash] = cached
+	}
+
+	// Serialize the state as the persister does
+	buf, err := json.Marshal(s.GetState())
+	require.NoError(t, err)
+	var restored state
+	require.NoError(t, json.Unmarshal(buf, &restored))
+
+	plugin := newTestStatsd()
+	plugin.DeleteCounters = false
+	plugin.FloatCounters = true
+	require.NoError(t, plugin.SetState(restored))
+	require.NoError(t, plugin.parseStatsdLine("requests:1|c", nil))
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"requests",
+			map[string]string{"metric_type": "counter"},
+			map[string]interface{}{"value": 3.5},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestUnixSockets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows, as unixgram sockets are not supported")
+	}
+
+	for _, protocol := range []string{"unix", "unixgram"} {
+		t.Run(protocol, func(t *testing.T) {
+			sock := filepath.Join(t.TempDir(), "statsd.sock")
+			plugin := &Statsd{
+				Log:                      testutil.Logger{},
+				Protocol:                 protocol,
+				ServiceAddress:           sock,
+				SocketMode:               "0666",
+				AllowedPendingMessages:   100,
+				MaxTCPConnections:        2,
+				NumberWorkerThreads:      1,
+				DataDogExtensions:        true,
+				DataDogOriginDetection:   true,
+				DataDogOriginCardinality: "high",
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+
+			info, err := os.Stat(sock)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0666), info.Mode().Perm())
+
+			conn, err := net.Dial(protocol, sock)
+			require.NoError(t, err)
+			_, err = conn.Write([]byte("cpu.time_idle:42|c|#env:test\n_sc|my.check|1\n"))
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+
+			require.Eventually(t, func() bool {
+				require.NoError(t, plugin.Gather(&acc))
+				return acc.NMetrics() >= 2
+			}, 3*time.Second, 50*time.Millisecond)
+
+			// Peer credentials are only available on Linux
+			counterTags := map[string]string{"metric_type": "counter", "env": "test"}
+			checkTags := map[string]string{}
+			if runtime.GOOS == "linux" {
+				pid := strconv.Itoa(os.Getpid())
+				counterTags["pid"] = pid
+				checkTags["pid"] = pid
+
+				// Handle running the tests in a container
+				cgroups, err := readCgroups(int32(os.Getpid()))
+				require.NoError(t, err)
+				if container := containerIDFromCgroups(cgroups); container != "" {
+					counterTags["container"] = container
+					checkTags["container"] = container
+				}
+			}
+			expected := []telegraf.Metric{
+				testutil.MustMetric(
+					"my.check",
+					checkTags,
+					map[string]interface{}{"status": int64(1)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"cpu_time_idle",
+					counterTags,
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0),
+					telegraf.Counter,
+				),
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/common/encoding"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -31,7 +32,7 @@ var sampleConfig string
 var (
 	once sync.Once
 
-	offsets      = make(map[string]int64)
+	offsets      = checkpoint.State{}
 	offsetsMutex = new(sync.Mutex)
 )
 
@@ -47,11 +48,11 @@ type Tail struct {
 	Filters      []string `toml:"filters"`
 	filterColors bool
 
-	Log        telegraf.Logger `toml:"-"`
-	tailers    map[string]*tail.Tail
-	offsets    map[string]int64
-	parserFunc telegraf.ParserFunc
-	wg         sync.WaitGroup
+	Log         telegraf.Logger `toml:"-"`
+	tailers     map[string]*tail.Tail
+	checkpoints *checkpoint.Checkpoints
+	parserFunc  telegraf.ParserFunc
+	wg          sync.WaitGroup
 
 	acc telegraf.TrackingAccumulator
 
@@ -67,6 +68,27 @@ type Tail struct {
 type empty struct{}
 type semaphore chan empty
 
+// reader keeps track of the (re-)opened readers of a tailed file to allow
+// computing the position in the file from the lines received
+type reader struct {
+	generation uint64
+	bomLength  int64
+	sync.Mutex
+}
+
+func (r *reader) opened(bomLength int64) {
+	r.Lock()
+	defer r.Unlock()
+	r.generation++
+	r.bomLength = bomLength
+}
+
+func (r *reader) current() (generation uint64, bomLength int64) {
+	r.Lock()
+	defer r.Unlock()
+	return r.generation, r.bomLength
+}
+
 func (*Tail) SampleConfig() string {
 	return sampleConfig
 }
@@ -86,8 +108,9 @@ func (t *Tail) Init() error {
 			t.filterColors = true
 		}
 	}
-	// init offsets
-	t.offsets = make(map[string]int64)
+	if t.checkpoints == nil {
+		t.checkpoints = checkpoint.New()
+	}
 
 	var err error
 	t.decoder, err = encoding.NewDecoder(t.CharacterEncoding)
@@ -106,7 +129,8 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 			select {
 			case <-t.ctx.Done():
 				return
-			case <-t.acc.Delivered():
+			case info := <-t.acc.Delivered():
+				t.checkpoints.Delivered(info)
 				<-t.sem
 			}
 		}
@@ -125,24 +149,22 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 
 	// assumption that once Start is called, all parallel plugins have already been initialized
 	offsetsMutex.Lock()
-	offsets = make(map[string]int64)
+	offsets = checkpoint.State{}
 	offsetsMutex.Unlock()
 
 	return err
 }
 
 func (t *Tail) GetState() interface{} {
-	return t.offsets
+	return t.checkpoints.GetState()
 }
 
 func (t *Tail) SetState(state interface{}) error {
-	offsetsState, ok := state.(map[string]int64)
+	offsetsState, ok := state.(checkpoint.State)
 	if !ok {
-		return errors.New("state has to be of type 'map[string]int64'")
-	}
-	for k, v := range offsetsState {
-		t.offsets[k] = v
+		return errors.New("state has to be of type 'checkpoint.State'")
 	}
+	t.checkpoints.SetState(offsetsState)
 	return nil
 }
 
@@ -152,16 +174,6 @@ func (t *Tail) Gather(_ telegraf.Accumulator) error {
 
 func (t *Tail) Stop() {
 	for _, tailer := range t.tailers {
-		if !t.Pipe && !t.FromBeginning {
-			// store offset for resume
-			offset, err := tailer.Tell()
-			if err == nil {
-				t.Log.Debugf("Recording offset %d for %q", offset, tailer.Filename)
-				t.offsets[tailer.Filename] = offset
-			} else {
-				t.Log.Errorf("Recording offset for %q: %s", tailer.Filename, err.Error())
-			}
-		}
 		err := tailer.Stop()
 		if err != nil {
 			t.Log.Errorf("Stopping tail on %q: %s", tailer.Filename, err.Error())
@@ -171,10 +183,14 @@ func (t *Tail) Stop() {
 	t.cancel()
 	t.wg.Wait()
 
-	// persist offsets
+	// persist the delivered offsets
+	state := t.checkpoints.GetState()
 	offsetsMutex.Lock()
-	for k, v := range t.offsets {
-		offsets[k] = v
+	if offsets.Files == nil {
+		offsets.Files = make(map[string]checkpoint.Entry, len(state.Files))
+	}
+	for k, v := range state.Files {
+		offsets.Files[k] = v
 	}
 	offsetsMutex.Unlock()
 }
@@ -198,21 +214,28 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 			}
 
 			var seek *tail.SeekInfo
-			if !t.Pipe && !fromBeginning {
-				if offset, ok := t.offsets[file]; ok {
-					t.Log.Debugf("Using offset %d for %q", offset, file)
-					seek = &tail.SeekInfo{
-						Whence: 0,
-						Offset: offset,
-					}
+			var offset int64
+			if !t.Pipe {
+				// Always start over if configured, otherwise resume at the
+				// last delivered position if any
+				if t.FromBeginning {
+					err = t.checkpoints.Reset(file)
 				} else {
-					seek = &tail.SeekInfo{
-						Whence: 2,
-						Offset: 0,
-					}
+					offset, err = t.checkpoints.Open(file, !fromBeginning)
+				}
+				if err != nil {
+					t.Log.Debugf("Failed to determine offset of file (%s): %v", file, err)
+					continue
+				}
+				t.Log.Debugf("Using offset %d for %q", offset, file)
+				seek = &tail.SeekInfo{
+					Whence: 0,
+					Offset: offset,
 				}
 			}
 
+			rd := &reader{}
+
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
@@ -222,8 +245,14 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 					Poll:      poll,
 					Pipe:      t.Pipe,
 					Logger:    tail.DiscardingLogger,
-					OpenReaderFunc: func(rd io.Reader) io.Reader {
-						r, _ := utfbom.Skip(t.decoder.Reader(rd))
+					OpenReaderFunc: func(raw io.Reader) io.Reader {
+						r, enc := utfbom.Skip(t.decoder.Reader(raw))
+						var bomLength int64
+						if enc != utfbom.Unknown {
+							// The decoder converts all BOMs to the utf-8 BOM
+							bomLength = int64(encoding.EncodedLength(t.CharacterEncoding, "\ufeff"))
+						}
+						rd.opened(bomLength)
 						return r
 					},
 				})
@@ -246,7 +275,7 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 
 			go func() {
 				defer t.wg.Done()
-				t.receiver(parser, tailer)
+				t.receiver(parser, tailer, rd, offset)
 
 				t.Log.Debugf("Tail removed for %q", tailer.Filename)
 
@@ -279,10 +308,15 @@ func parseLine(parser telegraf.Parser, line string) ([]telegraf.Metric, error) {
 
 // receiver is launched as a goroutine to continuously watch a tailed logfile
 // for changes, parse any incoming messages, and add to the accumulator.
-func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail) {
+func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail, rd *reader, offset int64) {
 	// holds the individual lines of multi-line log entries.
 	var buffer bytes.Buffer
 
+	// Position in the file after the last line received and the position of
+	// the first line currently held in the multi-line buffer
+	var generation uint64
+	var lineOffset, bufferOffset int64
+
 	var timer *time.Timer
 	var timeout <-chan time.Time
 
@@ -316,12 +350,38 @@ func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail) {
 
 		var text string
 
+		if line != nil && line.Err == nil && !t.Pipe {
+			// Determine the position in the file after the received line
+			// taking into account that the file might have been reopened
+			// due to rotation or truncation
+			current, bomLength := rd.current()
+			if current != generation {
+				if generation > 0 {
+					if err := t.checkpoints.Reset(tailer.Filename); err != nil {
+						t.Log.Debugf("Resetting offset of %q failed: %v", tailer.Filename, err)
+					}
+					offset = 0
+				}
+				offset += bomLength
+				generation = current
+			}
+			lineOffset = offset
+			offset += int64(encoding.EncodedLength(t.CharacterEncoding, line.Text+"\n"))
+		}
+
 		if line != nil {
 			// Fix up files with Windows line endings.
 			text = strings.TrimRight(line.Text, "\r")
 
 			if t.multiline.isEnabled() {
-				if text = t.multiline.processLine(text, &buffer); text == "" {
+				lineText := text
+				text = t.multiline.processLine(text, &buffer)
+
+				// The buffer starts over with the received line
+				if buffer.Len() > 0 && buffer.String() == lineText {
+					bufferOffset = lineOffset
+				}
+				if text == "" {
 					continue
 				}
 			}
@@ -349,10 +409,19 @@ func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail) {
 			text = string(out)
 		}
 
+		// Position in the file up to which the text was read
+		position := offset
+		if buffer.Len() > 0 {
+			position = bufferOffset
+		}
+
 		metrics, err := parseLine(parser, text)
 		if err != nil {
 			t.Log.Errorf("Malformed log line in %q: [%q]: %s",
 				tailer.Filename, text, err.Error())
+			if !t.Pipe {
+				t.checkpoints.Advance(tailer.Filename, position)
+			}
 			continue
 		}
 		if len(metrics) == 0 {
@@ -369,7 +438,7 @@ func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail) {
 		// try writing out metric first without blocking
 		select {
 		case t.sem <- empty{}:
-			t.acc.AddTrackingMetricGroup(metrics)
+			t.addMetrics(tailer.Filename, position, metrics)
 			if t.ctx.Err() != nil {
 				return // exit!
 			}
@@ -388,23 +457,30 @@ func (t *Tail) receiver(parser telegraf.Parser, tailer *tail.Tail) {
 		case <-tailer.Dying():
 			<-t.sem
 		case t.sem <- empty{}:
-			t.acc.AddTrackingMetricGroup(metrics)
+			t.addMetrics(tailer.Filename, position, metrics)
 		}
 	}
 }
 
+// addMetrics adds the metrics read from the file up to the given position and
+// commits the position once the metrics are delivered
+func (t *Tail) addMetrics(filename string, position int64, metrics []telegraf.Metric) {
+	id := t.acc.AddTrackingMetricGroup(metrics)
+	if !t.Pipe {
+		t.checkpoints.Track(id, filename, position)
+	}
+}
+
 func newTail() *Tail {
 	offsetsMutex.Lock()
-	offsetsCopy := make(map[string]int64, len(offsets))
-	for k, v := range offsets {
-		offsetsCopy[k] = v
-	}
+	checkpoints := checkpoint.New()
+	checkpoints.SetState(offsets)
 	offsetsMutex.Unlock()
 
 	return &Tail{
 		FromBeginning:       false,
 		MaxUndeliveredLines: 1000,
-		offsets:             offsetsCopy,
+		checkpoints:         checkpoints,
 		PathTag:             "path",
 	}
 }
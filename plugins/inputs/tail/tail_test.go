@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/checkpoint"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
@@ -31,10 +32,8 @@ func newInfluxParser() (telegraf.Parser, error) {
 
 func newTestTail() *Tail {
 	offsetsMutex.Lock()
-	offsetsCopy := make(map[string]int64, len(offsets))
-	for k, v := range offsets {
-		offsetsCopy[k] = v
-	}
+	checkpoints := checkpoint.New()
+	checkpoints.SetState(offsets)
 	offsetsMutex.Unlock()
 
 	watchMethod := "inotify"
@@ -45,7 +44,7 @@ func newTestTail() *Tail {
 	return &Tail{
 		FromBeginning:       false,
 		MaxUndeliveredLines: 1000,
-		offsets:             offsetsCopy,
+		checkpoints:         checkpoints,
 		WatchMethod:         watchMethod,
 		PathTag:             "path",
 	}
@@ -582,9 +581,9 @@ func TestCharacterEncoding(t *testing.T) {
 			require.NoError(t, plugin.Init())
 
 			if tt.offset != 0 {
-				plugin.offsets = map[string]int64{
-					plugin.Files[0]: tt.offset,
-				}
+				plugin.checkpoints.SetState(checkpoint.State{
+					Files: map[string]checkpoint.Entry{plugin.Files[0]: {Offset: tt.offset}},
+				})
 			}
 
 			var acc testutil.Accumulator
@@ -604,6 +603,48 @@ func TestCharacterEncoding(t *testing.T) {
 	}
 }
 
+func TestCharacterEncodingOffset(t *testing.T) {
+	watchMethod := "inotify"
+	if runtime.GOOS == "windows" {
+		watchMethod = "poll"
+	}
+
+	for _, enc := range []string{"utf-8", "utf-16le", "utf-16be"} {
+		t.Run(enc, func(t *testing.T) {
+			fn := filepath.Join("testdata", "cpu-"+enc+".influx")
+			info, err := os.Stat(fn)
+			require.NoError(t, err)
+
+			plugin := &Tail{
+				Files:               []string{fn},
+				FromBeginning:       true,
+				MaxUndeliveredLines: 1000,
+				Log:                 testutil.Logger{},
+				CharacterEncoding:   enc,
+				WatchMethod:         watchMethod,
+			}
+			plugin.SetParserFunc(newInfluxParser)
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+			require.Eventually(t, func() bool {
+				return acc.NMetrics() >= 5
+			}, time.Second, 10*time.Millisecond)
+
+			// The committed offset must match the raw size of the file
+			for _, m := range acc.GetTelegrafMetrics() {
+				m.Accept()
+			}
+			require.Eventually(t, func() bool {
+				offset, _ := plugin.checkpoints.Committed(fn)
+				return offset == info.Size()
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
 func TestTailEOF(t *testing.T) {
 	tmpfile, err := os.CreateTemp("", "")
 	require.NoError(t, err)
@@ -669,7 +710,6 @@ func TestCSVBehavior(t *testing.T) {
 		Files:               []string{input.Name()},
 		FromBeginning:       true,
 		MaxUndeliveredLines: 1000,
-		offsets:             make(map[string]int64, 0),
 		PathTag:             "path",
 		Log:                 testutil.Logger{},
 	}
@@ -745,8 +785,9 @@ func TestStatePersistence(t *testing.T) {
 	require.NoError(t, os.WriteFile(inputFilename, content, 0600))
 
 	// Define the metrics and state to skip the first metric
-	state := map[string]int64{inputFilename: int64(len(lines[0]))}
-	expectedState := map[string]int64{inputFilename: int64(len(content))}
+	state := checkpoint.State{
+		Files: map[string]checkpoint.Entry{inputFilename: {Offset: int64(len(lines[0]))}},
+	}
 	expected := []telegraf.Metric{
 		metric.New("metric",
 			map[string]string{"tag": "value"},
@@ -764,17 +805,16 @@ func TestStatePersistence(t *testing.T) {
 	plugin := &Tail{
 		Files:               []string{inputFilename},
 		MaxUndeliveredLines: 1000,
-		offsets:             make(map[string]int64, 0),
 		Log:                 testutil.Logger{},
 	}
 	plugin.SetParserFunc(newInfluxParser)
 	require.NoError(t, plugin.Init())
-	require.Empty(t, plugin.offsets)
+	require.Empty(t, plugin.checkpoints.GetState().Files)
 
 	// Setup the "persisted" state
 	var pi telegraf.StatefulPlugin = plugin
 	require.NoError(t, pi.SetState(state))
-	require.Len(t, plugin.offsets, 1)
+	require.Len(t, plugin.checkpoints.GetState().Files, 1)
 
 	// Run the plugin
 	var acc testutil.Accumulator
@@ -785,7 +825,6 @@ func TestStatePersistence(t *testing.T) {
 	require.Eventuallyf(t, func() bool {
 		return acc.NMetrics() >= uint64(len(expected))
 	}, time.Second, 100*time.Millisecond, "Expected %d metrics found %d", len(expected), acc.NMetrics())
-	plugin.Stop()
 
 	// Check the result
 	options := []cmp.Option{
@@ -795,8 +834,24 @@ func TestStatePersistence(t *testing.T) {
 	actual := acc.GetTelegrafMetrics()
 	testutil.RequireMetricsEqual(t, expected, actual, options...)
 
-	// Check getting the persisted state
-	actualState, ok := pi.GetState().(map[string]int64)
-	require.True(t, ok, "state is not a map[string]int64")
-	require.Equal(t, expectedState, actualState)
+	// The offset must only advance for delivered metrics
+	actualState, ok := pi.GetState().(checkpoint.State)
+	require.True(t, ok, "state is not a checkpoint.State")
+	require.EqualValues(t, len(lines[0]), actualState.Files[inputFilename].Offset)
+
+	actual[0].Accept()
+	require.Eventually(t, func() bool {
+		return plugin.checkpoints.GetState().Files[inputFilename].Offset == int64(len(lines[0])+len(lines[1]))
+	}, time.Second, 10*time.Millisecond)
+
+	// Rejected metrics must not block the offset
+	actual[1].Reject()
+	require.Eventually(t, func() bool {
+		return plugin.checkpoints.GetState().Files[inputFilename].Offset == int64(len(content))
+	}, time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	actualState, ok = pi.GetState().(checkpoint.State)
+	require.True(t, ok, "state is not a checkpoint.State")
+	require.EqualValues(t, len(content), actualState.Files[inputFilename].Offset)
 }
@@ -0,0 +1,5 @@
+//go:build !custom || outputs || outputs.object_storage
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/object_storage" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || outputs || outputs.webhook
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/webhook" // register plugin
@@ -4,6 +4,7 @@ package cloudwatch
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"math"
 	"net/http"
 	"sort"
@@ -15,6 +16,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -26,7 +28,7 @@ var sampleConfig string
 type CloudWatch struct {
 	Namespace             string `toml:"namespace"` // CloudWatch Metrics Namespace
 	HighResolutionMetrics bool   `toml:"high_resolution_metrics"`
-	svc                   *cloudwatch.Client
+	svc                   cloudwatchClient
 	WriteStatistics       bool            `toml:"write_statistics"`
 	Log                   telegraf.Logger `toml:"-"`
 	common_aws.CredentialConfig
@@ -34,6 +36,10 @@ type CloudWatch struct {
 	client *http.Client
 }
 
+type cloudwatchClient interface {
+	PutMetricData(context.Context, *cloudwatch.PutMetricDataInput, ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
 type statisticType int
 
 const (
@@ -191,23 +197,76 @@ func (c *CloudWatch) Close() error {
 
 func (c *CloudWatch) Write(metrics []telegraf.Metric) error {
 	var datums []types.MetricDatum
-	for _, m := range metrics {
+	var indices []int
+	for i, m := range metrics {
 		d := BuildMetricDatum(c.WriteStatistics, c.HighResolutionMetrics, m)
 		datums = append(datums, d...)
+		for range d {
+			indices = append(indices, i)
+		}
 	}
 
 	// PutMetricData only supports up to 1000 data metrics per call
 	// https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_PutMetricData.html
 	const maxDatumsPerCall = 1000
 
-	for _, partition := range PartitionDatums(maxDatumsPerCall, datums) {
+	// Each call either succeeds or fails as a whole, so track the outcome of
+	// the metrics per call. Metrics of calls failing with a transient error
+	// are kept, remaining calls are not attempted in this case.
+	const (
+		accepted = iota
+		kept
+		rejected
+	)
+	states := make([]int, len(metrics))
+	errs := make(map[int]error)
+	var writeErr error
+	for n, partition := range PartitionDatums(maxDatumsPerCall, datums) {
+		start := n * maxDatumsPerCall
+		partitionIndices := indices[start : start+len(partition)]
+
+		if writeErr != nil {
+			for _, idx := range partitionIndices {
+				states[idx] = max(states[idx], kept)
+			}
+			continue
+		}
+
 		err := c.WriteToCloudWatch(partition)
-		if err != nil {
-			return err
+		if err == nil {
+			continue
+		}
+		if isRetryable(err) {
+			writeErr = err
+			for _, idx := range partitionIndices {
+				states[idx] = max(states[idx], kept)
+			}
+			continue
+		}
+		for _, idx := range partitionIndices {
+			states[idx] = rejected
+			errs[idx] = err
 		}
 	}
 
-	return nil
+	if writeErr == nil && len(errs) == 0 {
+		return nil
+	}
+
+	werr := &internal.PartialWriteError{Err: writeErr}
+	for idx, state := range states {
+		switch state {
+		case accepted:
+			werr.MetricsAccept = append(werr.MetricsAccept, idx)
+		case rejected:
+			werr.MetricsReject = append(werr.MetricsReject, idx)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, errs[idx])
+			if werr.Err == nil {
+				werr.Err = errs[idx]
+			}
+		}
+	}
+	return werr
 }
 
 func (c *CloudWatch) WriteToCloudWatch(datums []types.MetricDatum) error {
@@ -225,6 +284,16 @@ func (c *CloudWatch) WriteToCloudWatch(datums []types.MetricDatum) error {
 	return err
 }
 
+// isRetryable returns false for errors caused by invalid data where retrying
+// the call would fail in the same way. All other errors like throttling or
+// service faults are considered transient.
+func isRetryable(err error) bool {
+	var invalidValue *types.InvalidParameterValueException
+	var invalidCombination *types.InvalidParameterCombinationException
+	var missing *types.MissingRequiredParameterException
+	return !errors.As(err, &invalidValue) && !errors.As(err, &invalidCombination) && !errors.As(err, &missing)
+}
+
 // PartitionDatums partitions the MetricDatums into smaller slices of a max size so that are under the limit
 // for the AWS API calls.
 func PartitionDatums(size int, datums []types.MetricDatum) [][]types.MetricDatum {
@@ -1,16 +1,20 @@
 package cloudwatch
 
 import (
+	"context"
+	"errors"
 	"math"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -156,3 +160,80 @@ func TestPartitionDatums(t *testing.T) {
 	require.Equal(t, [][]types.MetricDatum{twoDatum}, PartitionDatums(2, twoDatum))
 	require.Equal(t, [][]types.MetricDatum{twoDatum, oneDatum}, PartitionDatums(2, threeDatum))
 }
+
+type mockCloudWatchClient struct {
+	errs  []error
+	calls int
+}
+
+func (m *mockCloudWatchClient) PutMetricData(
+	context.Context,
+	*cloudwatch.PutMetricDataInput,
+	...func(*cloudwatch.Options),
+) (*cloudwatch.PutMetricDataOutput, error) {
+	m.calls++
+	if len(m.errs) == 0 {
+		return &cloudwatch.PutMetricDataOutput{}, nil
+	}
+	err := m.errs[0]
+	m.errs = m.errs[1:]
+	return &cloudwatch.PutMetricDataOutput{}, err
+}
+
+func TestPartialWrite(t *testing.T) {
+	// Produce two calls, the first with 1000 metrics and the second with one
+	input := make([]telegraf.Metric, 0, 1001)
+	for i := 0; i < 1001; i++ {
+		input = append(input, testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"value": float64(i)},
+			time.Unix(0, 0),
+		))
+	}
+
+	tests := []struct {
+		name     string
+		errs     []error
+		calls    int
+		accepted int
+		rejected int
+	}{
+		{
+			name:     "invalid data",
+			errs:     []error{&types.InvalidParameterValueException{Message: aws.String("invalid")}},
+			calls:    2,
+			accepted: 1,
+			rejected: 1000,
+		},
+		{
+			name:  "throttled",
+			errs:  []error{errors.New("throttled")},
+			calls: 1,
+		},
+		{
+			name:     "throttled second call",
+			errs:     []error{nil, errors.New("throttled")},
+			calls:    2,
+			accepted: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockCloudWatchClient{errs: tt.errs}
+			plugin := &CloudWatch{
+				Namespace: "namespace",
+				svc:       client,
+				Log:       testutil.Logger{},
+			}
+
+			err := plugin.Write(input)
+			var werr *internal.PartialWriteError
+			require.ErrorAs(t, err, &werr)
+			require.Equal(t, tt.calls, client.calls)
+			require.Len(t, werr.MetricsAccept, tt.accepted)
+			require.Len(t, werr.MetricsReject, tt.rejected)
+			require.Len(t, werr.MetricsRejectErrors, tt.rejected)
+		})
+	}
+}
@@ -86,6 +86,14 @@ func (c *CrateDB) Connect() error {
 	return nil
 }
 
+// Probe checks if the database server is reachable
+func (c *CrateDB) Probe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout))
+	defer cancel()
+
+	return c.db.PingContext(ctx)
+}
+
 func (c *CrateDB) Write(metrics []telegraf.Metric) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout))
 	defer cancel()
@@ -148,6 +148,19 @@ func TestConnectionIssueAtStartup(t *testing.T) {
 	}
 }
 
+func TestProbeUnreachable(t *testing.T) {
+	plugin := &CrateDB{
+		URL:     "postgres://crate@127.0.0.1:1/test",
+		Table:   "testing",
+		Timeout: config.Duration(time.Second * 5),
+	}
+
+	// Connecting without creating the table does not reach the server
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+	require.Error(t, plugin.Probe())
+}
+
 func TestInsertSQL(t *testing.T) {
 	tests := []struct {
 		Metrics []telegraf.Metric
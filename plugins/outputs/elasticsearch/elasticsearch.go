@@ -21,6 +21,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -57,8 +59,10 @@ type Elasticsearch struct {
 	pipelineTagKeys     []string
 	tagKeys             []string
 	tls.ClientConfig
+	common_http.EndpointPoolConfig
 
 	Client *elastic.Client
+	pool   *common_http.EndpointPool
 }
 
 const telegrafTemplate = `
@@ -181,12 +185,35 @@ func (a *Elasticsearch) Connect() error {
 		return fmt.Errorf("parsing URL failed: %w", err)
 	}
 
+	// When distributing the requests via the endpoint pool, the client only
+	// knows the primary node and the pool takes care of the node selection
+	// and health checking.
+	urls := a.URLs
+	healthCheckInterval := a.HealthCheckInterval
+	if a.EndpointStrategy != "" {
+		if a.EnableSniffer {
+			return errors.New("'enable_sniffer' cannot be used with 'endpoint_strategy'")
+		}
+		// Stop the pool of a previous, failed connection attempt
+		if a.pool != nil {
+			a.pool.Stop()
+		}
+		pool, err := a.EndpointPoolConfig.CreateEndpointPool(a.URLs, map[string]string{"output": "elasticsearch"})
+		if err != nil {
+			return err
+		}
+		pool.Wrap(httpclient)
+		a.pool = pool
+		urls = a.URLs[:1]
+		healthCheckInterval = 0
+	}
+
 	clientOptions = append(clientOptions,
 		elastic.SetHttpClient(httpclient),
 		elastic.SetSniff(a.EnableSniffer),
 		elastic.SetScheme(elasticURL.Scheme),
-		elastic.SetURL(a.URLs...),
-		elastic.SetHealthcheckInterval(time.Duration(a.HealthCheckInterval)),
+		elastic.SetURL(urls...),
+		elastic.SetHealthcheckInterval(time.Duration(healthCheckInterval)),
 		elastic.SetHealthcheckTimeout(time.Duration(a.HealthCheckTimeout)),
 		elastic.SetGzip(a.EnableGzip),
 	)
@@ -207,7 +234,7 @@ func (a *Elasticsearch) Connect() error {
 	}
 	clientOptions = append(clientOptions, authOptions...)
 
-	if time.Duration(a.HealthCheckInterval) == 0 {
+	if time.Duration(healthCheckInterval) == 0 {
 		clientOptions = append(clientOptions,
 			elastic.SetHealthcheck(false),
 		)
@@ -337,23 +364,58 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 	}
 
 	if res.Errors {
-		for id, err := range res.Failed() {
-			a.Log.Errorf(
-				"Elasticsearch indexing failure, id: %d, status: %d, error: %s, caused by: %s, %s",
-				id,
-				err.Status,
-				err.Error.Reason,
-				err.Error.CausedBy["reason"],
-				err.Error.CausedBy["type"],
-			)
-			break
-		}
-		return fmt.Errorf("elasticsearch failed to index %d metrics", len(res.Failed()))
+		return a.bulkWriteError(res, len(metrics))
 	}
 
 	return nil
 }
 
+// bulkWriteError determines the outcome of each metric from the item results
+// of the bulk response. Items failing due to overload or server errors are
+// kept for retry while all other failures are rejected.
+func (a *Elasticsearch) bulkWriteError(res *elastic.BulkResponse, count int) error {
+	err := fmt.Errorf("elasticsearch failed to index %d metrics", len(res.Failed()))
+	if len(res.Items) != count {
+		return err
+	}
+
+	werr := &internal.PartialWriteError{Err: err}
+	var logged bool
+	for i, item := range res.Items {
+		for _, r := range item {
+			switch {
+			case r.Status >= 200 && r.Status < 300:
+				werr.MetricsAccept = append(werr.MetricsAccept, i)
+			case r.Status == http.StatusConflict && a.UseOpTypeCreate:
+				// The document already exists e.g. due to a previous write
+				// of the metric with the same document ID
+				werr.MetricsAccept = append(werr.MetricsAccept, i)
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				// Keep the metric for retrying
+			default:
+				var reason string
+				if r.Error != nil {
+					reason = r.Error.Type + ": " + r.Error.Reason
+					if !logged {
+						a.Log.Errorf(
+							"Elasticsearch indexing failure, id: %d, status: %d, error: %s, caused by: %s, %s",
+							i,
+							r.Status,
+							r.Error.Reason,
+							r.Error.CausedBy["reason"],
+							r.Error.CausedBy["type"],
+						)
+						logged = true
+					}
+				}
+				werr.MetricsReject = append(werr.MetricsReject, i)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, fmt.Errorf("status %d: %s", r.Status, reason))
+			}
+		}
+	}
+	return werr
+}
+
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 	if a.TemplateName == "" {
 		return errors.New("elasticsearch template_name configuration not defined")
@@ -501,6 +563,10 @@ func getISOWeek(eventTime time.Time) string {
 }
 
 func (a *Elasticsearch) Close() error {
+	if a.pool != nil {
+		a.pool.Stop()
+		a.pool = nil
+	}
 	a.Client = nil
 	return nil
 }
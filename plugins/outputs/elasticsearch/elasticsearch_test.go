@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -718,6 +720,98 @@ func TestRequestHeaderWhenGzipIsEnabled(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPartialWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			if _, err := w.Write([]byte(`{"version": {"number": "7.8"}}`)); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"took": 1, "errors": true, "items": [
+			{"create": {"_index": "test", "status": 201}},
+			{"create": {"_index": "test", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}},
+			{"create": {"_index": "test", "status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "queue full"}}},
+			{"create": {"_index": "test", "status": 409, "error": {"type": "version_conflict_engine_exception", "reason": "exists"}}},
+			{"create": {"_index": "test", "status": 503, "error": {"type": "unavailable_shards_exception", "reason": "primary shard is not active"}}}
+		]}`)); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		URLs:            []string{"http://" + ts.Listener.Addr().String()},
+		IndexName:       "test",
+		Timeout:         config.Duration(time.Second * 5),
+		UseOpTypeCreate: true,
+		ForceDocumentID: true,
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, e.Connect())
+
+	metrics := make([]telegraf.Metric, 0, 5)
+	for i := range 5 {
+		metrics = append(metrics, testutil.TestMetric(i))
+	}
+
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, e.Write(metrics), &werr)
+	require.ErrorContains(t, werr, "elasticsearch failed to index 4 metrics")
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+	require.ErrorContains(t, werr.MetricsRejectErrors[0], "status 400: mapper_parsing_exception: failed to parse")
+}
+
+func TestEndpointFailover(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	var bulk atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			if _, err := w.Write([]byte(`{"version": {"number": "7.8"}}`)); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+		bulk.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"took": 1, "errors": false, "items": [{"index": {"_index": "test", "status": 201}}]}`)); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		URLs:      []string{failing.URL, ts.URL},
+		IndexName: "test",
+		Timeout:   config.Duration(time.Second * 5),
+		Log:       testutil.Logger{},
+	}
+	e.EndpointStrategy = "failover"
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.NoError(t, e.Write([]telegraf.Metric{testutil.TestMetric(1)}))
+	require.Equal(t, int64(1), bulk.Load())
+}
+
+func TestEndpointStrategyWithSniffer(t *testing.T) {
+	e := &Elasticsearch{
+		URLs:          []string{"http://localhost:9200"},
+		IndexName:     "test",
+		EnableSniffer: true,
+		Log:           testutil.Logger{},
+	}
+	e.EndpointStrategy = "failover"
+	require.ErrorContains(t, e.Connect(), "'enable_sniffer' cannot be used with 'endpoint_strategy'")
+}
+
 func TestRequestHeaderWhenGzipIsDisabled(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -97,6 +97,23 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error
 
 	if f.UseBatchFormat {
+		// Stream the serialized batch through the encoder if possible to
+		// avoid holding the whole batch in memory
+		if serializer, ok := f.serializer.(telegraf.StreamingSerializer); ok {
+			w, err := f.encoder.NewWriter(f.writer)
+			if err != nil {
+				return fmt.Errorf("creating encoder failed: %w", err)
+			}
+			if err := serializer.SerializeBatchTo(w, metrics); err != nil {
+				w.Close()
+				return fmt.Errorf("failed to write message: %w", err)
+			}
+			if err := w.Close(); err != nil {
+				return fmt.Errorf("failed to write message: %w", err)
+			}
+			return nil
+		}
+
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
 			f.Log.Errorf("Could not serialize metric: %v", err)
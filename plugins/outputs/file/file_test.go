@@ -239,6 +239,24 @@ type erroredString struct {
 	err error
 }
 
+func TestFileBatchWriteError(t *testing.T) {
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	f := File{
+		Files:            []string{tmpFile(t)},
+		UseBatchFormat:   true,
+		serializer:       s,
+		CompressionLevel: -1,
+	}
+	require.NoError(t, f.Init())
+	require.NoError(t, f.Connect())
+
+	// Writing to closed files must fail
+	require.NoError(t, f.Close())
+	require.ErrorContains(t, f.Write(testutil.MockMetrics()), "failed to write message")
+}
+
 func TestFileStdout(t *testing.T) {
 	// keep backup of the real stdout
 	old := os.Stdout
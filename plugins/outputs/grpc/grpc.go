@@ -0,0 +1,252 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package grpc
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/encoding/gzip" // Blank import to allow gzip encoding
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+var userAgent = internal.ProductToken()
+
+//go:embed sample.conf
+var sampleConfig string
+
+type GRPC struct {
+	ServiceAddress string            `toml:"service_address"`
+	Timeout        config.Duration   `toml:"timeout"`
+	Compression    string            `toml:"compression"`
+	Headers        map[string]string `toml:"headers"`
+	Mapping        protobuf.Mapping  `toml:"mapping"`
+	Log            telegraf.Logger   `toml:"-"`
+	protobuf.Config
+	tls.ClientConfig
+
+	method      protoreflect.MethodDescriptor
+	fullMethod  string
+	callOptions []grpc.CallOption
+	conn        *grpc.ClientConn
+}
+
+// request is a message sent to the service with the indices of the metrics
+// contained in the message
+type request struct {
+	msg     protoreflect.Message
+	indices []int
+}
+
+func (*GRPC) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GRPC) Init() error {
+	if g.ServiceAddress == "" {
+		return errors.New("'service_address' setting required")
+	}
+
+	switch g.Compression {
+	case "", "none":
+	case "gzip":
+		g.callOptions = append(g.callOptions, grpc.UseCompressor(g.Compression))
+	default:
+		return fmt.Errorf("invalid 'compression' setting %q", g.Compression)
+	}
+
+	method, err := g.Config.LoadMethod()
+	if err != nil {
+		return err
+	}
+	if err := g.Mapping.Init(method.Input()); err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+	g.method = method
+	g.fullMethod = protobuf.FullMethodName(method)
+
+	return nil
+}
+
+func (g *GRPC) Connect() error {
+	creds := insecure.NewCredentials()
+	tlsConfig, err := g.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	// The client connects lazily, so this only fails for invalid settings
+	conn, err := grpc.NewClient(
+		g.ServiceAddress,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return fmt.Errorf("creating client for %q failed: %w", g.ServiceAddress, err)
+	}
+	g.conn = conn
+
+	return nil
+}
+
+func (g *GRPC) Close() error {
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
+
+func (g *GRPC) Write(metrics []telegraf.Metric) error {
+	werr := &internal.PartialWriteError{}
+
+	// Convert the metrics to messages and reject the metrics failing to
+	// convert as those will never succeed
+	msgs := make([]protoreflect.Message, 0, len(metrics))
+	indices := make([]int, 0, len(metrics))
+	for i, m := range metrics {
+		msg, err := g.Mapping.Encode(m)
+		if err != nil {
+			g.Log.Errorf("Could not encode metric: %v", err)
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			continue
+		}
+		msgs = append(msgs, msg)
+		indices = append(indices, i)
+	}
+
+	var requests []request
+	if g.Mapping.Batched() {
+		if len(msgs) > 0 {
+			requests = append(requests, request{msg: g.Mapping.Batch(msgs), indices: indices})
+		}
+	} else {
+		requests = make([]request, 0, len(msgs))
+		for i, msg := range msgs {
+			requests = append(requests, request{msg: msg, indices: indices[i : i+1]})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(g.Timeout))
+	defer cancel()
+	if len(g.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(g.Headers))
+	}
+
+	if g.method.IsStreamingClient() {
+		g.handleResult(werr, requests, g.stream(ctx, requests))
+	} else {
+		for _, r := range requests {
+			if !g.handleResult(werr, []request{r}, g.invoke(ctx, r)) {
+				break
+			}
+		}
+	}
+
+	if werr.Err == nil && len(werr.MetricsReject) == 0 {
+		return nil
+	}
+	if werr.Err == nil {
+		werr.Err = internal.ErrSerialization
+	}
+	return werr
+}
+
+// handleResult accepts the metrics of the requests if the call succeeded and
+// rejects the metrics if the service refused the data as invalid. For all
+// other errors, the metrics are kept and false is returned to stop sending.
+func (g *GRPC) handleResult(werr *internal.PartialWriteError, requests []request, err error) bool {
+	if err == nil {
+		for _, r := range requests {
+			werr.MetricsAccept = append(werr.MetricsAccept, r.indices...)
+		}
+		return true
+	}
+
+	if status.Code(err) == codes.InvalidArgument {
+		g.Log.Errorf("Request refused by service: %v", err)
+		for _, r := range requests {
+			for _, idx := range r.indices {
+				werr.MetricsReject = append(werr.MetricsReject, idx)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			}
+		}
+		if werr.Err == nil {
+			werr.Err = err
+		}
+		return true
+	}
+
+	werr.Err = fmt.Errorf("calling %q failed: %w", g.fullMethod, err)
+	return false
+}
+
+func (g *GRPC) invoke(ctx context.Context, r request) error {
+	response := dynamicpb.NewMessage(g.method.Output())
+	return g.conn.Invoke(ctx, g.fullMethod, r.msg.Interface(), response, g.callOptions...)
+}
+
+func (g *GRPC) stream(ctx context.Context, requests []request) error {
+	desc := &grpc.StreamDesc{StreamName: string(g.method.Name()), ClientStreams: true}
+	stream, err := g.conn.NewStream(ctx, desc, g.fullMethod, g.callOptions...)
+	if err != nil {
+		return err
+	}
+
+	// A failed send indicates the stream was terminated by the service and
+	// the actual status is returned when receiving the response
+	var sent int
+	for _, r := range requests {
+		if err := stream.SendMsg(r.msg.Interface()); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+		sent++
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	response := dynamicpb.NewMessage(g.method.Output())
+	if err := stream.RecvMsg(response); err != nil {
+		return err
+	}
+	if sent < len(requests) {
+		return fmt.Errorf("stream closed by service after %d of %d messages", sent, len(requests))
+	}
+	return nil
+}
+
+func init() {
+	outputs.Add("grpc", func() telegraf.Output {
+		return &GRPC{
+			ServiceAddress: "localhost:50051",
+			Timeout:        config.Duration(5 * time.Second),
+			Compression:    "none",
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newPlugin(t *testing.T, method string) *GRPC {
+	creator, found := outputs.Outputs["grpc"]
+	require.True(t, found)
+	plugin := creator().(*GRPC)
+	plugin.Files = []string{"testdata/ingest.proto"}
+	plugin.Method = method
+	plugin.Log = testutil.Logger{}
+	return plugin
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*GRPC)
+		expected string
+	}{
+		{
+			name:     "missing address",
+			modify:   func(g *GRPC) { g.ServiceAddress = "" },
+			expected: "'service_address' setting required",
+		},
+		{
+			name:     "invalid compression",
+			modify:   func(g *GRPC) { g.Compression = "zstd" },
+			expected: "invalid 'compression' setting",
+		},
+		{
+			name:     "missing method",
+			modify:   func(g *GRPC) { g.Method = "" },
+			expected: "'method' setting required",
+		},
+		{
+			name:     "server streaming",
+			modify:   func(g *GRPC) { g.Method = "ingest.Ingest/Subscribe" },
+			expected: "server-streaming method",
+		},
+		{
+			name:     "invalid mapping",
+			modify:   func(g *GRPC) { g.Mapping.FieldMap = "" },
+			expected: "invalid mapping",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, "ingest.Ingest/Write")
+			plugin.Mapping.Metrics = "samples"
+			plugin.Mapping.FieldMap = "values"
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		metrics  string
+		messages int
+	}{
+		{
+			name:     "unary batched",
+			method:   "ingest.Ingest/Write",
+			metrics:  "samples",
+			messages: 1,
+		},
+		{
+			name:     "unary per metric",
+			method:   "ingest.Ingest/WriteSample",
+			messages: 2,
+		},
+		{
+			name:     "client streaming",
+			method:   "ingest.Ingest/Stream",
+			messages: 2,
+		},
+	}
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "b"}, map[string]interface{}{"used": 23.0}, time.Unix(2, 0)),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, tt.method)
+			plugin.Compression = "gzip"
+			plugin.Headers = map[string]string{"authorization": "secret"}
+			plugin.Mapping = protobuf.Mapping{
+				Metrics:    tt.metrics,
+				MetricName: "measurement",
+				Timestamp:  "time",
+				TagMap:     "labels",
+				FieldMap:   "values",
+			}
+			require.NoError(t, plugin.Init())
+
+			server := newServer(t, plugin.method, codes.OK)
+			plugin.ServiceAddress = server.address
+			require.NoError(t, plugin.Connect())
+			defer plugin.Close()
+
+			require.NoError(t, plugin.Write(input))
+
+			// Decode the received messages using the plugin's mapping
+			messages, md := server.received()
+			require.Len(t, messages, tt.messages)
+			var actual []telegraf.Metric
+			for _, msg := range messages {
+				metrics, err := plugin.Mapping.Decode(msg, "")
+				require.NoError(t, err)
+				actual = append(actual, metrics...)
+			}
+			testutil.RequireMetricsEqual(t, input, actual)
+			require.Equal(t, []string{"secret"}, md.Get("authorization"))
+		})
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		code     codes.Code
+		accept   []int
+		reject   []int
+		expected codes.Code
+	}{
+		{
+			name:     "unary invalid argument",
+			method:   "ingest.Ingest/WriteSample",
+			code:     codes.InvalidArgument,
+			reject:   []int{1, 0, 2},
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:     "unary unavailable",
+			method:   "ingest.Ingest/WriteSample",
+			code:     codes.Unavailable,
+			reject:   []int{1},
+			expected: codes.Unavailable,
+		},
+		{
+			name:     "streaming invalid argument",
+			method:   "ingest.Ingest/Stream",
+			code:     codes.InvalidArgument,
+			reject:   []int{1, 0, 2},
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:   "streaming success",
+			method: "ingest.Ingest/Stream",
+			code:   codes.OK,
+			accept: []int{0, 2},
+			reject: []int{1},
+		},
+	}
+
+	// The second metric cannot be converted to the message
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"count": 1}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"count": "foo"}, time.Unix(2, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"count": 3}, time.Unix(3, 0)),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, tt.method)
+			plugin.Mapping.Fields = map[string]string{"count": "count"}
+			require.NoError(t, plugin.Init())
+
+			server := newServer(t, plugin.method, tt.code)
+			plugin.ServiceAddress = server.address
+			require.NoError(t, plugin.Connect())
+			defer plugin.Close()
+
+			err := plugin.Write(input)
+			var werr *internal.PartialWriteError
+			require.ErrorAs(t, err, &werr)
+			require.Equal(t, tt.accept, werr.MetricsAccept)
+			require.Equal(t, tt.reject, werr.MetricsReject)
+			if tt.expected == codes.OK {
+				require.ErrorIs(t, err, internal.ErrSerialization)
+			} else {
+				require.Equal(t, tt.expected, status.Code(err))
+			}
+		})
+	}
+}
+
+// server is a gRPC server receiving messages of the given method and
+// responding with the given status code
+type server struct {
+	address  string
+	messages []protoreflect.Message
+	md       metadata.MD
+
+	sync.Mutex
+}
+
+func newServer(t *testing.T, method protoreflect.MethodDescriptor, code codes.Code) *server {
+	s := &server{}
+
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		if code != codes.OK {
+			return status.Error(code, "failed")
+		}
+
+		s.Lock()
+		s.md, _ = metadata.FromIncomingContext(stream.Context())
+		s.Unlock()
+		for {
+			msg := dynamicpb.NewMessage(method.Input())
+			if err := stream.RecvMsg(msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			s.Lock()
+			s.messages = append(s.messages, msg)
+			s.Unlock()
+		}
+		return stream.SendMsg(dynamicpb.NewMessage(method.Output()))
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(handler))
+	go srv.Serve(listener) //nolint:errcheck // ignore the returned error as we cannot do anything about it anyway
+	t.Cleanup(srv.Stop)
+
+	s.address = listener.Addr().String()
+	return s
+}
+
+func (s *server) received() ([]protoreflect.Message, metadata.MD) {
+	s.Lock()
+	defer s.Unlock()
+	return append([]protoreflect.Message(nil), s.messages...), s.md
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -43,19 +44,24 @@ const (
 
 type HTTP struct {
 	URL                     string                    `toml:"url"`
+	URLs                    []string                  `toml:"urls"`
 	Method                  string                    `toml:"method"`
 	Username                config.Secret             `toml:"username"`
 	Password                config.Secret             `toml:"password"`
 	Headers                 map[string]*config.Secret `toml:"headers"`
 	ContentEncoding         string                    `toml:"content_encoding"`
 	UseBatchFormat          bool                      `toml:"use_batch_format"`
+	StreamBatch             bool                      `toml:"stream_batch"`
 	AwsService              string                    `toml:"aws_service"`
 	NonRetryableStatusCodes []int                     `toml:"non_retryable_statuscodes"`
 	common_http.HTTPClientConfig
+	common_http.EndpointPoolConfig
 	Log telegraf.Logger `toml:"-"`
 
 	client     *http.Client
+	pool       *common_http.EndpointPool
 	serializer telegraf.Serializer
+	encoder    internal.ContentEncoder
 
 	awsCfg *aws.Config
 	common_aws.CredentialConfig
@@ -89,18 +95,50 @@ func (h *HTTP) Connect() error {
 		return fmt.Errorf("invalid method [%s] %s", h.URL, h.Method)
 	}
 
+	encoding := "identity"
+	if h.ContentEncoding == "gzip" {
+		encoding = "gzip"
+	}
+	encoder, err := internal.NewContentEncoder(encoding)
+	if err != nil {
+		return err
+	}
+	h.encoder = encoder
+
 	ctx := context.Background()
 	client, err := h.HTTPClientConfig.CreateClient(ctx, h.Log)
 	if err != nil {
 		return err
 	}
 
+	// Distribute the requests across the given endpoints with the primary
+	// endpoint being the first one
+	if len(h.URLs) > 0 {
+		if h.AwsService != "" && len(h.URLs) > 1 {
+			return errors.New("'aws_service' cannot be used with multiple 'urls'")
+		}
+		// The pool has to buffer the body for resending it to another endpoint
+		if h.StreamBatch {
+			return errors.New("'stream_batch' cannot be used with 'urls'")
+		}
+		h.URL = h.URLs[0]
+		pool, err := h.EndpointPoolConfig.CreateEndpointPool(h.URLs, map[string]string{"output": "http"})
+		if err != nil {
+			return err
+		}
+		pool.Wrap(client)
+		h.pool = pool
+	}
+
 	h.client = client
 
 	return nil
 }
 
 func (h *HTTP) Close() error {
+	if h.pool != nil {
+		h.pool.Stop()
+	}
 	if h.client != nil {
 		h.client.CloseIdleConnections()
 	}
@@ -110,6 +148,14 @@ func (h *HTTP) Close() error {
 
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
 	if h.UseBatchFormat {
+		// Stream the serialized batch to the request body if requested to
+		// avoid holding the whole batch in memory
+		if serializer, ok := h.serializer.(telegraf.StreamingSerializer); ok && h.StreamBatch {
+			return h.write(func(w io.Writer) error {
+				return serializer.SerializeBatchTo(w, metrics)
+			}, true)
+		}
+
 		reqBody, err := h.serializer.SerializeBatch(metrics)
 		if err != nil {
 			return err
@@ -132,30 +178,49 @@ func (h *HTTP) Write(metrics []telegraf.Metric) error {
 }
 
 func (h *HTTP) writeMetric(reqBody []byte) error {
-	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
-
-	var err error
-	if h.ContentEncoding == "gzip" {
-		rc := internal.CompressWithGzip(reqBodyBuffer)
-		defer rc.Close()
-		reqBodyBuffer = rc
-	}
+	return h.write(func(w io.Writer) error {
+		_, err := w.Write(reqBody)
+		return err
+	}, false)
+}
 
+// write sends a request with the body produced by the given function. The
+// body is encoded on the fly and streamed to the server if requested unless
+// the complete body is required for signing the request. Otherwise the
+// encoded body is buffered to set the content-length and allow resending the
+// request.
+func (h *HTTP) write(writeBody func(io.Writer) error, stream bool) error {
+	var reqBodyBuffer io.Reader
 	var payloadHash *string
-	if h.awsCfg != nil {
-		// We need a local copy of the full buffer, the signature scheme requires a sha256 of the request body.
+	if !stream || h.awsCfg != nil {
 		buf := new(bytes.Buffer)
-		_, err = io.Copy(buf, reqBodyBuffer)
-		if err != nil {
+		if err := h.encodeBody(buf, writeBody); err != nil {
 			return err
 		}
+		reqBodyBuffer = bytes.NewReader(buf.Bytes())
 
-		sum := sha256.Sum256(buf.Bytes())
-		reqBodyBuffer = buf
+		// We need a local copy of the full buffer, the signature scheme requires a sha256 of the request body.
+		if h.awsCfg != nil {
+			sum := sha256.Sum256(buf.Bytes())
 
-		// sha256 is hex encoded
-		hash := hex.EncodeToString(sum[:])
-		payloadHash = &hash
+			// sha256 is hex encoded
+			hash := hex.EncodeToString(sum[:])
+			payloadHash = &hash
+		}
+	} else {
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			pw.CloseWithError(h.encodeBody(pw, writeBody))
+		}()
+		defer func() {
+			// Unblock the encoding in case the request finished early and
+			// wait for it to stop accessing the data
+			pr.Close()
+			<-done
+		}()
+		reqBodyBuffer = pr
 	}
 
 	req, err := http.NewRequest(h.Method, h.URL, reqBodyBuffer)
@@ -264,6 +329,18 @@ func init() {
 	})
 }
 
+func (h *HTTP) encodeBody(w io.Writer, writeBody func(io.Writer) error) error {
+	ew, err := h.encoder.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := writeBody(ew); err != nil {
+		ew.Close()
+		return err
+	}
+	return ew.Close()
+}
+
 func (h *HTTP) getAccessToken(ctx context.Context, audience string) (*oauth2.Token, error) {
 	if h.oauth2Token.Valid() {
 		return h.oauth2Token, nil
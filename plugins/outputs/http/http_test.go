@@ -767,6 +767,132 @@ func TestBatchedUnbatched(t *testing.T) {
 	}
 }
 
+func TestMultipleEndpoints(t *testing.T) {
+	var primary, secondary int
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		primary++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primaryServer.Close()
+
+	var body string
+	secondaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary++
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body = string(buf)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondaryServer.Close()
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := &HTTP{
+		URLs:           []string{primaryServer.URL + "/telegraf", secondaryServer.URL + "/telegraf"},
+		Method:         defaultMethod,
+		UseBatchFormat: true,
+		EndpointPoolConfig: common_http.EndpointPoolConfig{
+			EndpointStrategy:         "failover",
+			EndpointFailureThreshold: 1,
+			EndpointOpenTimeout:      config.Duration(time.Hour),
+		},
+		Log: testutil.Logger{},
+	}
+	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	// The primary endpoint must be skipped after failing
+	require.NoError(t, plugin.Write(getMetrics(1)))
+	require.NoError(t, plugin.Write(getMetrics(1)))
+	require.Equal(t, 1, primary)
+	require.Equal(t, 2, secondary)
+	require.Equal(t, "cpu value=42 0\n", body)
+}
+
+func TestStreamingBatchMultipleEndpoints(t *testing.T) {
+	plugin := &HTTP{
+		URLs:           []string{"http://primary:8080/telegraf", "http://secondary:8080/telegraf"},
+		Method:         defaultMethod,
+		UseBatchFormat: true,
+		StreamBatch:    true,
+		Log:            testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Connect(), "'stream_batch' cannot be used with 'urls'")
+}
+
+func TestStreamingBatch(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	u, err := url.Parse("http://" + ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	jsonSerializer := &json.Serializer{}
+	require.NoError(t, jsonSerializer.Init())
+
+	metrics := getMetrics(1000)
+	expected, err := jsonSerializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		encoding string
+		stream   bool
+	}{
+		{encoding: "identity"},
+		{encoding: "gzip"},
+		{encoding: "identity", stream: true},
+		{encoding: "gzip", stream: true},
+	} {
+		t.Run(fmt.Sprintf("%s stream=%v", tt.encoding, tt.stream), func(t *testing.T) {
+			var received []byte
+			var contentLength int64
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentLength = r.ContentLength
+				var body io.Reader = r.Body
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					gr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					body = gr
+				}
+				buf, err := io.ReadAll(body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				received = buf
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			client := &HTTP{
+				URL:             u.String(),
+				Method:          defaultMethod,
+				ContentEncoding: tt.encoding,
+				UseBatchFormat:  true,
+				StreamBatch:     tt.stream,
+			}
+			client.SetSerializer(jsonSerializer)
+			require.NoError(t, client.Connect())
+			require.NoError(t, client.Write(metrics))
+			require.Equal(t, string(expected), string(received))
+
+			// Only streamed bodies are sent without a content-length
+			if tt.stream {
+				require.Equal(t, int64(-1), contentLength)
+			} else {
+				require.Positive(t, contentLength)
+			}
+		})
+	}
+}
+
 func TestAwsCredentials(t *testing.T) {
 	ts := httptest.NewServer(http.NotFoundHandler())
 	defer ts.Close()
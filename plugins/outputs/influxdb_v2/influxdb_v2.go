@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/ratelimiter"
 	commontls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -46,8 +48,10 @@ type InfluxDB struct {
 	Log              telegraf.Logger   `toml:"-"`
 	commontls.ClientConfig
 	ratelimiter.RateLimitConfig
+	common_http.EndpointPoolConfig
 
 	clients    []*httpClient
+	pool       *common_http.EndpointPool
 	encoder    internal.ContentEncoder
 	serializer ratelimiter.Serializer
 	tlsCfg     *tls.Config
@@ -181,10 +185,37 @@ func (i *InfluxDB) Connect() error {
 		}
 	}
 
+	// Select the server to write to using the endpoint pool if configured
+	if i.EndpointStrategy != "" {
+		pool, err := i.EndpointPoolConfig.CreateEndpointPool(i.URLs, map[string]string{"output": "influxdb_v2"})
+		if err != nil {
+			return err
+		}
+		proxy := http.ProxyFromEnvironment
+		if i.HTTPProxy != "" {
+			u, err := url.Parse(i.HTTPProxy)
+			if err != nil {
+				return fmt.Errorf("error parsing proxy_url [%s]: %w", i.HTTPProxy, err)
+			}
+			proxy = http.ProxyURL(u)
+		}
+		pool.Start(&http.Client{
+			Timeout: time.Duration(i.Timeout),
+			Transport: &http.Transport{
+				TLSClientConfig: i.tlsCfg,
+				Proxy:           proxy,
+			},
+		})
+		i.pool = pool
+	}
+
 	return nil
 }
 
 func (i *InfluxDB) Close() error {
+	if i.pool != nil {
+		i.pool.Stop()
+	}
 	for _, client := range i.clients {
 		client.Close()
 	}
@@ -192,10 +223,31 @@ func (i *InfluxDB) Close() error {
 }
 
 // Write sends metrics to one of the configured servers, logging each
-// unsuccessful. If all servers fail, return an error.
+// unsuccessful. If all servers fail, return an error. With an endpoint
+// strategy the servers are tried in the order determined by the pool,
+// otherwise in random order.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
+	if i.pool != nil {
+		return i.pool.Do(func(idx int) error {
+			client := i.clients[idx]
+			err := client.Write(ctx, metrics)
+			if err == nil {
+				return nil
+			}
+			i.Log.Errorf("When writing to [%s]: %v", client.url, err)
+
+			// Only try the next server if no metric was handled by this one
+			var werr *internal.PartialWriteError
+			if errors.As(err, &werr) && (len(werr.MetricsAccept) > 0 || len(werr.MetricsReject) > 0) ||
+				errors.Is(err, internal.ErrSizeLimitReached) {
+				return common_http.Permanent(err)
+			}
+			return err
+		})
+	}
+
 	for _, n := range rand.Perm(len(i.clients)) {
 		client := i.clients[n]
 		if err := client.Write(ctx, metrics); err != nil {
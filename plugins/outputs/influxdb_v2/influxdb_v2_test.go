@@ -859,3 +859,42 @@ func TestStatusCodeUnexpected(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpointFailover(t *testing.T) {
+	var primary, secondary atomic.Int64
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		primary.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primaryServer.Close()
+
+	secondaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		secondary.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer secondaryServer.Close()
+
+	plugin := &influxdb.InfluxDB{
+		URLs:   []string{primaryServer.URL, secondaryServer.URL},
+		Bucket: "telegraf",
+		Log:    &testutil.Logger{},
+	}
+	plugin.EndpointStrategy = "failover"
+	plugin.EndpointFailureThreshold = 1
+	plugin.EndpointOpenTimeout = config.Duration(time.Hour)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+
+	// The failing primary server must be skipped for subsequent writes
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Equal(t, int64(1), primary.Load())
+	require.Equal(t, int64(2), secondary.Load())
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gofrs/uuid/v5"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/common/proxy"
@@ -30,16 +31,19 @@ var ValidTopicSuffixMethods = []string{
 var zeroTime = time.Unix(0, 0)
 
 type Kafka struct {
-	Brokers           []string        `toml:"brokers"`
-	Topic             string          `toml:"topic"`
-	TopicTag          string          `toml:"topic_tag"`
-	ExcludeTopicTag   bool            `toml:"exclude_topic_tag"`
-	TopicSuffix       TopicSuffix     `toml:"topic_suffix"`
-	RoutingTag        string          `toml:"routing_tag"`
-	RoutingKey        string          `toml:"routing_key"`
-	ProducerTimestamp string          `toml:"producer_timestamp"`
-	MetricNameHeader  string          `toml:"metric_name_header"`
-	Log               telegraf.Logger `toml:"-"`
+	Brokers            []string        `toml:"brokers"`
+	Topic              string          `toml:"topic"`
+	TopicTag           string          `toml:"topic_tag"`
+	ExcludeTopicTag    bool            `toml:"exclude_topic_tag"`
+	TopicSuffix        TopicSuffix     `toml:"topic_suffix"`
+	RoutingTag         string          `toml:"routing_tag"`
+	RoutingKey         string          `toml:"routing_key"`
+	ProducerTimestamp  string          `toml:"producer_timestamp"`
+	MetricNameHeader   string          `toml:"metric_name_header"`
+	Transactional      bool            `toml:"transactional"`
+	TransactionalID    string          `toml:"transactional_id"`
+	TransactionTimeout config.Duration `toml:"transaction_timeout"`
+	Log                telegraf.Logger `toml:"-"`
 	proxy.Socks5ProxyConfig
 	kafka.WriteConfig
 
@@ -51,6 +55,7 @@ type Kafka struct {
 	// TLS certificate authority
 	CA string
 
+	pluginID     string
 	saramaConfig *sarama.Config
 	producerFunc func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error)
 	producer     sarama.SyncProducer
@@ -117,15 +122,19 @@ func (k *Kafka) SetSerializer(serializer telegraf.Serializer) {
 	k.serializer = serializer
 }
 
+func (k *Kafka) SetPluginID(id string) {
+	k.pluginID = id
+}
+
 func (k *Kafka) Init() error {
 	kafka.SetLogger(k.Log.Level())
 
 	if err := ValidateTopicSuffixMethod(k.TopicSuffix.Method); err != nil {
 		return err
 	}
-	config := sarama.NewConfig()
+	cfg := sarama.NewConfig()
 
-	if err := k.SetConfig(config, k.Log); err != nil {
+	if err := k.SetConfig(cfg, k.Log); err != nil {
 		return err
 	}
 
@@ -137,15 +146,40 @@ func (k *Kafka) Init() error {
 	}
 
 	if k.Socks5ProxyEnabled {
-		config.Net.Proxy.Enable = true
+		cfg.Net.Proxy.Enable = true
 
 		dialer, err := k.Socks5ProxyConfig.GetDialer()
 		if err != nil {
 			return fmt.Errorf("connecting to proxy server failed: %w", err)
 		}
-		config.Net.Proxy.Dialer = dialer
+		cfg.Net.Proxy.Dialer = dialer
+	}
+
+	if k.Transactional {
+		if k.RequiredAcks != -1 {
+			return errors.New("transactions require 'required_acks' to be -1")
+		}
+		if k.MaxRetry < 1 {
+			return errors.New("transactions require 'max_retry' to be at least 1")
+		}
+
+		// Derive the ID from the plugin's configuration to keep it stable
+		// across restarts. This allows the broker to fence previous instances
+		// of the producer and to abort their pending transactions.
+		if k.TransactionalID == "" {
+			if k.pluginID == "" {
+				return errors.New("'transactional_id' setting required")
+			}
+			k.TransactionalID = "telegraf-" + k.pluginID
+		}
+		cfg.Producer.Idempotent = true
+		cfg.Producer.Transaction.ID = k.TransactionalID
+		if k.TransactionTimeout > 0 {
+			cfg.Producer.Transaction.Timeout = time.Duration(k.TransactionTimeout)
+		}
+		cfg.Net.MaxOpenRequests = 1
 	}
-	k.saramaConfig = config
+	k.saramaConfig = cfg
 
 	switch k.ProducerTimestamp {
 	case "":
@@ -167,6 +201,24 @@ func (k *Kafka) Connect() error {
 	return nil
 }
 
+// Probe checks if the brokers are reachable and, for a static topic, if the
+// topic is available
+func (k *Kafka) Probe() error {
+	client, err := sarama.NewClient(k.Brokers, k.saramaConfig)
+	if err != nil {
+		return fmt.Errorf("connecting to brokers failed: %w", err)
+	}
+	defer client.Close()
+
+	if k.TopicTag != "" || k.TopicSuffix.Method != "" {
+		return nil
+	}
+	if _, err := client.Partitions(k.Topic); err != nil {
+		return fmt.Errorf("getting partitions of topic %q failed: %w", k.Topic, err)
+	}
+	return nil
+}
+
 func (k *Kafka) Close() error {
 	if k.producer == nil {
 		return nil
@@ -194,19 +246,32 @@ func (k *Kafka) routingKey(metric telegraf.Metric) (string, error) {
 }
 
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
+	// Recreate the producer if it was closed due to a fatal transaction error
+	if k.producer == nil {
+		producer, err := k.producerFunc(k.Brokers, k.saramaConfig)
+		if err != nil {
+			return fmt.Errorf("creating producer failed: %w", err)
+		}
+		k.producer = producer
+	}
+
+	werr := &internal.PartialWriteError{}
 	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
-	for _, metric := range metrics {
+	for i, metric := range metrics {
 		metric, topic := k.GetTopicName(metric)
 
 		buf, err := k.serializer.Serialize(metric)
 		if err != nil {
 			k.Log.Debugf("Could not serialize metric: %v", err)
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
 			continue
 		}
 
 		m := &sarama.ProducerMessage{
-			Topic: topic,
-			Value: sarama.ByteEncoder(buf),
+			Topic:    topic,
+			Value:    sarama.ByteEncoder(buf),
+			Metadata: i,
 		}
 
 		if k.MetricNameHeader != "" {
@@ -234,30 +299,156 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		msgs = append(msgs, m)
 	}
 
+	if k.Transactional {
+		return k.sendTransactional(msgs, werr)
+	}
+
 	err := k.producer.SendMessages(msgs)
-	if err != nil {
-		// We could have many errors, return only the first encountered.
-		var errs sarama.ProducerErrors
-		if errors.As(err, &errs) && len(errs) > 0 {
-			// Just return the first error encountered
-			firstErr := errs[0]
-			if errors.Is(firstErr.Err, sarama.ErrMessageSizeTooLarge) {
-				k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping batch")
+	if err == nil {
+		if len(werr.MetricsReject) == 0 {
+			return nil
+		}
+		for _, m := range msgs {
+			werr.MetricsAccept = append(werr.MetricsAccept, m.Metadata.(int))
+		}
+		werr.Err = internal.ErrSerialization
+		return werr
+	}
+
+	var errs sarama.ProducerErrors
+	if !errors.As(err, &errs) || len(errs) == 0 {
+		return err
+	}
+
+	// Sort out the failed messages and accept all others. Messages failing
+	// due to transient errors are neither accepted nor rejected, so they
+	// are retried with the next write.
+	failed := k.rejectFailed(errs, werr)
+	for _, m := range msgs {
+		if idx := m.Metadata.(int); !failed[idx] {
+			werr.MetricsAccept = append(werr.MetricsAccept, idx)
+		}
+	}
+	werr.Err = fmt.Errorf("sending %d of %d messages failed: %w", len(errs), len(msgs), errs[0].Err)
+
+	return werr
+}
+
+// sendTransactional sends the messages of a batch within a single transaction,
+// so consumers reading committed messages only see the messages after all
+// of them were written successfully. If any message fails, the transaction is
+// aborted and all messages are retried with the next write, except for the
+// messages failing due to their content, which are rejected.
+func (k *Kafka) sendTransactional(msgs []*sarama.ProducerMessage, werr *internal.PartialWriteError) error {
+	// Avoid empty transactions if no metric could be serialized
+	if len(msgs) == 0 {
+		if len(werr.MetricsReject) == 0 {
+			return nil
+		}
+		werr.Err = internal.ErrSerialization
+		return werr
+	}
+
+	if err := k.producer.BeginTxn(); err != nil {
+		k.checkProducer()
+		return fmt.Errorf("beginning transaction failed: %w", err)
+	}
+
+	err := k.producer.SendMessages(msgs)
+	if err == nil {
+		if err = k.producer.CommitTxn(); err == nil {
+			if len(werr.MetricsReject) == 0 {
 				return nil
 			}
-			if errors.Is(firstErr.Err, sarama.ErrInvalidTimestamp) {
-				k.Log.Error(
-					"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
-						"dropping batch",
-				)
-				return nil
+			for _, m := range msgs {
+				werr.MetricsAccept = append(werr.MetricsAccept, m.Metadata.(int))
 			}
-			return firstErr
+			werr.Err = internal.ErrSerialization
+			return werr
 		}
-		return err
+		err = fmt.Errorf("committing transaction failed: %w", err)
 	}
 
-	return nil
+	if aerr := k.producer.AbortTxn(); aerr != nil {
+		k.Log.Errorf("Aborting transaction failed: %v", aerr)
+	}
+	k.checkProducer()
+
+	var errs sarama.ProducerErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		k.rejectFailed(errs, werr)
+		err = fmt.Errorf("sending %d of %d messages failed: %w", len(errs), len(msgs), errs[0].Err)
+	}
+	werr.Err = err
+
+	return werr
+}
+
+// checkProducer closes the producer if it is in a fatal transaction state,
+// e.g. after being fenced by another producer using the same transactional
+// ID. The producer is recreated on the next write.
+func (k *Kafka) checkProducer() {
+	if k.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError == 0 {
+		return
+	}
+
+	k.Log.Errorf("Producer with transactional ID %q failed fatally, recreating producer", k.TransactionalID)
+	if err := k.producer.Close(); err != nil {
+		k.Log.Errorf("Closing producer failed: %v", err)
+	}
+	k.producer = nil
+}
+
+// rejectFailed rejects the metrics of messages failing due to their content
+// and returns the indices of all failed messages
+func (k *Kafka) rejectFailed(errs sarama.ProducerErrors, werr *internal.PartialWriteError) map[int]bool {
+	failed := make(map[int]bool, len(errs))
+	for _, perr := range errs {
+		idx, ok := perr.Msg.Metadata.(int)
+		if !ok {
+			continue
+		}
+		failed[idx] = true
+
+		switch {
+		case errors.Is(perr.Err, sarama.ErrMessageSizeTooLarge):
+			k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping metric")
+		case errors.Is(perr.Err, sarama.ErrInvalidTimestamp):
+			k.Log.Error(
+				"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
+					"dropping metric",
+			)
+		case isRetryable(perr.Err):
+			continue
+		}
+		werr.MetricsReject = append(werr.MetricsReject, idx)
+		werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, perr.Err)
+	}
+	return failed
+}
+
+// isRetryable returns true for transient errors where sending the message
+// again might succeed. Errors caused by the message itself, e.g. exceeding
+// the size limit, will fail in the same way when retried.
+func isRetryable(err error) bool {
+	var kerr sarama.KError
+	if !errors.As(err, &kerr) {
+		// Client-side errors like timeouts or closed connections
+		return true
+	}
+
+	switch kerr {
+	case sarama.ErrMessageSizeTooLarge,
+		sarama.ErrInvalidTimestamp,
+		sarama.ErrInvalidMessage,
+		sarama.ErrMessageSetSizeTooLarge,
+		sarama.ErrInvalidRecord,
+		sarama.ErrTopicAuthorizationFailed,
+		sarama.ErrInvalidTopic,
+		sarama.ErrUnsupportedCompressionType:
+		return false
+	}
+	return true
 }
 
 func init() {
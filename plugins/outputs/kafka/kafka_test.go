@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	kafkacontainer "github.com/testcontainers/testcontainers-go/modules/kafka"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -53,6 +56,20 @@ func TestConnectAndWriteIntegration(t *testing.T) {
 
 	// Verify that we can successfully write data to the kafka broker
 	require.NoError(t, plugin.Write(testutil.MockMetrics()))
+
+	// The topic exists after writing so probing must succeed
+	require.NoError(t, plugin.Probe())
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:      []string{"127.0.0.1:1"},
+		Topic:        "Test",
+		Log:          testutil.Logger{},
+		producerFunc: NewMockProducer,
+	}
+	require.NoError(t, plugin.Init())
+	require.ErrorContains(t, plugin.Probe(), "connecting to brokers failed")
 }
 
 func TestTopicSuffixes(t *testing.T) {
@@ -168,7 +185,14 @@ func TestRoutingKey(t *testing.T) {
 }
 
 type MockProducer struct {
-	sent []*sarama.ProducerMessage
+	sent      []*sarama.ProducerMessage
+	errs      map[string]error
+	commitErr error
+	status    sarama.ProducerTxnStatusFlag
+	begins    int
+	commits   int
+	aborts    int
+	closed    bool
 	sarama.SyncProducer
 }
 
@@ -178,11 +202,44 @@ func (p *MockProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32
 }
 
 func (p *MockProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
-	p.sent = append(p.sent, msgs...)
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		if err, found := p.errs[msg.Topic]; found {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: err})
+			continue
+		}
+		p.sent = append(p.sent, msg)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func (*MockProducer) Close() error {
+func (p *MockProducer) BeginTxn() error {
+	p.begins++
+	return nil
+}
+
+func (p *MockProducer) CommitTxn() error {
+	if p.commitErr != nil {
+		return p.commitErr
+	}
+	p.commits++
+	return nil
+}
+
+func (p *MockProducer) AbortTxn() error {
+	p.aborts++
+	return nil
+}
+
+func (p *MockProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	return p.status
+}
+
+func (p *MockProducer) Close() error {
+	p.closed = true
 	return nil
 }
 
@@ -312,3 +369,170 @@ func TestTopicTag(t *testing.T) {
 		})
 	}
 }
+
+func TestPartialWrite(t *testing.T) {
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	plugin := &Kafka{
+		Brokers:  []string{"127.0.0.1"},
+		Topic:    "telegraf",
+		TopicTag: "topic",
+		producer: &MockProducer{
+			errs: map[string]error{
+				"too_large": sarama.ErrMessageSizeTooLarge,
+				"replicas":  sarama.ErrNotEnoughReplicas,
+				"timeout":   errors.New("timeout"),
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	plugin.SetSerializer(s)
+
+	input := make([]telegraf.Metric, 0, 4)
+	for _, topic := range []string{"ok", "too_large", "replicas", "timeout"} {
+		input = append(input, metric.New(
+			"cpu",
+			map[string]string{"topic": topic},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	err := plugin.Write(input)
+	require.ErrorIs(t, err, sarama.ErrMessageSizeTooLarge)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+}
+
+func TestTransactionalInit(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:       []string{"127.0.0.1"},
+		Topic:         "telegraf",
+		Transactional: true,
+		WriteConfig: kafka.WriteConfig{
+			RequiredAcks: -1,
+			MaxRetry:     3,
+		},
+		Log: testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "'transactional_id' setting required")
+
+	// The transactional ID must be derived from the plugin ID if not set
+	plugin.SetPluginID("abc")
+	require.NoError(t, plugin.Init())
+	require.Equal(t, "telegraf-abc", plugin.TransactionalID)
+	require.Equal(t, "telegraf-abc", plugin.saramaConfig.Producer.Transaction.ID)
+	require.True(t, plugin.saramaConfig.Producer.Idempotent)
+	require.Equal(t, 1, plugin.saramaConfig.Net.MaxOpenRequests)
+
+	plugin.RequiredAcks = 1
+	require.ErrorContains(t, plugin.Init(), "transactions require 'required_acks' to be -1")
+}
+
+func TestTransactionalWrite(t *testing.T) {
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	input := make([]telegraf.Metric, 0, 3)
+	for _, topic := range []string{"ok", "too_large", "ok"} {
+		input = append(input, metric.New(
+			"cpu",
+			map[string]string{"topic": topic},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	tests := []struct {
+		name     string
+		producer *MockProducer
+		input    []telegraf.Metric
+		expected error
+		reject   []int
+	}{
+		{
+			name:     "success",
+			producer: &MockProducer{},
+			input:    []telegraf.Metric{input[0], input[2]},
+		},
+		{
+			name: "failed message",
+			producer: &MockProducer{
+				errs: map[string]error{"too_large": sarama.ErrMessageSizeTooLarge},
+			},
+			input:    input,
+			expected: sarama.ErrMessageSizeTooLarge,
+			reject:   []int{1},
+		},
+		{
+			name:     "failed commit",
+			producer: &MockProducer{commitErr: sarama.ErrNotEnoughReplicas},
+			input:    input,
+			expected: sarama.ErrNotEnoughReplicas,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Kafka{
+				Brokers:         []string{"127.0.0.1"},
+				Topic:           "telegraf",
+				TopicTag:        "topic",
+				Transactional:   true,
+				TransactionalID: "test",
+				producer:        tt.producer,
+				Log:             testutil.Logger{},
+			}
+			plugin.SetSerializer(s)
+
+			err := plugin.Write(tt.input)
+			require.Equal(t, 1, tt.producer.begins)
+			if tt.expected == nil {
+				require.NoError(t, err)
+				require.Equal(t, 1, tt.producer.commits)
+				require.Zero(t, tt.producer.aborts)
+				return
+			}
+
+			// No metric must be accepted if the transaction was aborted
+			require.ErrorIs(t, err, tt.expected)
+			var werr *internal.PartialWriteError
+			require.ErrorAs(t, err, &werr)
+			require.Empty(t, werr.MetricsAccept)
+			require.Equal(t, tt.reject, werr.MetricsReject)
+			require.Zero(t, tt.producer.commits)
+			require.Equal(t, 1, tt.producer.aborts)
+		})
+	}
+}
+
+func TestTransactionalFatalError(t *testing.T) {
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	failing := &MockProducer{
+		commitErr: sarama.ErrProducerFenced,
+		status:    sarama.ProducerTxnFlagFatalError,
+	}
+	plugin := &Kafka{
+		Brokers:         []string{"127.0.0.1"},
+		Topic:           "telegraf",
+		Transactional:   true,
+		TransactionalID: "test",
+		producerFunc:    NewMockProducer,
+		producer:        failing,
+		Log:             testutil.Logger{},
+	}
+	plugin.SetSerializer(s)
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	require.ErrorIs(t, plugin.Write([]telegraf.Metric{m}), sarama.ErrProducerFenced)
+	require.True(t, failing.closed)
+
+	// The producer must be recreated with the next write
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.NotSame(t, failing, plugin.producer)
+}
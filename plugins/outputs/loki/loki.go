@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -36,6 +37,7 @@ const (
 
 type Loki struct {
 	Domain             string            `toml:"domain"`
+	Domains            []string          `toml:"domains"`
 	Endpoint           string            `toml:"endpoint"`
 	Timeout            config.Duration   `toml:"timeout"`
 	Username           config.Secret     `toml:"username"`
@@ -51,7 +53,9 @@ type Loki struct {
 
 	url    string
 	client *http.Client
+	pool   *common_http.EndpointPool
 	tls.ClientConfig
+	common_http.EndpointPoolConfig
 }
 
 func (l *Loki) createClient(ctx context.Context) (*http.Client, error) {
@@ -87,6 +91,9 @@ func (*Loki) SampleConfig() string {
 }
 
 func (l *Loki) Connect() (err error) {
+	if len(l.Domains) > 0 {
+		l.Domain = l.Domains[0]
+	}
 	if l.Domain == "" {
 		return errors.New("domain is required")
 	}
@@ -107,10 +114,23 @@ func (l *Loki) Connect() (err error) {
 		return fmt.Errorf("http client fail: %w", err)
 	}
 
+	// Distribute the requests across the given domains with the primary
+	// domain being the first one
+	if len(l.Domains) > 0 {
+		l.pool, err = l.EndpointPoolConfig.CreateEndpointPool(l.Domains, map[string]string{"output": "loki"})
+		if err != nil {
+			return err
+		}
+		l.pool.Wrap(l.client)
+	}
+
 	return nil
 }
 
 func (l *Loki) Close() error {
+	if l.pool != nil {
+		l.pool.Stop()
+	}
 	l.client.CloseIdleConnections()
 
 	return nil
@@ -628,3 +628,42 @@ func TestSanitizeLabelName(t *testing.T) {
 		})
 	}
 }
+
+func TestMultipleDomains(t *testing.T) {
+	paths := make(chan string, 4)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.Host + r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ts1 := httptest.NewServer(handler)
+	defer ts1.Close()
+	ts2 := httptest.NewServer(handler)
+	defer ts2.Close()
+
+	plugin := &Loki{
+		Domains:     []string{ts1.URL, ts2.URL},
+		GZipRequest: true,
+	}
+	plugin.EndpointStrategy = "round-robin"
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	for range 4 {
+		require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+	}
+	close(paths)
+
+	var actual []string
+	for p := range paths {
+		actual = append(actual, p)
+	}
+	host1 := strings.TrimPrefix(ts1.URL, "http://")
+	host2 := strings.TrimPrefix(ts2.URL, "http://")
+	expected := []string{
+		host1 + defaultEndpoint,
+		host2 + defaultEndpoint,
+		host1 + defaultEndpoint,
+		host2 + defaultEndpoint,
+	}
+	require.Equal(t, expected, actual)
+}
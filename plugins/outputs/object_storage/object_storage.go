@@ -0,0 +1,280 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// The minimum part size for multipart uploads supported by S3
+const minPartSize = 5 * 1024 * 1024
+
+var extensions = map[string]string{
+	"gzip": ".gz",
+	"zlib": ".zz",
+	"zstd": ".zst",
+}
+
+type ObjectStorage struct {
+	Backend           string          `toml:"backend"`
+	Bucket            string          `toml:"bucket"`
+	KeyTemplate       string          `toml:"key_template"`
+	FileExtension     string          `toml:"file_extension"`
+	ContentEncoding   string          `toml:"content_encoding"`
+	CompressionLevel  int             `toml:"compression_level"`
+	UseBatchFormat    bool            `toml:"use_batch_format"`
+	PartSize          config.Size     `toml:"part_size"`
+	UploadConcurrency int             `toml:"upload_concurrency"`
+	ForcePathStyle    bool            `toml:"force_path_style"`
+	Timeout           config.Duration `toml:"timeout"`
+	Log               telegraf.Logger `toml:"-"`
+	common_aws.CredentialConfig
+
+	template   *template.Template
+	encoder    internal.ContentEncoder
+	serializer telegraf.Serializer
+	storage    storage
+}
+
+// object is a set of metrics stored in a single object
+type object struct {
+	prefix  string
+	indices []int
+	metrics []telegraf.Metric
+}
+
+func (*ObjectStorage) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *ObjectStorage) SetSerializer(serializer telegraf.Serializer) {
+	o.serializer = serializer
+}
+
+func (o *ObjectStorage) Init() error {
+	switch o.Backend {
+	case "":
+		o.Backend = "s3"
+	case "s3", "file":
+	default:
+		return fmt.Errorf("invalid 'backend' setting %q", o.Backend)
+	}
+
+	if o.Bucket == "" {
+		return errors.New("'bucket' setting required")
+	}
+
+	if o.PartSize < minPartSize {
+		return fmt.Errorf("invalid 'part_size' setting %d, must be at least 5MiB", o.PartSize)
+	}
+	if o.UploadConcurrency < 1 {
+		return fmt.Errorf("invalid 'upload_concurrency' setting %d", o.UploadConcurrency)
+	}
+
+	tmpl, err := template.New("key").Parse(o.KeyTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing key template failed: %w", err)
+	}
+	o.template = tmpl
+
+	var options []internal.EncodingOption
+	if o.CompressionLevel >= 0 {
+		options = append(options, internal.WithCompressionLevel(o.CompressionLevel))
+	}
+	o.encoder, err = internal.NewContentEncoder(o.ContentEncoding, options...)
+	if err != nil {
+		return fmt.Errorf("creating encoder failed: %w", err)
+	}
+
+	return nil
+}
+
+func (o *ObjectStorage) Connect() error {
+	if o.Backend == "file" {
+		o.storage = &fileStorage{root: o.Bucket}
+		return nil
+	}
+
+	s, err := o.newS3Storage()
+	if err != nil {
+		return err
+	}
+	o.storage = s
+	return nil
+}
+
+func (*ObjectStorage) Close() error {
+	return nil
+}
+
+func (o *ObjectStorage) Write(metrics []telegraf.Metric) error {
+	// Metrics are only accepted after the object containing them was
+	// uploaded. Metrics failing to serialize are rejected while the metrics
+	// of objects failing to upload are kept for the next write.
+	werr := &internal.PartialWriteError{}
+	reject := func(idx int, err error) {
+		werr.MetricsReject = append(werr.MetricsReject, idx)
+		werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+	}
+
+	// Group the metrics by the rendered key prefix
+	var objects []*object
+	lookup := make(map[string]*object)
+	var buf bytes.Buffer
+	for i, m := range metrics {
+		buf.Reset()
+		if err := o.template.Execute(&buf, &templateMetric{m}); err != nil {
+			o.Log.Errorf("Cannot create key for metric %v: %v", m, err)
+			reject(i, err)
+			continue
+		}
+		prefix := strings.Trim(buf.String(), "/")
+
+		obj, found := lookup[prefix]
+		if !found {
+			obj = &object{prefix: prefix}
+			lookup[prefix] = obj
+			objects = append(objects, obj)
+		}
+		obj.indices = append(obj.indices, i)
+		obj.metrics = append(obj.metrics, m)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
+	defer cancel()
+
+	for _, obj := range objects {
+		data, indices := o.serialize(obj, reject)
+		if len(indices) == 0 {
+			continue
+		}
+
+		encoded, err := o.encoder.Encode(data)
+		if err != nil {
+			for _, idx := range indices {
+				reject(idx, err)
+			}
+			continue
+		}
+
+		key, err := o.key(obj.prefix)
+		if err != nil {
+			return fmt.Errorf("creating object key failed: %w", err)
+		}
+		if err := o.storage.upload(ctx, key, encoded); err != nil {
+			o.Log.Errorf("Uploading object %q failed: %v", key, err)
+			if werr.Err == nil {
+				werr.Err = fmt.Errorf("uploading object %q failed: %w", key, err)
+			}
+			continue
+		}
+		o.Log.Debugf("Uploaded %d metrics to object %q", len(indices), key)
+		werr.MetricsAccept = append(werr.MetricsAccept, indices...)
+	}
+
+	if werr.Err == nil && len(werr.MetricsReject) == 0 {
+		return nil
+	}
+	if werr.Err == nil {
+		werr.Err = internal.ErrSerialization
+	}
+	return werr
+}
+
+// serialize returns the serialized metrics of the object together with the
+// indices of the successfully serialized metrics
+func (o *ObjectStorage) serialize(obj *object, reject func(int, error)) ([]byte, []int) {
+	if o.UseBatchFormat {
+		data, err := o.serializer.SerializeBatch(obj.metrics)
+		if err != nil {
+			o.Log.Errorf("Could not serialize metrics: %v", err)
+			for _, idx := range obj.indices {
+				reject(idx, err)
+			}
+			return nil, nil
+		}
+		return data, obj.indices
+	}
+
+	var data []byte
+	indices := make([]int, 0, len(obj.indices))
+	for i, m := range obj.metrics {
+		buf, err := o.serializer.Serialize(m)
+		if err != nil {
+			o.Log.Debugf("Could not serialize metric: %v", err)
+			reject(obj.indices[i], err)
+			continue
+		}
+		data = append(data, buf...)
+		indices = append(indices, obj.indices[i])
+	}
+	return data, indices
+}
+
+// key returns a unique object key below the given prefix. The key consists
+// of the upload time to ease sorting and a random ID to avoid collisions
+// between multiple instances.
+func (o *ObjectStorage) key(prefix string) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + id.String() + o.FileExtension + extensions[o.ContentEncoding]
+	if prefix == "" {
+		return name, nil
+	}
+	return prefix + "/" + name, nil
+}
+
+// templateMetric exposes the metric to the key template similar to
+// telegraf.TemplateMetric but with the time converted to UTC to get consistent
+// partitions independent of the local timezone
+type templateMetric struct {
+	telegraf.Metric
+}
+
+func (m *templateMetric) Tag(key string) string {
+	v, _ := m.GetTag(key)
+	return v
+}
+
+func (m *templateMetric) Field(key string) interface{} {
+	v, _ := m.GetField(key)
+	return v
+}
+
+func (m *templateMetric) Time() time.Time {
+	return m.Metric.Time().UTC()
+}
+
+func init() {
+	outputs.Add("object_storage", func() telegraf.Output {
+		return &ObjectStorage{
+			KeyTemplate:       `{{.Name}}/{{.Time.Format "2006/01/02/15"}}`,
+			ContentEncoding:   "identity",
+			CompressionLevel:  -1,
+			PartSize:          config.Size(minPartSize),
+			UploadConcurrency: 5,
+			Timeout:           config.Duration(5 * time.Minute),
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package object_storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newPlugin(t *testing.T) *ObjectStorage {
+	creator, found := outputs.Outputs["object_storage"]
+	require.True(t, found)
+	plugin := creator().(*ObjectStorage)
+	plugin.Log = testutil.Logger{}
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+	plugin.SetSerializer(serializer)
+
+	return plugin
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*ObjectStorage)
+		expected string
+	}{
+		{
+			name:     "missing bucket",
+			modify:   func(*ObjectStorage) {},
+			expected: "'bucket' setting required",
+		},
+		{
+			name:     "invalid backend",
+			modify:   func(o *ObjectStorage) { o.Bucket = "telegraf"; o.Backend = "foo" },
+			expected: "invalid 'backend' setting",
+		},
+		{
+			name:     "part size too small",
+			modify:   func(o *ObjectStorage) { o.Bucket = "telegraf"; o.PartSize = 1024 },
+			expected: "invalid 'part_size' setting",
+		},
+		{
+			name:     "invalid template",
+			modify:   func(o *ObjectStorage) { o.Bucket = "telegraf"; o.KeyTemplate = "{{.Name" },
+			expected: "parsing key template failed",
+		},
+		{
+			name:     "invalid encoding",
+			modify:   func(o *ObjectStorage) { o.Bucket = "telegraf"; o.ContentEncoding = "foo" },
+			expected: "creating encoder failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t)
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	root := t.TempDir()
+
+	plugin := newPlugin(t)
+	plugin.Backend = "file"
+	plugin.Bucket = root
+	plugin.KeyTemplate = `{{.Name}}/host={{.Tag "host"}}/{{.Time.Format "2006-01-02"}}`
+	plugin.FileExtension = ".influx"
+	plugin.ContentEncoding = "gzip"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 3.0}, time.Unix(86400, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 4.0}, time.Unix(10, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	expected := map[string]string{
+		"cpu/host=a/1970-01-01": "cpu,host=a value=1 0\ncpu,host=a value=4 10000000000\n",
+		"cpu/host=b/1970-01-01": "cpu,host=b value=2 0\n",
+		"cpu/host=a/1970-01-02": "cpu,host=a value=3 86400000000000\n",
+	}
+
+	decoder, err := internal.NewContentDecoder("gzip")
+	require.NoError(t, err)
+	actual := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		require.True(t, strings.HasSuffix(path, ".influx.gz"), path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoded, err := decoder.Decode(data)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		actual[filepath.ToSlash(rel)] = string(decoded)
+		return nil
+	}))
+	require.Equal(t, expected, actual)
+}
+
+func TestS3(t *testing.T) {
+	server := newFakeS3("telegraf")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	plugin := newPlugin(t)
+	plugin.Bucket = "telegraf"
+	plugin.KeyTemplate = `{{.Name}}`
+	plugin.EndpointURL = ts.URL
+	plugin.ForcePathStyle = true
+	plugin.Region = "us-east-1"
+	plugin.AccessKey = "access"
+	plugin.SecretKey = "secret"
+	plugin.PartSize = config.Size(minPartSize)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	// Create a metric exceeding the part size to force a multipart upload
+	large := strings.Repeat("x", minPartSize+1024)
+	input := []telegraf.Metric{
+		metric.New("small", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+		metric.New("large", map[string]string{}, map[string]interface{}{"value": large}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	keys := server.keys()
+	require.Len(t, keys, 2)
+	require.True(t, strings.HasPrefix(keys[0], "large/"), keys[0])
+	require.True(t, strings.HasPrefix(keys[1], "small/"), keys[1])
+	require.Equal(t, "small value=42i 0\n", string(server.object(keys[1])))
+	require.Equal(t, `large value="`+large+`" 0`+"\n", string(server.object(keys[0])))
+	require.Equal(t, 1, server.multipartUploads())
+}
+
+func TestS3UploadFailure(t *testing.T) {
+	server := newFakeS3("telegraf")
+	server.fail = func(key string) bool { return strings.HasPrefix(key, "fail/") }
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	plugin := newPlugin(t)
+	plugin.Bucket = "telegraf"
+	plugin.KeyTemplate = `{{.Name}}`
+	plugin.EndpointURL = ts.URL
+	plugin.ForcePathStyle = true
+	plugin.Region = "us-east-1"
+	plugin.AccessKey = "access"
+	plugin.SecretKey = "secret"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("ok", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("fail", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("ok", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+
+	// The metrics of the failed object must be kept for retrying while the
+	// others must be accepted
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
+
+	keys := server.keys()
+	require.Len(t, keys, 1)
+	require.Equal(t, "ok value=1i 0\nok value=3i 0\n", string(server.object(keys[0])))
+}
+
+// fakeS3 is a minimal stand-in for an S3-compatible service supporting
+// single and multipart uploads with path-style addressing
+type fakeS3 struct {
+	bucket  string
+	fail    func(key string) bool
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	// multipart counts the completed multipart uploads
+	multipart int
+
+	sync.Mutex
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, found := strings.CutPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if s.fail != nil && s.fail(key) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	query := r.URL.Query()
+	var response string
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = make(map[int][]byte)
+		response = fmt.Sprintf(
+			"<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>",
+			s.bucket, key, id,
+		)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, found := s.uploads[query.Get("uploadId")]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n, err := strconv.Atoi(query.Get("partNumber"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, n))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, found := s.uploads[query.Get("uploadId")]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var buf bytes.Buffer
+		for _, n := range numbers {
+			buf.Write(parts[n])
+		}
+		s.objects[key] = buf.Bytes()
+		s.multipart++
+		delete(s.uploads, query.Get("uploadId"))
+		response = fmt.Sprintf(
+			"<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>\"complete\"</ETag></CompleteMultipartUploadResult>",
+			s.bucket, key,
+		)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+		w.Header().Set("ETag", `"object"`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if response == "" {
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	if _, err := w.Write([]byte(response)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *fakeS3) keys() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (s *fakeS3) object(key string) []byte {
+	s.Lock()
+	defer s.Unlock()
+	return s.objects[key]
+}
+
+func (s *fakeS3) multipartUploads() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.multipart
+}
@@ -0,0 +1,85 @@
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// storage is the backend the objects are uploaded to
+type storage interface {
+	upload(ctx context.Context, key string, data []byte) error
+}
+
+// s3Storage uploads objects to S3-compatible services. Objects larger than
+// the part size are uploaded using multipart uploads.
+type s3Storage struct {
+	bucket   string
+	uploader *manager.Uploader
+}
+
+func (o *ObjectStorage) newS3Storage() (*s3Storage, error) {
+	cfg, err := o.CredentialConfig.Credentials()
+	if err != nil {
+		return nil, fmt.Errorf("getting credentials failed: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(options *s3.Options) {
+		if o.EndpointURL != "" {
+			options.BaseEndpoint = aws.String(o.EndpointURL)
+		}
+		options.UsePathStyle = o.ForcePathStyle
+	})
+
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = int64(o.PartSize)
+		u.Concurrency = o.UploadConcurrency
+	})
+
+	return &s3Storage{bucket: o.Bucket, uploader: uploader}, nil
+}
+
+func (s *s3Storage) upload(ctx context.Context, key string, data []byte) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// fileStorage stores objects as files below the root directory, mostly
+// useful for testing or for sharing the data via a network filesystem
+type fileStorage struct {
+	root string
+}
+
+func (s *fileStorage) upload(_ context.Context, key string, data []byte) error {
+	fn := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(fn), 0750); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first to make sure only complete objects
+	// become visible
+	f, err := os.CreateTemp(filepath.Dir(fn), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fn)
+}
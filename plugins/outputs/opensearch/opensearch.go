@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/choice"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -221,7 +223,11 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
 	defer cancel()
 
-	for _, metric := range metrics {
+	// Collect the outcome of each metric as reported by the bulk indexers
+	var mu sync.Mutex
+	werr := &internal.PartialWriteError{}
+
+	for i, metric := range metrics {
 		var name = metric.Name()
 
 		// index name has to be re-evaluated each time for telegraf
@@ -263,11 +269,29 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 		}
 
 		bulkIndxrItem := opensearchutil.BulkIndexerItem{
-			Action:    "index",
-			Index:     indexName,
-			Body:      strings.NewReader(string(body)),
-			OnSuccess: o.onSucc,
-			OnFailure: o.onFail,
+			Action: "index",
+			Index:  indexName,
+			Body:   strings.NewReader(string(body)),
+			OnSuccess: func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem) {
+				o.onSucc(ctx, item, res)
+				mu.Lock()
+				werr.MetricsAccept = append(werr.MetricsAccept, i)
+				mu.Unlock()
+			},
+			OnFailure: func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem, err error) {
+				o.onFail(ctx, item, res, err)
+				mu.Lock()
+				defer mu.Unlock()
+
+				// Keep metrics failing due to request errors, overload or
+				// server errors for retrying
+				if err != nil || res.Status == http.StatusTooManyRequests || res.Status >= 500 {
+					return
+				}
+				werr.MetricsReject = append(werr.MetricsReject, i)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors,
+					fmt.Errorf("status %d: %s: %s", res.Status, res.Error.Type, res.Error.Reason))
+			},
 		}
 		if o.ForceDocumentID {
 			bulkIndxrItem.DocumentID = getPointID(metric)
@@ -294,18 +318,33 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
+	// Metrics not reported as accepted or rejected, e.g. because they could
+	// not be added or the request failed, are kept for the next write
+	var closeErr error
+	var failed uint64
 	for _, bulkIndxr := range indexers {
 		if err := bulkIndxr.Close(ctx); err != nil {
-			return fmt.Errorf("error sending bulk request to OpenSearch: %w", err)
+			if closeErr == nil {
+				closeErr = fmt.Errorf("error sending bulk request to OpenSearch: %w", err)
+			}
+			continue
 		}
 
 		// Report the indexer statistics
 		stats := bulkIndxr.Stats()
-		if stats.NumFailed > 0 {
-			return fmt.Errorf("failed to index [%d] documents", stats.NumFailed)
-		}
+		failed += stats.NumFailed
+		o.Log.Debugf("Successfully indexed [%d] documents", stats.NumAdded-stats.NumFailed)
+	}
 
-		o.Log.Debugf("Successfully indexed [%d] documents", stats.NumAdded)
+	mu.Lock()
+	defer mu.Unlock()
+	if closeErr != nil {
+		werr.Err = closeErr
+		return werr
+	}
+	if failed > 0 {
+		werr.Err = fmt.Errorf("failed to index [%d] documents", failed)
+		return werr
 	}
 
 	return nil
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -196,6 +198,46 @@ func TestRequestHeaderWhenGzipIsEnabled(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPartialWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			if _, err := w.Write([]byte(`{"version": {"number": "7.8"}}`)); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"took": 1, "errors": true, "items": [
+			{"index": {"_index": "test", "status": 201}},
+			{"index": {"_index": "test", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}},
+			{"index": {"_index": "test", "status": 429, "error": {"type": "rejected_execution_exception", "reason": "queue full"}}}
+		]}`)); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	e := &Opensearch{
+		URLs:         []string{"http://" + ts.Listener.Addr().String()},
+		IndexName:    "test",
+		TemplateName: "telegraf",
+		Timeout:      config.Duration(time.Second * 5),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1), testutil.TestMetric(2), testutil.TestMetric(3)}
+
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, e.Write(metrics), &werr)
+	require.ErrorContains(t, werr, "failed to index [2] documents")
+	require.Equal(t, []int{0}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+	require.ErrorContains(t, werr.MetricsRejectErrors[0], "status 400: mapper_parsing_exception: failed to parse")
+}
+
 func TestRequestHeaderWhenGzipIsDisabled(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/apache/arrow/go/v18/arrow"
@@ -14,6 +17,7 @@ import (
 	"github.com/apache/arrow/go/v18/arrow/memory"
 	"github.com/apache/arrow/go/v18/parquet"
 	"github.com/apache/arrow/go/v18/parquet/pqarrow"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -24,20 +28,38 @@ var sampleConfig string
 
 var defaultTimestampFieldName = "timestamp"
 
+// hiveDefaultPartition is the partition value used by Hive-compatible tools
+// for rows where the partitioning column is missing
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
 type metricGroup struct {
+	name     string
+	dir      string
 	filename string
+	created  time.Time
+	written  time.Time
+	expires  time.Time
+	rows     int64
 	builder  *array.RecordBuilder
 	schema   *arrow.Schema
+	file     *os.File
 	writer   *pqarrow.FileWriter
 }
 
 type Parquet struct {
 	Directory          string          `toml:"directory"`
 	RotationInterval   config.Duration `toml:"rotation_interval"`
+	RotationMaxSize    config.Size     `toml:"rotation_max_size"`
+	IdleTimeout        config.Duration `toml:"idle_timeout"`
 	TimestampFieldName string          `toml:"timestamp_field_name"`
+	SchemaEvolution    bool            `toml:"schema_evolution"`
+	TypeCoercion       string          `toml:"type_coercion"`
+	PartitionByTime    string          `toml:"partition_by_time"`
+	PartitionByTags    []string        `toml:"partition_by_tags"`
 	Log                telegraf.Logger `toml:"-"`
 
-	metricGroups map[string]*metricGroup
+	timePartitions []string
+	metricGroups   map[string]*metricGroup
 }
 
 func (*Parquet) SampleConfig() string {
@@ -58,6 +80,32 @@ func (p *Parquet) Init() error {
 		return fmt.Errorf("provided directory %q is not a directory", p.Directory)
 	}
 
+	switch p.TypeCoercion {
+	case "":
+		p.TypeCoercion = "none"
+	case "none", "numeric", "string":
+	default:
+		return fmt.Errorf("invalid 'type_coercion' setting %q", p.TypeCoercion)
+	}
+
+	switch p.PartitionByTime {
+	case "":
+	case "year":
+		p.timePartitions = []string{"year"}
+	case "month":
+		p.timePartitions = []string{"year", "month"}
+	case "day":
+		p.timePartitions = []string{"year", "month", "day"}
+	case "hour":
+		p.timePartitions = []string{"year", "month", "day", "hour"}
+	default:
+		return fmt.Errorf("invalid 'partition_by_time' setting %q", p.PartitionByTime)
+	}
+
+	if p.RotationMaxSize < 0 {
+		return errors.New("'rotation_max_size' must not be negative")
+	}
+
 	p.metricGroups = make(map[string]*metricGroup)
 
 	return nil
@@ -70,9 +118,9 @@ func (*Parquet) Connect() error {
 func (p *Parquet) Close() error {
 	var errorOccurred bool
 
-	for _, metrics := range p.metricGroups {
-		if err := metrics.writer.Close(); err != nil {
-			p.Log.Errorf("failed to close file %q: %v", metrics.filename, err)
+	for _, group := range p.metricGroups {
+		if err := group.writer.Close(); err != nil {
+			p.Log.Errorf("failed to close file %q: %v", group.filename, err)
 			errorOccurred = true
 		}
 	}
@@ -86,69 +134,194 @@ func (p *Parquet) Close() error {
 
 func (p *Parquet) Write(metrics []telegraf.Metric) error {
 	groupedMetrics := make(map[string][]telegraf.Metric)
+	groupNames := make(map[string]string)
+	groupDirs := make(map[string]string)
 	for _, metric := range metrics {
-		groupedMetrics[metric.Name()] = append(groupedMetrics[metric.Name()], metric)
+		dir := p.partitionDir(metric)
+		key := filepath.Join(dir, metric.Name())
+		groupedMetrics[key] = append(groupedMetrics[key], metric)
+		groupNames[key] = metric.Name()
+		groupDirs[key] = dir
 	}
 
 	now := time.Now()
-	for name, metrics := range groupedMetrics {
-		if _, ok := p.metricGroups[name]; !ok {
-			filename := fmt.Sprintf("%s/%s-%s-%s.parquet", p.Directory, name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
-			schema, err := p.createSchema(metrics)
-			if err != nil {
-				return fmt.Errorf("failed to create schema for file %q: %w", name, err)
+	for key, metrics := range groupedMetrics {
+		schema, err := p.createSchema(metrics)
+		if err != nil {
+			return fmt.Errorf("failed to create schema for %q: %w", key, err)
+		}
+
+		group, found := p.metricGroups[key]
+		if !found {
+			group = &metricGroup{
+				name:    groupNames[key],
+				dir:     groupDirs[key],
+				expires: p.partitionEnd(metrics[0]),
 			}
-			writer, err := p.createWriter(name, filename, schema)
-			if err != nil {
-				return fmt.Errorf("failed to create writer for file %q: %w", name, err)
+			if err := p.openGroup(group, schema); err != nil {
+				return err
 			}
-			p.metricGroups[name] = &metricGroup{
-				builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
-				filename: filename,
-				schema:   schema,
-				writer:   writer,
+			p.metricGroups[key] = group
+		} else if p.SchemaEvolution {
+			if merged, changed := p.mergeSchema(group.schema, schema); changed {
+				p.Log.Debugf("Schema of %q changed, rolling over to a new file", key)
+				if err := p.rollover(group, merged); err != nil {
+					return err
+				}
 			}
 		}
 
-		if p.RotationInterval != 0 {
-			if err := p.rotateIfNeeded(name); err != nil {
-				return fmt.Errorf("failed to rotate file %q: %w", p.metricGroups[name].filename, err)
+		if p.rotationNeeded(group) {
+			if err := p.rollover(group, group.schema); err != nil {
+				return err
 			}
 		}
 
-		record, err := p.createRecord(metrics, p.metricGroups[name].builder, p.metricGroups[name].schema)
+		record, err := p.createRecord(metrics, group.builder, group.schema)
 		if err != nil {
-			return fmt.Errorf("failed to create record for file %q: %w", p.metricGroups[name].filename, err)
+			return fmt.Errorf("failed to create record for file %q: %w", group.filename, err)
 		}
-		if err = p.metricGroups[name].writer.WriteBuffered(record); err != nil {
-			return fmt.Errorf("failed to write to file %q: %w", p.metricGroups[name].filename, err)
+		if err = group.writer.WriteBuffered(record); err != nil {
+			return fmt.Errorf("failed to write to file %q: %w", group.filename, err)
 		}
+		group.rows += record.NumRows()
+		group.written = now
 		record.Release()
 	}
 
+	return p.closeInactive(now)
+}
+
+// closeInactive closes and removes the groups not written in the current
+// write whose time partition ended or which were idle for too long
+func (p *Parquet) closeInactive(now time.Time) error {
+	var errorOccurred bool
+	for key, group := range p.metricGroups {
+		if group.written.Equal(now) {
+			continue
+		}
+		ended := !group.expires.IsZero() && !now.Before(group.expires)
+		idle := p.IdleTimeout > 0 && now.Sub(group.written) >= time.Duration(p.IdleTimeout)
+		if !ended && !idle {
+			continue
+		}
+
+		p.Log.Debugf("Closing inactive file %q", group.filename)
+		if err := group.writer.Close(); err != nil {
+			p.Log.Errorf("failed to close file %q: %v", group.filename, err)
+			errorOccurred = true
+		}
+		group.builder.Release()
+		delete(p.metricGroups, key)
+	}
+
+	if errorOccurred {
+		return errors.New("failed closing one or more parquet files")
+	}
 	return nil
 }
 
-func (p *Parquet) rotateIfNeeded(name string) error {
-	fileInfo, err := os.Stat(p.metricGroups[name].filename)
-	if err != nil {
-		return fmt.Errorf("failed to stat file %q: %w", p.metricGroups[name].filename, err)
+// partitionDir returns the directory relative to the output directory in
+// which the given metric should be stored using a Hive-style layout.
+func (p *Parquet) partitionDir(metric telegraf.Metric) string {
+	if len(p.timePartitions) == 0 && len(p.PartitionByTags) == 0 {
+		return ""
+	}
+
+	ts := metric.Time().UTC()
+	parts := make([]string, 0, 1+len(p.timePartitions)+len(p.PartitionByTags))
+	parts = append(parts, escapePartitionValue(metric.Name()))
+	for _, part := range p.timePartitions {
+		switch part {
+		case "year":
+			parts = append(parts, fmt.Sprintf("year=%04d", ts.Year()))
+		case "month":
+			parts = append(parts, fmt.Sprintf("month=%02d", ts.Month()))
+		case "day":
+			parts = append(parts, fmt.Sprintf("day=%02d", ts.Day()))
+		case "hour":
+			parts = append(parts, fmt.Sprintf("hour=%02d", ts.Hour()))
+		}
+	}
+	for _, key := range p.PartitionByTags {
+		value, found := metric.GetTag(key)
+		if !found || value == "" {
+			value = hiveDefaultPartition
+		}
+		parts = append(parts, escapePartitionValue(key)+"="+escapePartitionValue(value))
 	}
 
-	expireTime := fileInfo.ModTime().Add(time.Duration(p.RotationInterval))
-	if time.Now().Before(expireTime) {
-		return nil
+	return filepath.Join(parts...)
+}
+
+// partitionEnd returns the end of the time partition of the given metric or
+// a zero time if no time partitioning is used
+func (p *Parquet) partitionEnd(metric telegraf.Metric) time.Time {
+	ts := metric.Time().UTC()
+	switch p.PartitionByTime {
+	case "year":
+		return time.Date(ts.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	case "month":
+		return time.Date(ts.Year(), ts.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	case "day":
+		return time.Date(ts.Year(), ts.Month(), ts.Day()+1, 0, 0, 0, 0, time.UTC)
+	case "hour":
+		return ts.Truncate(time.Hour).Add(time.Hour)
+	}
+	return time.Time{}
+}
+
+func (p *Parquet) rotationNeeded(group *metricGroup) bool {
+	if p.RotationInterval > 0 && time.Since(group.created) >= time.Duration(p.RotationInterval) {
+		return true
+	}
+
+	if p.RotationMaxSize > 0 && group.rows > 0 {
+		var size int64
+		if stat, err := group.file.Stat(); err == nil {
+			size = stat.Size()
+		}
+		size += group.writer.RowGroupTotalCompressedBytes()
+		return size >= int64(p.RotationMaxSize)
+	}
+
+	return false
+}
+
+func (p *Parquet) rollover(group *metricGroup, schema *arrow.Schema) error {
+	if err := group.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close file for rotation %q: %w", group.filename, err)
 	}
+	group.builder.Release()
+
+	return p.openGroup(group, schema)
+}
 
-	if err := p.metricGroups[name].writer.Close(); err != nil {
-		return fmt.Errorf("failed to close file for rotation %q: %w", p.metricGroups[name].filename, err)
+func (p *Parquet) openGroup(group *metricGroup, schema *arrow.Schema) error {
+	dir := filepath.Join(p.Directory, group.dir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", dir, err)
 	}
 
-	writer, err := p.createWriter(name, p.metricGroups[name].filename, p.metricGroups[name].schema)
+	now := time.Now()
+	file, filename, err := createUniqueFile(dir, group.name, now)
 	if err != nil {
-		return fmt.Errorf("failed to create new writer for file %q: %w", p.metricGroups[name].filename, err)
+		return err
 	}
-	p.metricGroups[name].writer = writer
+
+	writer, err := pqarrow.NewFileWriter(schema, file, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to create parquet writer for file %q: %w", filename, err)
+	}
+
+	group.filename = filename
+	group.created = now
+	group.rows = 0
+	group.schema = schema
+	group.file = file
+	group.writer = writer
+	group.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
 
 	return nil
 }
@@ -171,65 +344,13 @@ func (p *Parquet) createRecord(metrics []telegraf.Metric, builder *array.RecordB
 
 			// if neither field nor tag exists, append a null value
 			if !ok {
-				switch col.Type {
-				case arrow.PrimitiveTypes.Int8:
-					builder.Field(index).(*array.Int8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int16:
-					builder.Field(index).(*array.Int16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int32:
-					builder.Field(index).(*array.Int32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int64:
-					builder.Field(index).(*array.Int64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint8:
-					builder.Field(index).(*array.Uint8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint16:
-					builder.Field(index).(*array.Uint16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint32:
-					builder.Field(index).(*array.Uint32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint64:
-					builder.Field(index).(*array.Uint64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float32:
-					builder.Field(index).(*array.Float32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float64:
-					builder.Field(index).(*array.Float64Builder).AppendNull()
-				case arrow.BinaryTypes.String:
-					builder.Field(index).(*array.StringBuilder).AppendNull()
-				case arrow.FixedWidthTypes.Boolean:
-					builder.Field(index).(*array.BooleanBuilder).AppendNull()
-				default:
-					return nil, fmt.Errorf("unsupported type: %T", value)
-				}
-
+				builder.Field(index).AppendNull()
 				continue
 			}
 
-			switch col.Type {
-			case arrow.PrimitiveTypes.Int8:
-				builder.Field(index).(*array.Int8Builder).Append(value.(int8))
-			case arrow.PrimitiveTypes.Int16:
-				builder.Field(index).(*array.Int16Builder).Append(value.(int16))
-			case arrow.PrimitiveTypes.Int32:
-				builder.Field(index).(*array.Int32Builder).Append(value.(int32))
-			case arrow.PrimitiveTypes.Int64:
-				builder.Field(index).(*array.Int64Builder).Append(value.(int64))
-			case arrow.PrimitiveTypes.Uint8:
-				builder.Field(index).(*array.Uint8Builder).Append(value.(uint8))
-			case arrow.PrimitiveTypes.Uint16:
-				builder.Field(index).(*array.Uint16Builder).Append(value.(uint16))
-			case arrow.PrimitiveTypes.Uint32:
-				builder.Field(index).(*array.Uint32Builder).Append(value.(uint32))
-			case arrow.PrimitiveTypes.Uint64:
-				builder.Field(index).(*array.Uint64Builder).Append(value.(uint64))
-			case arrow.PrimitiveTypes.Float32:
-				builder.Field(index).(*array.Float32Builder).Append(value.(float32))
-			case arrow.PrimitiveTypes.Float64:
-				builder.Field(index).(*array.Float64Builder).Append(value.(float64))
-			case arrow.BinaryTypes.String:
-				builder.Field(index).(*array.StringBuilder).Append(value.(string))
-			case arrow.FixedWidthTypes.Boolean:
-				builder.Field(index).(*array.BooleanBuilder).Append(value.(bool))
-			default:
-				return nil, fmt.Errorf("unsupported type: %T", value)
+			if err := p.appendValue(builder.Field(index), col.Type, value); err != nil {
+				p.Log.Debugf("Omitting value of column %q in %q: %v", col.Name, m.Name(), err)
+				builder.Field(index).AppendNull()
 			}
 		}
 	}
@@ -242,13 +363,17 @@ func (p *Parquet) createSchema(metrics []telegraf.Metric) (*arrow.Schema, error)
 	rawFields := make(map[string]arrow.DataType, 0)
 	for _, metric := range metrics {
 		for _, field := range metric.FieldList() {
-			if _, ok := rawFields[field.Key]; !ok {
-				arrowType, err := goToArrowType(field.Value)
-				if err != nil {
-					return nil, fmt.Errorf("error converting '%s=%s' field to arrow type: %w", field.Key, field.Value, err)
+			arrowType, err := goToArrowType(field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("error converting '%s=%v' field to arrow type: %w", field.Key, field.Value, err)
+			}
+			if existing, ok := rawFields[field.Key]; ok {
+				if dt, ok := p.commonType(existing, arrowType); ok {
+					rawFields[field.Key] = dt
 				}
-				rawFields[field.Key] = arrowType
+				continue
 			}
+			rawFields[field.Key] = arrowType
 		}
 		for _, tag := range metric.TagList() {
 			if _, ok := rawFields[tag.Key]; !ok {
@@ -257,11 +382,20 @@ func (p *Parquet) createSchema(metrics []telegraf.Metric) (*arrow.Schema, error)
 		}
 	}
 
-	fields := make([]arrow.Field, 0)
-	for key, value := range rawFields {
+	// Sort the columns to get a deterministic schema independent of the
+	// order of fields within the metrics
+	keys := make([]string, 0, len(rawFields))
+	for key := range rawFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]arrow.Field, 0, len(keys)+1)
+	for _, key := range keys {
 		fields = append(fields, arrow.Field{
-			Name: key,
-			Type: value,
+			Name:     key,
+			Type:     rawFields[key],
+			Nullable: true,
 		})
 	}
 
@@ -275,25 +409,36 @@ func (p *Parquet) createSchema(metrics []telegraf.Metric) (*arrow.Schema, error)
 	return arrow.NewSchema(fields, nil), nil
 }
 
-func (p *Parquet) createWriter(name, filename string, schema *arrow.Schema) (*pqarrow.FileWriter, error) {
-	if _, err := os.Stat(filename); err == nil {
-		now := time.Now()
-		rotatedFilename := fmt.Sprintf("%s/%s-%s-%s.parquet", p.Directory, name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
-		if err := os.Rename(filename, rotatedFilename); err != nil {
-			return nil, fmt.Errorf("failed to rename file %q: %w", filename, err)
+func createUniqueFile(dir, name string, now time.Time) (*os.File, string, error) {
+	base := fmt.Sprintf("%s-%s-%s", name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
+	filename := filepath.Join(dir, base+".parquet")
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0640)
+		if err == nil {
+			return file, filename, nil
 		}
+		if !os.IsExist(err) {
+			return nil, "", fmt.Errorf("failed to create file %q: %w", filename, err)
+		}
+		filename = filepath.Join(dir, fmt.Sprintf("%s-%d.parquet", base, i))
 	}
-	file, err := os.Create(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file %q: %w", filename, err)
-	}
+}
 
-	writer, err := pqarrow.NewFileWriter(schema, file, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create parquet writer for file %q: %w", filename, err)
+// escapePartitionValue escapes characters not allowed in Hive partition
+// path segments using the percent-encoding used by Hive.
+func escapePartitionValue(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+			builder.WriteByte(b)
+		case b == '-' || b == '_' || b == '.' || b == ' ':
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
 	}
-
-	return writer, nil
+	return builder.String()
 }
 
 func goToArrowType(value interface{}) (arrow.DataType, error) {
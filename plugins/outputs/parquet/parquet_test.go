@@ -244,3 +244,327 @@ func TestTimestampDifferentName(t *testing.T) {
 	require.Equal(t, 1, int(metadata.NumRows))
 	require.Equal(t, 2, metadata.Schema.NumColumns())
 }
+
+func TestSchemaEvolution(t *testing.T) {
+	first := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 1.0,
+			},
+			time.Now(),
+		),
+	}
+	second := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 2.0,
+				"new":   int64(42),
+			},
+			time.Now(),
+		),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		SchemaEvolution:    true,
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(first))
+	require.NoError(t, plugin.Write(first))
+	require.NoError(t, plugin.Write(second))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	columns := make([]int, 0, len(files))
+	for _, f := range files {
+		reader, err := file.OpenParquetFile(filepath.Join(testDir, f.Name()), false)
+		require.NoError(t, err)
+		columns = append(columns, reader.MetaData().Schema.NumColumns())
+		reader.Close()
+	}
+	require.ElementsMatch(t, []int{2, 3}, columns)
+}
+
+func TestSchemaEvolutionTypeConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		coercion string
+		second   interface{}
+		numFiles int
+	}{
+		{
+			name:     "no coercion",
+			coercion: "none",
+			second:   "foo",
+			numFiles: 2,
+		},
+		{
+			name:     "numeric coercion into existing column",
+			coercion: "numeric",
+			second:   int64(1),
+			numFiles: 1,
+		},
+		{
+			name:     "numeric coercion widening",
+			coercion: "numeric",
+			second:   "foo",
+			numFiles: 2,
+		},
+		{
+			name:     "string coercion",
+			coercion: "string",
+			second:   "foo",
+			numFiles: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := testutil.MustMetric(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 1.5},
+				time.Now(),
+			)
+			second := testutil.MustMetric(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": tt.second},
+				time.Now(),
+			)
+
+			testDir := t.TempDir()
+			plugin := &Parquet{
+				Directory:          testDir,
+				SchemaEvolution:    true,
+				TypeCoercion:       tt.coercion,
+				TimestampFieldName: defaultTimestampFieldName,
+				Log:                testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+			require.NoError(t, plugin.Connect())
+			require.NoError(t, plugin.Write([]telegraf.Metric{first}))
+			require.NoError(t, plugin.Write([]telegraf.Metric{second}))
+			require.NoError(t, plugin.Close())
+
+			files, err := os.ReadDir(testDir)
+			require.NoError(t, err)
+			require.Len(t, files, tt.numFiles)
+		})
+	}
+}
+
+func TestTypeConflictWithoutEvolution(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{"value": 1.5},
+			time.Now(),
+		),
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{"value": "foo"},
+			time.Now(),
+		),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	reader, err := file.OpenParquetFile(filepath.Join(testDir, files[0].Name()), false)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	metadata := reader.MetaData()
+	require.Equal(t, 2, int(metadata.NumRows))
+	require.Equal(t, 2, metadata.Schema.NumColumns())
+}
+
+func TestPartitioning(t *testing.T) {
+	ts := time.Date(2024, time.May, 13, 14, 0, 0, 0, time.UTC)
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"value": 1.0},
+			ts,
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server/02"},
+			map[string]interface{}{"value": 2.0},
+			ts,
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 3.0},
+			ts.AddDate(0, 1, 0),
+		),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		PartitionByTime:    "month",
+		PartitionByTags:    []string{"host"},
+		TimestampFieldName: defaultTimestampFieldName,
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	expected := []string{
+		filepath.Join("cpu", "year=2024", "month=05", "host=server01"),
+		filepath.Join("cpu", "year=2024", "month=05", "host=server%2F02"),
+		filepath.Join("cpu", "year=2024", "month=06", "host="+hiveDefaultPartition),
+	}
+	for _, dir := range expected {
+		files, err := os.ReadDir(filepath.Join(testDir, dir))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	}
+}
+
+func TestRotationSize(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 1.0,
+			},
+			time.Now(),
+		),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		RotationMaxSize:    config.Size(1),
+		TimestampFieldName: defaultTimestampFieldName,
+	}
+
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+}
+
+func TestInvalidSettings(t *testing.T) {
+	plugin := &Parquet{
+		Directory:    t.TempDir(),
+		TypeCoercion: "foo",
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid 'type_coercion' setting")
+
+	plugin = &Parquet{
+		Directory:       t.TempDir(),
+		PartitionByTime: "minute",
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid 'partition_by_time' setting")
+}
+
+func TestCloseInactivePartitions(t *testing.T) {
+	old := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": 1.0},
+		time.Date(2024, time.May, 13, 14, 0, 0, 0, time.UTC),
+	)
+	current := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": 2.0},
+		time.Now(),
+	)
+
+	plugin := &Parquet{
+		Directory:          t.TempDir(),
+		PartitionByTime:    "month",
+		PartitionByTags:    []string{"host"},
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	// Groups written in the current call must stay open even if their
+	// partition ended
+	require.NoError(t, plugin.Write([]telegraf.Metric{old}))
+	require.Len(t, plugin.metricGroups, 1)
+
+	// The ended partition must be closed once not written anymore
+	require.NoError(t, plugin.Write([]telegraf.Metric{current}))
+	require.Len(t, plugin.metricGroups, 1)
+	for _, group := range plugin.metricGroups {
+		require.True(t, group.expires.After(current.Time()))
+	}
+	require.NoError(t, plugin.Close())
+}
+
+func TestCloseIdleGroups(t *testing.T) {
+	plugin := &Parquet{
+		Directory:          t.TempDir(),
+		PartitionByTags:    []string{"host"},
+		IdleTimeout:        config.Duration(time.Nanosecond),
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	for _, host := range []string{"server01", "server02"} {
+		m := testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": 1.0},
+			time.Now(),
+		)
+		require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+		require.Len(t, plugin.metricGroups, 1)
+	}
+	require.NoError(t, plugin.Close())
+
+	// The closed file must be complete and readable
+	dir := filepath.Join(plugin.Directory, "cpu", "host=server01")
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	reader, err := file.OpenParquetFile(filepath.Join(dir, files[0].Name()), false)
+	require.NoError(t, err)
+	defer reader.Close()
+	require.Equal(t, 1, int(reader.MetaData().NumRows))
+}
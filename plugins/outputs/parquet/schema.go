@@ -0,0 +1,224 @@
+package parquet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apache/arrow/go/v18/arrow"
+	"github.com/apache/arrow/go/v18/arrow/array"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// integer type widths used to determine the wider of two integer types
+var integerWidth = map[arrow.Type]int{
+	arrow.INT8:   8,
+	arrow.INT16:  16,
+	arrow.INT32:  32,
+	arrow.INT64:  64,
+	arrow.UINT8:  8,
+	arrow.UINT16: 16,
+	arrow.UINT32: 32,
+	arrow.UINT64: 64,
+}
+
+func isSigned(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64:
+		return true
+	}
+	return false
+}
+
+func isUnsigned(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return true
+	}
+	return false
+}
+
+func isFloat(dt arrow.DataType) bool {
+	return dt.ID() == arrow.FLOAT32 || dt.ID() == arrow.FLOAT64
+}
+
+func isNumeric(dt arrow.DataType) bool {
+	return isSigned(dt) || isUnsigned(dt) || isFloat(dt)
+}
+
+// commonType returns a type able to hold values of both given types according
+// to the configured type coercion. The second return value is false if no
+// such type exists.
+func (p *Parquet) commonType(a, b arrow.DataType) (arrow.DataType, bool) {
+	if arrow.TypeEqual(a, b) {
+		return a, true
+	}
+
+	switch p.TypeCoercion {
+	case "numeric", "string":
+		if isNumeric(a) && isNumeric(b) {
+			switch {
+			case isFloat(a) || isFloat(b):
+				return arrow.PrimitiveTypes.Float64, true
+			case isSigned(a) != isSigned(b):
+				return arrow.PrimitiveTypes.Int64, true
+			case integerWidth[a.ID()] >= integerWidth[b.ID()]:
+				return a, true
+			default:
+				return b, true
+			}
+		}
+		if p.TypeCoercion == "string" {
+			return arrow.BinaryTypes.String, true
+		}
+	}
+
+	return nil, false
+}
+
+// mergeSchema merges the schema of a new batch of metrics into the existing
+// schema of a file. Columns missing in the existing schema are added and the
+// type of conflicting columns is replaced by a common type if type coercion
+// allows for it or by the type of the new batch otherwise. The second return
+// value indicates if the resulting schema differs from the existing one.
+func (p *Parquet) mergeSchema(existing, batch *arrow.Schema) (*arrow.Schema, bool) {
+	var changed bool
+
+	fields := make([]arrow.Field, 0, existing.NumFields()+batch.NumFields())
+	for _, field := range existing.Fields() {
+		if p.TimestampFieldName != "" && field.Name == p.TimestampFieldName {
+			continue
+		}
+		if indices := batch.FieldIndices(field.Name); len(indices) > 0 {
+			dt := batch.Field(indices[0]).Type
+			if common, ok := p.commonType(field.Type, dt); ok {
+				dt = common
+			}
+			if !arrow.TypeEqual(field.Type, dt) {
+				field.Type = dt
+				changed = true
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	added := make([]arrow.Field, 0)
+	for _, field := range batch.Fields() {
+		if existing.HasField(field.Name) {
+			continue
+		}
+		added = append(added, field)
+	}
+	if len(added) > 0 {
+		sort.Slice(added, func(i, j int) bool { return added[i].Name < added[j].Name })
+		fields = append(fields, added...)
+		changed = true
+	}
+
+	if p.TimestampFieldName != "" {
+		fields = append(fields, arrow.Field{
+			Name: p.TimestampFieldName,
+			Type: arrow.PrimitiveTypes.Int64,
+		})
+	}
+
+	return arrow.NewSchema(fields, nil), changed
+}
+
+// appendValue adds the given value to the column builder applying the
+// configured type coercion if the value does not match the column type.
+func (p *Parquet) appendValue(builder array.Builder, dt arrow.DataType, value interface{}) error {
+	vt, err := goToArrowType(value)
+	if err != nil {
+		return err
+	}
+	if !arrow.TypeEqual(vt, dt) {
+		switch {
+		case p.TypeCoercion == "none":
+			return fmt.Errorf("type %v does not match column type %v", vt, dt)
+		case isNumeric(vt) && isNumeric(dt):
+		case p.TypeCoercion == "string" && dt.ID() == arrow.STRING:
+		default:
+			return fmt.Errorf("cannot coerce type %v to column type %v", vt, dt)
+		}
+	}
+
+	switch b := builder.(type) {
+	case *array.Int8Builder:
+		v, err := internal.ToInt8(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Int16Builder:
+		v, err := internal.ToInt16(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Int32Builder:
+		v, err := internal.ToInt32(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Int64Builder:
+		v, err := internal.ToInt64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint8Builder:
+		v, err := internal.ToUint8(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint16Builder:
+		v, err := internal.ToUint16(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint32Builder:
+		v, err := internal.ToUint32(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint64Builder:
+		v, err := internal.ToUint64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Float32Builder:
+		v, err := internal.ToFloat32(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Float64Builder:
+		v, err := internal.ToFloat64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.StringBuilder:
+		v, err := internal.ToString(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.BooleanBuilder:
+		v, err := internal.ToBool(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	default:
+		return fmt.Errorf("unsupported column type: %v", dt)
+	}
+
+	return nil
+}
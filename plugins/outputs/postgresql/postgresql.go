@@ -201,6 +201,11 @@ func (p *Postgresql) registerUint8(_ context.Context, conn *pgx.Conn) error {
 	return nil
 }
 
+// Probe checks if the database server is reachable
+func (p *Postgresql) Probe() error {
+	return p.db.Ping(p.dbContext)
+}
+
 // Close closes the connection(s) to the database.
 func (p *Postgresql) Close() error {
 	if p.writeChan != nil {
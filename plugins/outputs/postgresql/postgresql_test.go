@@ -293,6 +293,7 @@ func TestPostgresqlConnectIntegration(t *testing.T) {
 	p, err := newPostgresqlTest(t)
 	require.NoError(t, err)
 	require.NoError(t, p.Connect())
+	require.NoError(t, p.Probe())
 	require.EqualValues(t, 1, p.db.Stat().MaxConns())
 
 	p, err = newPostgresqlTest(t)
@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
 	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/outputs/prometheus_client/v1"
@@ -62,6 +63,8 @@ type PrometheusClient struct {
 	TypeMappings       serializers_prometheus.MetricTypes `toml:"metric_types"`
 	Log                telegraf.Logger                    `toml:"-"`
 
+	MetricRelabelConfigs []*relabel.Config `toml:"metric_relabel_configs"`
+
 	common_tls.ServerConfig
 
 	server    *http.Server
@@ -105,6 +108,10 @@ func (p *PrometheusClient) Init() error {
 		return err
 	}
 
+	if err := relabel.Init(p.MetricRelabelConfigs); err != nil {
+		return fmt.Errorf("invalid 'metric_relabel_configs': %w", err)
+	}
+
 	switch p.MetricVersion {
 	default:
 		fallthrough
@@ -284,7 +291,20 @@ func (p *PrometheusClient) Close() error {
 }
 
 func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
-	return p.collector.Add(metrics)
+	if len(p.MetricRelabelConfigs) == 0 {
+		return p.collector.Add(metrics)
+	}
+
+	// Relabel copies of the metrics as the originals might be shared with
+	// other outputs or be retried
+	relabeled := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		m = m.Copy()
+		if relabel.Metric(p.MetricRelabelConfigs, m) {
+			relabeled = append(relabeled, m)
+		}
+	}
+	return p.collector.Add(relabeled)
 }
 
 func init() {
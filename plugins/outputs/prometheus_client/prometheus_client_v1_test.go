@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
 	inputs "github.com/influxdata/telegraf/plugins/inputs/prometheus"
 	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
 	"github.com/influxdata/telegraf/testutil"
@@ -52,6 +53,59 @@ func TestMetricVersion1(t *testing.T) {
 # HELP cpu_time_idle Telegraf collected metric
 # TYPE cpu_time_idle untyped
 cpu_time_idle{host="example.org"} 42
+`),
+		},
+		{
+			name: "metric relabeling",
+			output: &PrometheusClient{
+				Listen:            ":0",
+				MetricVersion:     1,
+				CollectorsExclude: []string{"gocollector", "process"},
+				Path:              "/metrics",
+				Log:               logger,
+				MetricRelabelConfigs: []*relabel.Config{
+					{
+						Action:       "drop",
+						SourceLabels: []string{"__name__"},
+						Regex:        "mem",
+					},
+					{
+						SourceLabels: []string{"host"},
+						Regex:        "([^.]+)\\..*",
+						TargetLabel:  "short",
+					},
+					{
+						Action: "labeldrop",
+						Regex:  "host",
+					},
+				},
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{
+						"host": "example.org",
+					},
+					map[string]interface{}{
+						"time_idle": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"mem",
+					map[string]string{
+						"host": "example.org",
+					},
+					map[string]interface{}{
+						"used": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []byte(`
+# HELP cpu_time_idle Telegraf collected metric
+# TYPE cpu_time_idle untyped
+cpu_time_idle{short="example"} 42
 `),
 		},
 		{
@@ -0,0 +1,289 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package pulsar
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/gofrs/uuid/v5"
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_pulsar "github.com/influxdata/telegraf/plugins/common/pulsar"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var compressionTypes = map[string]pulsar.CompressionType{
+	"none": pulsar.NoCompression,
+	"lz4":  pulsar.LZ4,
+	"zlib": pulsar.ZLib,
+	"zstd": pulsar.ZSTD,
+}
+
+type Pulsar struct {
+	Topic                   string          `toml:"topic"`
+	ProducerName            string          `toml:"producer_name"`
+	RoutingTag              string          `toml:"routing_tag"`
+	RoutingKey              string          `toml:"routing_key"`
+	Batching                bool            `toml:"batching"`
+	BatchingMaxMessages     uint            `toml:"batching_max_messages"`
+	BatchingMaxSize         config.Size     `toml:"batching_max_size"`
+	BatchingMaxPublishDelay config.Duration `toml:"batching_max_publish_delay"`
+	MaxPendingMessages      int             `toml:"max_pending_messages"`
+	Compression             string          `toml:"compression"`
+	SchemaType              string          `toml:"schema_type"`
+	SchemaDefinition        string          `toml:"schema_definition"`
+	Timeout                 config.Duration `toml:"timeout"`
+	Log                     telegraf.Logger `toml:"-"`
+	common_pulsar.ClientConfig
+
+	serializer telegraf.Serializer
+	schema     pulsar.Schema
+	codec      *goavro.Codec
+	client     pulsar.Client
+	producer   pulsar.Producer
+}
+
+func (*Pulsar) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Pulsar) SetSerializer(serializer telegraf.Serializer) {
+	p.serializer = serializer
+}
+
+func (p *Pulsar) Init() error {
+	if p.Topic == "" {
+		return errors.New("'topic' setting required")
+	}
+
+	if _, found := compressionTypes[p.Compression]; !found {
+		return fmt.Errorf("invalid 'compression' setting %q", p.Compression)
+	}
+
+	// The schema is registered for the topic while the payload is created by
+	// the serializer. For Avro, the serializer output must be JSON which is
+	// converted to the binary Avro encoding using the schema.
+	var err error
+	switch p.SchemaType {
+	case "", "none":
+	case "json":
+		if p.SchemaDefinition == "" {
+			return errors.New("'schema_definition' setting required for schema type \"json\"")
+		}
+		p.schema, err = pulsar.NewJSONSchemaWithValidation(p.SchemaDefinition, nil)
+	case "avro":
+		if p.SchemaDefinition == "" {
+			return errors.New("'schema_definition' setting required for schema type \"avro\"")
+		}
+		p.schema, err = pulsar.NewAvroSchemaWithValidation(p.SchemaDefinition, nil)
+		if err == nil {
+			p.codec, err = goavro.NewCodec(p.SchemaDefinition)
+		}
+	default:
+		return fmt.Errorf("invalid 'schema_type' setting %q", p.SchemaType)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing schema definition failed: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Pulsar) Connect() error {
+	client, err := p.ClientConfig.NewClient(p.Log)
+	if err != nil {
+		return &internal.StartupError{
+			Err:   fmt.Errorf("creating client failed: %w", err),
+			Retry: true,
+		}
+	}
+
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{
+		Topic:                   p.Topic,
+		Name:                    p.ProducerName,
+		SendTimeout:             time.Duration(p.Timeout),
+		MaxPendingMessages:      p.MaxPendingMessages,
+		DisableBatching:         !p.Batching,
+		BatchingMaxMessages:     p.BatchingMaxMessages,
+		BatchingMaxSize:         uint(p.BatchingMaxSize),
+		BatchingMaxPublishDelay: time.Duration(p.BatchingMaxPublishDelay),
+		CompressionType:         compressionTypes[p.Compression],
+		Schema:                  p.schema,
+	})
+	if err != nil {
+		client.Close()
+		return &internal.StartupError{
+			Err:   fmt.Errorf("creating producer for topic %q failed: %w", p.Topic, err),
+			Retry: true,
+		}
+	}
+
+	p.client = client
+	p.producer = producer
+	return nil
+}
+
+func (p *Pulsar) Close() error {
+	if p.producer != nil {
+		p.producer.Close()
+		p.producer = nil
+	}
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+	return nil
+}
+
+func (p *Pulsar) Write(metrics []telegraf.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.Timeout))
+	defer cancel()
+
+	// Send all messages asynchronously and collect the acknowledgement of
+	// the broker for each metric. Each callback only writes its own entry.
+	serializeErrs := make([]error, len(metrics))
+	sendErrs := make([]error, len(metrics))
+	var wg sync.WaitGroup
+	for i, m := range metrics {
+		msg, err := p.message(m)
+		if err != nil {
+			p.Log.Errorf("Could not serialize metric: %v", err)
+			serializeErrs[i] = err
+			continue
+		}
+
+		wg.Add(1)
+		p.producer.SendAsync(ctx, msg, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+			defer wg.Done()
+			sendErrs[i] = err
+		})
+	}
+	if err := p.producer.FlushWithCtx(ctx); err != nil {
+		p.Log.Debugf("Flushing producer failed: %v", err)
+	}
+	wg.Wait()
+
+	// Metrics are only accepted after the broker acknowledged the message.
+	// Metrics failing to serialize or being refused by the broker due to the
+	// message content are rejected, all others are kept for the next write.
+	werr := &internal.PartialWriteError{}
+	var refused error
+	for i := range metrics {
+		switch {
+		case serializeErrs[i] != nil:
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, serializeErrs[i])
+		case sendErrs[i] == nil:
+			werr.MetricsAccept = append(werr.MetricsAccept, i)
+		case !isRetryable(sendErrs[i]):
+			p.Log.Errorf("Message refused: %v", sendErrs[i])
+			if refused == nil {
+				refused = sendErrs[i]
+			}
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, sendErrs[i])
+		case werr.Err == nil:
+			werr.Err = fmt.Errorf("sending message failed: %w", sendErrs[i])
+		}
+	}
+
+	if werr.Err == nil && len(werr.MetricsReject) == 0 {
+		return nil
+	}
+	switch {
+	case werr.Err != nil:
+	case refused != nil:
+		werr.Err = refused
+	default:
+		werr.Err = internal.ErrSerialization
+	}
+	return werr
+}
+
+func (p *Pulsar) message(m telegraf.Metric) (*pulsar.ProducerMessage, error) {
+	payload, err := p.serializer.Serialize(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.codec != nil {
+		native, _, err := p.codec.NativeFromTextual(payload)
+		if err != nil {
+			return nil, fmt.Errorf("converting to Avro failed: %w", err)
+		}
+		payload, err = p.codec.BinaryFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("encoding Avro failed: %w", err)
+		}
+	}
+
+	key, err := p.routingKey(m)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate routing key: %w", err)
+	}
+
+	return &pulsar.ProducerMessage{
+		Payload:   payload,
+		Key:       key,
+		EventTime: m.Time(),
+	}, nil
+}
+
+func (p *Pulsar) routingKey(m telegraf.Metric) (string, error) {
+	if p.RoutingTag != "" {
+		if key, ok := m.GetTag(p.RoutingTag); ok {
+			return key, nil
+		}
+	}
+
+	if p.RoutingKey == "random" {
+		u, err := uuid.NewV4()
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	}
+
+	return p.RoutingKey, nil
+}
+
+// isRetryable returns false for errors caused by the message itself, which
+// will fail again when resending the message
+func isRetryable(err error) bool {
+	var perr *pulsar.Error
+	if !errors.As(err, &perr) {
+		return true
+	}
+
+	switch perr.Result() {
+	case pulsar.MessageTooBig, pulsar.InvalidMessage, pulsar.SchemaFailure:
+		return false
+	}
+	return true
+}
+
+func init() {
+	outputs.Add("pulsar", func() telegraf.Output {
+		return &Pulsar{
+			Topic:                   "telegraf",
+			Batching:                true,
+			BatchingMaxMessages:     1000,
+			BatchingMaxSize:         config.Size(128 * 1024),
+			BatchingMaxPublishDelay: config.Duration(10 * time.Millisecond),
+			MaxPendingMessages:      1000,
+			Compression:             "none",
+			Timeout:                 config.Duration(30 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/docker/go-connections/nat"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	serializers_json "github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const avroSchema = `
+{
+  "type": "record",
+  "name": "metric",
+  "fields": [
+    {"name": "name", "type": "string"},
+    {"name": "tags", "type": {"type": "map", "values": "string"}},
+    {"name": "fields", "type": {"type": "map", "values": "double"}},
+    {"name": "timestamp", "type": "long"}
+  ]
+}`
+
+func newPlugin(t *testing.T) *Pulsar {
+	creator, found := outputs.Outputs["pulsar"]
+	require.True(t, found)
+	plugin := creator().(*Pulsar)
+	plugin.Log = testutil.Logger{}
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+	plugin.SetSerializer(serializer)
+
+	return plugin
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Pulsar)
+		expected string
+	}{
+		{
+			name:     "missing topic",
+			modify:   func(p *Pulsar) { p.Topic = "" },
+			expected: "'topic' setting required",
+		},
+		{
+			name:     "invalid compression",
+			modify:   func(p *Pulsar) { p.Compression = "snappy" },
+			expected: "invalid 'compression' setting",
+		},
+		{
+			name:     "invalid schema type",
+			modify:   func(p *Pulsar) { p.SchemaType = "protobuf" },
+			expected: "invalid 'schema_type' setting",
+		},
+		{
+			name:     "missing schema definition",
+			modify:   func(p *Pulsar) { p.SchemaType = "avro" },
+			expected: "'schema_definition' setting required",
+		},
+		{
+			name:     "invalid schema definition",
+			modify:   func(p *Pulsar) { p.SchemaType = "json"; p.SchemaDefinition = `{"type": "foo"}` },
+			expected: "parsing schema definition failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t)
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		routingTag string
+		routingKey string
+		tags       map[string]string
+		expected   string
+	}{
+		{
+			name:     "no key",
+			tags:     map[string]string{"host": "a"},
+			expected: "",
+		},
+		{
+			name:       "routing tag",
+			routingTag: "host",
+			routingKey: "fallback",
+			tags:       map[string]string{"host": "a"},
+			expected:   "a",
+		},
+		{
+			name:       "missing routing tag",
+			routingTag: "host",
+			routingKey: "fallback",
+			tags:       map[string]string{},
+			expected:   "fallback",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &mockProducer{}
+			plugin := newPlugin(t)
+			plugin.RoutingTag = tt.routingTag
+			plugin.RoutingKey = tt.routingKey
+			require.NoError(t, plugin.Init())
+			plugin.producer = producer
+
+			m := metric.New("cpu", tt.tags, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+			require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+			require.Len(t, producer.messages, 1)
+			require.Equal(t, tt.expected, producer.messages[0].Key)
+		})
+	}
+}
+
+func TestRandomRoutingKey(t *testing.T) {
+	producer := &mockProducer{}
+	plugin := newPlugin(t)
+	plugin.RoutingKey = "random"
+	require.NoError(t, plugin.Init())
+	plugin.producer = producer
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m, m}))
+	require.Len(t, producer.messages, 2)
+	require.Len(t, producer.messages[0].Key, 36)
+	require.NotEqual(t, producer.messages[0].Key, producer.messages[1].Key)
+}
+
+func TestPartialWrite(t *testing.T) {
+	producer := &mockProducer{
+		errs: map[string]error{
+			"too_large": pulsar.ErrMessageTooLarge,
+			"timeout":   pulsar.ErrSendTimeout,
+		},
+	}
+	plugin := newPlugin(t)
+	plugin.RoutingTag = "key"
+	require.NoError(t, plugin.Init())
+	plugin.producer = producer
+
+	input := make([]telegraf.Metric, 0, 4)
+	for _, key := range []string{"ok", "too_large", "timeout", "ok"} {
+		input = append(input, metric.New(
+			"cpu",
+			map[string]string{"key": key},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		))
+	}
+
+	// Metrics refused due to the message content must be rejected while
+	// metrics failing for other reasons must be kept for the next write
+	err := plugin.Write(input)
+	require.ErrorIs(t, err, pulsar.ErrSendTimeout)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+	require.ErrorIs(t, werr.MetricsRejectErrors[0], pulsar.ErrMessageTooLarge)
+}
+
+func TestAvroSchema(t *testing.T) {
+	serializer := &serializers_json.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	producer := &mockProducer{}
+	plugin := newPlugin(t)
+	plugin.SetSerializer(serializer)
+	plugin.SchemaType = "avro"
+	plugin.SchemaDefinition = avroSchema
+	require.NoError(t, plugin.Init())
+	plugin.producer = producer
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.0}, time.Unix(10, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": "string"}, time.Unix(10, 0)),
+	}
+
+	// The metric with a string value does not match the schema and must be
+	// rejected
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, producer.messages, 1)
+
+	codec, err := goavro.NewCodec(avroSchema)
+	require.NoError(t, err)
+	actual, _, err := codec.NativeFromBinary(producer.messages[0].Payload)
+	require.NoError(t, err)
+	expected := map[string]interface{}{
+		"name":      "cpu",
+		"tags":      map[string]interface{}{"host": "a"},
+		"fields":    map[string]interface{}{"value": 42.0},
+		"timestamp": int64(10),
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	container := testutil.Container{
+		Image:        "apachepulsar/pulsar",
+		Cmd:          []string{"bin/pulsar", "standalone"},
+		ExposedPorts: []string{"6650", "8080"},
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort(nat.Port("6650")),
+			wait.ForHTTP("/admin/v2/namespaces/public/default").WithPort("8080"),
+		),
+	}
+	require.NoError(t, container.Start(), "failed to start container")
+	defer container.Terminate()
+	url := fmt.Sprintf("pulsar://%s:%s", container.Address, container.Ports["6650"])
+
+	// Subscribe to the topic before writing to receive all messages
+	client, err := pulsar.NewClient(pulsar.ClientOptions{URL: url})
+	require.NoError(t, err)
+	defer client.Close()
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:            "telegraf",
+		SubscriptionName: "test",
+	})
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	serializer := &serializers_json.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := newPlugin(t)
+	plugin.SetSerializer(serializer)
+	plugin.ServiceURL = url
+	plugin.RoutingTag = "host"
+	plugin.SchemaType = "json"
+	plugin.SchemaDefinition = avroSchema
+	plugin.Timeout = config.Duration(10 * time.Second)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, m := range input {
+		msg, err := consumer.Receive(ctx)
+		require.NoError(t, err)
+		require.NoError(t, consumer.Ack(msg))
+
+		host, _ := m.GetTag("host")
+		require.Equal(t, host, msg.Key())
+
+		var actual map[string]interface{}
+		require.NoError(t, json.Unmarshal(msg.Payload(), &actual))
+		require.Equal(t, "cpu", actual["name"])
+		require.Equal(t, map[string]interface{}{"host": host}, actual["tags"])
+	}
+}
+
+// mockProducer records the messages sent and fails messages with a key
+// listed in errs
+type mockProducer struct {
+	errs     map[string]error
+	messages []*pulsar.ProducerMessage
+
+	sync.Mutex
+}
+
+func (*mockProducer) Topic() string {
+	return "telegraf"
+}
+
+func (*mockProducer) Name() string {
+	return "mock"
+}
+
+func (p *mockProducer) Send(ctx context.Context, msg *pulsar.ProducerMessage) (pulsar.MessageID, error) {
+	var id pulsar.MessageID
+	var err error
+	done := make(chan struct{})
+	p.SendAsync(ctx, msg, func(mid pulsar.MessageID, _ *pulsar.ProducerMessage, e error) {
+		id, err = mid, e
+		close(done)
+	})
+	<-done
+	return id, err
+}
+
+func (p *mockProducer) SendAsync(_ context.Context, msg *pulsar.ProducerMessage, callback func(pulsar.MessageID, *pulsar.ProducerMessage, error)) {
+	if err, found := p.errs[msg.Key]; found {
+		go callback(nil, msg, err)
+		return
+	}
+
+	p.Lock()
+	p.messages = append(p.messages, msg)
+	p.Unlock()
+	go callback(pulsar.EarliestMessageID(), msg, nil)
+}
+
+func (*mockProducer) LastSequenceID() int64 {
+	return -1
+}
+
+func (*mockProducer) Flush() error {
+	return nil
+}
+
+func (*mockProducer) FlushWithCtx(context.Context) error {
+	return nil
+}
+
+func (*mockProducer) Close() {}
@@ -0,0 +1,77 @@
+package sql
+
+import "fmt"
+
+// dialect contains the driver specific defaults and limits of the supported
+// databases
+type dialect struct {
+	// addColumnTemplates are the default templates used to add a column
+	addColumnTemplates []string
+	// maxParameters is the maximum number of parameters in a single statement
+	maxParameters int
+	// numberedPlaceholders indicates placeholders of the form $1, $2, ...
+	// instead of question marks
+	numberedPlaceholders bool
+	// preparedBatches indicates the driver batches rows of prepared statements
+	// executed in a transaction instead of using multi-row inserts
+	preparedBatches bool
+}
+
+var dialects = map[string]dialect{
+	"clickhouse": {
+		addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}"},
+		maxParameters:      65535,
+		preparedBatches:    true,
+	},
+	"mssql": {
+		addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD {COLUMN}"},
+		maxParameters:      2100,
+	},
+	"mysql": {
+		addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD COLUMN {COLUMN}"},
+		maxParameters:      65535,
+	},
+	"pgx": {
+		addColumnTemplates:   []string{"ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}"},
+		maxParameters:        65535,
+		numberedPlaceholders: true,
+	},
+	"snowflake": {
+		addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}"},
+		maxParameters:      16384,
+	},
+	"sqlite": {
+		addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD COLUMN {COLUMN}"},
+		maxParameters:      32766,
+	},
+}
+
+// genericDialect is used for drivers without a specific dialect
+var genericDialect = dialect{
+	addColumnTemplates: []string{"ALTER TABLE {TABLE} ADD COLUMN {COLUMN}"},
+	maxParameters:      999,
+}
+
+func lookupDialect(driver string) dialect {
+	if d, found := dialects[driver]; found {
+		return d
+	}
+	return genericDialect
+}
+
+// placeholder returns the placeholder for the n-th parameter starting at one
+func (d *dialect) placeholder(n int) string {
+	if d.numberedPlaceholders {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// rowsPerInsert returns the maximum number of rows to insert with a single
+// statement without exceeding the parameter limit of the database
+func (d *dialect) rowsPerInsert(batchSize, columns int) int {
+	if d.preparedBatches || columns == 0 {
+		return batchSize
+	}
+	return max(min(batchSize, d.maxParameters/columns), 1)
+}
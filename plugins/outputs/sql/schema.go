@@ -0,0 +1,267 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Kinds of columns and values used to detect type conflicts
+const (
+	kindUnknown   = ""
+	kindInteger   = "integer"
+	kindFloat     = "float"
+	kindString    = "string"
+	kindBool      = "bool"
+	kindTimestamp = "timestamp"
+)
+
+// table holds the cached schema of a database table
+type table struct {
+	// columns maps the column names to the kind of the column. A nil map
+	// indicates the schema could not be determined, in this case all columns
+	// are inserted without checks.
+	columns map[string]string
+}
+
+// row is a metric prepared for insertion into its table
+type row struct {
+	table   string
+	columns []string
+	values  []interface{}
+}
+
+// getTable returns the cached schema of the metric's table. The table is
+// created if it does not exist.
+func (p *SQL) getTable(metric telegraf.Metric) (*table, error) {
+	name := metric.Name()
+	if t, found := p.tables[name]; found {
+		return t, nil
+	}
+
+	if !p.tableExists(name) {
+		createStmt := p.generateCreateTable(metric)
+		if _, err := p.db.Exec(createStmt); err != nil {
+			return nil, err
+		}
+	}
+
+	t := &table{columns: p.readColumns(name)}
+	p.tables[name] = t
+	return t, nil
+}
+
+// readColumns queries the columns of the given table and their kinds. Nil is
+// returned if the columns cannot be determined.
+func (p *SQL) readColumns(tablename string) map[string]string {
+	rows, err := p.db.Query("SELECT * FROM " + quoteIdent(tablename) + " WHERE 1=0")
+	if err != nil {
+		p.Log.Debugf("Reading columns of table %q failed: %v", tablename, err)
+		return nil
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		p.Log.Debugf("Reading columns of table %q failed: %v", tablename, err)
+		return nil
+	}
+
+	columns := make(map[string]string, len(columnTypes))
+	for _, ct := range columnTypes {
+		columns[ct.Name()] = columnKind(ct.DatabaseTypeName())
+	}
+	return columns
+}
+
+// addColumn adds a column with the given definition to the table using the
+// configured templates
+func (p *SQL) addColumn(tablename string, t *table, column, datatype, kind string) error {
+	definition := fmt.Sprintf("%s %s", quoteIdent(column), datatype)
+	for _, tmpl := range p.AddColumnTemplates {
+		stmt := strings.ReplaceAll(tmpl, "{TABLE}", quoteIdent(tablename))
+		stmt = strings.ReplaceAll(stmt, "{TABLELITERAL}", quoteStr(tablename))
+		stmt = strings.ReplaceAll(stmt, "{COLUMN}", definition)
+		if _, err := p.db.Exec(stmt); err != nil {
+			// The cached schema might be outdated e.g. if the column was added
+			// by another client, so check the current schema of the table
+			if columns := p.readColumns(tablename); columns != nil {
+				if _, found := columns[column]; found {
+					t.columns = columns
+					return nil
+				}
+			}
+			return fmt.Errorf("adding column %q to table %q failed: %w", column, tablename, err)
+		}
+	}
+	p.Log.Debugf("Added column %q to table %q", column, tablename)
+	t.columns[column] = kind
+	return nil
+}
+
+// prepareRow determines the columns and values to insert for the metric,
+// adding missing columns to the table and resolving type conflicts according
+// to the configuration
+func (p *SQL) prepareRow(metric telegraf.Metric) (*row, error) {
+	t, err := p.getTable(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &row{
+		table:   metric.Name(),
+		columns: make([]string, 0, len(metric.TagList())+len(metric.FieldList())+1),
+		values:  make([]interface{}, 0, len(metric.TagList())+len(metric.FieldList())+1),
+	}
+
+	// ensureColumn checks if the column exists and tries to add it if it
+	// does not. It returns the kind of the column and false if the column is
+	// not available.
+	ensureColumn := func(column, datatype, kind string) (string, bool, error) {
+		if t.columns == nil {
+			return kindUnknown, true, nil
+		}
+		if k, found := t.columns[column]; found {
+			return k, true, nil
+		}
+		if len(p.AddColumnTemplates) == 0 {
+			p.Log.Debugf("Omitting column %q not existing in table %q", column, r.table)
+			return kindUnknown, false, nil
+		}
+		if err := p.addColumn(r.table, t, column, datatype, kind); err != nil {
+			return kindUnknown, false, err
+		}
+		return t.columns[column], true, nil
+	}
+
+	if p.TimestampColumn != "" {
+		_, ok, err := ensureColumn(p.TimestampColumn, p.Convert.Timestamp, kindTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r.columns = append(r.columns, p.TimestampColumn)
+			r.values = append(r.values, metric.Time())
+		}
+	}
+
+	for _, tag := range metric.TagList() {
+		_, ok, err := ensureColumn(tag.Key, p.Convert.Text, kindString)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r.columns = append(r.columns, tag.Key)
+			r.values = append(r.values, tag.Value)
+		}
+	}
+
+	var nfields int
+	for _, field := range metric.FieldList() {
+		columnKind, ok, err := ensureColumn(field.Key, p.deriveDatatype(field.Value), valueKind(field.Value))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		value := field.Value
+		if p.TypeConflict != "insert" && !compatible(valueKind(value), columnKind) {
+			switch p.TypeConflict {
+			case "convert":
+				v, err := convertValue(value, columnKind)
+				if err != nil {
+					p.Log.Debugf("Omitting field %q: %v", field.Key, err)
+					continue
+				}
+				value = v
+			case "drop_field":
+				p.Log.Debugf("Omitting field %q: type %T conflicts with column of kind %s", field.Key, value, columnKind)
+				continue
+			case "drop_metric":
+				return nil, fmt.Errorf("type %T of field %q conflicts with column of kind %s", value, field.Key, columnKind)
+			}
+		}
+		r.columns = append(r.columns, field.Key)
+		r.values = append(r.values, value)
+		nfields++
+	}
+
+	if nfields == 0 {
+		return nil, errors.New("no field can be written")
+	}
+
+	return r, nil
+}
+
+// valueKind returns the kind of a metric value
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case int64, uint64:
+		return kindInteger
+	case float64:
+		return kindFloat
+	case string:
+		return kindString
+	case bool:
+		return kindBool
+	case time.Time:
+		return kindTimestamp
+	}
+	return kindUnknown
+}
+
+// columnKind derives the kind of a column from the database type name
+func columnKind(datatype string) string {
+	datatype = strings.ToUpper(datatype)
+	switch {
+	case strings.Contains(datatype, "BOOL"), datatype == "BIT":
+		return kindBool
+	case strings.Contains(datatype, "TIME"), strings.Contains(datatype, "DATE"):
+		return kindTimestamp
+	case strings.Contains(datatype, "INT"):
+		return kindInteger
+	case strings.Contains(datatype, "FLOAT"), strings.Contains(datatype, "DOUBLE"), strings.Contains(datatype, "REAL"),
+		strings.Contains(datatype, "DECIMAL"), strings.Contains(datatype, "NUMERIC"), strings.Contains(datatype, "NUMBER"):
+		return kindFloat
+	case strings.Contains(datatype, "CHAR"), strings.Contains(datatype, "TEXT"), strings.Contains(datatype, "STRING"),
+		strings.Contains(datatype, "CLOB"):
+		return kindString
+	}
+	return kindUnknown
+}
+
+// compatible returns true if a value of the given kind can be inserted into a
+// column of the given kind without conversion
+func compatible(value, column string) bool {
+	if value == kindUnknown || column == kindUnknown || value == column {
+		return true
+	}
+	switch column {
+	case kindInteger:
+		return value == kindBool
+	case kindFloat:
+		return value == kindInteger
+	}
+	return false
+}
+
+// convertValue converts the value to the given column kind
+func convertValue(value interface{}, kind string) (interface{}, error) {
+	switch kind {
+	case kindInteger:
+		return internal.ToInt64(value)
+	case kindFloat:
+		return internal.ToFloat64(value)
+	case kindString:
+		return internal.ToString(value)
+	case kindBool:
+		return internal.ToBool(value)
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, kind)
+}
@@ -2,9 +2,13 @@
 package sql
 
 import (
+	"context"
 	gosql "database/sql"
+	"database/sql/driver"
 	_ "embed"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -20,6 +24,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -44,6 +49,9 @@ type SQL struct {
 	TableTemplate         string          `toml:"table_template"`
 	TableExistsTemplate   string          `toml:"table_exists_template"`
 	InitSQL               string          `toml:"init_sql"`
+	AddColumnTemplates    []string        `toml:"add_column_templates"`
+	TypeConflict          string          `toml:"type_conflict"`
+	BatchSize             int             `toml:"batch_size"`
 	Convert               ConvertStruct   `toml:"convert"`
 	ConnectionMaxIdleTime config.Duration `toml:"connection_max_idle_time"`
 	ConnectionMaxLifetime config.Duration `toml:"connection_max_lifetime"`
@@ -51,14 +59,36 @@ type SQL struct {
 	ConnectionMaxOpen     int             `toml:"connection_max_open"`
 	Log                   telegraf.Logger `toml:"-"`
 
-	db     *gosql.DB
-	tables map[string]bool
+	db      *gosql.DB
+	dialect dialect
+	tables  map[string]*table
 }
 
 func (*SQL) SampleConfig() string {
 	return sampleConfig
 }
 
+func (p *SQL) Init() error {
+	switch p.TypeConflict {
+	case "":
+		p.TypeConflict = "insert"
+	case "insert", "convert", "drop_field", "drop_metric":
+	default:
+		return fmt.Errorf("invalid 'type_conflict' setting %q", p.TypeConflict)
+	}
+
+	if p.BatchSize < 1 {
+		return fmt.Errorf("invalid 'batch_size' setting %d", p.BatchSize)
+	}
+
+	p.dialect = lookupDialect(p.Driver)
+	if p.AddColumnTemplates == nil {
+		p.AddColumnTemplates = p.dialect.addColumnTemplates
+	}
+
+	return nil
+}
+
 func (p *SQL) Connect() error {
 	db, err := gosql.Open(p.Driver, p.DataSourceName)
 	if err != nil {
@@ -83,7 +113,7 @@ func (p *SQL) Connect() error {
 	}
 
 	p.db = db
-	p.tables = make(map[string]bool)
+	p.tables = make(map[string]*table)
 
 	return nil
 }
@@ -169,28 +199,26 @@ func (p *SQL) generateCreateTable(metric telegraf.Metric) string {
 	return query
 }
 
-func (p *SQL) generateInsert(tablename string, columns []string) string {
-	placeholders := make([]string, 0, len(columns))
+func (p *SQL) generateInsert(tablename string, columns []string, rows int) string {
 	quotedColumns := make([]string, 0, len(columns))
 	for _, column := range columns {
 		quotedColumns = append(quotedColumns, quoteIdent(column))
 	}
-	if p.Driver == "pgx" {
-		// Postgres uses $1 $2 $3 as placeholders
-		for i := 0; i < len(columns); i++ {
-			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
-		}
-	} else {
-		// Everything else uses ? ? ? as placeholders
-		for i := 0; i < len(columns); i++ {
-			placeholders = append(placeholders, "?")
+
+	values := make([]string, 0, rows)
+	placeholders := make([]string, 0, len(columns))
+	for n := 0; n < rows; n++ {
+		placeholders = placeholders[:0]
+		for i := range columns {
+			placeholders = append(placeholders, p.dialect.placeholder(n*len(columns)+i+1))
 		}
+		values = append(values, "("+strings.Join(placeholders, ",")+")")
 	}
 
-	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)",
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES%s",
 		quoteIdent(tablename),
 		strings.Join(quotedColumns, ","),
-		strings.Join(placeholders, ","))
+		strings.Join(values, ","))
 }
 
 func (p *SQL) tableExists(tableName string) bool {
@@ -201,72 +229,139 @@ func (p *SQL) tableExists(tableName string) bool {
 }
 
 func (p *SQL) Write(metrics []telegraf.Metric) error {
-	var err error
-
-	for _, metric := range metrics {
-		tablename := metric.Name()
+	// Failures caused by the metric itself, e.g. due to constraint violations,
+	// reject the metric while writing is stopped on connection errors and the
+	// remaining metrics are kept for the next write.
+	werr := &internal.PartialWriteError{}
+	reject := func(idx int, err error) {
+		p.Log.Errorf("Dropping metric %q: %v", metrics[idx].Name(), err)
+		werr.MetricsReject = append(werr.MetricsReject, idx)
+		werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+		if werr.Err == nil {
+			werr.Err = err
+		}
+	}
 
-		// create table if needed
-		if !p.tables[tablename] && !p.tableExists(tablename) {
-			createStmt := p.generateCreateTable(metric)
-			_, err := p.db.Exec(createStmt)
-			if err != nil {
-				return err
+	// Prepare the rows and group them by table and columns for batching
+	type batch struct {
+		table   string
+		columns []string
+		indices []int
+		values  [][]interface{}
+	}
+	var batches []*batch
+	lookup := make(map[string]*batch)
+	for i, metric := range metrics {
+		r, err := p.prepareRow(metric)
+		if err != nil {
+			if p.isRetryable(err) {
+				werr.Err = err
+				return werr
 			}
+			reject(i, err)
+			continue
 		}
-		p.tables[tablename] = true
-
-		var columns []string
-		var values []interface{}
 
-		if p.TimestampColumn != "" {
-			columns = append(columns, p.TimestampColumn)
-			values = append(values, metric.Time())
+		key := r.table + "\x00" + strings.Join(r.columns, "\x00")
+		b, found := lookup[key]
+		if !found {
+			b = &batch{table: r.table, columns: r.columns}
+			lookup[key] = b
+			batches = append(batches, b)
 		}
+		b.indices = append(b.indices, i)
+		b.values = append(b.values, r.values)
+	}
 
-		for column, value := range metric.Tags() {
-			columns = append(columns, column)
-			values = append(values, value)
-		}
+	for _, b := range batches {
+		size := p.dialect.rowsPerInsert(p.BatchSize, len(b.columns))
+		for start := 0; start < len(b.values); start += size {
+			end := min(start+size, len(b.values))
+			err := p.insert(b.table, b.columns, b.values[start:end])
+			if err == nil {
+				werr.MetricsAccept = append(werr.MetricsAccept, b.indices[start:end]...)
+				continue
+			}
+			if p.isRetryable(err) {
+				werr.Err = err
+				return werr
+			}
 
-		for column, value := range metric.Fields() {
-			columns = append(columns, column)
-			values = append(values, value)
+			// The cached schema might be outdated so refresh it on next write
+			delete(p.tables, b.table)
+
+			// Insert the rows one by one to find the failing ones
+			for i := start; i < end; i++ {
+				err := p.insert(b.table, b.columns, b.values[i:i+1])
+				if err == nil {
+					werr.MetricsAccept = append(werr.MetricsAccept, b.indices[i])
+					continue
+				}
+				if p.isRetryable(err) {
+					werr.Err = err
+					return werr
+				}
+				reject(b.indices[i], err)
+			}
 		}
+	}
 
-		sql := p.generateInsert(tablename, columns)
+	if len(werr.MetricsReject) > 0 {
+		return werr
+	}
+	return nil
+}
 
-		switch p.Driver {
-		case "clickhouse":
-			// ClickHouse needs to batch inserts with prepared statements
-			tx, err := p.db.Begin()
-			if err != nil {
-				return fmt.Errorf("begin failed: %w", err)
-			}
-			stmt, err := tx.Prepare(sql)
-			if err != nil {
-				return fmt.Errorf("prepare failed: %w", err)
-			}
-			defer stmt.Close() //nolint:revive,gocritic // done on purpose, closing will be executed properly
+// insert writes the given rows to the table within a transaction
+func (p *SQL) insert(tablename string, columns []string, rows [][]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin failed: %w", err)
+	}
 
-			_, err = stmt.Exec(values...)
-			if err != nil {
-				return fmt.Errorf("execution failed: %w", err)
-			}
-			err = tx.Commit()
-			if err != nil {
-				return fmt.Errorf("commit failed: %w", err)
-			}
-		default:
-			_, err = p.db.Exec(sql, values...)
-			if err != nil {
+	if p.dialect.preparedBatches {
+		// ClickHouse needs to batch inserts with prepared statements
+		stmt, err := tx.Prepare(p.generateInsert(tablename, columns, 1))
+		if err != nil {
+			tx.Rollback() //nolint:errcheck // the prepare error is more relevant
+			return fmt.Errorf("prepare failed: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, values := range rows {
+			if _, err := stmt.Exec(values...); err != nil {
+				tx.Rollback() //nolint:errcheck // the execution error is more relevant
 				return fmt.Errorf("execution failed: %w", err)
 			}
 		}
+	} else {
+		args := make([]interface{}, 0, len(rows)*len(columns))
+		for _, values := range rows {
+			args = append(args, values...)
+		}
+		if _, err := tx.Exec(p.generateInsert(tablename, columns, len(rows)), args...); err != nil {
+			tx.Rollback() //nolint:errcheck // the execution error is more relevant
+			return fmt.Errorf("execution failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
 	}
 	return nil
 }
 
+// isRetryable returns true if the write error is likely caused by the
+// connection to the database instead of the metric, i.e. writing the metric
+// again might succeed.
+func (p *SQL) isRetryable(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return true
+	}
+	return p.db.Ping() != nil
+}
+
 func init() {
 	outputs.Add("sql", func() telegraf.Output { return newSQL() })
 }
@@ -276,6 +371,8 @@ func newSQL() *SQL {
 		TableTemplate:       "CREATE TABLE {TABLE}({COLUMNS})",
 		TableExistsTemplate: "SELECT 1 FROM {TABLE} LIMIT 1",
 		TimestampColumn:     "timestamp",
+		TypeConflict:        "insert",
+		BatchSize:           1000,
 		Convert: ConvertStruct{
 			Integer:         "INT",
 			Real:            "DOUBLE",
@@ -37,6 +37,65 @@ func TestSqlInsertStatementIntegration(t *testing.T) {
 	}
 }
 
+func TestGenerateInsert(t *testing.T) {
+	p := newSQL()
+	p.Driver = "mysql"
+	require.NoError(t, p.Init())
+	require.Equal(t,
+		`INSERT INTO "metric"("a","b") VALUES(?,?),(?,?)`,
+		p.generateInsert("metric", []string{"a", "b"}, 2),
+	)
+
+	p = newSQL()
+	p.Driver = "pgx"
+	require.NoError(t, p.Init())
+	require.Equal(t,
+		`INSERT INTO "metric"("a","b") VALUES($1,$2),($3,$4)`,
+		p.generateInsert("metric", []string{"a", "b"}, 2),
+	)
+}
+
+func TestInitDialect(t *testing.T) {
+	p := newSQL()
+	p.Driver = "mssql"
+	require.NoError(t, p.Init())
+	require.Equal(t, []string{"ALTER TABLE {TABLE} ADD {COLUMN}"}, p.AddColumnTemplates)
+	require.Equal(t, 1000, p.dialect.rowsPerInsert(p.BatchSize, 2))
+	require.Equal(t, 210, p.dialect.rowsPerInsert(p.BatchSize, 10))
+
+	p = newSQL()
+	p.Driver = "mssql"
+	p.AddColumnTemplates = []string{}
+	require.NoError(t, p.Init())
+	require.Empty(t, p.AddColumnTemplates)
+
+	p = newSQL()
+	p.TypeConflict = "foo"
+	require.ErrorContains(t, p.Init(), "invalid 'type_conflict' setting")
+}
+
+func TestColumnKind(t *testing.T) {
+	tests := map[string]string{
+		"INT":              kindInteger,
+		"INT UNSIGNED":     kindInteger,
+		"Int64":            kindInteger,
+		"Nullable(UInt64)": kindInteger,
+		"FLOAT8":           kindFloat,
+		"DOUBLE":           kindFloat,
+		"TEXT":             kindString,
+		"VARCHAR":          kindString,
+		"String":           kindString,
+		"BOOL":             kindBool,
+		"BIT":              kindBool,
+		"TIMESTAMP":        kindTimestamp,
+		"DateTime":         kindTimestamp,
+		"BLOB":             kindUnknown,
+	}
+	for datatype, expected := range tests {
+		require.Equal(t, expected, columnKind(datatype), datatype)
+	}
+}
+
 func pwgen(n int) string {
 	charset := []byte("abcdedfghijklmnopqrstABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
@@ -202,6 +261,7 @@ func TestMysqlIntegration(t *testing.T) {
 	p.DataSourceName = address
 	p.InitSQL = "SET sql_mode='ANSI_QUOTES';"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	require.NoError(t, p.Write(
 		testMetrics,
@@ -288,6 +348,7 @@ func TestPostgresIntegration(t *testing.T) {
 	p.Convert.Unsigned = "bigint"
 	p.Convert.ConversionStyle = "literal"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	defer p.Close()
 	require.NoError(t, p.Write(
@@ -377,6 +438,7 @@ func TestClickHouseIntegration(t *testing.T) {
 	p.Convert.Bool = "UInt8"
 	p.Convert.ConversionStyle = "literal"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	require.NoError(t, p.Write(testMetrics))
 
@@ -11,6 +11,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -26,6 +28,7 @@ func TestSqlite(t *testing.T) {
 	p.Driver = "sqlite"
 	p.DataSourceName = address
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	defer p.Close()
 	require.NoError(t, p.Write(testMetrics))
@@ -128,3 +131,218 @@ func TestSqlite(t *testing.T) {
 	require.Equal(t, "string2", k)
 	require.False(t, rows4.Next())
 }
+
+func TestSqlitePartialWrite(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "db")
+
+	// Create a table with a constraint to make some of the inserts fail
+	db, err := gosql.Open("sqlite", dbfile)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE "metric"("timestamp" TIMESTAMP, "value" INT CHECK ("value" >= 0))`)
+	require.NoError(t, err)
+
+	p := newSQL()
+	p.Log = testutil.Logger{}
+	p.Driver = "sqlite"
+	p.DataSourceName = dbfile
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"value": 1}, ts),
+		testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"value": -1}, ts),
+		testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"value": 2}, ts),
+	}
+	err = p.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+
+	var count int
+	require.NoError(t, db.QueryRow(`select count(*) from "metric"`).Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestSqliteAddColumns(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "db")
+
+	p := newSQL()
+	p.Log = testutil.Logger{}
+	p.Driver = "sqlite"
+	p.DataSourceName = dbfile
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	// The second metric contains a new tag and field not known when
+	// creating the table
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("metric", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, ts),
+	}))
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("metric", map[string]string{"host": "b", "region": "eu"}, map[string]interface{}{"value": 2, "status": "ok"}, ts),
+	}))
+
+	db, err := gosql.Open("sqlite", dbfile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var sql string
+	require.NoError(t, db.QueryRow("select sql from sqlite_master").Scan(&sql))
+	require.Equal(t,
+		`CREATE TABLE "metric"("timestamp" TIMESTAMP,"host" TEXT,"value" INT, "region" TEXT, "status" TEXT)`,
+		sql,
+	)
+
+	var host, region, status string
+	var value int64
+	require.NoError(t, db.QueryRow(`select host, region, value, status from "metric" where value = 2`).Scan(&host, &region, &value, &status))
+	require.Equal(t, "b", host)
+	require.Equal(t, "eu", region)
+	require.Equal(t, int64(2), value)
+	require.Equal(t, "ok", status)
+}
+
+func TestSqliteAddColumnsDisabled(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "db")
+
+	p := newSQL()
+	p.Log = testutil.Logger{}
+	p.Driver = "sqlite"
+	p.DataSourceName = dbfile
+	p.AddColumnTemplates = []string{}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	// Unknown columns must be omitted
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"value": 1}, ts),
+		testutil.MustMetric("metric", map[string]string{"host": "b"}, map[string]interface{}{"value": 2, "status": "ok"}, ts),
+	}))
+
+	db, err := gosql.Open("sqlite", dbfile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow(`select count(*) from "metric"`).Scan(&count))
+	require.Equal(t, 2, count)
+
+	// Metrics without any field left must be rejected
+	err = p.Write([]telegraf.Metric{
+		testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"status": "ok"}, ts),
+	})
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0}, werr.MetricsReject)
+}
+
+func TestSqliteTypeConflict(t *testing.T) {
+	tests := []struct {
+		policy   string
+		rejected []int
+		expected []interface{}
+	}{
+		{
+			// SQLite converts the string to an integer due to the column's
+			// type affinity if possible
+			policy:   "insert",
+			expected: []interface{}{int64(1), "abc", int64(42)},
+		},
+		{
+			policy:   "convert",
+			expected: []interface{}{int64(1), nil, int64(42)},
+		},
+		{
+			policy:   "drop_field",
+			expected: []interface{}{int64(1), nil, nil},
+		},
+		{
+			policy:   "drop_metric",
+			rejected: []int{1, 2},
+			expected: []interface{}{int64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dbfile := filepath.Join(t.TempDir(), "db")
+
+			p := newSQL()
+			p.Log = testutil.Logger{}
+			p.Driver = "sqlite"
+			p.DataSourceName = dbfile
+			p.TypeConflict = tt.policy
+			require.NoError(t, p.Init())
+			require.NoError(t, p.Connect())
+			defer p.Close()
+
+			// Use a second field to keep the metrics valid when dropping
+			// the conflicting field
+			input := []telegraf.Metric{
+				testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"id": 0, "value": 1}, ts),
+				testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"id": 1, "value": "abc"}, ts),
+				testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"id": 2, "value": "42"}, ts),
+			}
+			err := p.Write(input)
+			if len(tt.rejected) > 0 {
+				var werr *internal.PartialWriteError
+				require.ErrorAs(t, err, &werr)
+				require.Equal(t, tt.rejected, werr.MetricsReject)
+			} else {
+				require.NoError(t, err)
+			}
+
+			db, err := gosql.Open("sqlite", dbfile)
+			require.NoError(t, err)
+			defer db.Close()
+
+			rows, err := db.Query(`select value from "metric" order by id`)
+			require.NoError(t, err)
+			defer rows.Close()
+			actual := make([]interface{}, 0, len(tt.expected))
+			for rows.Next() {
+				var v interface{}
+				require.NoError(t, rows.Scan(&v))
+				actual = append(actual, v)
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSqliteBatches(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "db")
+
+	p := newSQL()
+	p.Log = testutil.Logger{}
+	p.Driver = "sqlite"
+	p.DataSourceName = dbfile
+	p.BatchSize = 3
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	input := make([]telegraf.Metric, 0, 10)
+	for i := 0; i < 10; i++ {
+		input = append(input, testutil.MustMetric("metric", map[string]string{}, map[string]interface{}{"value": i}, ts))
+	}
+	input = append(input, testutil.MustMetric("other", map[string]string{}, map[string]interface{}{"value": 42}, ts))
+	require.NoError(t, p.Write(input))
+
+	db, err := gosql.Open("sqlite", dbfile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count, sum int
+	require.NoError(t, db.QueryRow(`select count(*), sum(value) from "metric"`).Scan(&count, &sum))
+	require.Equal(t, 10, count)
+	require.Equal(t, 45, sum)
+	require.NoError(t, db.QueryRow(`select count(*) from "other"`).Scan(&count))
+	require.Equal(t, 1, count)
+}
@@ -87,6 +87,35 @@ func (s *Syslog) Connect() error {
 	return nil
 }
 
+// Probe checks if the connection to the syslog server is still established.
+// Datagram connections cannot be checked without sending a message.
+func (s *Syslog) Probe() error {
+	if s.Conn == nil {
+		return errors.New("not connected")
+	}
+	switch strings.SplitN(s.Address, "://", 2)[0] {
+	case "udp", "udp4", "udp6", "unixgram":
+		return nil
+	}
+
+	// Servers do not send any data, so reading times out for established
+	// connections but fails if the server closed the connection
+	if err := s.Conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		return err
+	}
+	defer s.Conn.SetReadDeadline(time.Time{}) //nolint:errcheck // reading is not used otherwise
+
+	var buf [1]byte
+	if _, err := s.Conn.Read(buf[:]); err != nil {
+		var nerr net.Error
+		if errors.As(err, &nerr) && nerr.Timeout() {
+			return nil
+		}
+		return fmt.Errorf("connection to %q lost: %w", s.Address, err)
+	}
+	return nil
+}
+
 func (s *Syslog) setKeepAlive(c net.Conn) error {
 	if s.KeepAlivePeriod == nil {
 		return nil
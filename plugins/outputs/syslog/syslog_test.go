@@ -114,6 +114,26 @@ func TestSyslogWriteWithTcp(t *testing.T) {
 	testSyslogWriteWithStream(t, s, lconn)
 }
 
+func TestSyslogProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	require.NoError(t, s.Init())
+	s.Address = "tcp://" + listener.Addr().String()
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	require.NoError(t, s.Probe())
+
+	// Probing must fail once the server closed the connection
+	require.NoError(t, lconn.Close())
+	require.Error(t, s.Probe())
+}
+
 func TestSyslogWriteWithUdp(t *testing.T) {
 	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
 	require.NoError(t, err)
@@ -19,6 +19,7 @@ import (
 	"github.com/aws/smithy-go"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -50,6 +51,19 @@ type (
 		common_aws.CredentialConfig
 	}
 
+	// writeRequest contains the Timestream write request together with the
+	// index of the metric each record was built from
+	writeRequest struct {
+		input   *timestreamwrite.WriteRecordsInput
+		metrics []int
+	}
+
+	writeResult struct {
+		request  *writeRequest
+		rejected map[int]error
+		err      error
+	}
+
 	WriteClient interface {
 		CreateTable(context.Context, *timestreamwrite.CreateTableInput, ...func(*timestreamwrite.Options)) (*timestreamwrite.CreateTableOutput, error)
 		WriteRecords(context.Context, *timestreamwrite.WriteRecordsInput, ...func(*timestreamwrite.Options)) (*timestreamwrite.WriteRecordsOutput, error)
@@ -210,18 +224,18 @@ func init() {
 }
 
 func (t *Timestream) Write(metrics []telegraf.Metric) error {
-	writeRecordsInputs := t.TransformMetrics(metrics)
+	requests, skipped := t.transformMetrics(metrics)
 
 	maxWriteJobs := t.MaxWriteGoRoutinesCount
-	numberOfWriteRecordsInputs := len(writeRecordsInputs)
+	numberOfWriteRecordsInputs := len(requests)
 
 	if numberOfWriteRecordsInputs < maxWriteJobs {
 		maxWriteJobs = numberOfWriteRecordsInputs
 	}
 
 	var wg sync.WaitGroup
-	errs := make(chan error, numberOfWriteRecordsInputs)
-	writeJobs := make(chan *timestreamwrite.WriteRecordsInput, maxWriteJobs)
+	results := make(chan *writeResult, numberOfWriteRecordsInputs)
+	writeJobs := make(chan *writeRequest, maxWriteJobs)
 
 	start := time.Now()
 
@@ -230,15 +244,14 @@ func (t *Timestream) Write(metrics []telegraf.Metric) error {
 		go func() {
 			defer wg.Done()
 			for writeJob := range writeJobs {
-				if err := t.writeToTimestream(writeJob, true); err != nil {
-					errs <- err
-				}
+				rejected, err := t.writeToTimestream(writeJob.input, true)
+				results <- &writeResult{request: writeJob, rejected: rejected, err: err}
 			}
 		}()
 	}
 
-	for i := range writeRecordsInputs {
-		writeJobs <- writeRecordsInputs[i]
+	for i := range requests {
+		writeJobs <- requests[i]
 	}
 
 	// Close channel once all jobs are added
@@ -247,70 +260,116 @@ func (t *Timestream) Write(metrics []telegraf.Metric) error {
 	wg.Wait()
 	elapsed := time.Since(start)
 
-	close(errs)
+	close(results)
 
 	t.Log.Infof("##WriteToTimestream - Metrics size: %d request size: %d time(ms): %d",
-		len(metrics), len(writeRecordsInputs), elapsed.Milliseconds())
-
-	// On partial failures, Telegraf will reject the entire batch of metrics and
-	// retry. writeToTimestream will return retryable exceptions only.
-	for err := range errs {
-		if err != nil {
-			return err
+		len(metrics), len(requests), elapsed.Milliseconds())
+
+	// Metrics of requests failing with a retryable error are kept for the next
+	// write. Metrics with at least one rejected record are rejected as
+	// retrying them would fail again, all other metrics are accepted.
+	var firstErr error
+	keep := make(map[int]bool)
+	reject := make(map[int]error, len(skipped))
+	for _, idx := range skipped {
+		reject[idx] = errors.New("no supported fields")
+	}
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			for _, idx := range r.request.metrics {
+				keep[idx] = true
+			}
+			continue
+		}
+		for recordIdx, err := range r.rejected {
+			idx := r.request.metrics[recordIdx]
+			if _, found := reject[idx]; !found {
+				reject[idx] = err
+			}
 		}
 	}
 
-	return nil
+	if firstErr == nil && len(reject) == 0 {
+		return nil
+	}
+
+	werr := &internal.PartialWriteError{Err: firstErr}
+	if werr.Err == nil {
+		werr.Err = fmt.Errorf("writing %d metrics to Timestream failed", len(reject))
+	}
+	for idx := range metrics {
+		if err, found := reject[idx]; found {
+			werr.MetricsReject = append(werr.MetricsReject, idx)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+		} else if !keep[idx] {
+			werr.MetricsAccept = append(werr.MetricsAccept, idx)
+		}
+	}
+	return werr
 }
 
-func (t *Timestream) writeToTimestream(writeRecordsInput *timestreamwrite.WriteRecordsInput, resourceNotFoundRetry bool) error {
+// writeToTimestream writes the given records and returns the errors of the
+// records rejected by Timestream keyed by the record index. An error is only
+// returned for retryable failures, i.e. throttling and 5xx exceptions, in
+// which case none of the records were written.
+func (t *Timestream) writeToTimestream(writeRecordsInput *timestreamwrite.WriteRecordsInput, resourceNotFoundRetry bool) (map[int]error, error) {
 	_, err := t.svc.WriteRecords(context.Background(), writeRecordsInput)
-	if err != nil {
-		// Telegraf will retry ingesting the metrics if an error is returned from the plugin.
-		// Therefore, return error only for retryable exceptions: ThrottlingException and 5xx exceptions.
-		var notFound *types.ResourceNotFoundException
-		if errors.As(err, &notFound) {
-			if resourceNotFoundRetry {
-				t.Log.Warnf("Failed to write to Timestream database %q table %q: %s",
-					t.DatabaseName, *writeRecordsInput.TableName, notFound)
-				return t.createTableAndRetry(writeRecordsInput)
-			}
-			t.logWriteToTimestreamError(notFound, writeRecordsInput.TableName)
-			// log error and return error to telegraf to retry in next flush interval
-			// We need this is to avoid data drop when there are no tables present in the database
-			return fmt.Errorf("failed to write to Timestream database %q table %q: %w", t.DatabaseName, *writeRecordsInput.TableName, err)
-		}
+	if err == nil {
+		return nil, nil
+	}
 
-		var rejected *types.RejectedRecordsException
-		if errors.As(err, &rejected) {
-			t.logWriteToTimestreamError(err, writeRecordsInput.TableName)
-			for _, rr := range rejected.RejectedRecords {
-				t.Log.Errorf("reject reason: %q, record index: '%d'", aws.ToString(rr.Reason), rr.RecordIndex)
-			}
-			return nil
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		if resourceNotFoundRetry {
+			t.Log.Warnf("Failed to write to Timestream database %q table %q: %s",
+				t.DatabaseName, *writeRecordsInput.TableName, notFound)
+			return t.createTableAndRetry(writeRecordsInput)
 		}
+		t.logWriteToTimestreamError(notFound, writeRecordsInput.TableName)
+		// log error and return error to telegraf to retry in next flush interval
+		// We need this is to avoid data drop when there are no tables present in the database
+		return nil, fmt.Errorf("failed to write to Timestream database %q table %q: %w", t.DatabaseName, *writeRecordsInput.TableName, err)
+	}
 
-		var throttling *types.ThrottlingException
-		if errors.As(err, &throttling) {
-			return fmt.Errorf("unable to write to Timestream database %q table %q: %w",
-				t.DatabaseName, *writeRecordsInput.TableName, throttling)
+	var rejected *types.RejectedRecordsException
+	if errors.As(err, &rejected) {
+		t.logWriteToTimestreamError(err, writeRecordsInput.TableName)
+		// Without details on the rejected records we have to assume all
+		// records of the request were rejected
+		if len(rejected.RejectedRecords) == 0 {
+			return rejectAll(writeRecordsInput, err), nil
 		}
-
-		var internal *types.InternalServerException
-		if errors.As(err, &internal) {
-			return fmt.Errorf("unable to write to Timestream database %q table %q: %w",
-				t.DatabaseName, *writeRecordsInput.TableName, internal)
+		errs := make(map[int]error, len(rejected.RejectedRecords))
+		for _, rr := range rejected.RejectedRecords {
+			t.Log.Errorf("reject reason: %q, record index: '%d'", aws.ToString(rr.Reason), rr.RecordIndex)
+			errs[int(rr.RecordIndex)] = errors.New(aws.ToString(rr.Reason))
 		}
+		return errs, nil
+	}
 
-		var operation *smithy.OperationError
-		if !errors.As(err, &operation) {
-			// Retry other, non-aws errors.
-			return fmt.Errorf("unable to write to Timestream database %q table %q: %w",
-				t.DatabaseName, *writeRecordsInput.TableName, err)
-		}
-		t.logWriteToTimestreamError(err, writeRecordsInput.TableName)
+	var throttling *types.ThrottlingException
+	if errors.As(err, &throttling) {
+		return nil, fmt.Errorf("unable to write to Timestream database %q table %q: %w",
+			t.DatabaseName, *writeRecordsInput.TableName, throttling)
 	}
-	return nil
+
+	var internalErr *types.InternalServerException
+	if errors.As(err, &internalErr) {
+		return nil, fmt.Errorf("unable to write to Timestream database %q table %q: %w",
+			t.DatabaseName, *writeRecordsInput.TableName, internalErr)
+	}
+
+	var operation *smithy.OperationError
+	if !errors.As(err, &operation) {
+		// Retry other, non-aws errors.
+		return nil, fmt.Errorf("unable to write to Timestream database %q table %q: %w",
+			t.DatabaseName, *writeRecordsInput.TableName, err)
+	}
+	t.logWriteToTimestreamError(err, writeRecordsInput.TableName)
+	return rejectAll(writeRecordsInput, err), nil
 }
 
 func (t *Timestream) logWriteToTimestreamError(err error, tableName *string) {
@@ -318,24 +377,34 @@ func (t *Timestream) logWriteToTimestreamError(err error, tableName *string) {
 		t.DatabaseName, *tableName, err.Error())
 }
 
-func (t *Timestream) createTableAndRetry(writeRecordsInput *timestreamwrite.WriteRecordsInput) error {
-	if t.CreateTableIfNotExists {
-		t.Log.Infof(
-			"Trying to create table %q in database %q, as 'CreateTableIfNotExists' config key is 'true'.",
-			*writeRecordsInput.TableName,
-			t.DatabaseName,
-		)
-		err := t.createTable(writeRecordsInput.TableName)
-		if err == nil {
-			t.Log.Infof("Table %q in database %q created. Retrying writing.", *writeRecordsInput.TableName, t.DatabaseName)
-			return t.writeToTimestream(writeRecordsInput, false)
-		}
-		t.Log.Errorf("Failed to create table %q in database %q: %s. Skipping metric!", *writeRecordsInput.TableName, t.DatabaseName, err.Error())
-	} else {
+func (t *Timestream) createTableAndRetry(writeRecordsInput *timestreamwrite.WriteRecordsInput) (map[int]error, error) {
+	if !t.CreateTableIfNotExists {
 		t.Log.Errorf("Not trying to create table %q in database %q, as 'CreateTableIfNotExists' config key is 'false'. Skipping metric!",
 			*writeRecordsInput.TableName, t.DatabaseName)
+		return rejectAll(writeRecordsInput, errors.New("table does not exist")), nil
 	}
-	return nil
+
+	t.Log.Infof(
+		"Trying to create table %q in database %q, as 'CreateTableIfNotExists' config key is 'true'.",
+		*writeRecordsInput.TableName,
+		t.DatabaseName,
+	)
+	err := t.createTable(writeRecordsInput.TableName)
+	if err != nil {
+		t.Log.Errorf("Failed to create table %q in database %q: %s. Skipping metric!", *writeRecordsInput.TableName, t.DatabaseName, err.Error())
+		return rejectAll(writeRecordsInput, err), nil
+	}
+	t.Log.Infof("Table %q in database %q created. Retrying writing.", *writeRecordsInput.TableName, t.DatabaseName)
+	return t.writeToTimestream(writeRecordsInput, false)
+}
+
+// rejectAll returns the given error for all records of the request
+func rejectAll(writeRecordsInput *timestreamwrite.WriteRecordsInput, err error) map[int]error {
+	errs := make(map[int]error, len(writeRecordsInput.Records))
+	for i := range writeRecordsInput.Records {
+		errs[i] = err
+	}
+	return errs
 }
 
 // createTable creates a Timestream table according to the configuration.
@@ -373,11 +442,25 @@ func (t *Timestream) createTable(tableName *string) error {
 // Telegraf Metrics are grouped by Name, Tag Keys and Time to use Timestream CommonAttributes.
 // Returns collection of write requests to be performed to Timestream.
 func (t *Timestream) TransformMetrics(metrics []telegraf.Metric) []*timestreamwrite.WriteRecordsInput {
-	writeRequests := make(map[string]*timestreamwrite.WriteRecordsInput, len(metrics))
-	for _, m := range metrics {
+	requests, _ := t.transformMetrics(metrics)
+
+	result := make([]*timestreamwrite.WriteRecordsInput, 0, len(requests))
+	for _, r := range requests {
+		result = append(result, r.input)
+	}
+	return result
+}
+
+// transformMetrics builds the write requests to Timestream together with the
+// index of the metric each record originates from. The indices of metrics
+// without any supported field are returned separately.
+func (t *Timestream) transformMetrics(metrics []telegraf.Metric) (requests []*writeRequest, skipped []int) {
+	writeRequests := make(map[string]*writeRequest, len(metrics))
+	for i, m := range metrics {
 		// build MeasureName, MeasureValue, MeasureValueType
 		records := t.buildWriteRecords(m)
 		if len(records) == 0 {
+			skipped = append(skipped, i)
 			continue
 		}
 
@@ -391,38 +474,44 @@ func (t *Timestream) TransformMetrics(metrics []telegraf.Metric) []*timestreamwr
 			tableName = m.Name()
 		}
 
-		if curr, ok := writeRequests[tableName]; !ok {
-			newWriteRecord := &timestreamwrite.WriteRecordsInput{
-				DatabaseName:     aws.String(t.DatabaseName),
-				TableName:        aws.String(tableName),
-				Records:          records,
-				CommonAttributes: &types.Record{},
+		curr, ok := writeRequests[tableName]
+		if !ok {
+			curr = &writeRequest{
+				input: &timestreamwrite.WriteRecordsInput{
+					DatabaseName:     aws.String(t.DatabaseName),
+					TableName:        aws.String(tableName),
+					CommonAttributes: &types.Record{},
+				},
 			}
-
-			writeRequests[tableName] = newWriteRecord
-		} else {
-			curr.Records = append(curr.Records, records...)
+			writeRequests[tableName] = curr
+		}
+		curr.input.Records = append(curr.input.Records, records...)
+		for range records {
+			curr.metrics = append(curr.metrics, i)
 		}
 	}
 
-	// Create result as array of WriteRecordsInput. Split requests over records count limit to smaller requests.
-	var result []*timestreamwrite.WriteRecordsInput
-	for _, writeRequest := range writeRequests {
-		if len(writeRequest.Records) > MaxRecordsPerCall {
-			for _, recordsPartition := range partitionRecords(MaxRecordsPerCall, writeRequest.Records) {
-				newWriteRecord := &timestreamwrite.WriteRecordsInput{
-					DatabaseName:     writeRequest.DatabaseName,
-					TableName:        writeRequest.TableName,
+	// Split requests over records count limit to smaller requests.
+	for _, req := range writeRequests {
+		if len(req.input.Records) <= MaxRecordsPerCall {
+			requests = append(requests, req)
+			continue
+		}
+
+		indices := partitionRecords(MaxRecordsPerCall, req.metrics)
+		for i, recordsPartition := range partitionRecords(MaxRecordsPerCall, req.input.Records) {
+			requests = append(requests, &writeRequest{
+				input: &timestreamwrite.WriteRecordsInput{
+					DatabaseName:     req.input.DatabaseName,
+					TableName:        req.input.TableName,
 					Records:          recordsPartition,
-					CommonAttributes: writeRequest.CommonAttributes,
-				}
-				result = append(result, newWriteRecord)
-			}
-		} else {
-			result = append(result, writeRequest)
+					CommonAttributes: req.input.CommonAttributes,
+				},
+				metrics: indices[i],
+			})
 		}
 	}
-	return result
+	return requests, skipped
 }
 
 func (t *Timestream) buildDimensions(point telegraf.Metric) []types.Dimension {
@@ -526,13 +615,13 @@ func (t *Timestream) buildMultiMeasureWriteRecords(point telegraf.Metric) []type
 // partitionRecords splits the Timestream records into smaller slices of a max size
 // so that are under the limit for the Timestream API call.
 // It returns the array of array of records.
-func partitionRecords(size int, records []types.Record) [][]types.Record {
+func partitionRecords[T any](size int, records []T) [][]T {
 	numberOfPartitions := len(records) / size
 	if len(records)%size != 0 {
 		numberOfPartitions++
 	}
 
-	partitions := make([][]types.Record, 0, numberOfPartitions)
+	partitions := make([][]T, 0, numberOfPartitions)
 	for i := 0; i < numberOfPartitions; i++ {
 		start := size * i
 		end := size * (i + 1)
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -578,6 +579,10 @@ func TestThrottlingErrorIsReturnedToTelegraf(t *testing.T) {
 
 	require.Error(t, err, "Expected an error to be returned to Telegraf, "+
 		"so that the write will be retried by Telegraf later.")
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
 }
 
 func TestRejectedRecordsErrorResultsInMetricsBeingSkipped(t *testing.T) {
@@ -602,9 +607,61 @@ func TestRejectedRecordsErrorResultsInMetricsBeingSkipped(t *testing.T) {
 
 	err := plugin.Write([]telegraf.Metric{input})
 
-	require.NoError(t, err, "Expected to silently swallow the RejectedRecordsException, "+
-		"as retrying this error doesn't make sense.")
+	// Retrying rejected records doesn't make sense so the metrics must be
+	// rejected instead of being kept for the next write
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Equal(t, []int{0}, werr.MetricsReject)
 }
+
+func TestRejectedRecordsPartialWrite(t *testing.T) {
+	WriteFactory = func(*common_aws.CredentialConfig) (WriteClient, error) {
+		return &mockTimestreamErrorClient{
+			ErrorToReturnOnWriteRecords: &types.RejectedRecordsException{
+				Message: aws.String("RejectedRecords Test"),
+				RejectedRecords: []types.RejectedRecord{
+					{RecordIndex: 1, Reason: aws.String("The record timestamp is outside the time range")},
+				},
+			},
+		}, nil
+	}
+
+	plugin := Timestream{
+		MappingMode:  MappingModeMultiTable,
+		DatabaseName: tsDbName,
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+	input := []telegraf.Metric{
+		testutil.MustMetric(
+			metricName1,
+			map[string]string{"tag1": "value1"},
+			map[string]interface{}{"value": float64(1)},
+			time1,
+		),
+		testutil.MustMetric(
+			metricName1,
+			map[string]string{"tag1": "value1"},
+			map[string]interface{}{"value": float64(2)},
+			time2,
+		),
+		testutil.MustMetric(
+			metricName1,
+			map[string]string{"tag1": "value1"},
+			map[string]interface{}{},
+			time2,
+		),
+	}
+
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0}, werr.MetricsAccept)
+	require.Equal(t, []int{1, 2}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 2)
+}
+
 func TestWriteWhenRequestsGreaterThanMaxWriteGoRoutinesCount(t *testing.T) {
 	t.Skip("Skipping test due to data race, will be re-visited")
 	const maxWriteRecordsCalls = 5
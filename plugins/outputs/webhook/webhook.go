@@ -0,0 +1,547 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
+	"github.com/influxdata/telegraf/plugins/common/ratelimiter"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	maxErrMsgLen       = 1024
+	defaultContentType = "application/json"
+)
+
+type Webhook struct {
+	URL               string                    `toml:"url"`
+	Method            string                    `toml:"method"`
+	Headers           map[string]*config.Secret `toml:"headers"`
+	ContentEncoding   string                    `toml:"content_encoding"`
+	GroupKey          string                    `toml:"group_key"`
+	RateLimit         int64                     `toml:"rate_limit"`
+	RateLimitPeriod   config.Duration           `toml:"rate_limit_period"`
+	RateLimitKey      string                    `toml:"rate_limit_key"`
+	RateLimitDrop     bool                      `toml:"rate_limit_drop"`
+	DedupWindow       config.Duration           `toml:"dedup_window"`
+	DedupKey          string                    `toml:"dedup_key"`
+	NonRetryableCodes []int                     `toml:"non_retryable_statuscodes"`
+	RetryBackoff      config.Duration           `toml:"retry_backoff"`
+	RetryBackoffMax   config.Duration           `toml:"retry_backoff_max"`
+	Log               telegraf.Logger           `toml:"-"`
+	common_http.HTTPClientConfig
+
+	serializer   telegraf.Serializer
+	encoder      internal.ContentEncoder
+	client       *http.Client
+	url          *template.Template
+	groupKey     *template.Template
+	rateLimitKey *template.Template
+	dedupKey     *template.Template
+	headers      map[string]*template.Template
+	limiters     map[string]*limiter
+	sent         map[string]time.Time
+	retries      map[string]*retry
+}
+
+// retry is the state of a URL with failing requests
+type retry struct {
+	failures int
+	until    time.Time
+}
+
+// limiter is the rate-limiter of a key along with the time of its last use
+type limiter struct {
+	*ratelimiter.RateLimiter
+	used time.Time
+}
+
+// request is a single webhook call for one or more metrics
+type request struct {
+	indices []int
+	metrics []telegraf.Metric
+}
+
+// sendError is returned for failed requests and indicates whether the
+// request should be retried
+type sendError struct {
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+}
+
+func (e *sendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+func (*Webhook) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *Webhook) SetSerializer(serializer telegraf.Serializer) {
+	w.serializer = serializer
+}
+
+func (w *Webhook) Init() error {
+	if w.URL == "" {
+		return errors.New("'url' setting required")
+	}
+
+	w.Method = strings.ToUpper(w.Method)
+	switch w.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("invalid 'method' setting %q", w.Method)
+	}
+
+	switch w.ContentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return fmt.Errorf("invalid 'content_encoding' setting %q", w.ContentEncoding)
+	}
+	encoder, err := internal.NewContentEncoder(w.ContentEncoding)
+	if err != nil {
+		return err
+	}
+	w.encoder = encoder
+
+	if w.RateLimit < 0 {
+		return fmt.Errorf("invalid 'rate_limit' setting %d", w.RateLimit)
+	}
+	if w.RateLimit > 0 && w.RateLimitPeriod <= 0 {
+		return errors.New("'rate_limit_period' setting required")
+	}
+
+	if w.RetryBackoff < 0 {
+		return fmt.Errorf("invalid 'retry_backoff' setting %s", time.Duration(w.RetryBackoff))
+	}
+	if w.RetryBackoffMax < w.RetryBackoff {
+		w.RetryBackoffMax = w.RetryBackoff
+	}
+
+	// Parse the templates
+	if w.url, err = parseTemplate("url", w.URL); err != nil {
+		return err
+	}
+	if w.groupKey, err = parseTemplate("group_key", w.GroupKey); err != nil {
+		return err
+	}
+	if w.rateLimitKey, err = parseTemplate("rate_limit_key", w.RateLimitKey); err != nil {
+		return err
+	}
+	if w.dedupKey, err = parseTemplate("dedup_key", w.DedupKey); err != nil {
+		return err
+	}
+
+	w.headers = make(map[string]*template.Template)
+	w.limiters = make(map[string]*limiter)
+	w.sent = make(map[string]time.Time)
+	w.retries = make(map[string]*retry)
+
+	return nil
+}
+
+func (w *Webhook) Connect() error {
+	client, err := w.HTTPClientConfig.CreateClient(context.Background(), w.Log)
+	if err != nil {
+		return err
+	}
+	w.client = client
+
+	return nil
+}
+
+func (w *Webhook) Close() error {
+	if w.client != nil {
+		w.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (w *Webhook) Write(metrics []telegraf.Metric) error {
+	werr := &internal.PartialWriteError{}
+	now := time.Now()
+	w.expire(now)
+
+	for _, r := range w.group(metrics, werr) {
+		err := w.process(r, now)
+		if err == nil {
+			werr.MetricsAccept = append(werr.MetricsAccept, r.indices...)
+			continue
+		}
+
+		var serr *sendError
+		if errors.As(err, &serr) && serr.retryable {
+			// Keep the metrics for the next write
+			w.Log.Errorf("Sending request failed: %v", err)
+			if werr.Err == nil {
+				werr.Err = err
+			}
+			continue
+		}
+
+		w.Log.Errorf("Request refused, dropping %d metric(s): %v", len(r.indices), err)
+		for _, idx := range r.indices {
+			werr.MetricsReject = append(werr.MetricsReject, idx)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+		}
+	}
+
+	if werr.Err == nil && len(werr.MetricsReject) == 0 {
+		return nil
+	}
+	if werr.Err == nil {
+		werr.Err = werr.MetricsRejectErrors[0]
+	}
+	return werr
+}
+
+// group splits the metrics into requests using the group key while keeping
+// the order of the metrics. Metrics failing to render the key are rejected.
+func (w *Webhook) group(metrics []telegraf.Metric, werr *internal.PartialWriteError) []*request {
+	if w.groupKey == nil {
+		requests := make([]*request, 0, len(metrics))
+		for i, m := range metrics {
+			requests = append(requests, &request{indices: []int{i}, metrics: []telegraf.Metric{m}})
+		}
+		return requests
+	}
+
+	var requests []*request
+	groups := make(map[string]*request)
+	for i, m := range metrics {
+		key, err := render(w.groupKey, m)
+		if err != nil {
+			w.Log.Errorf("Rendering group key failed: %v", err)
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			continue
+		}
+		r, found := groups[key]
+		if !found {
+			r = &request{}
+			groups[key] = r
+			requests = append(requests, r)
+		}
+		r.indices = append(r.indices, i)
+		r.metrics = append(r.metrics, m)
+	}
+	return requests
+}
+
+// process renders and sends the request unless it is suppressed due to
+// deduplication or rate-limiting
+func (w *Webhook) process(r *request, now time.Time) error {
+	// The first metric of a request is used for rendering the URL, headers
+	// and keys
+	first := r.metrics[0]
+
+	url, err := render(w.url, first)
+	if err != nil {
+		return fmt.Errorf("rendering URL failed: %w", err)
+	}
+	headers, err := w.renderHeaders(first)
+	if err != nil {
+		return err
+	}
+	body, err := w.serialize(r.metrics)
+	if err != nil {
+		return fmt.Errorf("serializing metrics failed: %w", err)
+	}
+
+	var dedupKey string
+	if w.DedupWindow > 0 {
+		dedupKey = url + "\n" + string(body)
+		if w.dedupKey != nil {
+			if dedupKey, err = render(w.dedupKey, first); err != nil {
+				return fmt.Errorf("rendering deduplication key failed: %w", err)
+			}
+		}
+		if _, found := w.sent[dedupKey]; found {
+			w.Log.Debugf("Suppressing duplicate request to %q", url)
+			return nil
+		}
+	}
+
+	// Keep the metrics for a later write if the URL is backing off after
+	// failures or the server asked us to wait
+	if r, found := w.retries[url]; found && now.Before(r.until) {
+		return &sendError{
+			err:       fmt.Errorf("postponing request to %q until %s", url, r.until.Format(time.RFC3339)),
+			retryable: true,
+		}
+	}
+
+	// Reserve the request in the rate-limiter and release it if sending fails
+	var l *limiter
+	var ratets time.Time
+	if w.RateLimit > 0 {
+		key, err := render(w.rateLimitKey, first)
+		if err != nil {
+			return fmt.Errorf("rendering rate-limit key failed: %w", err)
+		}
+		if l, err = w.limiter(key); err != nil {
+			return err
+		}
+		ratets = time.Now()
+		if l.Remaining(ratets) < 1 {
+			if w.RateLimitDrop {
+				w.Log.Debugf("Rate-limit exceeded for key %q, dropping request to %q", key, url)
+				return nil
+			}
+			return &sendError{
+				err:       fmt.Errorf("rate-limit exceeded for key %q", key),
+				retryable: true,
+			}
+		}
+		l.Accept(ratets, 1)
+		l.used = ratets
+	}
+
+	if err := w.send(url, headers, body); err != nil {
+		if l != nil {
+			l.Undo(ratets, 1)
+		}
+		var serr *sendError
+		if errors.As(err, &serr) && serr.retryable {
+			w.backoff(url, now, serr.retryAfter)
+		}
+		return err
+	}
+	delete(w.retries, url)
+
+	if w.DedupWindow > 0 {
+		w.sent[dedupKey] = now
+	}
+	return nil
+}
+
+func (w *Webhook) send(url string, headers map[string]string, body []byte) error {
+	payload, err := w.encoder.Encode(body)
+	if err != nil {
+		return fmt.Errorf("encoding body failed: %w", err)
+	}
+
+	req, err := http.NewRequest(w.Method, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request failed: %w", err)
+	}
+	req.Header.Set("User-Agent", internal.ProductToken())
+	req.Header.Set("Content-Type", defaultContentType)
+	if w.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, "host") {
+			req.Host = v
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return &sendError{err: err, retryable: true}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	var msg string
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxErrMsgLen))
+	if scanner.Scan() {
+		msg = scanner.Text()
+	}
+	serr := &sendError{
+		err:       fmt.Errorf("received status code %d from %q: %s", resp.StatusCode, url, msg),
+		retryable: w.isRetryable(resp.StatusCode),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		serr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return serr
+}
+
+// isRetryable returns false for status codes indicating an issue with the
+// request itself, i.e. client errors except for timeouts and rate-limiting,
+// and for the status codes configured as non-retryable
+func (w *Webhook) isRetryable(code int) bool {
+	for _, c := range w.NonRetryableCodes {
+		if code == c {
+			return false
+		}
+	}
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return code < 400 || code >= 500
+}
+
+func (w *Webhook) serialize(metrics []telegraf.Metric) ([]byte, error) {
+	if len(metrics) == 1 {
+		return w.serializer.Serialize(metrics[0])
+	}
+
+	// Serializers such as the template serializer require the raw metrics
+	plain := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if wm, ok := m.(telegraf.UnwrappableMetric); ok {
+			m = wm.Unwrap()
+		}
+		plain = append(plain, m)
+	}
+	return w.serializer.SerializeBatch(plain)
+}
+
+func (w *Webhook) renderHeaders(m telegraf.Metric) (map[string]string, error) {
+	headers := make(map[string]string, len(w.Headers))
+	for k, v := range w.Headers {
+		secret, err := v.Get()
+		if err != nil {
+			return nil, fmt.Errorf("getting header %q failed: %w", k, err)
+		}
+		value := secret.String()
+		secret.Destroy()
+
+		// Cache the templates as the header values only change for dynamic
+		// secrets
+		tmpl, found := w.headers[value]
+		if !found {
+			if tmpl, err = parseTemplate("header "+k, value); err != nil {
+				return nil, err
+			}
+			w.headers[value] = tmpl
+		}
+		if headers[k], err = render(tmpl, m); err != nil {
+			return nil, fmt.Errorf("rendering header %q failed: %w", k, err)
+		}
+	}
+	return headers, nil
+}
+
+func (w *Webhook) limiter(key string) (*limiter, error) {
+	if l, found := w.limiters[key]; found {
+		return l, nil
+	}
+
+	cfg := &ratelimiter.RateLimitConfig{
+		Limit:  config.Size(w.RateLimit),
+		Period: w.RateLimitPeriod,
+	}
+	rl, err := cfg.CreateRateLimiter()
+	if err != nil {
+		return nil, fmt.Errorf("creating rate-limiter failed: %w", err)
+	}
+	l := &limiter{RateLimiter: rl}
+	w.limiters[key] = l
+	return l, nil
+}
+
+// backoff postpones further requests to the URL after a failure. The delay
+// doubles with each consecutive failure up to the maximum backoff, a longer
+// delay requested by the server takes precedence.
+func (w *Webhook) backoff(url string, now time.Time, requested time.Duration) {
+	r, found := w.retries[url]
+	if !found {
+		r = &retry{}
+		w.retries[url] = r
+	}
+	r.failures++
+
+	delay := time.Duration(w.RetryBackoff)
+	for i := 1; i < r.failures && delay < time.Duration(w.RetryBackoffMax); i++ {
+		delay *= 2
+	}
+	delay = min(delay, time.Duration(w.RetryBackoffMax))
+	r.until = now.Add(max(delay, requested))
+}
+
+// expire removes the deduplication entries outside of the window, the
+// rate-limiters not used within the rate-limit period and the retry state of
+// URLs not failing within the maximum backoff
+func (w *Webhook) expire(now time.Time) {
+	for key, t := range w.sent {
+		if now.Sub(t) >= time.Duration(w.DedupWindow) {
+			delete(w.sent, key)
+		}
+	}
+	for key, l := range w.limiters {
+		if now.Sub(l.used) >= time.Duration(w.RateLimitPeriod) {
+			delete(w.limiters, key)
+		}
+	}
+	for url, r := range w.retries {
+		if now.Sub(r.until) >= time.Duration(w.RetryBackoffMax) {
+			delete(w.retries, url)
+		}
+	}
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s template failed: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// render executes the template with the given metric, an unset template
+// renders to an empty string
+func render(tmpl *template.Template, m telegraf.Metric) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	if wm, ok := m.(telegraf.UnwrappableMetric); ok {
+		m = wm.Unwrap()
+	}
+	tm, ok := m.(telegraf.TemplateMetric)
+	if !ok {
+		return "", fmt.Errorf("metric of type %T is not a template metric", m)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, tm); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func init() {
+	outputs.Add("webhook", func() telegraf.Output {
+		return &Webhook{
+			Method:          http.MethodPost,
+			RetryBackoff:    config.Duration(time.Second),
+			RetryBackoffMax: config.Duration(5 * time.Minute),
+		}
+	})
+}
@@ -0,0 +1,438 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newPlugin(t *testing.T, url string) *Webhook {
+	creator, found := outputs.Outputs["webhook"]
+	require.True(t, found)
+	plugin := creator().(*Webhook)
+	plugin.URL = url
+	plugin.Log = testutil.Logger{}
+
+	serializer := &template.Serializer{
+		Template:      `{{.Name}} {{.Tag "host"}} {{.Field "value"}}`,
+		BatchTemplate: `{{range .}}{{.Field "value"}};{{end}}`,
+	}
+	require.NoError(t, serializer.Init())
+	plugin.SetSerializer(serializer)
+
+	return plugin
+}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Webhook)
+		expected string
+	}{
+		{
+			name:     "missing url",
+			modify:   func(w *Webhook) { w.URL = "" },
+			expected: "'url' setting required",
+		},
+		{
+			name:     "invalid method",
+			modify:   func(w *Webhook) { w.Method = "GET" },
+			expected: "invalid 'method' setting",
+		},
+		{
+			name:     "invalid content encoding",
+			modify:   func(w *Webhook) { w.ContentEncoding = "br" },
+			expected: "invalid 'content_encoding' setting",
+		},
+		{
+			name:     "missing rate limit period",
+			modify:   func(w *Webhook) { w.RateLimit = 1 },
+			expected: "'rate_limit_period' setting required",
+		},
+		{
+			name:     "invalid retry backoff",
+			modify:   func(w *Webhook) { w.RetryBackoff = config.Duration(-time.Second) },
+			expected: "invalid 'retry_backoff' setting",
+		},
+		{
+			name:     "invalid template",
+			modify:   func(w *Webhook) { w.GroupKey = "{{.Tag" },
+			expected: "parsing group_key template failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, "http://localhost")
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL+`/hooks/{{.Tag "team"}}`)
+	plugin.Headers = map[string]*config.Secret{
+		"X-Host": newSecret(`{{.Tag "host"}}`),
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("alert", map[string]string{"host": "a", "team": "db"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("alert", map[string]string{"host": "b", "team": "web"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	expected := []received{
+		{path: "/hooks/db", host: "a", contentType: "application/json", body: "alert a 1"},
+		{path: "/hooks/web", host: "b", contentType: "application/json", body: "alert b 2"},
+	}
+	require.Equal(t, expected, server.requests())
+}
+
+func TestWriteGrouped(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.GroupKey = `{{.Tag "host"}}`
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("alert", map[string]string{"host": "b"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	// Groups with a single metric use the single-metric template
+	actual := server.requests()
+	require.Len(t, actual, 2)
+	require.Equal(t, "1;3;", actual[0].body)
+	require.Equal(t, "alert b 2", actual[1].body)
+}
+
+func TestDeduplication(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.DedupWindow = config.Duration(time.Hour)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m1 := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	m2 := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 2}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m1, m1}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m1, m2}))
+
+	actual := server.requests()
+	require.Len(t, actual, 2)
+	require.Equal(t, "alert a 1", actual[0].body)
+	require.Equal(t, "alert a 2", actual[1].body)
+
+	// Requests must be sent again after the window elapsed
+	plugin.expire(time.Now().Add(2 * time.Hour))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m1}))
+	require.Len(t, server.requests(), 3)
+}
+
+func TestRateLimit(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.RateLimit = 2
+	plugin.RateLimitPeriod = config.Duration(time.Hour)
+	plugin.RateLimitKey = `{{.Tag "host"}}`
+	plugin.RateLimitDrop = true
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := make([]telegraf.Metric, 0, 4)
+	for i, host := range []string{"a", "a", "b", "a"} {
+		input = append(input, metric.New("alert", map[string]string{"host": host}, map[string]interface{}{"value": i}, time.Unix(0, 0)))
+	}
+
+	// Rate-limited metrics must be dropped without error
+	require.NoError(t, plugin.Write(input))
+	actual := server.requests()
+	require.Len(t, actual, 3)
+	require.Equal(t, "alert a 0", actual[0].body)
+	require.Equal(t, "alert a 1", actual[1].body)
+	require.Equal(t, "alert b 2", actual[2].body)
+}
+
+func TestRateLimitKeep(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.RateLimit = 1
+	plugin.RateLimitPeriod = config.Duration(time.Hour)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+	}
+
+	// Rate-limited metrics must be kept for a later write
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorContains(t, err, "rate-limit exceeded")
+	require.Equal(t, []int{0}, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
+	require.Len(t, server.requests(), 1)
+}
+
+func TestRateLimitExpiry(t *testing.T) {
+	server := newServer(t)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.RateLimit = 1
+	plugin.RateLimitPeriod = config.Duration(50 * time.Millisecond)
+	plugin.RateLimitKey = `{{.Tag "host"}}`
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	input := []telegraf.Metric{
+		metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("alert", map[string]string{"host": "b"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+	require.Len(t, server.requests(), 2)
+	require.Len(t, plugin.limiters, 2)
+
+	// Limiters idle for longer than the period must be removed
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, plugin.Write(input[:1]))
+	require.Len(t, server.requests(), 3)
+	require.Len(t, plugin.limiters, 1)
+	require.Contains(t, plugin.limiters, "a")
+}
+
+func TestRetry(t *testing.T) {
+	server := newServer(t, http.StatusServiceUnavailable)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.RetryBackoff = 0
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	// Failed requests must not be retried within the write but the metrics
+	// must be kept for the next write
+	m := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	err := plugin.Write([]telegraf.Metric{m})
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
+	require.Len(t, server.requests(), 1)
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Len(t, server.requests(), 2)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	server := newServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	plugin := newPlugin(t, server.URL)
+	plugin.RetryBackoff = config.Duration(time.Minute)
+	plugin.RetryBackoffMax = config.Duration(3 * time.Minute)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.Error(t, plugin.Write([]telegraf.Metric{m}))
+	require.Len(t, server.requests(), 1)
+	require.Equal(t, 1, plugin.retries[server.URL].failures)
+	require.WithinDuration(t, time.Now().Add(time.Minute), plugin.retries[server.URL].until, time.Second)
+
+	// Requests must be postponed while backing off and the metrics kept
+	err := plugin.Write([]telegraf.Metric{m})
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorContains(t, err, "postponing request")
+	require.Empty(t, werr.MetricsReject)
+	require.Len(t, server.requests(), 1)
+
+	// The delay must double with consecutive failures
+	plugin.retries[server.URL].until = time.Now().Add(-time.Second)
+	require.Error(t, plugin.Write([]telegraf.Metric{m}))
+	require.Len(t, server.requests(), 2)
+	require.Equal(t, 2, plugin.retries[server.URL].failures)
+	require.WithinDuration(t, time.Now().Add(2*time.Minute), plugin.retries[server.URL].until, time.Second)
+
+	// Succeeding requests must reset the backoff
+	plugin.retries[server.URL].until = time.Now().Add(-time.Second)
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Len(t, server.requests(), 3)
+	require.NotContains(t, plugin.retries, server.URL)
+}
+
+func TestRetryAfter(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if count.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.Error(t, plugin.Write([]telegraf.Metric{m}))
+	require.EqualValues(t, 1, count.Load())
+
+	// The request must be delayed as requested by the server and the metrics
+	// must be kept
+	err := plugin.Write([]telegraf.Metric{m})
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorContains(t, err, "postponing request")
+	require.Empty(t, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
+	require.EqualValues(t, 1, count.Load())
+
+	// Sending must resume once the delay elapsed
+	require.WithinDuration(t, time.Now().Add(time.Hour), plugin.retries[server.URL].until, time.Second)
+	plugin.retries[server.URL].until = time.Now().Add(-time.Second)
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.EqualValues(t, 2, count.Load())
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		nonRetryable []int
+		requests     int
+		accept       []int
+		reject       []int
+	}{
+		{
+			name:     "client error",
+			status:   http.StatusBadRequest,
+			requests: 1,
+			reject:   []int{0},
+		},
+		{
+			name:     "server error",
+			status:   http.StatusInternalServerError,
+			requests: 1,
+		},
+		{
+			name:         "non-retryable server error",
+			status:       http.StatusInternalServerError,
+			nonRetryable: []int{http.StatusInternalServerError},
+			requests:     1,
+			reject:       []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t, tt.status, tt.status, tt.status)
+
+			plugin := newPlugin(t, server.URL)
+			plugin.NonRetryableCodes = tt.nonRetryable
+			require.NoError(t, plugin.Init())
+			require.NoError(t, plugin.Connect())
+			defer plugin.Close()
+
+			m := metric.New("alert", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+			err := plugin.Write([]telegraf.Metric{m})
+			var werr *internal.PartialWriteError
+			require.ErrorAs(t, err, &werr)
+			require.Equal(t, tt.accept, werr.MetricsAccept)
+			require.Equal(t, tt.reject, werr.MetricsReject)
+			require.Len(t, server.requests(), tt.requests)
+		})
+	}
+}
+
+type received struct {
+	path        string
+	host        string
+	contentType string
+	body        string
+}
+
+// server records the received requests and responds with the given status
+// codes before responding with success
+type server struct {
+	*httptest.Server
+	codes    []int
+	received []received
+
+	sync.Mutex
+}
+
+func newServer(t *testing.T, codes ...int) *server {
+	s := &server{codes: codes}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		s.Lock()
+		defer s.Unlock()
+		s.received = append(s.received, received{
+			path:        r.URL.Path,
+			host:        r.Header.Get("X-Host"),
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		})
+		if len(s.codes) > 0 {
+			w.WriteHeader(s.codes[0])
+			s.codes = s.codes[1:]
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *server) requests() []received {
+	s.Lock()
+	defer s.Unlock()
+	return append([]received(nil), s.received...)
+}
+
+func newSecret(s string) *config.Secret {
+	secret := config.NewSecret([]byte(s))
+	return &secret
+}
@@ -266,7 +266,93 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 }
 
 func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
-	lineReader := bufio.NewReader(r)
+	csvReader, err := p.readHeader(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, record := range table {
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseStream parses the CSV data read from the given reader record by record
+// and calls the given function for each metric.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	// Replacing the delimiter requires the whole data so fall back to parsing
+	// everything at once
+	if p.invalidDelimiter {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil && !errors.Is(err, parsers.ErrEOF) {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Reset the parser according to the specified mode
+	if p.ResetMode == "always" {
+		p.Reset()
+	}
+
+	csvReader, err := p.readHeader(bufio.NewReader(r))
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	csvReader.ReuseRecord = true
+
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
+// readHeader skips the configured rows, parses the metadata and header rows
+// and returns a CSV reader positioned at the first data row.
+func (p *Parser) readHeader(lineReader *bufio.Reader) (*csv.Reader, error) {
 	// skip first rows
 	for p.remainingSkipRows > 0 {
 		line, err := lineReader.ReadString('\n')
@@ -321,24 +407,7 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 		p.gotColumnNames = true
 	}
 
-	table, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	metrics := make([]telegraf.Metric, 0)
-	for _, record := range table {
-		m, err := p.parseRecord(record)
-		if err != nil {
-			if p.SkipErrors {
-				p.Log.Debugf("Parsing error: %v", err)
-				continue
-			}
-			return metrics, err
-		}
-		metrics = append(metrics, m)
-	}
-	return metrics, nil
+	return csvReader, nil
 }
 
 func (p *Parser) parseRecord(record []string) (telegraf.Metric, error) {
@@ -1576,3 +1576,40 @@ func BenchmarkParsing(b *testing.B) {
 		plugin.Parse([]byte(benchmarkData))
 	}
 }
+
+func TestParseStreamRows(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:  1,
+		TagColumns:      []string{"host"},
+		TimestampColumn: "time",
+		TimestampFormat: "unix",
+		MetricName:      "test",
+	}
+	require.NoError(t, p.Init())
+
+	input := `host,value,time
+a,1,1577923199
+b,2,1577923200
+`
+
+	var actual []telegraf.Metric
+	err := p.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": int64(1)}, time.Unix(1577923199, 0)),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(2)}, time.Unix(1577923200, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Stop on errors returned by the callback
+	callbackErr := errors.New("callback failed")
+	p.Reset()
+	err = p.ParseStream(strings.NewReader(input), func(telegraf.Metric) error {
+		return callbackErr
+	})
+	require.ErrorIs(t, err, callbackErr)
+}
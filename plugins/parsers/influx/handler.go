@@ -3,6 +3,8 @@ package influx
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -15,6 +17,13 @@ type MetricHandler struct {
 	timePrecision time.Duration
 	timeFunc      TimeFunc
 	metric        telegraf.Metric
+
+	// Repair and strict-validation settings, stats are only required in
+	// repair mode
+	repair    bool
+	strict    bool
+	validName *regexp.Regexp
+	stats     *repairStats
 }
 
 func NewMetricHandler() *MetricHandler {
@@ -38,6 +47,38 @@ func (h *MetricHandler) SetTimeFunc(f TimeFunc) {
 	h.timeFunc = f
 }
 
+// copyValidation takes over the repair and validation settings of the given
+// handler.
+func (h *MetricHandler) copyValidation(other *MetricHandler) {
+	h.repair = other.repair
+	h.strict = other.strict
+	h.validName = other.validName
+	h.stats = other.stats
+}
+
+func (h *MetricHandler) validate(kind, name string) error {
+	if h.strict && !h.validName.MatchString(name) {
+		return fmt.Errorf("invalid character in %s %q", kind, name)
+	}
+	return nil
+}
+
+func (h *MetricHandler) addField(key string, value interface{}) error {
+	if (h.strict || h.repair) && h.metric.HasField(key) {
+		if h.strict {
+			return fmt.Errorf("duplicate field %q", key)
+		}
+		// Keep the first occurrence of the field
+		h.stats.duplicateFields.Incr(1)
+		return nil
+	}
+	if err := h.validate("field key", key); err != nil {
+		return err
+	}
+	h.metric.AddField(key, value)
+	return nil
+}
+
 func (h *MetricHandler) Metric() telegraf.Metric {
 	if h.metric.Time().IsZero() {
 		h.metric.SetTime(h.timeFunc().Truncate(h.timePrecision))
@@ -48,12 +89,15 @@ func (h *MetricHandler) Metric() telegraf.Metric {
 func (h *MetricHandler) SetMeasurement(name []byte) error {
 	h.metric = metric.New(nameUnescape(name),
 		nil, nil, time.Time{})
-	return nil
+	return h.validate("measurement", h.metric.Name())
 }
 
 func (h *MetricHandler) AddTag(key, value []byte) error {
 	tk := unescape(key)
 	tv := unescape(value)
+	if err := h.validate("tag key", tk); err != nil {
+		return err
+	}
 	h.metric.AddTag(tk, tv)
 	return nil
 }
@@ -68,8 +112,7 @@ func (h *MetricHandler) AddInt(key, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
-	return nil
+	return h.addField(fk, fv)
 }
 
 func (h *MetricHandler) AddUint(key, value []byte) error {
@@ -82,8 +125,7 @@ func (h *MetricHandler) AddUint(key, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
-	return nil
+	return h.addField(fk, fv)
 }
 
 func (h *MetricHandler) AddFloat(key, value []byte) error {
@@ -96,15 +138,13 @@ func (h *MetricHandler) AddFloat(key, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
-	return nil
+	return h.addField(fk, fv)
 }
 
 func (h *MetricHandler) AddString(key, value []byte) error {
 	fk := unescape(key)
 	fv := stringFieldUnescape(value)
-	h.metric.AddField(fk, fv)
-	return nil
+	return h.addField(fk, fv)
 }
 
 func (h *MetricHandler) AddBool(key, value []byte) error {
@@ -113,8 +153,7 @@ func (h *MetricHandler) AddBool(key, value []byte) error {
 	if err != nil {
 		return errors.New("unparsable bool")
 	}
-	h.metric.AddField(fk, fv)
-	return nil
+	return h.addField(fk, fv)
 }
 
 func (h *MetricHandler) SetTimestamp(tm []byte) error {
@@ -128,7 +167,20 @@ func (h *MetricHandler) SetTimestamp(tm []byte) error {
 	}
 
 	// time precision is overloaded to mean time unit here
-	ns := v * int64(h.timePrecision)
+	precision := h.timePrecision
+	if (h.strict || h.repair) && !fitsPrecision(v, precision) {
+		// The timestamp overflows with the configured precision
+		if h.strict {
+			return errors.New("timestamp out of range")
+		}
+		// Assume the timestamp is given in a finer precision
+		precision = fitPrecision(v, precision)
+		if precision == 0 {
+			return errors.New("timestamp out of range")
+		}
+		h.stats.timestamps.Incr(1)
+	}
+	ns := v * int64(precision)
 	h.metric.SetTime(time.Unix(0, ns))
 	return nil
 }
@@ -87,6 +87,15 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("metric parse error: %s at %d:%d: %q", e.Err, e.Line, e.Column, buffer)
 }
 
+// ErrorOffset returns the byte offset of the error within the parsed data or
+// -1 if the offset cannot be determined.
+func (e *ParseError) ErrorOffset() int {
+	if e.buf == "" {
+		return -1
+	}
+	return nthIndexAny(e.buf, "\n", int(e.Line-1)) + 1 + int(e.Column) - 1
+}
+
 // convertToParseError attempts to convert a lineprotocol.DecodeError to a ParseError
 func convertToParseError(input []byte, rawErr error) error {
 	var decErr *lineprotocol.DecodeError
@@ -1,9 +1,11 @@
 package influx
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -58,10 +60,18 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("metric parse error: %s at %d:%d: %q", e.msg, e.LineNumber, e.Column, buffer)
 }
 
+// ErrorOffset returns the byte offset of the error within the parsed data.
+func (e *ParseError) ErrorOffset() int {
+	return e.Offset
+}
+
 // Parser is an InfluxDB Line Protocol parser that implements the
 // parsers.Parser interface.
 type Parser struct {
 	InfluxTimestampPrecision config.Duration   `toml:"influx_timestamp_precision"`
+	InfluxRepair             bool              `toml:"influx_repair"`
+	InfluxStrict             bool              `toml:"influx_strict"`
+	InfluxStrictCharset      string            `toml:"influx_strict_charset"`
 	DefaultTags              map[string]string `toml:"-"`
 	// If set to "series" a series machine will be initialized, defaults to regular machine
 	Type string `toml:"-"`
@@ -69,6 +79,7 @@ type Parser struct {
 	sync.Mutex
 	*machine
 	handler *MetricHandler
+	parent  string
 }
 
 func (p *Parser) SetTimeFunc(f TimeFunc) {
@@ -92,6 +103,12 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 		}
 
 		if err != nil {
+			if p.InfluxRepair {
+				if m := p.repair(lineAt(input, p.machine.LineOffset())); m != nil {
+					metrics = append(metrics, m)
+					continue
+				}
+			}
 			return nil, &ParseError{
 				Offset:     p.machine.Position(),
 				LineOffset: p.machine.LineOffset(),
@@ -127,6 +144,115 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metrics[0], nil
 }
 
+// ParseStream parses line protocol read from the given reader and calls the
+// given function for each metric without reading the whole data into memory.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	// The series machine is not available for streams so fall back to parsing
+	// the data at once.
+	if p.Type == "series" {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Repairing requires the full line, so parse the data line by line
+	if p.InfluxRepair {
+		return p.parseStreamLines(r, fn)
+	}
+
+	sp := NewStreamParser(r)
+	p.Lock()
+	sp.SetTimePrecision(p.handler.timePrecision)
+	sp.SetTimeFunc(p.handler.timeFunc)
+	sp.handler.copyValidation(p.handler)
+	p.Unlock()
+
+	for {
+		m, err := sp.Next()
+		if errors.Is(err, EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			continue
+		}
+		p.applyDefaultTagsSingle(m)
+
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Parser) parseStreamLines(r io.Reader, fn func(telegraf.Metric) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			metrics, perr := p.Parse(line)
+			if perr != nil {
+				return perr
+			}
+			for _, m := range metrics {
+				if err := fn(m); err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// repair tries to fix problems in the given line and returns the resulting
+// metric or nil if the line cannot be repaired.
+func (p *Parser) repair(line []byte) telegraf.Metric {
+	repaired, escapes, emptyTags := repairLine(line)
+	if repaired == nil {
+		return nil
+	}
+
+	// Parse the repaired line with a separate machine to not interfere
+	// with the state of the data currently being parsed.
+	machine := NewMachine(p.handler)
+	machine.SetData(repaired)
+	var m telegraf.Metric
+	for {
+		err := machine.Next()
+		if errors.Is(err, EOF) {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		m = p.handler.Metric()
+	}
+	if m == nil {
+		return nil
+	}
+
+	p.handler.stats.escapes.Incr(int64(escapes))
+	p.handler.stats.emptyTags.Incr(int64(emptyTags))
+	return m
+}
+
 func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
@@ -166,13 +292,32 @@ func (p *Parser) Init() error {
 		return fmt.Errorf("invalid time precision: %d", p.InfluxTimestampPrecision)
 	}
 
+	if p.InfluxRepair && p.InfluxStrict {
+		return errors.New("'influx_repair' and 'influx_strict' are mutually exclusive")
+	}
+	if p.InfluxRepair {
+		p.handler.repair = true
+		p.handler.stats = newRepairStats(p.parent)
+	}
+	if p.InfluxStrict {
+		if p.InfluxStrictCharset == "" {
+			p.InfluxStrictCharset = "A-Za-z0-9_.:-"
+		}
+		re, err := regexp.Compile("^[" + p.InfluxStrictCharset + "]+$")
+		if err != nil {
+			return fmt.Errorf("invalid 'influx_strict_charset' setting %q: %w", p.InfluxStrictCharset, err)
+		}
+		p.handler.strict = true
+		p.handler.validName = re
+	}
+
 	return nil
 }
 
 func init() {
 	parsers.Add("influx",
-		func(string) telegraf.Parser {
-			return &Parser{}
+		func(parent string) telegraf.Parser {
+			return &Parser{parent: parent}
 		},
 	)
 }
@@ -1038,3 +1038,223 @@ func BenchmarkParsing(b *testing.B) {
 		plugin.Parse([]byte(benchmarkData))
 	}
 }
+
+func TestParserParseStream(t *testing.T) {
+	for _, tt := range ptests {
+		if tt.err != nil {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			parser := Parser{}
+			require.NoError(t, parser.Init())
+			parser.SetTimeFunc(DefaultTime)
+			if tt.timeFunc != nil {
+				parser.SetTimeFunc(tt.timeFunc)
+			}
+
+			var metrics []telegraf.Metric
+			err := parser.ParseStream(bytes.NewBuffer(tt.input), func(m telegraf.Metric) error {
+				metrics = append(metrics, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.metrics, metrics)
+		})
+	}
+}
+
+func TestParserParseStreamDefaultTags(t *testing.T) {
+	parser := Parser{}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"host": "localhost"})
+
+	var metrics []telegraf.Metric
+	err := parser.ParseStream(bytes.NewBufferString("cpu value=42 0\ncpu,host=a value=23 0\n"), func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "localhost"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 23.0}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParserRepair(t *testing.T) {
+	tests := []struct {
+		name      string
+		precision config.Duration
+		input     string
+		expected  []telegraf.Metric
+	}{
+		{
+			name:  "duplicate field",
+			input: "cpu value=42,value=23 0\n",
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+			},
+		},
+		{
+			name:      "timestamp precision",
+			precision: config.Duration(time.Second),
+			input:     "cpu value=42 1700000000\ncpu value=42 1700000000123\ncpu value=42 1700000000123456789\n",
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(1700000000, 0)),
+				metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(1700000000, 123000000)),
+				metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(1700000000, 123456789)),
+			},
+		},
+		{
+			name:  "unescaped equal sign",
+			input: "cpu,host=a=b,cpu=c=d=e value=42 0\n",
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "a=b", "cpu": "c=d=e"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+			},
+		},
+		{
+			name:  "stray comma",
+			input: "cpu,host=a,b,c value=42 0\nmem,total value=1 0\n",
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "a,b,c"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+				metric.New("mem,total", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+			},
+		},
+		{
+			name:  "empty tag",
+			input: "cpu,host=,cpu=0 value=42 0\r\ncpu value=23 0\n",
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"cpu": "0"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+				metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 23.0}, time.Unix(0, 0)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				InfluxTimestampPrecision: tt.precision,
+				InfluxRepair:             true,
+			}
+			require.NoError(t, parser.Init())
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
+
+			var streamed []telegraf.Metric
+			err = parser.ParseStream(strings.NewReader(tt.input), func(m telegraf.Metric) error {
+				streamed = append(streamed, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, streamed, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParserRepairStats(t *testing.T) {
+	parser := &Parser{InfluxRepair: true, parent: "repair_stats_test"}
+	require.NoError(t, parser.Init())
+	stats := parser.handler.stats
+	before := []int64{stats.duplicateFields.Get(), stats.timestamps.Get(), stats.escapes.Get(), stats.emptyTags.Get()}
+
+	_, err := parser.Parse([]byte("cpu,host=a=b,x= value=1,value=2 0\n"))
+	require.NoError(t, err)
+
+	require.EqualValues(t, 1, stats.duplicateFields.Get()-before[0])
+	require.EqualValues(t, 0, stats.timestamps.Get()-before[1])
+	require.EqualValues(t, 1, stats.escapes.Get()-before[2])
+	require.EqualValues(t, 1, stats.emptyTags.Get()-before[3])
+}
+
+func TestParserRepairUnfixable(t *testing.T) {
+	parser := &Parser{InfluxRepair: true}
+	require.NoError(t, parser.Init())
+
+	for _, input := range []string{
+		`cpu value="a"b" 0`,
+		`cpu,=a value=42 0`,
+		`cpu value=42 99999999999999999999`,
+	} {
+		_, err := parser.Parse([]byte(input))
+		var perr *ParseError
+		require.ErrorAs(t, err, &perr, input)
+	}
+}
+
+func TestParserStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		charset  string
+		input    string
+		expected string
+	}{
+		{
+			name:     "valid",
+			input:    "cpu_load,host=a.b value=42 0\n",
+			expected: "",
+		},
+		{
+			name:     "invalid measurement",
+			input:    "cpu\\ load value=42 0\n",
+			expected: `invalid character in measurement "cpu load"`,
+		},
+		{
+			name:     "invalid tag key",
+			input:    "cpu,h%st=a value=42 0\n",
+			expected: `invalid character in tag key "h%st"`,
+		},
+		{
+			name:     "invalid field key",
+			input:    "cpu valüe=42 0\n",
+			expected: `invalid character in field key "valüe"`,
+		},
+		{
+			name:     "custom charset",
+			charset:  "a-z",
+			input:    "cpu value2=42 0\n",
+			expected: `invalid character in field key "value2"`,
+		},
+		{
+			name:     "duplicate field",
+			input:    "cpu value=42,value=23 0\n",
+			expected: `duplicate field "value"`,
+		},
+		{
+			name:     "timestamp overflow",
+			input:    "cpu value=42 1700000000123456789\n",
+			expected: "timestamp out of range",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				InfluxStrict:        true,
+				InfluxStrictCharset: tt.charset,
+			}
+			if tt.name == "timestamp overflow" {
+				parser.InfluxTimestampPrecision = config.Duration(time.Millisecond)
+			}
+			require.NoError(t, parser.Init())
+			_, err := parser.Parse([]byte(tt.input))
+			if tt.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParserRepairStrictInvalid(t *testing.T) {
+	parser := &Parser{InfluxRepair: true, InfluxStrict: true}
+	require.ErrorContains(t, parser.Init(), "mutually exclusive")
+
+	parser = &Parser{InfluxStrict: true, InfluxStrictCharset: "a-"}
+	require.NoError(t, parser.Init())
+
+	parser = &Parser{InfluxStrict: true, InfluxStrictCharset: "z-a"}
+	require.ErrorContains(t, parser.Init(), "invalid 'influx_strict_charset' setting")
+}
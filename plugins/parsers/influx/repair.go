@@ -0,0 +1,162 @@
+package influx
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// repairStats counts the repairs done by the parser per kind of problem
+type repairStats struct {
+	duplicateFields selfstat.Stat
+	timestamps      selfstat.Stat
+	escapes         selfstat.Stat
+	emptyTags       selfstat.Stat
+}
+
+func newRepairStats(parent string) *repairStats {
+	tags := map[string]string{"parent": parent}
+	return &repairStats{
+		duplicateFields: selfstat.Register("influx_parser", "repaired_duplicate_fields", tags),
+		timestamps:      selfstat.Register("influx_parser", "repaired_timestamps", tags),
+		escapes:         selfstat.Register("influx_parser", "repaired_escapes", tags),
+		emptyTags:       selfstat.Register("influx_parser", "repaired_empty_tags", tags),
+	}
+}
+
+// fitsPrecision checks if the timestamp value with the given precision can be
+// represented in nanoseconds.
+func fitsPrecision(v int64, precision time.Duration) bool {
+	return v <= math.MaxInt64/int64(precision) && v >= math.MinInt64/int64(precision)
+}
+
+// fitPrecision returns the coarsest precision finer than the given one for
+// which the timestamp value can be represented in nanoseconds. If no such
+// precision exists, zero is returned.
+func fitPrecision(v int64, precision time.Duration) time.Duration {
+	for _, p := range []time.Duration{time.Second, time.Millisecond, time.Microsecond, time.Nanosecond} {
+		if p >= precision {
+			continue
+		}
+		if fitsPrecision(v, p) {
+			return p
+		}
+	}
+	return 0
+}
+
+// repairLine tries to fix the series part, i.e. the measurement and tags, of
+// the given line. Unescaped equal signs in tag values and stray commas are
+// escaped and tags with empty values are dropped. The function returns the
+// repaired line and the number of escaped characters and dropped tags. If the
+// line cannot be repaired nil is returned.
+func repairLine(line []byte) (repaired []byte, escapes, emptyTags int) {
+	// Find the end of the series part being the first unescaped space
+	end := indexUnescaped(line, ' ')
+	if end < 0 {
+		return nil, 0, 0
+	}
+
+	// Split the series into measurement and tag pairs. Elements without an
+	// equal sign are the result of a stray comma and are merged into the
+	// preceding element.
+	elements := splitUnescaped(line[:end], ',')
+	series := append(make([]byte, 0, len(line)+8), elements[0]...)
+	var current []byte
+	for _, element := range elements[1:] {
+		idx := indexUnescaped(element, '=')
+		if idx < 0 {
+			escapes++
+			if current == nil {
+				series = append(series, '\\', ',')
+				series = append(series, element...)
+			} else {
+				current = append(current, '\\', ',')
+				current = append(current, element...)
+			}
+			continue
+		}
+		if idx == 0 {
+			return nil, 0, 0
+		}
+
+		// Flush the previous tag
+		if current != nil {
+			series = appendTag(series, current, &emptyTags)
+		}
+
+		// Escape equal signs in the tag value
+		current = append(make([]byte, 0, len(element)+2), element[:idx+1]...)
+		for i := idx + 1; i < len(element); i++ {
+			if element[i] == '\\' && i+1 < len(element) {
+				current = append(current, element[i], element[i+1])
+				i++
+				continue
+			}
+			if element[i] == '=' {
+				current = append(current, '\\')
+				escapes++
+			}
+			current = append(current, element[i])
+		}
+	}
+	if current != nil {
+		series = appendTag(series, current, &emptyTags)
+	}
+
+	if escapes == 0 && emptyTags == 0 {
+		return nil, 0, 0
+	}
+	return append(series, line[end:]...), escapes, emptyTags
+}
+
+func appendTag(series, tag []byte, emptyTags *int) []byte {
+	if tag[len(tag)-1] == '=' {
+		*emptyTags++
+		return series
+	}
+	series = append(series, ',')
+	return append(series, tag...)
+}
+
+// indexUnescaped returns the index of the first occurrence of c not being
+// escaped by a backslash or -1 if none is found.
+func indexUnescaped(b []byte, c byte) int {
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped splits the given data at all occurrences of c not being
+// escaped by a backslash.
+func splitUnescaped(b []byte, c byte) [][]byte {
+	var parts [][]byte
+	for {
+		idx := indexUnescaped(b, c)
+		if idx < 0 {
+			return append(parts, b)
+		}
+		parts = append(parts, b[:idx])
+		b = b[idx+1:]
+	}
+}
+
+// lineAt returns the line starting at the given offset without line-ending
+func lineAt(data []byte, offset int) []byte {
+	if offset > len(data) {
+		return nil
+	}
+	line := data[offset:]
+	if idx := bytes.IndexByte(line, '\n'); idx >= 0 {
+		line = line[:idx]
+	}
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
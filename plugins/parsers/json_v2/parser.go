@@ -1,6 +1,7 @@
 package json_v2
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -131,6 +132,33 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return p.parseCriticalPath(input)
 }
 
+// ParseStream reads a sequence of JSON documents, e.g. newline-delimited JSON,
+// from the given reader and calls the given function for each metric. Only
+// one document is kept in memory at a time.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	body, _ := utfbom.Skip(r)
+	decoder := json.NewDecoder(body)
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("decoding JSON document failed: %w", err)
+		}
+
+		metrics, err := p.Parse(doc)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (p *Parser) parseCriticalPath(input []byte) ([]telegraf.Metric, error) {
 	p.parseMutex.Lock()
 	defer p.parseMutex.Unlock()
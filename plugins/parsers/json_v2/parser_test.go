@@ -166,3 +166,46 @@ func BenchmarkParsingParallel(b *testing.B) {
 		}
 	})
 }
+
+func TestParseStream(t *testing.T) {
+	parser := &json_v2.Parser{
+		DefaultMetricName: "test",
+		Configs: []json_v2.Config{
+			{
+				MeasurementName: "test",
+				Fields: []json_v2.DataSet{
+					{Path: "value", Type: "int"},
+				},
+				Tags: []json_v2.DataSet{
+					{Path: "host"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	input := `{"host": "a", "value": 1}
+{"host": "b", "value": 2}
+{"host": "c", "value": 3}`
+
+	var actual []telegraf.Metric
+	err := parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("test", map[string]string{"host": "a"}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0)),
+		testutil.MustMetric("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(2)}, time.Unix(0, 0)),
+		testutil.MustMetric("test", map[string]string{"host": "c"}, map[string]interface{}{"value": int64(3)}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+
+	// Invalid documents should stop the parsing
+	err = parser.ParseStream(strings.NewReader(`{"host": "a", "value": 1} {"host"`), func(telegraf.Metric) error {
+		return nil
+	})
+	require.ErrorContains(t, err, "decoding JSON document failed")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"slices"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/antchfx/jsonquery"
+	"github.com/antchfx/xmlquery"
 	path "github.com/antchfx/xpath"
 	"github.com/srebhan/cborquery"
 	"github.com/srebhan/protobufquery"
@@ -45,6 +47,7 @@ type Parser struct {
 	PrintDocument        bool              `toml:"xpath_print_document"`
 	AllowEmptySelection  bool              `toml:"xpath_allow_empty_selection"`
 	NativeTypes          bool              `toml:"xpath_native_types"`
+	StreamElement        string            `toml:"xpath_stream_element"`
 	Trace                bool              `toml:"xpath_trace" deprecated:"1.35.0;use 'log_level' 'trace' instead"`
 	Configs              []Config          `toml:"xpath"`
 	DefaultMetricName    string            `toml:"-"`
@@ -156,6 +159,15 @@ func (p *Parser) Init() error {
 		return fmt.Errorf("unknown data-format %q for xpath parser", p.Format)
 	}
 
+	if p.StreamElement != "" {
+		if _, ok := p.document.(*xmlDocument); !ok {
+			return fmt.Errorf("streaming is not supported for data-format %q", p.Format)
+		}
+		if _, err := path.Compile(p.StreamElement); err != nil {
+			return fmt.Errorf("invalid stream element expression: %w", err)
+		}
+	}
+
 	// Make sure we do have a metric name
 	if p.DefaultMetricName == "" {
 		return errors.New("missing default metric name")
@@ -204,6 +216,67 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	return p.parseDocument(t, doc, p.AllowEmptySelection)
+}
+
+// ParseStream parses the data read from the given reader and calls the given
+// function for each metric. For XML data with 'xpath_stream_element' set,
+// the elements matching the expression are processed one after the other
+// without keeping the whole document in memory. All other data is read at
+// once.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	if p.StreamElement == "" {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t := time.Now()
+	sp, err := xmlquery.CreateStreamParser(r, p.StreamElement)
+	if err != nil {
+		return err
+	}
+	for {
+		node, err := sp.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Query the partial document containing the current element and its
+		// ancestors. Previously streamed elements are already removed from
+		// the tree, so selections not matching the current element are fine.
+		root := node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		metrics, err := p.parseDocument(t, root, true)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *Parser) parseDocument(t time.Time, doc dataNode, allowEmpty bool) ([]telegraf.Metric, error) {
 	if p.PrintDocument {
 		p.Log.Debugf("XML document equivalent: %q", p.document.OutputXML(doc))
 	}
@@ -216,7 +289,7 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 		if err != nil {
 			return nil, err
 		}
-		if (len(selectedNodes) < 1 || selectedNodes[0] == nil) && !p.AllowEmptySelection {
+		if (len(selectedNodes) < 1 || selectedNodes[0] == nil) && !allowEmpty {
 			p.debugEmptyQuery("metric selection", doc, cfg.Selection)
 			return metrics, errors.New("cannot parse with empty selection node")
 		}
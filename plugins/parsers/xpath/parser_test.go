@@ -1818,3 +1818,75 @@ func BenchmarkParsingCBOR(b *testing.B) {
 		plugin.Parse(benchmarkData)
 	}
 }
+
+func TestParseStream(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<Gateway timestamp="1577923199">
+	<Device name="Device 1"><Value>42.0</Value></Device>
+	<Device name="Device 2"><Value>42.1</Value></Device>
+	<Device name="Device 3"><Value>42.2</Value></Device>
+</Gateway>
+`
+	configs := []Config{
+		{
+			Selection: "/Gateway/Device",
+			Timestamp: "/Gateway/@timestamp",
+			Fields: map[string]string{
+				"value": "number(Value)",
+			},
+			Tags: map[string]string{
+				"name": "@name",
+			},
+		},
+	}
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{"name": "Device 1"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(1577923199, 0),
+		),
+		testutil.MustMetric(
+			"test",
+			map[string]string{"name": "Device 2"},
+			map[string]interface{}{"value": 42.1},
+			time.Unix(1577923199, 0),
+		),
+		testutil.MustMetric(
+			"test",
+			map[string]string{"name": "Device 3"},
+			map[string]interface{}{"value": 42.2},
+			time.Unix(1577923199, 0),
+		),
+	}
+
+	for _, element := range []string{"", "/Gateway/Device"} {
+		t.Run("element "+element, func(t *testing.T) {
+			parser := &Parser{
+				DefaultMetricName: "test",
+				StreamElement:     element,
+				Configs:           configs,
+				Log:               testutil.Logger{Name: "parsers.xml"},
+			}
+			require.NoError(t, parser.Init())
+
+			var actual []telegraf.Metric
+			err := parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+func TestParseStreamUnsupportedFormat(t *testing.T) {
+	parser := &Parser{
+		Format:            "xpath_json",
+		DefaultMetricName: "test",
+		StreamElement:     "/Device",
+		Log:               testutil.Logger{Name: "parsers.xpath_json"},
+	}
+	require.ErrorContains(t, parser.Init(), "streaming is not supported")
+}
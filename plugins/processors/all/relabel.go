@@ -0,0 +1,5 @@
+//go:build !custom || processors || processors.relabel
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/processors/relabel" // register plugin
@@ -0,0 +1,47 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package relabel
+
+import (
+	_ "embed"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type Relabel struct {
+	Rules []*relabel.Config `toml:"relabel_configs"`
+	Log   telegraf.Logger   `toml:"-"`
+}
+
+func (*Relabel) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Relabel) Init() error {
+	if len(r.Rules) == 0 {
+		r.Log.Warn("No relabel rules provided, metrics will pass unchanged")
+	}
+	return relabel.Init(r.Rules)
+}
+
+func (r *Relabel) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		if relabel.Metric(r.Rules, m) {
+			out = append(out, m)
+		} else {
+			m.Drop()
+		}
+	}
+	return out
+}
+
+func init() {
+	processors.Add("relabel", func() telegraf.Processor {
+		return &Relabel{}
+	})
+}
@@ -0,0 +1,115 @@
+package relabel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/relabel"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestApply(t *testing.T) {
+	plugin := &Relabel{
+		Rules: []*relabel.Config{
+			{
+				SourceLabels: []string{"host"},
+				Regex:        `([^.]+)\..*`,
+				TargetLabel:  "short_host",
+			},
+			{
+				Action: "labeldrop",
+				Regex:  "host",
+			},
+			{
+				Action:       "drop",
+				SourceLabels: []string{"__name__"},
+				Regex:        "swap",
+			},
+			{
+				SourceLabels: []string{"__name__"},
+				Regex:        "(.*)",
+				TargetLabel:  "__name__",
+				Replacement:  ptr("system_${1}"),
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01.example.org"},
+			map[string]interface{}{"usage_idle": 99.1},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"swap",
+			map[string]string{"host": "server01.example.org"},
+			map[string]interface{}{"used": int64(0)},
+			time.Unix(0, 0),
+		),
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"system_cpu",
+			map[string]string{"short_host": "server01"},
+			map[string]interface{}{"usage_idle": 99.1},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual := plugin.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestInitInvalid(t *testing.T) {
+	plugin := &Relabel{
+		Rules: []*relabel.Config{{Action: "foo"}},
+		Log:   testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), `invalid 'action' setting "foo"`)
+}
+
+func TestTracking(t *testing.T) {
+	var delivered []telegraf.DeliveryInfo
+	notify := func(di telegraf.DeliveryInfo) {
+		delivered = append(delivered, di)
+	}
+
+	input := make([]telegraf.Metric, 0, 2)
+	for _, name := range []string{"cpu", "swap"} {
+		m := metric.New(name, map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+		tm, _ := metric.WithTracking(m, notify)
+		input = append(input, tm)
+	}
+
+	plugin := &Relabel{
+		Rules: []*relabel.Config{
+			{
+				Action:       "drop",
+				SourceLabels: []string{"__name__"},
+				Regex:        "swap",
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	actual := plugin.Apply(input...)
+	require.Len(t, actual, 1)
+	require.Len(t, delivered, 1)
+	for _, m := range actual {
+		m.Accept()
+	}
+	require.Len(t, delivered, 2)
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"runtime"
 	"sort"
 	"strconv"
@@ -78,15 +79,48 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	// Clear the buffer
 	s.buffer.Truncate(0)
 
+	if err := s.writeBatch(metrics); err != nil {
+		return nil, err
+	}
+
+	// Finish up
+	s.writer.Flush()
+	return s.buffer.Bytes(), nil
+}
+
+// SerializeBatchTo writes the batch of metrics to the given writer without
+// buffering the whole batch in memory.
+func (s *Serializer) SerializeBatchTo(w io.Writer, metrics []telegraf.Metric) error {
+	if len(metrics) < 1 {
+		return nil
+	}
+
+	// Temporarily redirect the CSV writer to the given writer
+	bufferWriter := s.writer
+	defer func() { s.writer = bufferWriter }()
+	s.writer = csv.NewWriter(w)
+	s.writer.Comma = bufferWriter.Comma
+	s.writer.UseCRLF = bufferWriter.UseCRLF
+
+	if err := s.writeBatch(metrics); err != nil {
+		return err
+	}
+
+	// Finish up
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+func (s *Serializer) writeBatch(metrics []telegraf.Metric) error {
 	// Write the header if the user wants us to
 	if s.Header {
 		if len(s.Columns) > 0 {
 			if err := s.writeHeaderOrdered(); err != nil {
-				return nil, fmt.Errorf("writing header failed: %w", err)
+				return fmt.Errorf("writing header failed: %w", err)
 			}
 		} else {
 			if err := s.writeHeader(metrics[0]); err != nil {
-				return nil, fmt.Errorf("writing header failed: %w", err)
+				return fmt.Errorf("writing header failed: %w", err)
 			}
 		}
 		s.Header = false
@@ -95,18 +129,16 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	for _, m := range metrics {
 		if len(s.Columns) > 0 {
 			if err := s.writeDataOrdered(m); err != nil {
-				return nil, fmt.Errorf("writing data failed: %w", err)
+				return fmt.Errorf("writing data failed: %w", err)
 			}
 		} else {
 			if err := s.writeData(m); err != nil {
-				return nil, fmt.Errorf("writing data failed: %w", err)
+				return fmt.Errorf("writing data failed: %w", err)
 			}
 		}
 	}
 
-	// Finish up
-	s.writer.Flush()
-	return s.buffer.Bytes(), nil
+	return nil
 }
 
 func (s *Serializer) writeHeader(metric telegraf.Metric) error {
@@ -209,6 +209,20 @@ func TestSerializeTransformationBatch(t *testing.T) {
 
 			// Compare
 			require.EqualValues(t, string(expected), string(actual))
+
+			// Serialize to a writer
+			streamSerializer := Serializer{
+				TimestampFormat: cfg.TimestampFormat,
+				Separator:       cfg.Separator,
+				Header:          cfg.Header,
+				Prefix:          cfg.Prefix,
+				Columns:         cfg.Columns,
+			}
+			require.NoError(t, streamSerializer.Init())
+			streamSerializer.writer.UseCRLF = false
+			var buf bytes.Buffer
+			require.NoError(t, streamSerializer.SerializeBatchTo(&buf, metrics))
+			require.EqualValues(t, string(expected), buf.String())
 		})
 	}
 }
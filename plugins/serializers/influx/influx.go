@@ -1,6 +1,7 @@
 package influx
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
@@ -98,6 +99,24 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	out := make([]byte, 0, s.buf.Len())
 	return append(out, s.buf.Bytes()...), nil
 }
+
+// SerializeBatchTo writes the slice of metrics to the given writer. Metrics
+// that cannot be serialized are skipped in the same way as for SerializeBatch.
+func (s *Serializer) SerializeBatchTo(w io.Writer, metrics []telegraf.Metric) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		err := s.Write(bw, m)
+		if err != nil {
+			var mErr *MetricError
+			if errors.As(err, &mErr) {
+				continue
+			}
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
 func (s *Serializer) Write(w io.Writer, m telegraf.Metric) error {
 	return s.writeMetric(w, m)
 }
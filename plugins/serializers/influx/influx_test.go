@@ -1,6 +1,7 @@
 package influx
 
 import (
+	"bytes"
 	"math"
 	"testing"
 	"time"
@@ -551,6 +552,34 @@ func TestSerialize_SerializeBatch(t *testing.T) {
 	require.Equal(t, []byte("cpu value=42 0\ncpu value=42 0\n"), output)
 }
 
+func TestSerializeBatchTo(t *testing.T) {
+	m := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	invalid := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Unix(0, 0),
+	)
+
+	metrics := []telegraf.Metric{m, invalid, m}
+
+	serializer := &Serializer{
+		SortFields: true,
+	}
+	require.NoError(t, serializer.Init())
+
+	var buf bytes.Buffer
+	require.NoError(t, serializer.SerializeBatchTo(&buf, metrics))
+	require.Equal(t, "cpu value=42 0\ncpu value=42 0\n", buf.String())
+}
+
 func BenchmarkSerialize(b *testing.B) {
 	s := &Serializer{}
 	require.NoError(b, s.Init())
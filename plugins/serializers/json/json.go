@@ -1,9 +1,11 @@
 package json
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"time"
 
@@ -108,6 +110,43 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	return serialized, nil
 }
 
+// SerializeBatchTo writes the batch of metrics to the given writer. Without a
+// transformation the metrics are encoded one after the other, otherwise the
+// whole batch is serialized at once as the transformation needs all data.
+func (s *Serializer) SerializeBatchTo(w io.Writer, metrics []telegraf.Metric) error {
+	if s.Transformation != "" {
+		serialized, err := s.SerializeBatch(metrics)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(serialized)
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(`{"metrics":[`); err != nil {
+		return err
+	}
+	for i, metric := range metrics {
+		if i > 0 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		serialized, err := json.Marshal(s.createObject(metric))
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(serialized); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
 func (s *Serializer) createObject(metric telegraf.Metric) map[string]interface{} {
 	m := make(map[string]interface{}, 4)
 
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -39,6 +40,40 @@ func TestSerializeMetricFloat(t *testing.T) {
 	require.Equal(t, string(expS), string(buf))
 }
 
+func TestSerializeBatchTo(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": float64(91.5)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"usage_idle": float64(90)},
+			time.Unix(0, 0),
+		),
+	}
+
+	s := Serializer{}
+	require.NoError(t, s.Init())
+
+	expected, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.SerializeBatchTo(&buf, metrics))
+	require.Equal(t, string(expected), buf.String())
+
+	// Empty batches must produce valid JSON
+	expected, err = s.SerializeBatch(nil)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, s.SerializeBatchTo(&buf, nil))
+	require.Equal(t, string(expected), buf.String())
+}
+
 func TestSerialize_TimestampUnits(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
@@ -30,6 +31,17 @@ func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 }
 
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.SerializeBatchTo(&buf, metrics); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeBatchTo writes the snappy-compressed remote-write request for the
+// given metrics to the writer. Snappy block compression requires the whole
+// message, so only the final copy of the payload is avoided.
+func (s *Serializer) SerializeBatchTo(w io.Writer, metrics []telegraf.Metric) error {
 	var lastErr error
 	// traceAndKeepErr logs on Trace level every passed error.
 	// with each call it updates lastErr, so it can be logged later with higher level.
@@ -38,7 +50,6 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 		s.Log.Trace(lastErr)
 	}
 
-	var buf bytes.Buffer
 	var entries = make(map[MetricKey]prompb.TimeSeries)
 	var labels = make([]prompb.Label, 0)
 	for _, metric := range metrics {
@@ -179,7 +190,7 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 					metrickey, promts = getPromTS(metricName, labels, value, metric.Time(), extraLabel)
 				}
 			default:
-				return nil, fmt.Errorf("unknown type %v", metric.Type())
+				return fmt.Errorf("unknown type %v", metric.Type())
 			}
 
 			// A batch of metrics can contain multiple values for a single
@@ -236,11 +247,10 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	pb := &prompb.WriteRequest{Timeseries: promTS}
 	data, err := pb.Marshal()
 	if err != nil {
-		return nil, fmt.Errorf("unable to marshal protobuf: %w", err)
+		return fmt.Errorf("unable to marshal protobuf: %w", err)
 	}
-	encoded := snappy.Encode(nil, data)
-	buf.Write(encoded)
-	return buf.Bytes(), nil
+	_, err = w.Write(snappy.Encode(nil, data))
+	return err
 }
 
 func hasLabel(name string, labels []prompb.Label) bool {
@@ -279,6 +279,34 @@ func TestRemoteWriteSerializeNegative(t *testing.T) {
 	assert("failed to parse", err)
 }
 
+func TestRemoteWriteSerializeBatchTo(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "one.example.org"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "two.example.org"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+
+	s := &Serializer{
+		SortMetrics: true,
+		Log:         &testutil.Logger{},
+	}
+	expected, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.SerializeBatchTo(&buf, metrics))
+	require.Equal(t, expected, buf.Bytes())
+}
+
 func TestRemoteWriteSerializeBatch(t *testing.T) {
 	tests := []struct {
 		name          string
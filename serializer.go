@@ -1,5 +1,7 @@
 package telegraf
 
+import "io"
+
 // Serializer is an interface defining functions that a serializer plugin must
 // satisfy.
 //
@@ -20,6 +22,18 @@ type Serializer interface {
 	SerializeBatch(metrics []Metric) ([]byte, error)
 }
 
+// StreamingSerializer is an optional interface for serializers able to write
+// a batch of metrics directly to a writer instead of returning the whole
+// serialized batch as a byte buffer.
+type StreamingSerializer interface {
+	Serializer
+
+	// SerializeBatchTo serializes the given metrics and writes the result to
+	// the given writer. The written data must be identical to the output of
+	// SerializeBatch.
+	SerializeBatchTo(w io.Writer, metrics []Metric) error
+}
+
 // SerializerFunc is a function to create a new instance of a serializer
 type SerializerFunc func() (Serializer, error)
 
@@ -0,0 +1,46 @@
+package testutil
+
+import "sync"
+
+// Backpressure is a backpressure signal controlled by the test
+type Backpressure struct {
+	throttled bool
+	released  chan struct{}
+	sync.Mutex
+}
+
+// Throttle enables or releases the backpressure signal
+func (b *Backpressure) Throttle(throttled bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.released == nil {
+		b.released = make(chan struct{})
+		close(b.released)
+	}
+	switch {
+	case throttled && !b.throttled:
+		b.released = make(chan struct{})
+	case !throttled && b.throttled:
+		close(b.released)
+	}
+	b.throttled = throttled
+}
+
+func (b *Backpressure) Throttled() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.throttled
+}
+
+func (b *Backpressure) Released() <-chan struct{} {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.released == nil {
+		b.released = make(chan struct{})
+		close(b.released)
+	}
+	return b.released
+}